@@ -262,18 +262,9 @@ func (wdp *WorkspaceSubscriptionDebtProcessor) flushWorkspaceDebtStatus(
 		}
 		// currentDebtStatus = types.SubscriptionStatusDeleted
 		wdp.Logger.Info("Namespace is terminating, set to Deleted", "namespace", subscription.Workspace, "currentStatus", currentDebtStatus)
-		return wdp.updateSubscriptionStatus(ctx, subscription, types.SubscriptionStatusDeleted)
+		return wdp.updateSubscriptionStatus(wdp.db.WithContext(ctx), subscription, types.SubscriptionStatusDeleted)
 	}
 
-	userUID := subscription.UserUID
-	nr, err := wdp.AccountV2.GetNotificationRecipient(subscription.UserUID)
-	if err != nil {
-		// logrus.Errorf("failed to get notification recipient for user %s: %v", userUID, err)
-		wdp.VLogger.Errorf("failed to get notification recipient for user %s: %v", userUID, err)
-	}
-	wdp.UserContactProvider.SetUserContact(userUID, nr)
-	defer wdp.UserContactProvider.RemoveUserContact(userUID)
-
 	eventData := &usernotify.WorkspaceSubscriptionDebtEventData{
 		Type:          usernotify.EventTypeWorkspaceSubscriptionDebt,
 		PlanName:      subscription.PlanName,
@@ -301,14 +292,6 @@ func (wdp *WorkspaceSubscriptionDebtProcessor) flushWorkspaceDebtStatus(
 		if err := wdp.updateWorkspaceDebtStatus(ctx, types.SuspendDebtNamespaceAnnoStatus, namespaces); err != nil {
 			return fmt.Errorf("update workspace debt status error: %w", err)
 		}
-		if _, err = wdp.UserNotificationService.HandleWorkspaceSubscriptionEvent(context.Background(), userUID, eventData, types.SubscriptionTransactionTypeDebt, []usernotify.NotificationMethod{usernotify.NotificationMethodEmail}); err != nil {
-			wdp.VLogger.Errorf(
-				"failed to send subscription success notification for user %s: %v",
-				userUID,
-				err,
-			)
-			// return fmt.Errorf("failed to send subscription success notification to user %s: %w", userUID, err)
-		}
 
 	case types.SubscriptionStatusDebtPreDeletion:
 		if err := wdp.sendWorkspaceDesktopNotice(ctx, currentDebtStatus, namespaces); err != nil {
@@ -332,20 +315,49 @@ func (wdp *WorkspaceSubscriptionDebtProcessor) flushWorkspaceDebtStatus(
 	if err := wdp.readWorkspaceNotices(ctx, namespaces, wdp.getWorkspaceStatusesGreaterThan(currentDebtStatus)...); err != nil {
 		return fmt.Errorf("read workspace notices error: %w", err)
 	}
-	if err := wdp.updateSubscriptionStatus(ctx, subscription, currentDebtStatus); err != nil {
-		return fmt.Errorf("update subscription status error: %w", err)
-	}
+	// 欠费通知与订阅状态在同一事务中提交，状态变更后通知不会丢失
+	return wdp.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if currentDebtStatus == types.SubscriptionStatusDebt {
+			if err := wdp.enqueueDebtNotification(tx, subscription, currentDebtStatus, eventData); err != nil {
+				return fmt.Errorf("enqueue workspace debt notification error: %w", err)
+			}
+		}
+		if err := wdp.updateSubscriptionStatus(tx, subscription, currentDebtStatus); err != nil {
+			return fmt.Errorf("update subscription status error: %w", err)
+		}
+		return nil
+	})
+}
 
-	return nil
+// enqueueDebtNotification 将工作空间欠费邮件通知写入发件箱，由账户服务的发件箱投递。
+// 幂等键包含订阅周期，状态更新失败后重新处理时不会重复通知
+func (wdp *WorkspaceSubscriptionDebtProcessor) enqueueDebtNotification(
+	tx *gorm.DB,
+	subscription *types.WorkspaceSubscription,
+	status types.SubscriptionStatus,
+	eventData usernotify.EventData,
+) error {
+	if wdp.UserNotificationService == nil {
+		return nil
+	}
+	event := usernotify.NewWorkspaceSubscriptionEvent(
+		subscription.UserUID,
+		eventData,
+		types.SubscriptionTransactionTypeDebt,
+		[]usernotify.NotificationMethod{usernotify.NotificationMethodEmail},
+	)
+	idempotencyKey := fmt.Sprintf("workspace-subscription-debt/%s/%s/%d",
+		subscription.ID, status, subscription.CurrentPeriodEndAt.Unix())
+	return usernotify.EnqueueEvent(tx, event, idempotencyKey)
 }
 
-// updateSubscriptionStatus 更新订阅状态
+// updateSubscriptionStatus 更新订阅状态，db 可以是事务
 func (wdp *WorkspaceSubscriptionDebtProcessor) updateSubscriptionStatus(
-	ctx context.Context,
+	db *gorm.DB,
 	subscription *types.WorkspaceSubscription,
 	status types.SubscriptionStatus,
 ) error {
-	return wdp.db.WithContext(ctx).
+	return db.
 		Debug().
 		Model(&types.WorkspaceSubscription{}).
 		Where("id = ?", subscription.ID).
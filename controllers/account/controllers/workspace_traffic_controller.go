@@ -181,56 +181,43 @@ func (c *WorkspaceTrafficController) consumeWorkspaceTraffic(
 	// Update subscription status if changed
 	oldStatus := subscription.TrafficStatus
 	if oldStatus != newStatus {
-		err = c.updateWorkspaceTrafficStatus(subscription.ID, newStatus)
+		// Send notification for Exhausted or UsedUp
+		var usagePercent int
+		var totalBytes, usedBytes int64
+		switch newStatus {
+		case types.WorkspaceTrafficStatusUsedUp:
+			usagePercent = 100
+			totalBytes = 0
+			usedBytes = 0
+		case types.WorkspaceTrafficStatusExhausted:
+			usagePercent = int(math.Round(usagePercentage))
+			totalBytes = totalTraffic
+			usedBytes = usedTraffic
+		}
+		plan, err := c.AccountV2.GetWorkspaceSubscriptionPlan(subscription.PlanName)
 		if err != nil {
-			return fmt.Errorf("failed to update workspace traffic status: %w", err)
+			return fmt.Errorf("failed to get workspace subscription plan: %w", err)
 		}
-
-		// Send notification for Exhausted or UsedUp
-		userUID := subscription.UserUID
-		nr, err := c.AccountV2.GetNotificationRecipient(subscription.UserUID)
+		features, err := types.ParseMaxResource(plan.MaxResources, plan.Traffic)
 		if err != nil {
-			c.VLogger.Errorf("failed to get notification recipient for user %s: %v", userUID, err)
-		} else {
-			c.UserContactProvider.SetUserContact(userUID, nr)
-			defer c.UserContactProvider.RemoveUserContact(userUID)
-
-			var usagePercent int
-			var totalBytes, usedBytes int64
-			switch newStatus {
-			case types.WorkspaceTrafficStatusUsedUp:
-				usagePercent = 100
-				totalBytes = 0
-				usedBytes = 0
-			case types.WorkspaceTrafficStatusExhausted:
-				usagePercent = int(math.Round(usagePercentage))
-				totalBytes = totalTraffic
-				usedBytes = usedTraffic
-			}
-			plan, err := c.AccountV2.GetWorkspaceSubscriptionPlan(subscription.PlanName)
-			if err != nil {
-				return fmt.Errorf("failed to get workspace subscription plan: %w", err)
-			}
-			features, err := types.ParseMaxResource(plan.MaxResources, plan.Traffic)
-			if err != nil {
-				return fmt.Errorf("failed to parse plan features: %w", err)
-			}
-			eventData := &usernotify.WorkspaceSubscriptionTrafficEventData{
-				Type:         usernotify.EventTypeTrafficUsageAlert,
-				PlanName:     subscription.PlanName,
-				RegionDomain: subscription.RegionDomain,
-				UsagePercent: usagePercent,
-				TotalBytes:   totalBytes,
-				UsedBytes:    usedBytes,
-				Workspace:    subscription.Workspace,
-				ExpirationDate: fmt.Sprintf("%s - %s",
-					subscription.CurrentPeriodStartAt.Format("2006.1.2"),
-					subscription.CurrentPeriodEndAt.Format("2006.1.2")),
-				Features: features,
-			}
-			if _, err = c.UserNotificationService.HandleWorkspaceSubscriptionEvent(context.Background(), userUID, eventData, types.SubscriptionTransactionTypeOther, []usernotify.NotificationMethod{usernotify.NotificationMethodEmail}); err != nil {
-				c.VLogger.Errorf("failed to send traffic usage alert notification for user %s: %v", userUID, err)
-			}
+			return fmt.Errorf("failed to parse plan features: %w", err)
+		}
+		eventData := &usernotify.WorkspaceSubscriptionTrafficEventData{
+			Type:         usernotify.EventTypeTrafficUsageAlert,
+			PlanName:     subscription.PlanName,
+			RegionDomain: subscription.RegionDomain,
+			UsagePercent: usagePercent,
+			TotalBytes:   totalBytes,
+			UsedBytes:    usedBytes,
+			Workspace:    subscription.Workspace,
+			ExpirationDate: fmt.Sprintf("%s - %s",
+				subscription.CurrentPeriodStartAt.Format("2006.1.2"),
+				subscription.CurrentPeriodEndAt.Format("2006.1.2")),
+			Features: features,
+		}
+		err = c.updateWorkspaceTrafficStatus(subscription, newStatus, eventData)
+		if err != nil {
+			return fmt.Errorf("failed to update workspace traffic status: %w", err)
 		}
 
 		// Suspend workspace only if fully used up
@@ -277,7 +264,26 @@ func (c *WorkspaceTrafficController) handleNoAvailableTraffic(
 	c.Logger.Info("Handling no available traffic", "workspace", subscription.Workspace)
 	oldStatus := subscription.TrafficStatus
 	if oldStatus != types.WorkspaceTrafficStatusUsedUp {
-		err := c.updateWorkspaceTrafficStatus(subscription.ID, types.WorkspaceTrafficStatusUsedUp)
+		plan, err := c.AccountV2.GetWorkspaceSubscriptionPlan(subscription.PlanName)
+		if err != nil {
+			return fmt.Errorf("failed to get workspace subscription plan: %w", err)
+		}
+		features, err := types.ParseMaxResource(plan.MaxResources, plan.Traffic)
+		if err != nil {
+			return fmt.Errorf("failed to parse plan features: %w", err)
+		}
+		eventData := &usernotify.WorkspaceSubscriptionTrafficEventData{
+			Type:         usernotify.EventTypeTrafficUsageAlert,
+			PlanName:     subscription.PlanName,
+			RegionDomain: subscription.RegionDomain,
+			UsagePercent: 100,
+			Workspace:    subscription.Workspace,
+			Features:     features,
+			ExpirationDate: fmt.Sprintf("%s - %s",
+				subscription.CurrentPeriodStartAt.Format("2006.1.2"),
+				subscription.CurrentPeriodEndAt.Format("2006.1.2")),
+		}
+		err = c.updateWorkspaceTrafficStatus(subscription, types.WorkspaceTrafficStatusUsedUp, eventData)
 		if err != nil {
 			return fmt.Errorf("failed to update workspace traffic status: %w", err)
 		}
@@ -286,57 +292,44 @@ func (c *WorkspaceTrafficController) handleNoAvailableTraffic(
 		if err != nil {
 			return fmt.Errorf("failed to suspend workspace traffic: %w", err)
 		}
-
-		userUID := subscription.UserUID
-		nr, err := c.AccountV2.GetNotificationRecipient(subscription.UserUID)
-		if err != nil {
-			c.VLogger.Errorf("failed to get notification recipient for user %s: %v", userUID, err)
-		} else {
-			c.UserContactProvider.SetUserContact(userUID, nr)
-			defer c.UserContactProvider.RemoveUserContact(userUID)
-			plan, err := c.AccountV2.GetWorkspaceSubscriptionPlan(subscription.PlanName)
-			if err != nil {
-				return fmt.Errorf("failed to get workspace subscription plan: %w", err)
-			}
-			features, err := types.ParseMaxResource(plan.MaxResources, plan.Traffic)
-			if err != nil {
-				return fmt.Errorf("failed to parse plan features: %w", err)
-			}
-			eventData := &usernotify.WorkspaceSubscriptionTrafficEventData{
-				Type:         usernotify.EventTypeTrafficUsageAlert,
-				PlanName:     subscription.PlanName,
-				RegionDomain: subscription.RegionDomain,
-				UsagePercent: 100,
-				Workspace:    subscription.Workspace,
-				Features:     features,
-				ExpirationDate: fmt.Sprintf("%s - %s",
-					subscription.CurrentPeriodStartAt.Format("2006.1.2"),
-					subscription.CurrentPeriodEndAt.Format("2006.1.2")),
-			}
-
-			if _, err = c.UserNotificationService.HandleWorkspaceSubscriptionEvent(context.Background(), userUID, eventData, types.SubscriptionTransactionTypeOther, []usernotify.NotificationMethod{usernotify.NotificationMethodEmail}); err != nil {
-				c.VLogger.Errorf("failed to send subscription success notification for user %s: %v", userUID, err)
-			}
-		}
 	}
 
 	return nil
 }
 
-// updateWorkspaceTrafficStatus updates the traffic status of a workspace
+// updateWorkspaceTrafficStatus updates the traffic status of a workspace and enqueues the
+// email traffic usage alert of the new status in the same transaction, it is delivered
+// by the notification outbox worker of the account service once committed
 func (c *WorkspaceTrafficController) updateWorkspaceTrafficStatus(
-	subscriptionID uuid.UUID,
+	subscription *types.WorkspaceSubscription,
 	status types.WorkspaceTrafficStatus,
+	eventData usernotify.EventData,
 ) error {
-	result := c.GlobalDB.Model(&types.WorkspaceSubscription{}).
-		Where("id = ?", subscriptionID).
-		Update("traffic_status", status)
-
-	if result.Error != nil {
-		return fmt.Errorf("failed to update traffic status: %w", result.Error)
-	}
+	return c.GlobalDB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&types.WorkspaceSubscription{}).
+			Where("id = ?", subscription.ID).
+			Update("traffic_status", status)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update traffic status: %w", result.Error)
+		}
 
-	return nil
+		if c.UserNotificationService == nil {
+			return nil
+		}
+		event := usernotify.NewWorkspaceSubscriptionEvent(
+			subscription.UserUID,
+			eventData,
+			types.SubscriptionTransactionTypeOther,
+			[]usernotify.NotificationMethod{usernotify.NotificationMethodEmail},
+		)
+		// The status change is committed with the event, so each change has its own key
+		idempotencyKey := fmt.Sprintf("workspace-traffic/%s/%s/%d",
+			subscription.ID, status, time.Now().UnixNano())
+		if err := usernotify.EnqueueEvent(tx, event, idempotencyKey); err != nil {
+			return fmt.Errorf("failed to enqueue traffic usage alert: %w", err)
+		}
+		return nil
+	})
 }
 
 // ProcessTrafficWithTimeRange processes workspace traffic within time ranges
@@ -221,6 +221,12 @@ func main() {
 		setupLog.Error(err, "unable to init region env")
 		os.Exit(1)
 	}
+	// the workspace traffic and debt processors enqueue user notifications in the outbox,
+	// which the account service delivers
+	if err = cockroach.CreateTableIfNotExist(v2Account.GetGlobalDB(), types.NotificationOutbox{}); err != nil {
+		setupLog.Error(err, "unable to create notification outbox table")
+		os.Exit(1)
+	}
 	if os.Getenv(cockroach.EnvBaseBalance) != "" {
 		balance, err := strconv.ParseInt(os.Getenv(cockroach.EnvBaseBalance), 10, 64)
 		if err == nil {
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -103,6 +104,33 @@ type Config struct {
 	Volumes []corev1.Volume `json:"volumes,omitempty"`
 }
 
+type PersistentVolumeReclaimPolicy string
+
+const (
+	// PersistentVolumeReclaimRetain means the volume is kept after the Devbox is deleted
+	PersistentVolumeReclaimRetain PersistentVolumeReclaimPolicy = "Retain"
+	// PersistentVolumeReclaimDelete means the volume is deleted with the Devbox
+	PersistentVolumeReclaimDelete PersistentVolumeReclaimPolicy = "Delete"
+)
+
+// PersistentVolumeSpec defines a volume mounted into the Devbox, its content is not part of the container
+// filesystem and is therefore never committed into the Devbox image
+type PersistentVolumeSpec struct {
+	// +kubebuilder:validation:Required
+	Size resource.Quantity `json:"size"`
+	// +kubebuilder:validation:Optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// MountPath is where the volume is mounted in the Devbox, it should not contain the working directory,
+	// which would hide the project files committed into the Devbox image
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	MountPath string `json:"mountPath"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default=Delete
+	ReclaimPolicy PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
 // DevboxSpec defines the desired state of Devbox
 type DevboxSpec struct {
 	// +kubebuilder:validation:Required
@@ -127,6 +155,9 @@ type DevboxSpec struct {
 	// +kubebuilder:validation:Required
 	NetworkSpec NetworkSpec `json:"network,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	PersistentVolume *PersistentVolumeSpec `json:"persistentVolume,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	RuntimeClassName string `json:"runtimeClassName,omitempty"`
 	// +kubebuilder:validation:Optional
@@ -145,7 +176,7 @@ type NetworkStatus struct {
 	// +kubebuilder:validation:Optional
 	NodePort int32 `json:"nodePort"`
 
-	// todo TailNet
+	// TailNet is the address of the devbox in the tailnet
 	// +kubebuilder:validation:Optional
 	TailNet string `json:"tailnet"`
 }
@@ -197,6 +228,41 @@ const (
 	DevboxPhaseUnknown DevboxPhase = "Unknown"
 )
 
+const (
+	// DevboxConditionPodScheduled means the Devbox pod has been scheduled to a node
+	DevboxConditionPodScheduled = "PodScheduled"
+	// DevboxConditionImagePulled means the image of the Devbox pod has been pulled
+	DevboxConditionImagePulled = "ImagePulled"
+	// DevboxConditionCommitSucceeded means the last commit of the Devbox succeeded
+	DevboxConditionCommitSucceeded = "CommitSucceeded"
+	// DevboxConditionNetworkReady means the Devbox is reachable through its network type
+	DevboxConditionNetworkReady = "NetworkReady"
+	// DevboxConditionQuotaExceeded means the Devbox pod could not be created because the namespace quota is exceeded
+	DevboxConditionQuotaExceeded = "QuotaExceeded"
+	// DevboxConditionPersistentVolumeResized means the persistent volume claim of the Devbox has the size of the spec
+	DevboxConditionPersistentVolumeResized = "PersistentVolumeResized"
+)
+
+const (
+	DevboxReasonPodNotCreated          = "PodNotCreated"
+	DevboxReasonPodCreated             = "PodCreated"
+	DevboxReasonScheduled              = "Scheduled"
+	DevboxReasonSchedulingPending      = "SchedulingPending"
+	DevboxReasonPulled                 = "Pulled"
+	DevboxReasonPulling                = "Pulling"
+	DevboxReasonCommitted              = "Committed"
+	DevboxReasonCommitFailed           = "CommitFailed"
+	DevboxReasonCommitUnknown          = "CommitUnknown"
+	DevboxReasonNodePortAssigned       = "NodePortAssigned"
+	DevboxReasonNodePortNotAssigned    = "NodePortNotAssigned"
+	DevboxReasonTailnetAddressAssigned = "TailnetAddressAssigned"
+	DevboxReasonTailnetNotRegistered   = "TailnetNotRegistered"
+	DevboxReasonNetworkSyncFailed      = "NetworkSyncFailed"
+	DevboxReasonExceededQuota          = "ExceededQuota"
+	DevboxReasonResized                = "Resized"
+	DevboxReasonResizeFailed           = "ResizeFailed"
+)
+
 // DevboxStatus defines the observed state of Devbox
 type DevboxStatus struct {
 	// +kubebuilder:validation:Optional
@@ -210,6 +276,14 @@ type DevboxStatus struct {
 	State corev1.ContainerState `json:"state"`
 	// +kubebuilder:validation:Optional
 	LastTerminationState corev1.ContainerState `json:"lastState"`
+
+	// Conditions explain the current state of the Devbox, e.g. why the Devbox is pending
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
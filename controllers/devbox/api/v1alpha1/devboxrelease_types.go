@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,6 +29,25 @@ type DevBoxReleaseSpec struct {
 	NewTag string `json:"newTag"`
 	// +kubebuilder:validation:Optional
 	Notes string `json:"notes,omitempty"`
+	// Target publishes the released image to an external registry after it has been tagged
+	// +kubebuilder:validation:Optional
+	Target *ReleaseTarget `json:"target,omitempty"`
+}
+
+// ReleaseTarget defines the external registry a DevBoxRelease is pushed to
+type ReleaseTarget struct {
+	// Image is the image reference to push to, e.g. registry.example.com/team/app,
+	// newTag is used as the tag if the reference does not contain one
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+	// SecretRef is a secret in the same namespace holding the credentials of the target registry,
+	// either a kubernetes.io/dockerconfigjson secret or a secret with username and password keys
+	// +kubebuilder:validation:Optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+	// Insecure allows pushing to a registry over plain http
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	Insecure bool `json:"insecure,omitempty"`
 }
 
 type DevboxReleasePhase string
@@ -41,6 +61,13 @@ const (
 	DevboxReleasePhaseFailed DevboxReleasePhase = "Failed"
 )
 
+const (
+	// DevBoxReleaseConditionTagDeleted means the release tag has been deleted from the devbox registry
+	DevBoxReleaseConditionTagDeleted = "TagDeleted"
+
+	DevBoxReleaseReasonTagDeletionUnsupported = "TagDeletionUnsupported"
+)
+
 // DevBoxReleaseStatus defines the observed state of DevBoxRelease
 type DevBoxReleaseStatus struct {
 	// +kubebuilder:validation:Optional
@@ -48,6 +75,23 @@ type DevBoxReleaseStatus struct {
 	Phase DevboxReleasePhase `json:"phase"`
 	// +kubebuilder:validation:Optional
 	OriginalImage string `json:"originalImage"`
+	// TargetImage is the image the release has been published to
+	// +kubebuilder:validation:Optional
+	TargetImage string `json:"targetImage,omitempty"`
+	// TargetDigest is the manifest digest of the published image
+	// +kubebuilder:validation:Optional
+	TargetDigest string `json:"targetDigest,omitempty"`
+	// Tag is the release tag created in the devbox registry, it is deleted with the release
+	// +kubebuilder:validation:Optional
+	Tag string `json:"tag,omitempty"`
+
+	// Conditions explain the current state of the release, e.g. why its tag could not be deleted
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
@@ -21,7 +21,8 @@ limitations under the License.
 package v1alpha1
 
 import (
-	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -128,8 +129,8 @@ func (in *DevBoxRelease) DeepCopyInto(out *DevBoxRelease) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DevBoxRelease.
@@ -185,6 +186,11 @@ func (in *DevBoxReleaseList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DevBoxReleaseSpec) DeepCopyInto(out *DevBoxReleaseSpec) {
 	*out = *in
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(ReleaseTarget)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DevBoxReleaseSpec.
@@ -200,6 +206,13 @@ func (in *DevBoxReleaseSpec) DeepCopy() *DevBoxReleaseSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DevBoxReleaseStatus) DeepCopyInto(out *DevBoxReleaseStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DevBoxReleaseStatus.
@@ -283,6 +296,11 @@ func (in *DevboxSpec) DeepCopyInto(out *DevboxSpec) {
 	}
 	in.Config.DeepCopyInto(&out.Config)
 	in.NetworkSpec.DeepCopyInto(&out.NetworkSpec)
+	if in.PersistentVolume != nil {
+		in, out := &in.PersistentVolume, &out.PersistentVolume
+		*out = new(PersistentVolumeSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -331,6 +349,13 @@ func (in *DevboxStatus) DeepCopyInto(out *DevboxStatus) {
 	}
 	in.State.DeepCopyInto(&out.State)
 	in.LastTerminationState.DeepCopyInto(&out.LastTerminationState)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DevboxStatus.
@@ -467,6 +492,47 @@ func (in *OperationRequestStatus) DeepCopy() *OperationRequestStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistentVolumeSpec) DeepCopyInto(out *PersistentVolumeSpec) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeSpec.
+func (in *PersistentVolumeSpec) DeepCopy() *PersistentVolumeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolumeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseTarget) DeepCopyInto(out *ReleaseTarget) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseTarget.
+func (in *ReleaseTarget) DeepCopy() *ReleaseTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RuntimeRef) DeepCopyInto(out *RuntimeRef) {
 	*out = *in
@@ -49,6 +49,7 @@ import (
 	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/matcher"
 	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/registry"
 	utilresource "github.com/labring/sealos/controllers/devbox/internal/controller/utils/resource"
+	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/tailnet"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -93,6 +94,12 @@ func main() {
 	var configBurst int
 	// config restart predicate duration
 	var restartPredicateDuration time.Duration
+	// tailnet flag
+	var tailnetCoordinatorURL string
+	var tailnetCoordinatorToken string
+	var tailnetControlURL string
+	var tailnetControlToken string
+	var tailnetSidecarImage string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -125,6 +132,12 @@ func main() {
 	flag.IntVar(&configBurst, "config-burst", 100, "The burst of the config")
 	// config restart predicate duration
 	flag.DurationVar(&restartPredicateDuration, "restart-predicate-duration", 2*time.Hour, "Sets the restart predicate time duration for devbox controller restart. By default, the duration is set to 2 hours.")
+	// tailnet flag
+	flag.StringVar(&tailnetCoordinatorURL, "tailnet-coordinator-url", "", "The url of the tailnet coordination server, the tailnet network type is disabled if it is empty")
+	flag.StringVar(&tailnetCoordinatorToken, "tailnet-coordinator-token", "", "The token used to register devboxes to the tailnet coordination server")
+	flag.StringVar(&tailnetControlURL, "tailnet-control-url", "", "The url the tailnet sidecar fetches peers from. By default, the coordinator url is used.")
+	flag.StringVar(&tailnetControlToken, "tailnet-control-token", "", "The token the tailnet sidecar fetches peers with, it is stored in the devbox secret. By default, the coordinator token is used if the control url is not set.")
+	flag.StringVar(&tailnetSidecarImage, "tailnet-sidecar-image", "", "The image of the tailnet sidecar in devbox pods, required if the tailnet coordinator url is set")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -207,9 +220,10 @@ func main() {
 
 		NewCache: func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
 			opts.ByObject = map[client.Object]cache.ByObject{
-				&corev1.Service{}: {Label: cacheObjLabelSelector},
-				&corev1.Pod{}:     {Label: cacheObjLabelSelector},
-				&corev1.Secret{}:  {Label: cacheObjLabelSelector},
+				&corev1.Service{}:               {Label: cacheObjLabelSelector},
+				&corev1.Pod{}:                   {Label: cacheObjLabelSelector},
+				&corev1.Secret{}:                {Label: cacheObjLabelSelector},
+				&corev1.PersistentVolumeClaim{}: {Label: cacheObjLabelSelector},
 			}
 			return cache.New(config, opts)
 		},
@@ -243,6 +257,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	var tailnetConfig *tailnet.Config
+	if tailnetCoordinatorURL != "" {
+		if tailnetSidecarImage == "" {
+			setupLog.Error(nil, "tailnet-sidecar-image must be set if tailnet-coordinator-url is set")
+			os.Exit(1)
+		}
+		if tailnetControlURL == "" {
+			tailnetControlURL = tailnetCoordinatorURL
+			if tailnetControlToken == "" {
+				tailnetControlToken = tailnetCoordinatorToken
+			}
+		}
+		tailnetConfig = &tailnet.Config{
+			Coordinator: &tailnet.Client{
+				URL:   tailnetCoordinatorURL,
+				Token: tailnetCoordinatorToken,
+			},
+			ControlURL:   tailnetControlURL,
+			ControlToken: tailnetControlToken,
+			SidecarImage: tailnetSidecarImage,
+		}
+	}
+
 	if err = (&controller.DevboxReconciler{
 		Client:              mgr.GetClient(),
 		Scheme:              mgr.GetScheme(),
@@ -258,6 +295,7 @@ func main() {
 			MaximumLimit:   resource.MustParse(maximumLimitEphemeralStorage),
 		},
 		PodMatchers:               podMatchers,
+		Tailnet:                   tailnetConfig,
 		DebugMode:                 debugMode,
 		StartupConfigMapName:      startupCMName,
 		StartupConfigMapNamespace: startupCMNamespace,
@@ -268,8 +306,10 @@ func main() {
 	}
 
 	if err = (&controller.DevBoxReleaseReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("devboxrelease-controller"),
 		Registry: &registry.Client{
 			Username: registryUser,
 			Password: registryPassword,
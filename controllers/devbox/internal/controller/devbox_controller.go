@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"time"
 
@@ -25,10 +26,12 @@ import (
 	"github.com/labring/sealos/controllers/devbox/internal/controller/helper"
 	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/matcher"
 	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/resource"
+	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/tailnet"
 	"github.com/labring/sealos/controllers/devbox/label"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -47,6 +50,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// persistentVolumeResizeRetryInterval is how often a failed resize of the persistent volume claim is retried
+const persistentVolumeResizeRetryInterval = 5 * time.Minute
+
+// errPersistentVolumeResize is returned by syncPersistentVolume if the persistent volume claim could not be resized
+var errPersistentVolumeResize = goerrors.New("failed to resize persistent volume claim")
+
 // DevboxReconciler reconciles a Devbox object
 type DevboxReconciler struct {
 	CommitImageRegistry string
@@ -56,6 +65,9 @@ type DevboxReconciler struct {
 
 	PodMatchers []matcher.PodMatcher
 
+	// Tailnet is nil if the tailnet network type is not enabled
+	Tailnet *tailnet.Config
+
 	DebugMode                 bool
 	StartupConfigMapName      string
 	StartupConfigMapNamespace string
@@ -76,6 +88,7 @@ type DevboxReconciler struct {
 // +kubebuilder:rbac:groups="",resources=services,verbs=*
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=*
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=*
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=*
 
 func (r *DevboxReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -151,8 +164,9 @@ func (r *DevboxReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		r.Recorder.Eventf(devbox, corev1.EventTypeNormal, "Sync startup configmap success", "Sync startup configmap success")
 	}
 
-	// create service if network type is NodePort
-	if devbox.Spec.NetworkSpec.Type == devboxv1alpha1.NetworkTypeNodePort {
+	switch devbox.Spec.NetworkSpec.Type {
+	case devboxv1alpha1.NetworkTypeNodePort:
+		// create service if network type is NodePort
 		logger.Info("syncing service")
 		if err := r.Get(ctx, req.NamespacedName, devbox); err != nil {
 			return ctrl.Result{}, err
@@ -160,10 +174,46 @@ func (r *DevboxReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		if err := r.syncService(ctx, devbox, recLabels); err != nil {
 			logger.Error(err, "sync service failed")
 			r.Recorder.Eventf(devbox, corev1.EventTypeWarning, "Sync service failed", "%v", err)
+			if err := r.updateConditions(ctx, devbox, helper.GenerateNetworkSyncFailedCondition(devbox, err)); err != nil {
+				logger.Error(err, "update network condition failed")
+			}
 			return ctrl.Result{}, err
 		}
 		logger.Info("sync service success")
 		r.Recorder.Eventf(devbox, corev1.EventTypeNormal, "Sync service success", "Sync service success")
+	case devboxv1alpha1.NetworkTypeTailnet:
+		// register devbox to the tailnet if network type is Tailnet
+		logger.Info("syncing tailnet")
+		if err := r.Get(ctx, req.NamespacedName, devbox); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.syncTailnet(ctx, devbox); err != nil {
+			logger.Error(err, "sync tailnet failed")
+			r.Recorder.Eventf(devbox, corev1.EventTypeWarning, "Sync tailnet failed", "%v", err)
+			if err := r.updateConditions(ctx, devbox, helper.GenerateNetworkSyncFailedCondition(devbox, err)); err != nil {
+				logger.Error(err, "update network condition failed")
+			}
+			return ctrl.Result{}, err
+		}
+		logger.Info("sync tailnet success")
+		r.Recorder.Eventf(devbox, corev1.EventTypeNormal, "Sync tailnet success", "Sync tailnet success")
+	}
+
+	var result ctrl.Result
+	if devbox.Spec.PersistentVolume != nil {
+		// create or resize persistent volume claim
+		logger.Info("syncing persistent volume")
+		if err := r.syncPersistentVolume(ctx, devbox, recLabels); goerrors.Is(err, errPersistentVolumeResize) {
+			// the devbox keeps running with the current size, the resize is retried later
+			logger.Error(err, "resize persistent volume failed")
+			result.RequeueAfter = persistentVolumeResizeRetryInterval
+		} else if err != nil {
+			logger.Error(err, "sync persistent volume failed")
+			r.Recorder.Eventf(devbox, corev1.EventTypeWarning, "Sync persistent volume failed", "%v", err)
+			return ctrl.Result{}, err
+		}
+		logger.Info("sync persistent volume success")
+		r.Recorder.Eventf(devbox, corev1.EventTypeNormal, "Sync persistent volume success", "Sync persistent volume success")
 	}
 
 	// create or update pod
@@ -177,7 +227,7 @@ func (r *DevboxReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	r.Recorder.Eventf(devbox, corev1.EventTypeNormal, "Sync pod success", "Sync pod success")
 
 	logger.Info("devbox reconcile success")
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 func (r *DevboxReconciler) syncStartupConfigMap(ctx context.Context, devbox *devboxv1alpha1.Devbox, recLabels map[string]string) error {
@@ -234,6 +284,101 @@ func (r *DevboxReconciler) syncStartupConfigMap(ctx context.Context, devbox *dev
 	return nil
 }
 
+func (r *DevboxReconciler) syncPersistentVolume(ctx context.Context, devbox *devboxv1alpha1.Devbox, recLabels map[string]string) error {
+	logger := log.FromContext(ctx)
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: devbox.Namespace, Name: helper.GetPersistentVolumeClaimName(devbox)}, pvc)
+	if err == nil {
+		// persistent volume claim already exists, resize it if the size is increased
+		var resizeErr error
+		current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		switch devbox.Spec.PersistentVolume.Size.Cmp(current) {
+		case 1:
+			logger.Info("resize persistent volume claim", "from", current.String(), "to", devbox.Spec.PersistentVolume.Size.String())
+			if pvc.Spec.Resources.Requests == nil {
+				pvc.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = devbox.Spec.PersistentVolume.Size
+			if err := r.Update(ctx, pvc); err != nil {
+				// e.g. the storage class does not allow volume expansion, keep the current size
+				resizeErr = fmt.Errorf("%w: %w", errPersistentVolumeResize, err)
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = current
+			}
+			if err := r.updateConditions(ctx, devbox, helper.GeneratePersistentVolumeResizedCondition(devbox, resizeErr)); err != nil {
+				return fmt.Errorf("failed to update persistent volume condition: %w", err)
+			}
+		case 0:
+			// the size of a failed resize may be reverted in the spec
+			if meta.IsStatusConditionFalse(devbox.Status.Conditions, devboxv1alpha1.DevboxConditionPersistentVolumeResized) {
+				if err := r.updateConditions(ctx, devbox, helper.GeneratePersistentVolumeResizedCondition(devbox, nil)); err != nil {
+					return fmt.Errorf("failed to update persistent volume condition: %w", err)
+				}
+			}
+		case -1:
+			// persistent volume claims can not be shrunk, keep the current size
+			r.Recorder.Eventf(devbox, corev1.EventTypeWarning, "Persistent volume can not be shrunk",
+				"Persistent volume size %s is smaller than current size %s", devbox.Spec.PersistentVolume.Size.String(), current.String())
+		}
+		// a retained claim of a deleted devbox with the same name is adopted
+		if metav1.GetControllerOf(pvc) == nil {
+			if err := controllerutil.SetControllerReference(devbox, pvc, r.Scheme); err != nil {
+				return fmt.Errorf("failed to set controller reference: %w", err)
+			}
+			if err := r.Update(ctx, pvc); err != nil {
+				return fmt.Errorf("failed to adopt persistent volume claim: %w", err)
+			}
+		}
+		return resizeErr
+	}
+	if client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get persistent volume claim: %w", err)
+	}
+
+	pvc = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      helper.GetPersistentVolumeClaimName(devbox),
+			Namespace: devbox.Namespace,
+			Labels:    recLabels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: devbox.Spec.PersistentVolume.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: devbox.Spec.PersistentVolume.Size,
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(devbox, pvc, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set controller reference: %w", err)
+	}
+	if err := r.Create(ctx, pvc); err != nil {
+		return fmt.Errorf("failed to create persistent volume claim: %w", err)
+	}
+	return nil
+}
+
+// removePersistentVolume deletes the persistent volume claim of the devbox or, if the reclaim policy is Retain,
+// removes the owner reference so it is not garbage collected with the devbox
+func (r *DevboxReconciler) removePersistentVolume(ctx context.Context, devbox *devboxv1alpha1.Devbox) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: devbox.Namespace, Name: helper.GetPersistentVolumeClaimName(devbox)}, pvc)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if devbox.Spec.PersistentVolume != nil && devbox.Spec.PersistentVolume.ReclaimPolicy == devboxv1alpha1.PersistentVolumeReclaimRetain {
+		if !metav1.IsControlledBy(pvc, devbox) {
+			return nil
+		}
+		if err := controllerutil.RemoveControllerReference(devbox, pvc, r.Scheme); err != nil {
+			return err
+		}
+		return client.IgnoreNotFound(r.Update(ctx, pvc))
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, pvc))
+}
+
 func (r *DevboxReconciler) syncSecret(ctx context.Context, devbox *devboxv1alpha1.Devbox, recLabels map[string]string) error {
 	objectMeta := metav1.ObjectMeta{
 		Name:      devbox.Name,
@@ -320,8 +465,12 @@ func (r *DevboxReconciler) syncPod(ctx context.Context, devbox *devboxv1alpha1.D
 	}
 	logger.Info("pod list", "length", len(podList.Items))
 
+	// quotaCondition is set once a pod is created or failed to be created because of the quota
+	var quotaCondition *metav1.Condition
+
 	// update devbox status after pod is created or updated
 	defer func() {
+		var changedConditions []metav1.Condition
 		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 			logger.Info("update devbox status after pod synced")
 			latestDevbox := &devboxv1alpha1.Devbox{}
@@ -334,6 +483,7 @@ func (r *DevboxReconciler) syncPod(ctx context.Context, devbox *devboxv1alpha1.D
 			logger.Info("merge commit history", "devbox", devbox.Status.CommitHistory, "latestDevbox", latestDevbox.Status.CommitHistory)
 			devbox.Status.Phase = helper.GenerateDevboxPhase(devbox, podList)
 			helper.UpdateDevboxStatus(devbox, latestDevbox)
+			changedConditions = r.updateDevboxConditions(devbox, latestDevbox, podList, quotaCondition)
 			return r.Status().Update(ctx, latestDevbox)
 		}); err != nil {
 			logger.Error(err, "sync pod failed")
@@ -342,6 +492,7 @@ func (r *DevboxReconciler) syncPod(ctx context.Context, devbox *devboxv1alpha1.D
 		}
 		logger.Info("update devbox status success")
 		r.Recorder.Eventf(devbox, corev1.EventTypeNormal, "Sync pod success", "Sync pod success")
+		r.recordConditionEvents(devbox, changedConditions)
 	}()
 
 	switch devbox.Spec.State {
@@ -359,12 +510,14 @@ func (r *DevboxReconciler) syncPod(ctx context.Context, devbox *devboxv1alpha1.D
 				r.Recorder.Eventf(devbox, corev1.EventTypeWarning, "Devbox is exceeded quota", "Devbox is exceeded quota")
 				devbox.Spec.State = devboxv1alpha1.DevboxStateStopped
 				_ = r.Update(ctx, devbox)
+				quotaCondition = ptr.To(helper.GenerateQuotaExceededCondition(devbox, err))
 				return nil
 			}
 			if err != nil {
 				logger.Error(err, "create pod failed")
 				return err
 			}
+			quotaCondition = ptr.To(helper.GenerateQuotaExceededCondition(devbox, nil))
 			return nil
 		case 1:
 			pod := &podList.Items[0]
@@ -424,6 +577,65 @@ func (r *DevboxReconciler) syncPod(ctx context.Context, devbox *devboxv1alpha1.D
 	return nil
 }
 
+// updateDevboxConditions sets the conditions of latestDevbox from the synced devbox and returns the changed conditions
+func (r *DevboxReconciler) updateDevboxConditions(devbox *devboxv1alpha1.Devbox, latestDevbox *devboxv1alpha1.Devbox, podList corev1.PodList, quotaCondition *metav1.Condition) []metav1.Condition {
+	var conditions []metav1.Condition
+	if devbox.Spec.State == devboxv1alpha1.DevboxStateRunning {
+		conditions = append(conditions, helper.GeneratePodConditions(devbox, podList)...)
+	} else {
+		// pod conditions only describe the pod of a running devbox
+		meta.RemoveStatusCondition(&latestDevbox.Status.Conditions, devboxv1alpha1.DevboxConditionPodScheduled)
+		meta.RemoveStatusCondition(&latestDevbox.Status.Conditions, devboxv1alpha1.DevboxConditionImagePulled)
+	}
+	if c := helper.GenerateCommitCondition(latestDevbox); c != nil {
+		conditions = append(conditions, *c)
+	}
+	if devbox.Spec.State == devboxv1alpha1.DevboxStateShutdown {
+		// network is removed on purpose when the devbox is shutdown
+		meta.RemoveStatusCondition(&latestDevbox.Status.Conditions, devboxv1alpha1.DevboxConditionNetworkReady)
+	} else {
+		conditions = append(conditions, helper.GenerateNetworkCondition(latestDevbox))
+	}
+	// only report a resolved quota condition if the quota has been exceeded before
+	if quotaCondition != nil && (quotaCondition.Status == metav1.ConditionTrue ||
+		meta.FindStatusCondition(latestDevbox.Status.Conditions, devboxv1alpha1.DevboxConditionQuotaExceeded) != nil) {
+		conditions = append(conditions, *quotaCondition)
+	}
+	return helper.SetConditions(&latestDevbox.Status.Conditions, conditions...)
+}
+
+// updateConditions sets conditions on the latest devbox status and records events for the changed ones
+func (r *DevboxReconciler) updateConditions(ctx context.Context, devbox *devboxv1alpha1.Devbox, conditions ...metav1.Condition) error {
+	var changedConditions []metav1.Condition
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latestDevbox := &devboxv1alpha1.Devbox{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(devbox), latestDevbox); err != nil {
+			return err
+		}
+		changedConditions = helper.SetConditions(&latestDevbox.Status.Conditions, conditions...)
+		if len(changedConditions) == 0 {
+			return nil
+		}
+		return r.Status().Update(ctx, latestDevbox)
+	})
+	if err != nil {
+		return err
+	}
+	r.recordConditionEvents(devbox, changedConditions)
+	return nil
+}
+
+// recordConditionEvents records an event for every condition transition, unhealthy conditions are recorded as warnings
+func (r *DevboxReconciler) recordConditionEvents(devbox *devboxv1alpha1.Devbox, conditions []metav1.Condition) {
+	for _, c := range conditions {
+		eventType := corev1.EventTypeNormal
+		if !helper.IsConditionHealthy(c) {
+			eventType = corev1.EventTypeWarning
+		}
+		r.Recorder.Eventf(devbox, eventType, c.Reason, "%s is %s: %s", c.Type, c.Status, c.Message)
+	}
+}
+
 func (r *DevboxReconciler) syncService(ctx context.Context, devbox *devboxv1alpha1.Devbox, recLabels map[string]string) error {
 	var servicePorts []corev1.ServicePort
 	for _, port := range devbox.Spec.Config.Ports {
@@ -515,6 +727,101 @@ func (r *DevboxReconciler) syncService(ctx context.Context, devbox *devboxv1alph
 	return nil
 }
 
+func (r *DevboxReconciler) syncTailnet(ctx context.Context, devbox *devboxv1alpha1.Devbox) error {
+	if r.Tailnet == nil {
+		return fmt.Errorf("tailnet network type is not enabled")
+	}
+	nodeName := helper.GetTailnetNodeName(devbox)
+	switch devbox.Spec.State {
+	case devboxv1alpha1.DevboxStateShutdown:
+		if err := r.Tailnet.Coordinator.Deregister(ctx, nodeName); err != nil {
+			return fmt.Errorf("failed to deregister tailnet node: %w", err)
+		}
+		return r.updateNetworkStatus(ctx, devbox, devboxv1alpha1.NetworkStatus{
+			Type: devboxv1alpha1.NetworkTypeTailnet,
+		})
+	case devboxv1alpha1.DevboxStateRunning, devboxv1alpha1.DevboxStateStopped:
+		// the devbox is only reachable through the tailnet, remove the service left by NodePort network type
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      devbox.Name + "-svc",
+				Namespace: devbox.Namespace,
+			},
+		}
+		if err := r.Client.Delete(ctx, service); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		publicKey, err := r.syncTailnetSecret(ctx, devbox)
+		if err != nil {
+			return err
+		}
+		// register on every reconcile, the coordinator keeps the address of a registered node
+		node, err := r.Tailnet.Coordinator.Register(ctx, nodeName, publicKey)
+		if err != nil {
+			return fmt.Errorf("failed to register tailnet node: %w", err)
+		}
+		return r.updateNetworkStatus(ctx, devbox, devboxv1alpha1.NetworkStatus{
+			Type:    devboxv1alpha1.NetworkTypeTailnet,
+			TailNet: node.Address,
+		})
+	}
+	return nil
+}
+
+// updateNetworkStatus sets the network status on the latest devbox status, the devbox is updated on success
+func (r *DevboxReconciler) updateNetworkStatus(ctx context.Context, devbox *devboxv1alpha1.Devbox, network devboxv1alpha1.NetworkStatus) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latestDevbox := &devboxv1alpha1.Devbox{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(devbox), latestDevbox); err != nil {
+			return err
+		}
+		if latestDevbox.Status.Network == network {
+			return nil
+		}
+		latestDevbox.Status.Network = network
+		return r.Status().Update(ctx, latestDevbox)
+	})
+	if err != nil {
+		return err
+	}
+	devbox.Status.Network = network
+	return nil
+}
+
+// syncTailnetSecret generates the tailnet node key of the devbox if it does not exist, stores the control token
+// the sidecar fetches peers with in the devbox secret and returns the public key
+func (r *DevboxReconciler) syncTailnetSecret(ctx context.Context, devbox *devboxv1alpha1.Devbox) (string, error) {
+	devboxSecret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: devbox.Namespace, Name: devbox.Name}, devboxSecret); err != nil {
+		return "", fmt.Errorf("failed to get secret: %w", err)
+	}
+	if devboxSecret.Data == nil {
+		devboxSecret.Data = make(map[string][]byte)
+	}
+	updated := false
+	publicKey, ok := devboxSecret.Data["SEALOS_DEVBOX_TAILNET_PUBLIC_KEY"]
+	if !ok {
+		privateKey, newPublicKey, err := tailnet.GenerateKey()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate tailnet key: %w", err)
+		}
+		publicKey = []byte(newPublicKey)
+		devboxSecret.Data["SEALOS_DEVBOX_TAILNET_PRIVATE_KEY"] = []byte(privateKey)
+		devboxSecret.Data["SEALOS_DEVBOX_TAILNET_PUBLIC_KEY"] = publicKey
+		updated = true
+	}
+	if token := r.Tailnet.ControlToken; token != "" && string(devboxSecret.Data["SEALOS_DEVBOX_TAILNET_CONTROL_TOKEN"]) != token {
+		devboxSecret.Data["SEALOS_DEVBOX_TAILNET_CONTROL_TOKEN"] = []byte(token)
+		updated = true
+	}
+	if updated {
+		if err := r.Update(ctx, devboxSecret); err != nil {
+			return "", fmt.Errorf("failed to update secret: %w", err)
+		}
+	}
+	return string(publicKey), nil
+}
+
 // create a new pod, add predicated status to nextCommitHistory
 func (r *DevboxReconciler) createPod(ctx context.Context, devbox *devboxv1alpha1.Devbox, expectPod *corev1.Pod, nextCommitHistory *devboxv1alpha1.CommitHistory) error {
 	logger := log.FromContext(ctx)
@@ -576,6 +883,12 @@ func (r *DevboxReconciler) handlePodDeleted(ctx context.Context, devbox *devboxv
 }
 
 func (r *DevboxReconciler) removeAll(ctx context.Context, devbox *devboxv1alpha1.Devbox, recLabels map[string]string) error {
+	// Deregister tailnet node
+	if devbox.Spec.NetworkSpec.Type == devboxv1alpha1.NetworkTypeTailnet && r.Tailnet != nil {
+		if err := r.Tailnet.Coordinator.Deregister(ctx, helper.GetTailnetNodeName(devbox)); err != nil {
+			return err
+		}
+	}
 	// Delete Pod
 	podList := &corev1.PodList{}
 	if err := r.List(ctx, podList, client.InNamespace(devbox.Namespace), client.MatchingLabels(recLabels)); err != nil {
@@ -591,6 +904,10 @@ func (r *DevboxReconciler) removeAll(ctx context.Context, devbox *devboxv1alpha1
 	if err := r.deleteResourcesByLabels(ctx, &corev1.Pod{}, devbox.Namespace, recLabels); err != nil {
 		return err
 	}
+	// Delete or retain PersistentVolumeClaim
+	if err := r.removePersistentVolume(ctx, devbox); err != nil {
+		return err
+	}
 	// Delete Service
 	if err := r.deleteResourcesByLabels(ctx, &corev1.Service{}, devbox.Namespace, recLabels); err != nil {
 		return err
@@ -645,6 +962,10 @@ func (r *DevboxReconciler) generateDevboxPod(devbox *devboxv1alpha1.Devbox, next
 	if r.StartupConfigMapName != "" {
 		volumeMounts = append(volumeMounts, helper.GenerateStartupVolumeMounts()...)
 	}
+	if devbox.Spec.PersistentVolume != nil {
+		volumes = append(volumes, helper.GeneratePersistentVolume(devbox))
+		volumeMounts = append(volumeMounts, helper.GeneratePersistentVolumeMounts(devbox)...)
+	}
 
 	containers := []corev1.Container{
 		{
@@ -660,6 +981,11 @@ func (r *DevboxReconciler) generateDevboxPod(devbox *devboxv1alpha1.Devbox, next
 			Resources:  helper.GenerateResourceRequirements(devbox, r.RequestRate, r.EphemeralStorage)},
 	}
 
+	var initContainers []corev1.Container
+	if devbox.Spec.NetworkSpec.Type == devboxv1alpha1.NetworkTypeTailnet && r.Tailnet != nil {
+		initContainers = append(initContainers, helper.GenerateTailnetSidecar(devbox, r.Tailnet))
+	}
+
 	terminationGracePeriodSeconds := 300
 	automountServiceAccountToken := false
 
@@ -678,9 +1004,10 @@ func (r *DevboxReconciler) generateDevboxPod(devbox *devboxv1alpha1.Devbox, next
 			AutomountServiceAccountToken:  ptr.To(automountServiceAccountToken),
 			RestartPolicy:                 corev1.RestartPolicyNever,
 
-			Hostname:   devbox.Name,
-			Containers: containers,
-			Volumes:    volumes,
+			Hostname:       devbox.Name,
+			InitContainers: initContainers,
+			Containers:     containers,
+			Volumes:        volumes,
 
 			RuntimeClassName: runtimeClassNamePtr,
 
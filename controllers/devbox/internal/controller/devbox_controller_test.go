@@ -18,16 +18,26 @@ package controller
 
 import (
 	"context"
+	goerrors "errors"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	devboxv1alpha1 "github.com/labring/sealos/controllers/devbox/api/v1alpha1"
+	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/tailnet"
 )
 
 var _ = Describe("Devbox Controller", func() {
@@ -82,3 +92,255 @@ var _ = Describe("Devbox Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("Devbox persistent volume", func() {
+	Context("When the size of the persistent volume is increased", func() {
+		const (
+			resourceName     = "test-persistent-volume"
+			storageClassName = "test-expandable"
+		)
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		pvcNamespacedName := types.NamespacedName{
+			Name:      resourceName + "-home",
+			Namespace: "default",
+		}
+
+		var reconciler *DevboxReconciler
+
+		BeforeEach(func() {
+			By("creating an expandable storage class and a devbox with a persistent volume")
+			storageClass := &storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{Name: storageClassName},
+				Provisioner:          "devbox.sealos.io/test",
+				AllowVolumeExpansion: ptr.To(true),
+			}
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, storageClass))).To(Succeed())
+
+			devbox := &devboxv1alpha1.Devbox{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: devboxv1alpha1.DevboxSpec{
+					State: devboxv1alpha1.DevboxStateRunning,
+					Resource: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					Image:       "ghcr.io/labring-actions/devbox/go:latest",
+					NetworkSpec: devboxv1alpha1.NetworkSpec{Type: devboxv1alpha1.NetworkTypeNodePort},
+					PersistentVolume: &devboxv1alpha1.PersistentVolumeSpec{
+						Size:             resource.MustParse("1Gi"),
+						StorageClassName: ptr.To(storageClassName),
+						MountPath:        "/home/devbox/data",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, devbox)).To(Succeed())
+
+			reconciler = &DevboxReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+			Expect(reconciler.syncPersistentVolume(ctx, devbox, nil)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the devbox and its persistent volume claim")
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(k8sClient.Get(ctx, pvcNamespacedName, pvc)).To(Succeed())
+			pvc.Finalizers = nil
+			Expect(k8sClient.Update(ctx, pvc)).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, pvc))).To(Succeed())
+
+			devbox := &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, devbox)).To(Succeed())
+		})
+
+		// increaseSize increases the persistent volume size of the devbox and syncs the persistent volume
+		increaseSize := func() error {
+			devbox := &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			devbox.Spec.PersistentVolume.Size = resource.MustParse("2Gi")
+			Expect(k8sClient.Update(ctx, devbox)).To(Succeed())
+			return reconciler.syncPersistentVolume(ctx, devbox, nil)
+		}
+
+		It("should resize a bound persistent volume claim", func() {
+			By("binding the persistent volume claim")
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(k8sClient.Get(ctx, pvcNamespacedName, pvc)).To(Succeed())
+			pvc.Status.Phase = corev1.ClaimBound
+			Expect(k8sClient.Status().Update(ctx, pvc)).To(Succeed())
+
+			Expect(increaseSize()).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, pvcNamespacedName, pvc)).To(Succeed())
+			Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("2Gi"))
+
+			devbox := &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(devbox.Status.Conditions, devboxv1alpha1.DevboxConditionPersistentVolumeResized)).To(BeTrue())
+		})
+
+		It("should keep the current size when the resize is rejected", func() {
+			By("resizing the unbound persistent volume claim, which the api server rejects")
+			err := increaseSize()
+			Expect(goerrors.Is(err, errPersistentVolumeResize)).To(BeTrue())
+
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(k8sClient.Get(ctx, pvcNamespacedName, pvc)).To(Succeed())
+			Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("1Gi"))
+
+			devbox := &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			condition := meta.FindStatusCondition(devbox.Status.Conditions, devboxv1alpha1.DevboxConditionPersistentVolumeResized)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(devboxv1alpha1.DevboxReasonResizeFailed))
+		})
+	})
+})
+
+// fakeCoordinator assigns tailnet addresses in memory
+type fakeCoordinator struct {
+	mu    sync.Mutex
+	nodes map[string]*tailnet.Node
+}
+
+func (c *fakeCoordinator) Register(_ context.Context, name string, publicKey string) (*tailnet.Node, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nodes == nil {
+		c.nodes = make(map[string]*tailnet.Node)
+	}
+	if _, ok := c.nodes[name]; !ok {
+		c.nodes[name] = &tailnet.Node{Name: name, Address: "100.64.0.1"}
+	}
+	c.nodes[name].PublicKey = publicKey
+	return c.nodes[name], nil
+}
+
+func (c *fakeCoordinator) Deregister(_ context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nodes, name)
+	return nil
+}
+
+var _ = Describe("Devbox tailnet", func() {
+	Context("When the devbox is changed while syncing the tailnet", func() {
+		const resourceName = "test-tailnet"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		var (
+			reconciler *DevboxReconciler
+			stale      *devboxv1alpha1.Devbox
+		)
+
+		BeforeEach(func() {
+			By("creating a tailnet devbox and its secret")
+			devbox := &devboxv1alpha1.Devbox{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: devboxv1alpha1.DevboxSpec{
+					State: devboxv1alpha1.DevboxStateRunning,
+					Resource: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					Image:       "ghcr.io/labring-actions/devbox/go:latest",
+					NetworkSpec: devboxv1alpha1.NetworkSpec{Type: devboxv1alpha1.NetworkTypeTailnet},
+				},
+			}
+			Expect(k8sClient.Create(ctx, devbox)).To(Succeed())
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+			reconciler = &DevboxReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Tailnet:  &tailnet.Config{Coordinator: &fakeCoordinator{}, ControlToken: "control-token"},
+			}
+
+			By("updating the devbox after it was read by the reconciler")
+			stale = &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, stale)).To(Succeed())
+			latest := stale.DeepCopy()
+			latest.Status.CommitHistory = append(latest.Status.CommitHistory, &devboxv1alpha1.CommitHistory{
+				Image:  "registry.example.com/default/test-tailnet:commit",
+				Time:   metav1.Now(),
+				Pod:    resourceName + "-pod",
+				Status: devboxv1alpha1.CommitStatusPending,
+			})
+			Expect(k8sClient.Status().Update(ctx, latest)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the devbox and its secret")
+			Expect(k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"}})).To(Succeed())
+			devbox := &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, devbox)).To(Succeed())
+		})
+
+		It("should record the tailnet address on the latest devbox", func() {
+			Expect(reconciler.syncTailnet(ctx, stale)).To(Succeed())
+
+			devbox := &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			Expect(devbox.Status.Network.Type).To(Equal(devboxv1alpha1.NetworkTypeTailnet))
+			Expect(devbox.Status.Network.TailNet).To(Equal("100.64.0.1"))
+			Expect(devbox.Status.CommitHistory).To(HaveLen(1))
+		})
+
+		It("should register the node with the namespace and store the control token in the devbox secret", func() {
+			Expect(reconciler.syncTailnet(ctx, stale)).To(Succeed())
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(string(secret.Data["SEALOS_DEVBOX_TAILNET_CONTROL_TOKEN"])).To(Equal("control-token"))
+			node := reconciler.Tailnet.Coordinator.(*fakeCoordinator).nodes["default."+resourceName]
+			Expect(node).NotTo(BeNil())
+			Expect(node.PublicKey).To(Equal(string(secret.Data["SEALOS_DEVBOX_TAILNET_PUBLIC_KEY"])))
+		})
+
+		It("should clear the tailnet address of a shutdown devbox", func() {
+			Expect(reconciler.syncTailnet(ctx, stale)).To(Succeed())
+
+			By("shutting down the devbox")
+			devbox := &devboxv1alpha1.Devbox{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			devbox.Spec.State = devboxv1alpha1.DevboxStateShutdown
+			Expect(k8sClient.Update(ctx, devbox)).To(Succeed())
+			Expect(reconciler.syncTailnet(ctx, devbox.DeepCopy())).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, devbox)).To(Succeed())
+			Expect(devbox.Status.Network.Type).To(Equal(devboxv1alpha1.NetworkTypeTailnet))
+			Expect(devbox.Status.Network.TailNet).To(BeEmpty())
+			Expect(devbox.Status.CommitHistory).To(HaveLen(1))
+		})
+	})
+})
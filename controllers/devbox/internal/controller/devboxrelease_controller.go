@@ -22,14 +22,19 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	reference "github.com/google/go-containerregistry/pkg/name"
 
 	devboxv1alpha1 "github.com/labring/sealos/controllers/devbox/api/v1alpha1"
 	"github.com/labring/sealos/controllers/devbox/internal/controller/helper"
 	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/registry"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -40,13 +45,17 @@ import (
 // DevBoxReleaseReconciler reconciles a DevBoxRelease object
 type DevBoxReleaseReconciler struct {
 	client.Client
-	Registry *registry.Client
-	Scheme   *runtime.Scheme
+	// APIReader reads secrets of release targets, which are not in the cache of the manager
+	APIReader client.Reader
+	Registry  *registry.Client
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=devbox.sealos.io,resources=devboxreleases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=devbox.sealos.io,resources=devboxreleases/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=devbox.sealos.io,resources=devboxreleases/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *DevBoxReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -62,6 +71,20 @@ func (r *DevBoxReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			}
 		}
 	} else {
+		logger.Info("Deleting release tag", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
+		err := r.DeleteReleaseTag(ctx, devboxRelease)
+		if errors.Is(err, registry.ErrorTagDeletionUnsupported) {
+			// the registry never accepts the deletion, report the tag left behind and delete the release anyway
+			logger.Info("Registry does not support tag deletion, keep the tag", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
+			r.Recorder.Eventf(devboxRelease, corev1.EventTypeWarning, "Tag deletion unsupported",
+				"The registry of %s does not support deleting tag %s, the tag is kept", devboxRelease.Status.OriginalImage, devboxRelease.Spec.NewTag)
+			if err := r.setTagDeletionUnsupported(ctx, devboxRelease); err != nil {
+				return ctrl.Result{}, err
+			}
+		} else if err != nil {
+			logger.Error(err, "Failed to delete release tag", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
+			return ctrl.Result{}, err
+		}
 		if controllerutil.RemoveFinalizer(devboxRelease, devboxv1alpha1.FinalizerName) {
 			if err := r.Update(ctx, devboxRelease); err != nil {
 				return ctrl.Result{}, err
@@ -83,20 +106,29 @@ func (r *DevBoxReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	if devboxRelease.Status.Phase == devboxv1alpha1.DevboxReleasePhasePending {
-		logger.Info("Creating release tag", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
-		err := r.CreateReleaseTag(ctx, devboxRelease)
-		if err != nil && errors.Is(err, registry.ErrorManifestNotFound) {
-			logger.Info("Manifest not found, retrying", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
-			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
-		} else if err != nil {
-			logger.Error(err, "Failed to create release tag", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
-			devboxRelease.Status.Phase = devboxv1alpha1.DevboxReleasePhaseFailed
-			_ = r.Status().Update(ctx, devboxRelease)
-			return ctrl.Result{}, err
+		// the tag is already created when a transient publish error was requeued
+		if devboxRelease.Status.Tag != devboxRelease.Spec.NewTag {
+			logger.Info("Creating release tag", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
+			err := r.CreateReleaseTag(ctx, devboxRelease)
+			if err != nil && errors.Is(err, registry.ErrorManifestNotFound) {
+				logger.Info("Manifest not found, retrying", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
+				return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+			} else if err != nil {
+				logger.Error(err, "Failed to create release tag", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
+				return r.releaseFailed(ctx, devboxRelease, err)
+			}
+			logger.Info("Release tag created", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
+		}
+		if devboxRelease.Spec.Target != nil {
+			logger.Info("Publishing release", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag, "target", devboxRelease.Spec.Target.Image)
+			if err := r.PublishRelease(ctx, devboxRelease); err != nil {
+				logger.Error(err, "Failed to publish release", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag, "target", devboxRelease.Spec.Target.Image)
+				return r.releaseFailed(ctx, devboxRelease, err)
+			}
+			logger.Info("Release published", "devbox", devboxRelease.Spec.DevboxName, "targetImage", devboxRelease.Status.TargetImage, "targetDigest", devboxRelease.Status.TargetDigest)
 		}
-		logger.Info("Release tag created", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
 		devboxRelease.Status.Phase = devboxv1alpha1.DevboxReleasePhaseSuccess
-		if err = r.Status().Update(ctx, devboxRelease); err != nil {
+		if err := r.Status().Update(ctx, devboxRelease); err != nil {
 			logger.Error(err, "Failed to update status", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
 			return ctrl.Result{}, err
 		}
@@ -105,6 +137,16 @@ func (r *DevBoxReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return ctrl.Result{}, nil
 }
 
+// releaseFailed requeues the release on transient registry errors and fails it on the other errors
+func (r *DevBoxReleaseReconciler) releaseFailed(ctx context.Context, devboxRelease *devboxv1alpha1.DevBoxRelease, err error) (ctrl.Result, error) {
+	if registry.IsTransient(err) {
+		return ctrl.Result{}, err
+	}
+	devboxRelease.Status.Phase = devboxv1alpha1.DevboxReleasePhaseFailed
+	_ = r.Status().Update(ctx, devboxRelease)
+	return ctrl.Result{}, err
+}
+
 func (r *DevBoxReleaseReconciler) CreateReleaseTag(ctx context.Context, devboxRelease *devboxv1alpha1.DevBoxRelease) error {
 	logger := log.FromContext(ctx)
 	devbox := &devboxv1alpha1.Devbox{}
@@ -133,11 +175,98 @@ func (r *DevBoxReleaseReconciler) CreateReleaseTag(ctx context.Context, devboxRe
 		logger.Error(err, "Failed to update status", "devbox", devboxRelease.Spec.DevboxName, "newTag", devboxRelease.Spec.NewTag)
 		return err
 	}
-	return r.Registry.TagImage(hostName, imageName, oldTag, devboxRelease.Spec.NewTag)
+	if err = r.Registry.TagImage(hostName, imageName, oldTag, devboxRelease.Spec.NewTag); err != nil {
+		return err
+	}
+	// record the tag so that it is deleted with the release whatever the phase
+	devboxRelease.Status.Tag = devboxRelease.Spec.NewTag
+	return r.Status().Update(ctx, devboxRelease)
+}
+
+// DeleteReleaseTag removes the release tag from the devbox registry, images published to targets are kept.
+// registry.ErrorTagDeletionUnsupported is returned if the registry does not support deleting tags.
+func (r *DevBoxReleaseReconciler) DeleteReleaseTag(ctx context.Context, devboxRelease *devboxv1alpha1.DevBoxRelease) error {
+	logger := log.FromContext(ctx)
+	tag := devboxRelease.Status.Tag
+	// releases which succeeded before the tag was recorded in status
+	if tag == "" && devboxRelease.Status.Phase == devboxv1alpha1.DevboxReleasePhaseSuccess {
+		tag = devboxRelease.Spec.NewTag
+	}
+	if tag == "" || devboxRelease.Status.OriginalImage == "" {
+		return nil
+	}
+	res, err := reference.ParseReference(devboxRelease.Status.OriginalImage)
+	if err != nil {
+		return err
+	}
+	// never delete the commit image the release was tagged from
+	if res.Identifier() == tag {
+		return nil
+	}
+	hostName, imageName := res.Context().RegistryStr(), res.Context().RepositoryStr()
+	err = r.Registry.DeleteTag(hostName, imageName, tag)
+	if errors.Is(err, registry.ErrorManifestNotFound) {
+		logger.Info("Release tag already deleted", "host", hostName, "image", imageName, "tag", tag)
+		return nil
+	}
+	return err
+}
+
+// setTagDeletionUnsupported sets the TagDeleted condition of a release whose tag can not be deleted from the registry,
+// devboxRelease is refreshed to the updated release
+func (r *DevBoxReleaseReconciler) setTagDeletionUnsupported(ctx context.Context, devboxRelease *devboxv1alpha1.DevBoxRelease) error {
+	condition := metav1.Condition{
+		Type:               devboxv1alpha1.DevBoxReleaseConditionTagDeleted,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: devboxRelease.Generation,
+		Reason:             devboxv1alpha1.DevBoxReleaseReasonTagDeletionUnsupported,
+		Message: fmt.Sprintf("The registry of %s does not support deleting tag %s, the tag is kept",
+			devboxRelease.Status.OriginalImage, devboxRelease.Spec.NewTag),
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(devboxRelease), devboxRelease); err != nil {
+			return err
+		}
+		if len(helper.SetConditions(&devboxRelease.Status.Conditions, condition)) == 0 {
+			return nil
+		}
+		return r.Status().Update(ctx, devboxRelease)
+	})
 }
 
-func (r *DevBoxReleaseReconciler) DeleteReleaseTag(_ context.Context, _ *devboxv1alpha1.DevBoxRelease) error {
-	//todo only delete CR without doing any other operations
+// PublishRelease pushes the release tag to the target registry and records the pushed image and digest in status
+func (r *DevBoxReleaseReconciler) PublishRelease(ctx context.Context, devboxRelease *devboxv1alpha1.DevBoxRelease) error {
+	target := devboxRelease.Spec.Target
+	options := []reference.Option{reference.WithDefaultTag(devboxRelease.Spec.NewTag)}
+	if target.Insecure {
+		options = append(options, reference.Insecure)
+	}
+	targetRef, err := reference.NewTag(target.Image, options...)
+	if err != nil {
+		return fmt.Errorf("invalid target image %s: %w", target.Image, err)
+	}
+
+	var targetAuth authn.Authenticator
+	if target.SecretRef != nil {
+		secret := &corev1.Secret{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: devboxRelease.Namespace, Name: target.SecretRef.Name}, secret); err != nil {
+			return fmt.Errorf("failed to get target secret: %w", err)
+		}
+		if targetAuth, err = registry.AuthFromSecret(secret, targetRef.RegistryStr()); err != nil {
+			return err
+		}
+	}
+
+	res, err := reference.ParseReference(devboxRelease.Status.OriginalImage)
+	if err != nil {
+		return err
+	}
+	digest, err := r.Registry.PushImage(ctx, res.Context().RegistryStr(), res.Context().RepositoryStr(), devboxRelease.Spec.NewTag, targetRef, targetAuth)
+	if err != nil {
+		return err
+	}
+	devboxRelease.Status.TargetImage = targetRef.Name()
+	devboxRelease.Status.TargetDigest = digest
 	return nil
 }
 
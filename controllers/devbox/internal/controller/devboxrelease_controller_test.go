@@ -18,16 +18,21 @@ package controller
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	devboxv1alpha1 "github.com/labring/sealos/controllers/devbox/api/v1alpha1"
+	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/registry"
 )
 
 var _ = Describe("DevBoxRelease Controller", func() {
@@ -81,4 +86,65 @@ var _ = Describe("DevBoxRelease Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When deleting a release whose registry does not support tag deletion", func() {
+		const resourceName = "test-release-tag-kept"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		It("should delete the release and keep the tag", func() {
+			var deletedTags []string
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method == http.MethodDelete {
+					deletedTags = append(deletedTags, req.URL.Path)
+				}
+				rw.WriteHeader(http.StatusMethodNotAllowed)
+			}))
+			defer server.Close()
+			host := strings.TrimPrefix(server.URL, "http://")
+
+			By("creating a released DevBoxRelease")
+			resource := &devboxv1alpha1.DevBoxRelease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       resourceName,
+					Namespace:  "default",
+					Finalizers: []string{devboxv1alpha1.FinalizerName},
+				},
+				Spec: devboxv1alpha1.DevBoxReleaseSpec{
+					DevboxName: "devbox",
+					NewTag:     "v1",
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			resource.Status.Phase = devboxv1alpha1.DevboxReleasePhaseSuccess
+			resource.Status.OriginalImage = host + "/ns/devbox:commit"
+			resource.Status.Tag = "v1"
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("reconciling the deleted release")
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &DevBoxReleaseReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Registry: &registry.Client{},
+				Recorder: recorder,
+			}
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(deletedTags).To(Equal([]string{"/v2/ns/devbox/manifests/v1"}))
+			Expect(recorder.Events).To(Receive(ContainSubstring("Tag deletion unsupported")))
+
+			err = k8sClient.Get(ctx, typeNamespacedName, &devboxv1alpha1.DevBoxRelease{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
 })
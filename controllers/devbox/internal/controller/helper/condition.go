@@ -0,0 +1,191 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	devboxv1alpha1 "github.com/labring/sealos/controllers/devbox/api/v1alpha1"
+)
+
+// imagePullFailedReasons are the waiting reasons of a container whose image can not be pulled
+var imagePullFailedReasons = map[string]bool{
+	"ErrImagePull":        true,
+	"ImagePullBackOff":    true,
+	"InvalidImageName":    true,
+	"ErrImageNeverPull":   true,
+	"RegistryUnavailable": true,
+}
+
+// SetConditions sets the conditions and returns the ones whose status, reason or message changed
+func SetConditions(conditions *[]metav1.Condition, newConditions ...metav1.Condition) []metav1.Condition {
+	var changed []metav1.Condition
+	for _, c := range newConditions {
+		existing := meta.FindStatusCondition(*conditions, c.Type)
+		if existing != nil && existing.Status == c.Status && existing.Reason == c.Reason && existing.Message == c.Message {
+			// only refresh the observed generation
+			meta.SetStatusCondition(conditions, c)
+			continue
+		}
+		meta.SetStatusCondition(conditions, c)
+		changed = append(changed, c)
+	}
+	return changed
+}
+
+// IsConditionHealthy returns false if the condition explains why the Devbox is stuck
+func IsConditionHealthy(c metav1.Condition) bool {
+	if c.Type == devboxv1alpha1.DevboxConditionQuotaExceeded {
+		return c.Status != metav1.ConditionTrue
+	}
+	return c.Status != metav1.ConditionFalse
+}
+
+// GeneratePodConditions generates the PodScheduled and ImagePulled conditions of a running Devbox
+func GeneratePodConditions(devbox *devboxv1alpha1.Devbox, podList corev1.PodList) []metav1.Condition {
+	if len(podList.Items) == 0 {
+		return []metav1.Condition{
+			newCondition(devbox, devboxv1alpha1.DevboxConditionPodScheduled, metav1.ConditionUnknown, devboxv1alpha1.DevboxReasonPodNotCreated, "Devbox pod has not been created"),
+			newCondition(devbox, devboxv1alpha1.DevboxConditionImagePulled, metav1.ConditionUnknown, devboxv1alpha1.DevboxReasonPodNotCreated, "Devbox pod has not been created"),
+		}
+	}
+	pod := &podList.Items[0]
+	return []metav1.Condition{generatePodScheduledCondition(devbox, pod), generateImagePulledCondition(devbox, pod)}
+}
+
+func generatePodScheduledCondition(devbox *devboxv1alpha1.Devbox, pod *corev1.Pod) metav1.Condition {
+	for _, c := range pod.Status.Conditions {
+		if c.Type != corev1.PodScheduled {
+			continue
+		}
+		if c.Status == corev1.ConditionTrue {
+			return newCondition(devbox, devboxv1alpha1.DevboxConditionPodScheduled, metav1.ConditionTrue, devboxv1alpha1.DevboxReasonScheduled,
+				fmt.Sprintf("Devbox pod %s is scheduled to node %s", pod.Name, pod.Spec.NodeName))
+		}
+		reason := c.Reason
+		if reason == "" {
+			reason = devboxv1alpha1.DevboxReasonSchedulingPending
+		}
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionPodScheduled, metav1.ConditionFalse, reason, c.Message)
+	}
+	return newCondition(devbox, devboxv1alpha1.DevboxConditionPodScheduled, metav1.ConditionUnknown, devboxv1alpha1.DevboxReasonSchedulingPending,
+		fmt.Sprintf("Devbox pod %s is waiting to be scheduled", pod.Name))
+}
+
+func generateImagePulledCondition(devbox *devboxv1alpha1.Devbox, pod *corev1.Pod) metav1.Condition {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionImagePulled, metav1.ConditionUnknown, devboxv1alpha1.DevboxReasonPulling,
+			fmt.Sprintf("Devbox pod %s has no container status yet", pod.Name))
+	}
+	status := pod.Status.ContainerStatuses[0]
+	if waiting := status.State.Waiting; waiting != nil && imagePullFailedReasons[waiting.Reason] {
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionImagePulled, metav1.ConditionFalse, waiting.Reason, waiting.Message)
+	}
+	if status.ContainerID != "" || status.State.Running != nil || status.State.Terminated != nil {
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionImagePulled, metav1.ConditionTrue, devboxv1alpha1.DevboxReasonPulled,
+			fmt.Sprintf("Image %s is pulled", status.Image))
+	}
+	return newCondition(devbox, devboxv1alpha1.DevboxConditionImagePulled, metav1.ConditionUnknown, devboxv1alpha1.DevboxReasonPulling,
+		fmt.Sprintf("Image %s is being pulled", status.Image))
+}
+
+// GenerateCommitCondition generates the CommitSucceeded condition from the latest finished commit, it returns nil if no commit has finished
+func GenerateCommitCondition(devbox *devboxv1alpha1.Devbox) *metav1.Condition {
+	history := make([]*devboxv1alpha1.CommitHistory, 0, len(devbox.Status.CommitHistory))
+	for _, c := range devbox.Status.CommitHistory {
+		if c.Status != devboxv1alpha1.CommitStatusPending {
+			history = append(history, c)
+		}
+	}
+	if len(history) == 0 {
+		return nil
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Time.After(history[j].Time.Time)
+	})
+	var c metav1.Condition
+	switch latest := history[0]; latest.Status {
+	case devboxv1alpha1.CommitStatusSuccess:
+		c = newCondition(devbox, devboxv1alpha1.DevboxConditionCommitSucceeded, metav1.ConditionTrue, devboxv1alpha1.DevboxReasonCommitted,
+			fmt.Sprintf("Devbox pod %s is committed to image %s", latest.Pod, latest.Image))
+	case devboxv1alpha1.CommitStatusFailed:
+		c = newCondition(devbox, devboxv1alpha1.DevboxConditionCommitSucceeded, metav1.ConditionFalse, devboxv1alpha1.DevboxReasonCommitFailed,
+			fmt.Sprintf("Devbox pod %s failed to commit to image %s", latest.Pod, latest.Image))
+	default:
+		c = newCondition(devbox, devboxv1alpha1.DevboxConditionCommitSucceeded, metav1.ConditionUnknown, devboxv1alpha1.DevboxReasonCommitUnknown,
+			fmt.Sprintf("Commit status of devbox pod %s is %s", latest.Pod, latest.Status))
+	}
+	return &c
+}
+
+// GenerateNetworkCondition generates the NetworkReady condition from the network status
+func GenerateNetworkCondition(devbox *devboxv1alpha1.Devbox) metav1.Condition {
+	network := devbox.Status.Network
+	switch devbox.Spec.NetworkSpec.Type {
+	case devboxv1alpha1.NetworkTypeTailnet:
+		if network.TailNet != "" {
+			return newCondition(devbox, devboxv1alpha1.DevboxConditionNetworkReady, metav1.ConditionTrue, devboxv1alpha1.DevboxReasonTailnetAddressAssigned,
+				fmt.Sprintf("Devbox is reachable at tailnet address %s", network.TailNet))
+		}
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionNetworkReady, metav1.ConditionFalse, devboxv1alpha1.DevboxReasonTailnetNotRegistered,
+			"Devbox is not registered to the tailnet")
+	default:
+		if network.NodePort != 0 {
+			return newCondition(devbox, devboxv1alpha1.DevboxConditionNetworkReady, metav1.ConditionTrue, devboxv1alpha1.DevboxReasonNodePortAssigned,
+				fmt.Sprintf("Devbox is reachable at node port %d", network.NodePort))
+		}
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionNetworkReady, metav1.ConditionFalse, devboxv1alpha1.DevboxReasonNodePortNotAssigned,
+			"Devbox service has no node port")
+	}
+}
+
+// GenerateNetworkSyncFailedCondition generates the NetworkReady condition when the network failed to sync
+func GenerateNetworkSyncFailedCondition(devbox *devboxv1alpha1.Devbox, err error) metav1.Condition {
+	return newCondition(devbox, devboxv1alpha1.DevboxConditionNetworkReady, metav1.ConditionFalse, devboxv1alpha1.DevboxReasonNetworkSyncFailed, err.Error())
+}
+
+// GenerateQuotaExceededCondition generates the QuotaExceeded condition, err is the error of creating the Devbox pod
+func GenerateQuotaExceededCondition(devbox *devboxv1alpha1.Devbox, err error) metav1.Condition {
+	if err != nil {
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionQuotaExceeded, metav1.ConditionTrue, devboxv1alpha1.DevboxReasonExceededQuota, err.Error())
+	}
+	return newCondition(devbox, devboxv1alpha1.DevboxConditionQuotaExceeded, metav1.ConditionFalse, devboxv1alpha1.DevboxReasonPodCreated,
+		"Devbox pod is created within the quota")
+}
+
+// GeneratePersistentVolumeResizedCondition generates the PersistentVolumeResized condition, err is the error of resizing the persistent volume claim
+func GeneratePersistentVolumeResizedCondition(devbox *devboxv1alpha1.Devbox, err error) metav1.Condition {
+	if err != nil {
+		return newCondition(devbox, devboxv1alpha1.DevboxConditionPersistentVolumeResized, metav1.ConditionFalse, devboxv1alpha1.DevboxReasonResizeFailed,
+			fmt.Sprintf("Persistent volume could not be resized to %s: %v", devbox.Spec.PersistentVolume.Size.String(), err))
+	}
+	return newCondition(devbox, devboxv1alpha1.DevboxConditionPersistentVolumeResized, metav1.ConditionTrue, devboxv1alpha1.DevboxReasonResized,
+		fmt.Sprintf("Persistent volume is resized to %s", devbox.Spec.PersistentVolume.Size.String()))
+}
+
+func newCondition(devbox *devboxv1alpha1.Devbox, conditionType string, status metav1.ConditionStatus, reason string, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: devbox.Generation,
+		Reason:             reason,
+		Message:            message,
+	}
+}
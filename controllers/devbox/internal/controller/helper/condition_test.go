@@ -0,0 +1,140 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	devboxv1alpha1 "github.com/labring/sealos/controllers/devbox/api/v1alpha1"
+)
+
+func TestGeneratePodConditions(t *testing.T) {
+	devbox := &devboxv1alpha1.Devbox{}
+	tests := []struct {
+		name          string
+		pods          []corev1.Pod
+		wantScheduled metav1.ConditionStatus
+		wantPulled    metav1.ConditionStatus
+		wantReason    string
+	}{
+		{
+			name:          "no pod",
+			wantScheduled: metav1.ConditionUnknown,
+			wantPulled:    metav1.ConditionUnknown,
+			wantReason:    devboxv1alpha1.DevboxReasonPodNotCreated,
+		},
+		{
+			name: "unschedulable",
+			pods: []corev1.Pod{{
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/3 nodes are available"}},
+				},
+			}},
+			wantScheduled: metav1.ConditionFalse,
+			wantPulled:    metav1.ConditionUnknown,
+			wantReason:    devboxv1alpha1.DevboxReasonPulling,
+		},
+		{
+			name: "image pull back off",
+			pods: []corev1.Pod{{
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}},
+					ContainerStatuses: []corev1.ContainerStatus{{
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "back-off pulling image"}},
+					}},
+				},
+			}},
+			wantScheduled: metav1.ConditionTrue,
+			wantPulled:    metav1.ConditionFalse,
+			wantReason:    "ImagePullBackOff",
+		},
+		{
+			name: "running",
+			pods: []corev1.Pod{{
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}},
+					ContainerStatuses: []corev1.ContainerStatus{{
+						ContainerID: "containerd://abc",
+						State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					}},
+				},
+			}},
+			wantScheduled: metav1.ConditionTrue,
+			wantPulled:    metav1.ConditionTrue,
+			wantReason:    devboxv1alpha1.DevboxReasonPulled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := GeneratePodConditions(devbox, corev1.PodList{Items: tt.pods})
+			if len(conditions) != 2 {
+				t.Fatalf("GeneratePodConditions() returned %d conditions, want 2", len(conditions))
+			}
+			if conditions[0].Status != tt.wantScheduled {
+				t.Errorf("PodScheduled = %v, want %v", conditions[0].Status, tt.wantScheduled)
+			}
+			if conditions[1].Status != tt.wantPulled || conditions[1].Reason != tt.wantReason {
+				t.Errorf("ImagePulled = %v/%v, want %v/%v", conditions[1].Status, conditions[1].Reason, tt.wantPulled, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGenerateCommitCondition(t *testing.T) {
+	now := time.Now()
+	devbox := &devboxv1alpha1.Devbox{}
+	if c := GenerateCommitCondition(devbox); c != nil {
+		t.Errorf("GenerateCommitCondition() = %v, want nil without commit history", c)
+	}
+	devbox.Status.CommitHistory = []*devboxv1alpha1.CommitHistory{
+		{Pod: "devbox-a", Status: devboxv1alpha1.CommitStatusSuccess, Time: metav1.NewTime(now.Add(-2 * time.Hour))},
+		{Pod: "devbox-b", Status: devboxv1alpha1.CommitStatusFailed, Time: metav1.NewTime(now.Add(-time.Hour))},
+		{Pod: "devbox-c", Status: devboxv1alpha1.CommitStatusPending, Time: metav1.NewTime(now)},
+	}
+	c := GenerateCommitCondition(devbox)
+	if c == nil || c.Status != metav1.ConditionFalse || c.Reason != devboxv1alpha1.DevboxReasonCommitFailed {
+		t.Errorf("GenerateCommitCondition() = %v, want failed commit of devbox-b", c)
+	}
+}
+
+func TestSetConditions(t *testing.T) {
+	devbox := &devboxv1alpha1.Devbox{}
+	var conditions []metav1.Condition
+	quota := GenerateQuotaExceededCondition(devbox, errors.New("exceeded quota: cpu"))
+	if changed := SetConditions(&conditions, quota); len(changed) != 1 {
+		t.Fatalf("SetConditions() changed %d conditions, want 1", len(changed))
+	}
+	if IsConditionHealthy(quota) {
+		t.Errorf("IsConditionHealthy(%v) = true, want false", quota)
+	}
+	if changed := SetConditions(&conditions, quota); len(changed) != 0 {
+		t.Errorf("SetConditions() with the same condition changed %v", changed)
+	}
+	resolved := GenerateQuotaExceededCondition(devbox, nil)
+	if changed := SetConditions(&conditions, resolved); len(changed) != 1 {
+		t.Errorf("SetConditions() changed %d conditions, want 1", len(changed))
+	}
+	if !IsConditionHealthy(resolved) {
+		t.Errorf("IsConditionHealthy(%v) = false, want true", resolved)
+	}
+	if len(conditions) != 1 {
+		t.Errorf("conditions = %v, want a single QuotaExceeded condition", conditions)
+	}
+}
@@ -31,6 +31,7 @@ import (
 
 	devboxv1alpha1 "github.com/labring/sealos/controllers/devbox/api/v1alpha1"
 	utilsresource "github.com/labring/sealos/controllers/devbox/internal/controller/utils/resource"
+	"github.com/labring/sealos/controllers/devbox/internal/controller/utils/tailnet"
 	"github.com/labring/sealos/controllers/devbox/label"
 )
 
@@ -352,6 +353,103 @@ func GenerateStartupVolumeMounts() []corev1.VolumeMount {
 	}
 }
 
+// GetPersistentVolumeClaimName get the name of the persistent volume claim of the Devbox
+func GetPersistentVolumeClaimName(devbox *devboxv1alpha1.Devbox) string {
+	return devbox.Name + "-home"
+}
+
+// GetPersistentVolumeMountPath get the mount path of the persistent volume
+func GetPersistentVolumeMountPath(devbox *devboxv1alpha1.Devbox) string {
+	return devbox.Spec.PersistentVolume.MountPath
+}
+
+// GeneratePersistentVolume generates a volume for the persistent volume claim of the Devbox
+func GeneratePersistentVolume(devbox *devboxv1alpha1.Devbox) corev1.Volume {
+	return corev1.Volume{
+		Name: "devbox-home",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: GetPersistentVolumeClaimName(devbox),
+			},
+		},
+	}
+}
+
+// GeneratePersistentVolumeMounts generates volume mounts for the persistent volume of the Devbox
+func GeneratePersistentVolumeMounts(devbox *devboxv1alpha1.Devbox) []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			Name:      "devbox-home",
+			MountPath: GetPersistentVolumeMountPath(devbox),
+		},
+	}
+}
+
+// GetTailnetNodeName get the name of the Devbox in the tailnet
+func GetTailnetNodeName(devbox *devboxv1alpha1.Devbox) string {
+	return devbox.Namespace + "." + devbox.Name
+}
+
+// GenerateTailnetSidecar generates the sidecar joining the Devbox pod to the tailnet, it runs as a restartable init container
+// so the Devbox container stays the first container of the pod
+func GenerateTailnetSidecar(devbox *devboxv1alpha1.Devbox, config *tailnet.Config) corev1.Container {
+	sidecar := corev1.Container{
+		Name:          "tailnet",
+		Image:         config.SidecarImage,
+		RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways),
+		Env: []corev1.EnvVar{
+			{
+				Name:  "TAILNET_NODE_NAME",
+				Value: GetTailnetNodeName(devbox),
+			},
+			{
+				Name:  "TAILNET_ADDRESS",
+				Value: devbox.Status.Network.TailNet,
+			},
+			{
+				Name:  "TAILNET_CONTROL_URL",
+				Value: config.ControlURL,
+			},
+			{
+				Name: "TAILNET_PRIVATE_KEY",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: devbox.Name},
+						Key:                  "SEALOS_DEVBOX_TAILNET_PRIVATE_KEY",
+					},
+				},
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("16Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Capabilities: &corev1.Capabilities{
+				Add: []corev1.Capability{"NET_ADMIN"},
+			},
+		},
+	}
+	if config.ControlToken != "" {
+		sidecar.Env = append(sidecar.Env, corev1.EnvVar{
+			Name: "TAILNET_CONTROL_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: devbox.Name},
+					Key:                  "SEALOS_DEVBOX_TAILNET_CONTROL_TOKEN",
+				},
+			},
+		})
+	}
+	return sidecar
+}
+
 // GenerateResourceRequirements generates the resource requirements for the Devbox pod
 func GenerateResourceRequirements(devbox *devboxv1alpha1.Devbox, requestRate utilsresource.RequestRate, ephemeralStorage utilsresource.EphemeralStorage) corev1.ResourceRequirements {
 	return corev1.ResourceRequirements{
@@ -27,7 +27,8 @@ type Client struct {
 }
 
 var (
-	ErrorManifestNotFound = errors.New("manifest not found")
+	ErrorManifestNotFound       = errors.New("manifest not found")
+	ErrorTagDeletionUnsupported = errors.New("tag deletion is not supported by the registry")
 )
 
 // TODO: refactor tag image, use go package to do this
@@ -40,6 +41,37 @@ func (t *Client) TagImage(hostName string, imageName string, oldTag string, newT
 	return t.pushManifest(t.Username, t.Password, hostName, imageName, newTag, manifest)
 }
 
+// DeleteTag removes a tag from the registry, the manifest is kept as long as other tags still reference it
+func (t *Client) DeleteTag(hostName string, imageName string, tag string) error {
+	var (
+		client = http.DefaultClient
+		url    = "http://" + hostName + "/v2/" + imageName + "/manifests/" + tag
+	)
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.Username, t.Password)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		return nil
+	case http.StatusNotFound:
+		return ErrorManifestNotFound
+	case http.StatusBadRequest, http.StatusMethodNotAllowed:
+		// registries which only allow deleting manifests by digest or have deletion disabled
+		return ErrorTagDeletionUnsupported
+	default:
+		return errors.New(resp.Status)
+	}
+}
+
 func (t *Client) pullManifest(username string, password string, hostName string, imageName string, tag string) ([]byte, error) {
 	var (
 		client = http.DefaultClient
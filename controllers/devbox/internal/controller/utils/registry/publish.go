@@ -0,0 +1,143 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PushImage copies hostName/imageName:tag from the registry to target and returns the digest of the pushed manifest.
+// The manifest is copied as is, so the digest is the same in both registries.
+func (t *Client) PushImage(ctx context.Context, hostName string, imageName string, tag string, target name.Reference, targetAuth authn.Authenticator) (string, error) {
+	source, err := name.ParseReference(hostName+"/"+imageName+":"+tag, name.Insecure)
+	if err != nil {
+		return "", err
+	}
+	desc, err := remote.Get(source,
+		remote.WithContext(ctx),
+		remote.WithAuth(&authn.Basic{Username: t.Username, Password: t.Password}))
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return "", ErrorManifestNotFound
+		}
+		return "", err
+	}
+
+	if targetAuth == nil {
+		targetAuth = authn.Anonymous
+	}
+	options := []remote.Option{remote.WithContext(ctx), remote.WithAuth(targetAuth)}
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return "", err
+		}
+		err = remote.WriteIndex(target, index, options...)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		image, err := desc.Image()
+		if err != nil {
+			return "", err
+		}
+		err = remote.Write(target, image, options...)
+		if err != nil {
+			return "", err
+		}
+	}
+	return desc.Digest.String(), nil
+}
+
+// IsTransient reports whether err is worth retrying: a network error, or a registry error which
+// the registry reports as temporary, like 429 Too Many Requests or 503 Service Unavailable
+func IsTransient(err error) bool {
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		return terr.Temporary()
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr)
+}
+
+// AuthFromSecret returns the credentials for registry stored in secret, the secret is either
+// a kubernetes.io/dockerconfigjson secret or a secret with username and password keys.
+func AuthFromSecret(secret *corev1.Secret, registry string) (authn.Authenticator, error) {
+	if data, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+		return authFromDockerConfig(data, registry)
+	}
+	username, password := secret.Data[corev1.BasicAuthUsernameKey], secret.Data[corev1.BasicAuthPasswordKey]
+	if len(username) == 0 || len(password) == 0 {
+		return nil, fmt.Errorf("secret %s/%s has neither %s nor %s and %s keys", secret.Namespace, secret.Name,
+			corev1.DockerConfigJsonKey, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	}
+	return &authn.Basic{Username: string(username), Password: string(password)}, nil
+}
+
+type dockerConfig struct {
+	Auths map[string]authn.AuthConfig `json:"auths"`
+}
+
+func authFromDockerConfig(data []byte, registry string) (authn.Authenticator, error) {
+	config := dockerConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	for server, auth := range config.Auths {
+		if registryHost(server) != registry {
+			continue
+		}
+		// auth holds base64 encoded username:password, used when username and password are not set
+		if auth.Username == "" && auth.Password == "" && auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode auth of %s: %w", server, err)
+			}
+			username, password, found := strings.Cut(string(decoded), ":")
+			if !found {
+				return nil, fmt.Errorf("invalid auth of %s", server)
+			}
+			auth.Username, auth.Password = username, password
+		}
+		return authn.FromConfig(auth), nil
+	}
+	return nil, fmt.Errorf("no credentials found for registry %s", registry)
+}
+
+// registryHost strips the scheme and path of docker config server keys, e.g. https://index.docker.io/v1/
+func registryHost(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	server, _, _ = strings.Cut(server, "/")
+	if server == "docker.io" {
+		return name.DefaultRegistry
+	}
+	return server
+}
@@ -0,0 +1,244 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newTestRegistry(t *testing.T) string {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func mustParseReference(t *testing.T, ref string) name.Reference {
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestClient_PushImage(t *testing.T) {
+	source, target := newTestRegistry(t), newTestRegistry(t)
+	image, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(mustParseReference(t, source+"/ns/devbox:v1"), image); err != nil {
+		t.Fatal(err)
+	}
+	want, err := image.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Client{}
+	targetRef := mustParseReference(t, target+"/team/app:v1")
+	digest, err := c.PushImage(context.Background(), source, "ns/devbox", "v1", targetRef, nil)
+	if err != nil {
+		t.Fatalf("PushImage() error = %v", err)
+	}
+	if digest != want.String() {
+		t.Errorf("PushImage() digest = %v, want %v", digest, want)
+	}
+	desc, err := remote.Head(targetRef)
+	if err != nil {
+		t.Fatalf("target image not found: %v", err)
+	}
+	if desc.Digest != want {
+		t.Errorf("target digest = %v, want %v", desc.Digest, want)
+	}
+
+	_, err = c.PushImage(context.Background(), source, "ns/devbox", "missing", targetRef, nil)
+	if !errors.Is(err, ErrorManifestNotFound) {
+		t.Errorf("PushImage() error = %v, want %v", err, ErrorManifestNotFound)
+	}
+}
+
+func TestClient_DeleteTag(t *testing.T) {
+	host := newTestRegistry(t)
+	image, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"commit", "release"} {
+		if err := remote.Write(mustParseReference(t, host+"/ns/devbox:"+tag), image); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &Client{}
+	if err := c.DeleteTag(host, "ns/devbox", "release"); err != nil {
+		t.Fatalf("DeleteTag() error = %v", err)
+	}
+	if _, err := remote.Head(mustParseReference(t, host+"/ns/devbox:release")); err == nil {
+		t.Errorf("release tag still exists")
+	}
+	if _, err := remote.Head(mustParseReference(t, host+"/ns/devbox:commit")); err != nil {
+		t.Errorf("commit tag should be kept: %v", err)
+	}
+	if err := c.DeleteTag(host, "ns/devbox", "release"); !errors.Is(err, ErrorManifestNotFound) {
+		t.Errorf("DeleteTag() error = %v, want %v", err, ErrorManifestNotFound)
+	}
+}
+
+func TestClient_DeleteTag_Unsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	c := &Client{}
+	err := c.DeleteTag(strings.TrimPrefix(server.URL, "http://"), "ns/devbox", "release")
+	if !errors.Is(err, ErrorTagDeletionUnsupported) {
+		t.Errorf("DeleteTag() error = %v, want %v", err, ErrorTagDeletionUnsupported)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	source := newTestRegistry(t)
+	image, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(mustParseReference(t, source+"/ns/devbox:v1"), image); err != nil {
+		t.Fatal(err)
+	}
+	statusTarget := func(status int) string {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+		return strings.TrimPrefix(server.URL, "http://")
+	}
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name   string
+		target string
+		want   bool
+	}{
+		{name: "unreachable registry", target: strings.TrimPrefix(closed.URL, "http://"), want: true},
+		{name: "unavailable registry", target: statusTarget(http.StatusServiceUnavailable), want: true},
+		{name: "unauthorized", target: statusTarget(http.StatusUnauthorized), want: false},
+	}
+	c := &Client{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.PushImage(context.Background(), source, "ns/devbox", "v1", mustParseReference(t, tt.target+"/team/app:v1"), nil)
+			if err == nil {
+				t.Fatal("PushImage() succeeded, want error")
+			}
+			if got := IsTransient(err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+	if IsTransient(errors.New("invalid target image")) {
+		t.Errorf("IsTransient() = true for a plain error")
+	}
+}
+
+func TestAuthFromSecret(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("robot:token"))
+	tests := []struct {
+		name         string
+		data         map[string][]byte
+		registry     string
+		wantUsername string
+		wantPassword string
+		wantErr      bool
+	}{
+		{
+			name: "basic auth",
+			data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte("admin"),
+				corev1.BasicAuthPasswordKey: []byte("passw0rd"),
+			},
+			registry:     "registry.example.com",
+			wantUsername: "admin",
+			wantPassword: "passw0rd",
+		},
+		{
+			name: "docker config with username and password",
+			data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"username":"admin","password":"passw0rd"}}}`),
+			},
+			registry:     "registry.example.com",
+			wantUsername: "admin",
+			wantPassword: "passw0rd",
+		},
+		{
+			name: "docker config with encoded auth",
+			data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"` + encoded + `"}}}`),
+			},
+			registry:     name.DefaultRegistry,
+			wantUsername: "robot",
+			wantPassword: "token",
+		},
+		{
+			name: "docker config without registry",
+			data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(`{"auths":{"other.example.com":{"username":"admin","password":"passw0rd"}}}`),
+			},
+			registry: "registry.example.com",
+			wantErr:  true,
+		},
+		{
+			name:     "empty secret",
+			data:     map[string][]byte{},
+			registry: "registry.example.com",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := AuthFromSecret(&corev1.Secret{Data: tt.data}, tt.registry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuthFromSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			config, err := authn.Authorization(context.Background(), auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Username != tt.wantUsername || config.Password != tt.wantPassword {
+				t.Errorf("AuthFromSecret() = %v:%v, want %v:%v", config.Username, config.Password, tt.wantUsername, tt.wantPassword)
+			}
+		})
+	}
+}
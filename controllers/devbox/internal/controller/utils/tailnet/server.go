@@ -0,0 +1,130 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sync"
+)
+
+// Server is a minimal in-memory coordination server, it assigns addresses from a prefix and
+// serves the api used by Client. It is a local stand-in for development and tests.
+type Server struct {
+	prefix netip.Prefix
+	token  string
+
+	mu    sync.Mutex
+	nodes map[string]*Node
+	used  map[netip.Addr]bool
+	mux   *http.ServeMux
+}
+
+// NewServer creates a coordination server assigning addresses from prefix, requests must carry token if it is not empty
+func NewServer(prefix netip.Prefix, token string) *Server {
+	s := &Server{
+		prefix: prefix.Masked(),
+		token:  token,
+		nodes:  make(map[string]*Node),
+		used:   make(map[netip.Addr]bool),
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("PUT /api/v1/nodes/{name}", s.register)
+	s.mux.HandleFunc("DELETE /api/v1/nodes/{name}", s.deregister)
+	s.mux.HandleFunc("GET /api/v1/nodes", s.list)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Nodes returns a copy of the registered nodes
+func (s *Server) Nodes() []Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodes := make([]Node, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		nodes = append(nodes, *n)
+	}
+	return nodes
+}
+
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	req := &Node{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid node: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.PublicKey == "" {
+		http.Error(w, "public key is required", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node, ok := s.nodes[name]
+	if !ok {
+		addr, err := s.allocate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		node = &Node{Name: name, Address: addr.String()}
+		s.nodes[name] = node
+	}
+	node.PublicKey = req.PublicKey
+	writeJSON(w, node)
+}
+
+func (s *Server) deregister(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node, ok := s.nodes[r.PathValue("name")]
+	if !ok {
+		http.Error(w, "node not found", http.StatusNotFound)
+		return
+	}
+	delete(s.nodes, node.Name)
+	delete(s.used, netip.MustParseAddr(node.Address))
+	writeJSON(w, node)
+}
+
+func (s *Server) list(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.Nodes())
+}
+
+// allocate returns the first free address of the prefix, skipping the network address
+func (s *Server) allocate() (netip.Addr, error) {
+	for addr := s.prefix.Addr().Next(); s.prefix.Contains(addr); addr = addr.Next() {
+		if !s.used[addr] {
+			s.used[addr] = true
+			return addr, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("no address left in %s", s.prefix)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,143 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailnet
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// Node is a devbox registered in the tailnet
+type Node struct {
+	// Name identifies the devbox, it is the devbox namespace and name joined by a dot,
+	// namespaces contain no dots so names of devboxes in different namespaces never clash
+	Name string `json:"name"`
+	// PublicKey is the base64 encoded WireGuard public key of the devbox
+	PublicKey string `json:"publicKey"`
+	// Address is the tailnet address assigned to the devbox by the coordinator
+	Address string `json:"address,omitempty"`
+}
+
+// Coordinator assigns tailnet addresses to devbox nodes and distributes their keys to peers
+type Coordinator interface {
+	// Register registers the node or updates its public key, the address of a registered node never changes
+	Register(ctx context.Context, name string, publicKey string) (*Node, error)
+	// Deregister removes the node from the tailnet, removing an unknown node is not an error
+	Deregister(ctx context.Context, name string) error
+}
+
+// Config configures the tailnet network type of devboxes
+type Config struct {
+	Coordinator Coordinator
+	// ControlURL is the url of the coordination server the sidecar fetches peers from
+	ControlURL string
+	// ControlToken authenticates the sidecar to the control url, it is copied into the devbox secret
+	ControlToken string
+	// SidecarImage is the image of the tailnet sidecar injected into devbox pods
+	SidecarImage string
+}
+
+// GenerateKey generates a WireGuard key pair, both keys are base64 encoded
+func GenerateKey() (string, string, error) {
+	privateKey := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(privateKey); err != nil {
+		return "", "", err
+	}
+	// clamp the private key as described in https://cr.yp.to/ecdh.html
+	privateKey[0] &= 248
+	privateKey[31] = (privateKey[31] & 127) | 64
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(privateKey), base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// Client is a Coordinator talking to a coordination server over http
+type Client struct {
+	URL   string
+	Token string
+
+	HTTPClient *http.Client
+}
+
+func (c *Client) Register(ctx context.Context, name string, publicKey string) (*Node, error) {
+	body, err := json.Marshal(&Node{Name: name, PublicKey: publicKey})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, http.MethodPut, name, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	node := &Node{}
+	if err := json.NewDecoder(resp.Body).Decode(node); err != nil {
+		return nil, fmt.Errorf("failed to decode node: %w", err)
+	}
+	if node.Address == "" {
+		return nil, fmt.Errorf("coordinator assigned no address to node %s", name)
+	}
+	return node, nil
+}
+
+func (c *Client) Deregister(ctx context.Context, name string) error {
+	resp, err := c.do(ctx, http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method string, name string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+"/api/v1/nodes/"+url.PathEscape(name), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+func responseError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if len(message) == 0 {
+		return errors.New(resp.Status)
+	}
+	return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(message))
+}
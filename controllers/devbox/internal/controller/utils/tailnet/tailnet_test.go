@@ -0,0 +1,99 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailnet
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestGenerateKey(t *testing.T) {
+	privateKey, publicKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil || len(private) != curve25519.ScalarSize {
+		t.Fatalf("invalid private key %q: %v", privateKey, err)
+	}
+	public, err := curve25519.X25519(private, curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base64.StdEncoding.EncodeToString(public) != publicKey {
+		t.Errorf("public key %q does not belong to private key", publicKey)
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(NewServer(netip.MustParsePrefix("100.64.0.0/30"), "token"))
+	defer server.Close()
+	ctx := context.Background()
+	c := &Client{URL: server.URL, Token: "token"}
+
+	first, err := c.Register(ctx, "ns.devbox-a", "key-a")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if first.Address != "100.64.0.1" {
+		t.Errorf("Register() address = %v, want 100.64.0.1", first.Address)
+	}
+	// registering again with a rotated key keeps the address
+	again, err := c.Register(ctx, "ns.devbox-a", "key-a2")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if again.Address != first.Address || again.PublicKey != "key-a2" {
+		t.Errorf("Register() = %+v, want address %v and key key-a2", again, first.Address)
+	}
+
+	second, err := c.Register(ctx, "ns.devbox-b", "key-b")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if second.Address != "100.64.0.2" {
+		t.Errorf("Register() address = %v, want 100.64.0.2", second.Address)
+	}
+	if _, err := c.Register(ctx, "ns.devbox-c", "key-c"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if _, err := c.Register(ctx, "ns.devbox-d", "key-d"); err == nil {
+		t.Errorf("Register() expected error when prefix is exhausted")
+	}
+
+	if err := c.Deregister(ctx, "ns.devbox-a"); err != nil {
+		t.Fatalf("Deregister() error = %v", err)
+	}
+	if err := c.Deregister(ctx, "ns.devbox-a"); err != nil {
+		t.Errorf("Deregister() of unknown node error = %v", err)
+	}
+	reused, err := c.Register(ctx, "ns.devbox-d", "key-d")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if reused.Address != first.Address {
+		t.Errorf("Register() address = %v, want released address %v", reused.Address, first.Address)
+	}
+
+	unauthorized := &Client{URL: server.URL}
+	if _, err := unauthorized.Register(ctx, "ns.devbox-e", "key-e"); err == nil {
+		t.Errorf("Register() without token expected error")
+	}
+}
@@ -1978,6 +1978,10 @@ func (c *Cockroach) createTables() error {
 		types.WorkspaceSubscriptionPlan{},
 		types.ProductPrice{},
 		types.UserAlertNotificationAccount{},
+		types.UserNotificationChannel{},
+		types.NotificationOutbox{},
+		types.NotificationPreference{},
+		types.NotificationHeldEvent{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create table: %w", err)
@@ -22,5 +22,10 @@ func (c *Cockroach) GetNotificationRecipient(
 	if err != nil {
 		return nil, err
 	}
+	err = c.DB.Where("user_uid = ? AND is_enabled = ?", userUID, true).
+		Find(&result.Channels).Error
+	if err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
@@ -421,6 +421,19 @@ type NotificationRecipient struct {
 	PhoneNumber string    `json:"phone_number,omitempty" gorm:"column:phone_number"`
 	UserID      string    `json:"user_id,omitempty"      gorm:"column:user_id"`
 	UserUID     uuid.UUID `json:"user_uid,omitempty"     gorm:"column:user_uid"`
+	// Channels 用户启用的群聊机器人和Webhook通知渠道
+	Channels []UserNotificationChannel `json:"channels,omitempty" gorm:"-"`
+}
+
+// ChannelsOf 返回指定通知方式的渠道
+func (r *NotificationRecipient) ChannelsOf(method string) []UserNotificationChannel {
+	var channels []UserNotificationChannel
+	for _, channel := range r.Channels {
+		if channel.Method == method {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
 }
 
 // UserAlertNotificationAccount 用户自定义告警通知账号
@@ -437,3 +450,104 @@ type UserAlertNotificationAccount struct {
 func (UserAlertNotificationAccount) TableName() string {
 	return "UserAlertNotificationAccount"
 }
+
+// UserNotificationChannel 用户通知渠道，如通用Webhook、Slack、钉钉和企业微信机器人
+type UserNotificationChannel struct {
+	ID      uuid.UUID `json:"id"               gorm:"column:id;type:uuid;default:gen_random_uuid();primary_key"`
+	UserUID uuid.UUID `json:"user_uid"         gorm:"column:user_uid;type:uuid;not null;index"`
+	// Method 通知方式：webhook、slack、dingtalk、wecom
+	Method string `json:"method"           gorm:"column:method;type:text;not null"`
+	URL    string `json:"url"              gorm:"column:url;type:text;not null"`
+	// Secret Webhook和钉钉机器人的签名密钥
+	Secret    string    `json:"secret,omitempty" gorm:"column:secret;type:text"`
+	IsEnabled bool      `json:"is_enabled"       gorm:"column:is_enabled;type:boolean;not null;default:true"`
+	CreatedAt time.Time `json:"created_at"       gorm:"column:created_at;type:timestamp(3);default:current_timestamp"`
+	UpdatedAt time.Time `json:"updated_at"       gorm:"column:updated_at;type:timestamp(3);default:current_timestamp"`
+}
+
+func (UserNotificationChannel) TableName() string {
+	return "UserNotificationChannel"
+}
+
+type NotificationOutboxStatus string
+
+const (
+	// NotificationOutboxStatusPending 等待投递或等待重试
+	NotificationOutboxStatusPending NotificationOutboxStatus = "pending"
+	// NotificationOutboxStatusDelivered 投递成功
+	NotificationOutboxStatusDelivered NotificationOutboxStatus = "delivered"
+	// NotificationOutboxStatusSkipped 用户没有该通知方式的联系方式，未投递
+	NotificationOutboxStatusSkipped NotificationOutboxStatus = "skipped"
+	// NotificationOutboxStatusFailed 重试次数用尽，投递失败
+	NotificationOutboxStatusFailed NotificationOutboxStatus = "failed"
+	// NotificationOutboxStatusHeld 根据用户偏好延迟到免打扰时段结束或摘要中发送
+	NotificationOutboxStatusHeld NotificationOutboxStatus = "held"
+)
+
+// NotificationOutbox 通知发件箱，每条记录为一个事件的一种通知方式，同时作为用户的投递历史
+type NotificationOutbox struct {
+	ID uuid.UUID `json:"id"                          gorm:"column:id;type:uuid;default:gen_random_uuid();primary_key"`
+	// IdempotencyKey 幂等键，相同幂等键的事件只投递一次
+	IdempotencyKey string    `json:"idempotency_key"             gorm:"column:idempotency_key;type:text;not null;uniqueIndex"`
+	UserUID        uuid.UUID `json:"user_uid"                    gorm:"column:user_uid;type:uuid;not null;index:idx_notification_outbox_user,priority:1"`
+	EventType      string    `json:"event_type"                  gorm:"column:event_type;type:text;not null"`
+	Method         string    `json:"method"                      gorm:"column:method;type:text;not null"`
+	Priority       string    `json:"priority"                    gorm:"column:priority;type:text"`
+	// Payload 序列化的通知事件
+	Payload       string                   `json:"-"                           gorm:"column:payload;type:text;not null"`
+	Status        NotificationOutboxStatus `json:"status"                      gorm:"column:status;type:text;not null;index:idx_notification_outbox_due,priority:1"`
+	Attempts      int                      `json:"attempts"                    gorm:"column:attempts;type:int;not null;default:0"`
+	NextAttemptAt time.Time                `json:"next_attempt_at"             gorm:"column:next_attempt_at;type:timestamp(3);not null;index:idx_notification_outbox_due,priority:2"`
+	LastError     string                   `json:"last_error,omitempty"        gorm:"column:last_error;type:text"`
+	// ProviderResponse 投递成功时提供者的响应
+	ProviderResponse string     `json:"provider_response,omitempty" gorm:"column:provider_response;type:text"`
+	DeliveredAt      *time.Time `json:"delivered_at,omitempty"      gorm:"column:delivered_at;type:timestamp(3)"`
+	CreatedAt        time.Time  `json:"created_at"                  gorm:"column:created_at;type:timestamp(3);default:current_timestamp;index:idx_notification_outbox_user,priority:2"`
+	UpdatedAt        time.Time  `json:"updated_at"                  gorm:"column:updated_at;type:timestamp(3);default:current_timestamp"`
+}
+
+func (NotificationOutbox) TableName() string {
+	return "NotificationOutbox"
+}
+
+// NotificationPreference 用户通知偏好，Workspace 为空时对用户的所有工作空间生效，
+// 否则只对该工作空间的事件生效并优先于用户级偏好
+type NotificationPreference struct {
+	ID        uuid.UUID `json:"id"                      gorm:"column:id;type:uuid;default:gen_random_uuid();primary_key"`
+	UserUID   uuid.UUID `json:"user_uid"                gorm:"column:user_uid;type:uuid;not null;uniqueIndex:idx_notification_preference_scope"`
+	Workspace string    `json:"workspace"               gorm:"column:workspace;type:text;not null;default:'';uniqueIndex:idx_notification_preference_scope"`
+	// EventMethods 事件类型可用的通知方式，键为事件类型，"*" 匹配其他事件类型；为空时不限制
+	EventMethods map[string][]string `json:"event_methods,omitempty" gorm:"column:event_methods;type:jsonb;serializer:json"`
+	// MinPriority 最低通知优先级，低于该优先级的事件不通知
+	MinPriority string `json:"min_priority,omitempty"  gorm:"column:min_priority;type:text"`
+	// QuietHoursStart QuietHoursEnd 免打扰时段，格式为 HH:MM，可跨越零点
+	QuietHoursStart string `json:"quiet_hours_start,omitempty" gorm:"column:quiet_hours_start;type:text"`
+	QuietHoursEnd   string `json:"quiet_hours_end,omitempty"   gorm:"column:quiet_hours_end;type:text"`
+	// TimeZone 免打扰时段的时区，如 Asia/Shanghai，默认为UTC
+	TimeZone string `json:"time_zone,omitempty"     gorm:"column:time_zone;type:text"`
+	// DigestInterval 摘要合并的间隔（分钟），0 表示不合并
+	DigestInterval int       `json:"digest_interval"         gorm:"column:digest_interval;type:int;not null;default:0"`
+	CreatedAt      time.Time `json:"created_at"              gorm:"column:created_at;type:timestamp(3);default:current_timestamp"`
+	UpdatedAt      time.Time `json:"updated_at"              gorm:"column:updated_at;type:timestamp(3);default:current_timestamp"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "NotificationPreference"
+}
+
+// NotificationHeldEvent 因免打扰时段或摘要合并而延迟发送的通知
+type NotificationHeldEvent struct {
+	ID      uuid.UUID `json:"id"         gorm:"column:id;type:uuid;default:gen_random_uuid();primary_key"`
+	UserUID uuid.UUID `json:"user_uid"   gorm:"column:user_uid;type:uuid;not null"`
+	Method  string    `json:"method"     gorm:"column:method;type:text;not null"`
+	// Payload 序列化的通知事件
+	Payload string `json:"-"          gorm:"column:payload;type:text;not null"`
+	// Digest 是否与同一用户同一通知方式的其他通知合并为摘要发送
+	Digest    bool      `json:"digest"     gorm:"column:digest;type:boolean;not null;default:false"`
+	ReleaseAt time.Time `json:"release_at" gorm:"column:release_at;type:timestamp(3);not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp(3);default:current_timestamp"`
+}
+
+func (NotificationHeldEvent) TableName() string {
+	return "NotificationHeldEvent"
+}
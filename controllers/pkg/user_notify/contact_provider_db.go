@@ -0,0 +1,57 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernotify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+)
+
+// RecipientGetter 查询用户的通知接收者信息
+type RecipientGetter interface {
+	GetNotificationRecipient(userUID uuid.UUID) (*types.NotificationRecipient, error)
+}
+
+// DBContactProvider 优先使用内存中设置的联系方式，没有时从数据库查询。
+// 发件箱异步投递时请求中设置的联系方式已被移除，需要从数据库查询。
+type DBContactProvider struct {
+	*MemoryContactProvider
+	db RecipientGetter
+}
+
+func NewDBContactProvider(db RecipientGetter) *DBContactProvider {
+	return &DBContactProvider{
+		MemoryContactProvider: NewMemoryContactProvider(),
+		db:                    db,
+	}
+}
+
+func (p *DBContactProvider) GetUserContact(
+	ctx context.Context,
+	userUID uuid.UUID,
+) (*types.NotificationRecipient, error) {
+	if contact, err := p.MemoryContactProvider.GetUserContact(ctx, userUID); err == nil {
+		return contact, nil
+	}
+
+	contact, err := p.db.GetNotificationRecipient(userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification recipient for user %s: %w", userUID, err)
+	}
+	return contact, nil
+}
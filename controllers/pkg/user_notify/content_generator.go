@@ -22,6 +22,9 @@ import (
 	"github.com/labring/sealos/controllers/pkg/types"
 )
 
+// emailFooter 邮件内容的页脚
+const emailFooter = "\n\n如有疑问，请联系技术支持。\n\n此邮件由Sealos系统自动发送，请勿回复。"
+
 // DefaultContentGenerator 默认内容生成器
 type DefaultContentGenerator struct {
 	config map[NotificationMethod]ProviderConfig
@@ -60,6 +63,8 @@ func (g *DefaultContentGenerator) GenerateContent(
 		return g.generateTrafficContent(method, event.EventData, event.Recipient)
 	case EventTypeCustom:
 		return g.generateCustomContent(method, event.EventData, event.Recipient)
+	case EventTypeNotificationDigest:
+		return g.generateDigestContent(method, event)
 	default:
 		return "", "", "", fmt.Errorf("unsupported event type: %s", event.EventType)
 	}
@@ -317,6 +322,39 @@ func (g *DefaultContentGenerator) generateCustomContent(
 	return title, content, templateID, nil
 }
 
+// generateDigestContent 生成通知摘要内容，逐条生成被合并通知的标题和内容
+func (g *DefaultContentGenerator) generateDigestContent(
+	method NotificationMethod,
+	event *NotificationEvent,
+) (title, content, templateID string, err error) {
+	var digestData DigestEventData
+	dataBytes, err := json.Marshal(event.EventData)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal digest event data: %w", err)
+	}
+	if err := json.Unmarshal(dataBytes, &digestData); err != nil {
+		return "", "", "", fmt.Errorf("failed to parse digest event data: %w", err)
+	}
+
+	var builder strings.Builder
+	for i := range digestData.Events {
+		item := &digestData.Events[i]
+		item.Recipient = event.Recipient
+		itemTitle, itemContent, _, err := g.GenerateContent(item, method)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to generate digest item %s: %w", item.EventType, err)
+		}
+		fmt.Fprintf(&builder, "%d. %s\n%s\n\n", i+1, itemTitle, strings.TrimSuffix(itemContent, emailFooter))
+	}
+
+	title = fmt.Sprintf("Sealos Notification Digest: %d notifications", len(digestData.Events))
+	content = strings.TrimSpace(builder.String())
+	if method == NotificationMethodEmail {
+		content = g.enrichEmailContent(content)
+	}
+	return title, content, "", nil
+}
+
 // formatContent 格式化内容，根据不同的通知方式进行适配
 func (g *DefaultContentGenerator) formatContent(
 	method NotificationMethod,
@@ -359,7 +397,7 @@ func (g *DefaultContentGenerator) simplifySMSContent(content string) string {
 // enrichEmailContent 丰富Email内容
 func (g *DefaultContentGenerator) enrichEmailContent(content string) string {
 	// Email可以添加更多详细信息和格式
-	return content + "\n\n如有疑问，请联系技术支持。\n\n此邮件由Sealos系统自动发送，请勿回复。"
+	return content + emailFooter
 }
 
 // optimizeVMSContent 优化VMS语音内容
@@ -0,0 +1,425 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultOutboxPollInterval  = 10 * time.Second
+	defaultOutboxBatchSize     = 50
+	defaultOutboxLeaseDuration = 5 * time.Minute
+	defaultOutboxMaxBackoff    = time.Hour
+	defaultOutboxRetryDelay    = 30 * time.Second
+	defaultOutboxMaxRetries    = 5
+)
+
+// EnqueueEvent 将事件写入通知发件箱，每种通知方式一条记录。
+// tx 应为引起该事件的业务状态变更所在的事务，事务提交后才会投递；
+// 幂等键相同的事件只写入一次。
+func EnqueueEvent(tx *gorm.DB, event *NotificationEvent, idempotencyKey string) error {
+	if idempotencyKey == "" {
+		return errors.New("idempotency key is required")
+	}
+	if len(event.Methods) == 0 {
+		return nil
+	}
+	if event.Priority == "" {
+		event.Priority = NotificationPriorityNormal
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification event: %w", err)
+	}
+
+	now := time.Now()
+	entries := make([]types.NotificationOutbox, 0, len(event.Methods))
+	for _, method := range event.Methods {
+		entries = append(entries, types.NotificationOutbox{
+			ID:             uuid.New(),
+			IdempotencyKey: idempotencyKey + "/" + string(method),
+			UserUID:        event.UserUID,
+			EventType:      string(event.EventType),
+			Method:         string(method),
+			Priority:       string(event.Priority),
+			Payload:        string(payload),
+			Status:         types.NotificationOutboxStatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "idempotency_key"}},
+		DoNothing: true,
+	}).Create(&entries).Error
+	if err != nil {
+		return fmt.Errorf("failed to enqueue notification event: %w", err)
+	}
+	return nil
+}
+
+// OutboxConfig 通知发件箱投递配置，重试次数和初始重试间隔取各通知方式的
+// ProviderConfig.MaxRetries 和 ProviderConfig.RetryDelay
+type OutboxConfig struct {
+	// PollInterval 查询到期通知的间隔
+	PollInterval time.Duration
+	// BatchSize 每次领取的通知数量
+	BatchSize int
+	// LeaseDuration 领取的通知在该时间内未完成投递时可被重新领取
+	LeaseDuration time.Duration
+	// MaxBackoff 指数退避的最大重试间隔
+	MaxBackoff time.Duration
+}
+
+// OutboxWorker 投递通知发件箱中的通知，失败时按通知方式指数退避重试；
+// 投递前应用用户通知偏好，并将延迟发送的到期通知写入发件箱
+type OutboxWorker struct {
+	db          *gorm.DB
+	service     EventNotificationService
+	preferences PreferenceStore
+	providers   map[NotificationMethod]ProviderConfig
+	config      OutboxConfig
+	now         func() time.Time
+}
+
+// NewOutboxWorker 创建通知发件箱投递器，通过 service 发送通知，
+// preferences 为 nil 时不应用用户通知偏好
+func NewOutboxWorker(
+	db *gorm.DB,
+	service EventNotificationService,
+	preferences PreferenceStore,
+	providers map[NotificationMethod]ProviderConfig,
+	config OutboxConfig,
+) *OutboxWorker {
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultOutboxPollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultOutboxBatchSize
+	}
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = defaultOutboxLeaseDuration
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultOutboxMaxBackoff
+	}
+	return &OutboxWorker{
+		db:          db,
+		service:     service,
+		preferences: preferences,
+		providers:   providers,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// Run 周期性投递到期的通知，直到 ctx 结束
+func (w *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.ReleaseHeld(ctx); err != nil {
+			log.Printf("Failed to release held notifications: %v", err)
+		}
+		if _, err := w.DeliverDue(ctx); err != nil {
+			log.Printf("Failed to deliver notification outbox: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue 领取并投递一批到期的通知，返回投递的数量
+func (w *OutboxWorker) DeliverDue(ctx context.Context) (int, error) {
+	entries, err := w.claim()
+	if err != nil {
+		return 0, err
+	}
+	for i := range entries {
+		entry := &entries[i]
+		w.deliver(ctx, entry)
+		if err := w.db.Save(entry).Error; err != nil {
+			return i, fmt.Errorf("failed to update notification outbox %s: %w", entry.ID, err)
+		}
+	}
+	return len(entries), nil
+}
+
+// ReleaseHeld 将一批到期的延迟通知写入发件箱并删除，返回写入的通知数量
+func (w *OutboxWorker) ReleaseHeld(ctx context.Context) (int, error) {
+	now := w.now()
+	var released int
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var held []types.NotificationHeldEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("release_at <= ?", now).
+			Order("release_at, created_at").
+			Limit(w.config.BatchSize).
+			Find(&held).Error
+		if err != nil || len(held) == 0 {
+			return err
+		}
+
+		for _, release := range groupHeldEvents(held, now) {
+			if err := EnqueueEvent(tx, release.event, release.idempotencyKey); err != nil {
+				return err
+			}
+		}
+		ids := make([]uuid.UUID, len(held))
+		for i := range held {
+			ids[i] = held[i].ID
+		}
+		released = len(held)
+		return tx.Where("id IN ?", ids).Delete(&types.NotificationHeldEvent{}).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to release held notifications: %w", err)
+	}
+	return released, nil
+}
+
+// heldRelease 到期发送的延迟通知
+type heldRelease struct {
+	event          *NotificationEvent
+	idempotencyKey string
+}
+
+// groupHeldEvents 将同一用户同一通知方式的摘要通知合并为一条摘要事件，其他通知逐条发送
+func groupHeldEvents(held []types.NotificationHeldEvent, now time.Time) []heldRelease {
+	type digestKey struct {
+		userUID uuid.UUID
+		method  string
+	}
+	var releases []heldRelease
+	digests := make(map[digestKey][]NotificationEvent)
+	digestIDs := make(map[digestKey]uuid.UUID)
+	var digestOrder []digestKey
+	for _, h := range held {
+		var event NotificationEvent
+		if err := json.Unmarshal([]byte(h.Payload), &event); err != nil {
+			log.Printf("Failed to unmarshal held notification %s: %v", h.ID, err)
+			continue
+		}
+		event.Methods = []NotificationMethod{NotificationMethod(h.Method)}
+		event.SkipPreferences = true
+		if !h.Digest {
+			releases = append(releases, heldRelease{
+				event:          &event,
+				idempotencyKey: "notification-held/" + h.ID.String(),
+			})
+			continue
+		}
+		key := digestKey{userUID: h.UserUID, method: h.Method}
+		if _, ok := digests[key]; !ok {
+			digestOrder = append(digestOrder, key)
+			digestIDs[key] = h.ID
+		}
+		digests[key] = append(digests[key], event)
+	}
+
+	for _, key := range digestOrder {
+		events := digests[key]
+		idempotencyKey := "notification-held/" + digestIDs[key].String()
+		if len(events) == 1 {
+			releases = append(releases, heldRelease{event: &events[0], idempotencyKey: idempotencyKey})
+			continue
+		}
+		priority := NotificationPriorityLow
+		for _, event := range events {
+			if priorityRank(event.Priority) > priorityRank(priority) {
+				priority = event.Priority
+			}
+		}
+		releases = append(releases, heldRelease{
+			event: &NotificationEvent{
+				UserUID:         key.userUID,
+				EventType:       EventTypeNotificationDigest,
+				EventData:       map[string]any{"events": events},
+				Methods:         []NotificationMethod{NotificationMethod(key.method)},
+				Priority:        priority,
+				Timestamp:       now,
+				SkipPreferences: true,
+			},
+			idempotencyKey: idempotencyKey,
+		})
+	}
+	return releases
+}
+
+// claim 领取到期的通知，领取期间其他投递器不会再领取
+func (w *OutboxWorker) claim() ([]types.NotificationOutbox, error) {
+	now := w.now()
+	var entries []types.NotificationOutbox
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", types.NotificationOutboxStatusPending, now).
+			Order("next_attempt_at").
+			Limit(w.config.BatchSize).
+			Find(&entries).Error
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(entries))
+		for i := range entries {
+			ids[i] = entries[i].ID
+		}
+		return tx.Model(&types.NotificationOutbox{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(w.config.LeaseDuration)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notification outbox: %w", err)
+	}
+	return entries, nil
+}
+
+// deliver 投递一条通知并根据结果更新记录的状态
+func (w *OutboxWorker) deliver(ctx context.Context, entry *types.NotificationOutbox) {
+	now := w.now()
+	entry.UpdatedAt = now
+
+	var event NotificationEvent
+	if err := json.Unmarshal([]byte(entry.Payload), &event); err != nil {
+		entry.Status = types.NotificationOutboxStatusFailed
+		entry.LastError = fmt.Sprintf("failed to unmarshal notification event: %v", err)
+		return
+	}
+	method := NotificationMethod(entry.Method)
+	event.Methods = []NotificationMethod{method}
+
+	// 应用用户通知偏好，获取偏好失败时仍然发送
+	if w.preferences != nil && !event.SkipPreferences {
+		decision, err := w.applyPreference(ctx, &event)
+		switch {
+		case err != nil:
+			log.Printf("Failed to apply notification preference: UserUID=%s, EventType=%s, Error=%v",
+				event.UserUID, event.EventType, err)
+		case decision == nil:
+		case len(decision.hold) > 0:
+			// 根据用户偏好延迟发送，到期后作为新的通知写入发件箱
+			entry.Status = types.NotificationOutboxStatusHeld
+			entry.LastError = ""
+			entry.NextAttemptAt = decision.hold[0].releaseAt
+			return
+		case len(decision.send) == 0:
+			// 用户偏好不接收该通知
+			entry.Status = types.NotificationOutboxStatusSkipped
+			return
+		}
+	}
+
+	results, err := w.service.SendEventNotification(ctx, &event)
+	entry.Attempts++
+
+	var result *NotificationResult
+	for _, r := range results {
+		if r != nil && r.Method == method {
+			result = r
+		}
+	}
+	switch {
+	case result != nil && result.Success:
+		entry.Status = types.NotificationOutboxStatusDelivered
+		entry.LastError = ""
+		entry.ProviderResponse = result.ProviderResponse
+		entry.DeliveredAt = &now
+	case result == nil && err == nil:
+		// 用户没有该通知方式的联系方式或该通知方式未配置
+		entry.Status = types.NotificationOutboxStatusSkipped
+	default:
+		if err != nil {
+			entry.LastError = err.Error()
+		} else {
+			entry.LastError = result.Error
+		}
+		if entry.Attempts > w.maxRetries(method) {
+			entry.Status = types.NotificationOutboxStatusFailed
+			log.Printf("Notification %s to user %s via %s failed after %d attempts: %s",
+				entry.IdempotencyKey, entry.UserUID, entry.Method, entry.Attempts, entry.LastError)
+			return
+		}
+		entry.NextAttemptAt = now.Add(w.backoff(method, entry.Attempts))
+	}
+}
+
+// deliveryStatusCode 匹配postJSON返回的非2xx状态码错误
+var deliveryStatusCode = regexp.MustCompile(`unexpected status code (\d+)`)
+
+// DeliveryErrorClass 返回投递错误的类别，LastError可能包含远端的响应内容，
+// 向用户展示时只返回状态码或错误类别
+func DeliveryErrorClass(lastError string) string {
+	switch {
+	case lastError == "":
+		return ""
+	case strings.Contains(lastError, ErrForbiddenAddress.Error()):
+		return "forbidden_address"
+	case strings.Contains(lastError, "failed to unmarshal notification event"):
+		return "invalid_event"
+	case strings.Contains(lastError, "bot API error"):
+		return "bot_api_error"
+	case strings.Contains(lastError, "Client.Timeout") ||
+		strings.Contains(lastError, "context deadline exceeded") ||
+		strings.Contains(lastError, "i/o timeout"):
+		return "timeout"
+	case strings.Contains(lastError, "failed to send request"):
+		return "connection_error"
+	}
+	if m := deliveryStatusCode.FindStringSubmatch(lastError); m != nil {
+		return "status_code_" + m[1]
+	}
+	return "provider_error"
+}
+
+func (w *OutboxWorker) maxRetries(method NotificationMethod) int {
+	if retries := w.providers[method].MaxRetries; retries > 0 {
+		return retries
+	}
+	return defaultOutboxMaxRetries
+}
+
+// backoff 第 attempts 次投递失败后的重试间隔
+func (w *OutboxWorker) backoff(method NotificationMethod, attempts int) time.Duration {
+	delay := w.providers[method].RetryDelay
+	if delay <= 0 {
+		delay = defaultOutboxRetryDelay
+	}
+	for i := 1; i < attempts && delay < w.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, w.config.MaxBackoff)
+}
@@ -0,0 +1,146 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+)
+
+type fakeNotificationService struct {
+	EventNotificationService
+	results []*NotificationResult
+	err     error
+	events  []*NotificationEvent
+}
+
+func (s *fakeNotificationService) SendEventNotification(
+	_ context.Context,
+	event *NotificationEvent,
+) ([]*NotificationResult, error) {
+	s.events = append(s.events, event)
+	return s.results, s.err
+}
+
+func outboxEntry(t *testing.T, method NotificationMethod) *types.NotificationOutbox {
+	t.Helper()
+	payload, err := json.Marshal(&NotificationEvent{
+		UserUID:   uuid.New(),
+		EventType: EventTypeWorkspaceSubscriptionRenewedFailed,
+		Methods:   []NotificationMethod{NotificationMethodEmail, NotificationMethodSMS},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.NotificationOutbox{
+		Method:  string(method),
+		Payload: string(payload),
+		Status:  types.NotificationOutboxStatusPending,
+	}
+}
+
+func TestOutboxWorker_Deliver(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := &fakeNotificationService{}
+	worker := NewOutboxWorker(nil, service, nil, map[NotificationMethod]ProviderConfig{
+		NotificationMethodEmail: {MaxRetries: 2, RetryDelay: time.Minute},
+	}, OutboxConfig{})
+	worker.now = func() time.Time { return now }
+
+	entry := outboxEntry(t, NotificationMethodEmail)
+	service.err = errors.New("smtp unavailable")
+	worker.deliver(context.Background(), entry)
+	if methods := service.events[0].Methods; len(methods) != 1 || methods[0] != NotificationMethodEmail {
+		t.Errorf("delivered methods = %v, want only the method of the entry", methods)
+	}
+	if entry.Status != types.NotificationOutboxStatusPending || entry.Attempts != 1 ||
+		!entry.NextAttemptAt.Equal(now.Add(time.Minute)) || entry.LastError != "smtp unavailable" {
+		t.Fatalf("entry after the first failure = %+v", entry)
+	}
+	worker.deliver(context.Background(), entry)
+	if !entry.NextAttemptAt.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("second retry at %v, want backoff of 2m", entry.NextAttemptAt)
+	}
+	worker.deliver(context.Background(), entry)
+	if entry.Status != types.NotificationOutboxStatusFailed {
+		t.Errorf("status after exhausting retries = %s, want failed", entry.Status)
+	}
+
+	entry = outboxEntry(t, NotificationMethodEmail)
+	service.err = nil
+	service.results = []*NotificationResult{{Method: NotificationMethodEmail, Success: true, ProviderResponse: "ok"}}
+	worker.deliver(context.Background(), entry)
+	if entry.Status != types.NotificationOutboxStatusDelivered || entry.DeliveredAt == nil ||
+		entry.ProviderResponse != "ok" {
+		t.Errorf("delivered entry = %+v", entry)
+	}
+
+	entry = outboxEntry(t, NotificationMethodSMS)
+	service.results = nil
+	worker.deliver(context.Background(), entry)
+	if entry.Status != types.NotificationOutboxStatusSkipped {
+		t.Errorf("status without contact = %s, want skipped", entry.Status)
+	}
+}
+
+func TestOutboxWorker_Backoff(t *testing.T) {
+	worker := NewOutboxWorker(nil, nil, nil, nil, OutboxConfig{MaxBackoff: 10 * time.Minute})
+	want := []time.Duration{
+		30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute,
+	}
+	for i, w := range want {
+		if got := worker.backoff(NotificationMethodWebhook, i+1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestDeliveryErrorClass(t *testing.T) {
+	tests := []struct {
+		lastError string
+		want      string
+	}{
+		{lastError: "", want: ""},
+		{
+			lastError: "failed to send webhook: channel 1: unexpected status code 502: <html>secret upstream page</html>",
+			want:      "status_code_502",
+		},
+		{
+			lastError: "failed to send webhook: channel 1: failed to send request: Post \"http://x\": " +
+				"context deadline exceeded (Client.Timeout exceeded while awaiting headers)",
+			want: "timeout",
+		},
+		{
+			lastError: "failed to send webhook: channel 1: failed to send request: dial tcp: " +
+				"notification channel address is not allowed: 10.0.0.1",
+			want: "forbidden_address",
+		},
+		{lastError: "failed to send request: dial tcp 1.2.3.4:443: connect: connection refused", want: "connection_error"},
+		{lastError: "failed to send wecom: channel 1: bot API error 93000: invalid webhook url", want: "bot_api_error"},
+		{lastError: "failed to unmarshal notification event: unexpected end of JSON input", want: "invalid_event"},
+		{lastError: "email provider is not available", want: "provider_error"},
+	}
+	for _, tt := range tests {
+		if got := DeliveryErrorClass(tt.lastError); got != tt.want {
+			t.Errorf("DeliveryErrorClass(%q) = %q, want %q", tt.lastError, got, tt.want)
+		}
+	}
+}
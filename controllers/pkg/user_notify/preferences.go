@@ -0,0 +1,329 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+	"gorm.io/gorm"
+)
+
+// AllEventTypes 通知偏好中匹配未单独配置的事件类型的键
+const AllEventTypes = "*"
+
+// maxDigestInterval 摘要合并间隔的上限（分钟）
+const maxDigestInterval = 24 * 60
+
+// PreferenceStore 用户通知偏好和延迟发送通知的存储
+type PreferenceStore interface {
+	// GetPreference 获取事件适用的偏好，工作空间偏好优先于用户偏好，都没有时返回 nil
+	GetPreference(
+		ctx context.Context,
+		userUID uuid.UUID,
+		workspace string,
+	) (*types.NotificationPreference, error)
+	// HoldEvents 暂存延迟发送的通知，到期后由发件箱投递器发送
+	HoldEvents(ctx context.Context, events []types.NotificationHeldEvent) error
+}
+
+// DBPreferenceStore 使用数据库存储通知偏好和延迟发送的通知
+type DBPreferenceStore struct {
+	db *gorm.DB
+}
+
+func NewDBPreferenceStore(db *gorm.DB) *DBPreferenceStore {
+	return &DBPreferenceStore{db: db}
+}
+
+func (s *DBPreferenceStore) GetPreference(
+	ctx context.Context,
+	userUID uuid.UUID,
+	workspace string,
+) (*types.NotificationPreference, error) {
+	var preference types.NotificationPreference
+	// 按 workspace 降序时工作空间偏好排在用户偏好（workspace 为空）之前，优先于用户偏好
+	err := s.db.WithContext(ctx).
+		Where("user_uid = ? AND workspace IN ?", userUID, []string{"", workspace}).
+		Order("workspace DESC").
+		First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+	return &preference, nil
+}
+
+func (s *DBPreferenceStore) HoldEvents(ctx context.Context, events []types.NotificationHeldEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Create(&events).Error; err != nil {
+		return fmt.Errorf("failed to hold notification events: %w", err)
+	}
+	return nil
+}
+
+// ValidatePreference 校验通知偏好的事件通知方式、优先级、免打扰时段和摘要间隔
+func ValidatePreference(preference *types.NotificationPreference) error {
+	for eventType, methods := range preference.EventMethods {
+		for _, method := range methods {
+			if !NotificationMethod(method).IsValid() {
+				return fmt.Errorf("unsupported notification method %q for event type %q", method, eventType)
+			}
+		}
+	}
+	if preference.MinPriority != "" && priorityRank(NotificationPriority(preference.MinPriority)) < 0 {
+		return fmt.Errorf("unsupported notification priority: %s", preference.MinPriority)
+	}
+	if (preference.QuietHoursStart == "") != (preference.QuietHoursEnd == "") {
+		return errors.New("quiet hours start and end must be set together")
+	}
+	if preference.QuietHoursStart != "" {
+		if _, err := parseClock(preference.QuietHoursStart); err != nil {
+			return err
+		}
+		if _, err := parseClock(preference.QuietHoursEnd); err != nil {
+			return err
+		}
+	}
+	if _, err := time.LoadLocation(preference.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", preference.TimeZone, err)
+	}
+	if preference.DigestInterval < 0 || preference.DigestInterval > maxDigestInterval {
+		return fmt.Errorf("digest interval must be between 0 and %d minutes", maxDigestInterval)
+	}
+	return nil
+}
+
+// IsValid 是否为支持的通知方式
+func (m NotificationMethod) IsValid() bool {
+	switch m {
+	case NotificationMethodEmail, NotificationMethodSMS, NotificationMethodVMS:
+		return true
+	default:
+		return m.IsChannelMethod()
+	}
+}
+
+// supportsDigest 是否可以将多条通知合并为一条摘要发送，短信和语音只能按模板逐条发送
+func (m NotificationMethod) supportsDigest() bool {
+	return m == NotificationMethodEmail || m.IsChannelMethod()
+}
+
+// priorityRank 优先级的排序，未知优先级返回 -1
+func priorityRank(priority NotificationPriority) int {
+	switch priority {
+	case NotificationPriorityLow:
+		return 0
+	case NotificationPriorityNormal:
+		return 1
+	case NotificationPriorityHigh:
+		return 2
+	case NotificationPriorityCritical:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// parseClock 解析 HH:MM 格式的时间，返回当天的分钟数
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM: %w", clock, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// eventWorkspace 事件所属的工作空间
+func eventWorkspace(event *NotificationEvent) string {
+	for _, key := range []string{"workspace_name", "workspace"} {
+		if workspace, ok := event.EventData[key].(string); ok && workspace != "" {
+			return workspace
+		}
+	}
+	return ""
+}
+
+// preferenceDecision 应用通知偏好的结果
+type preferenceDecision struct {
+	// send 立即发送的通知方式
+	send []NotificationMethod
+	// hold 延迟发送的通知方式
+	hold []heldMethod
+}
+
+// heldMethod 延迟发送的通知方式
+type heldMethod struct {
+	method    NotificationMethod
+	releaseAt time.Time
+	// digest 是否与其他通知合并为摘要
+	digest bool
+}
+
+// decidePreference 根据偏好决定事件的各通知方式立即发送、延迟发送或不发送：
+// 过滤事件类型不允许的通知方式，丢弃低于最低优先级的事件；
+// 紧急事件不受免打扰时段限制，其他事件延迟到免打扰时段结束；
+// 低和普通优先级的事件在支持摘要的通知方式上合并到摘要间隔结束时发送。
+func decidePreference(
+	preference *types.NotificationPreference,
+	event *NotificationEvent,
+	now time.Time,
+) preferenceDecision {
+	var decision preferenceDecision
+	rank := priorityRank(event.Priority)
+	if preference.MinPriority != "" && rank < priorityRank(NotificationPriority(preference.MinPriority)) {
+		return decision
+	}
+
+	methods := event.Methods
+	if allowed, ok := preference.EventMethods[string(event.EventType)]; ok {
+		methods = filterMethods(methods, allowed)
+	} else if allowed, ok := preference.EventMethods[AllEventTypes]; ok {
+		methods = filterMethods(methods, allowed)
+	}
+
+	var quietUntil time.Time
+	if rank < priorityRank(NotificationPriorityCritical) {
+		quietUntil = quietHoursEnd(preference, now)
+	}
+	var digestAt time.Time
+	if preference.DigestInterval > 0 && rank <= priorityRank(NotificationPriorityNormal) {
+		digestAt = nextDigest(preference, now)
+	}
+
+	for _, method := range methods {
+		switch {
+		case !digestAt.IsZero() && method.supportsDigest():
+			releaseAt := digestAt
+			if quietUntil.After(releaseAt) {
+				releaseAt = quietUntil
+			}
+			decision.hold = append(decision.hold, heldMethod{method: method, releaseAt: releaseAt, digest: true})
+		case !quietUntil.IsZero():
+			decision.hold = append(decision.hold, heldMethod{method: method, releaseAt: quietUntil})
+		default:
+			decision.send = append(decision.send, method)
+		}
+	}
+	return decision
+}
+
+func filterMethods(methods []NotificationMethod, allowed []string) []NotificationMethod {
+	var filtered []NotificationMethod
+	for _, method := range methods {
+		for _, a := range allowed {
+			if string(method) == a {
+				filtered = append(filtered, method)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// quietHoursEnd now 处于免打扰时段时返回时段的结束时间，否则返回零值
+func quietHoursEnd(preference *types.NotificationPreference, now time.Time) time.Time {
+	if preference.QuietHoursStart == "" || preference.QuietHoursEnd == "" {
+		return time.Time{}
+	}
+	start, err := parseClock(preference.QuietHoursStart)
+	if err != nil {
+		return time.Time{}
+	}
+	end, err := parseClock(preference.QuietHoursEnd)
+	if err != nil || start == end {
+		return time.Time{}
+	}
+	location := preferenceLocation(preference)
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	days := 0
+	switch {
+	case start < end && minute >= start && minute < end:
+	case start > end && minute >= start:
+		// 跨越零点的时段在第二天结束
+		days = 1
+	case start > end && minute < end:
+	default:
+		return time.Time{}
+	}
+	return time.Date(local.Year(), local.Month(), local.Day()+days, end/60, end%60, 0, 0, location)
+}
+
+// nextDigest 返回 now 之后的下一个摘要发送时间，摘要间隔从偏好时区的零点开始对齐
+func nextDigest(preference *types.NotificationPreference, now time.Time) time.Time {
+	location := preferenceLocation(preference)
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	next := (minute/preference.DigestInterval + 1) * preference.DigestInterval
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, next, 0, 0, location)
+}
+
+// preferenceLocation 返回偏好的时区，未设置或无效时为 UTC
+func preferenceLocation(preference *types.NotificationPreference) *time.Location {
+	location, err := time.LoadLocation(preference.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// applyPreference 应用用户通知偏好并暂存延迟发送的通知，没有偏好时返回 nil
+func (w *OutboxWorker) applyPreference(
+	ctx context.Context,
+	event *NotificationEvent,
+) (*preferenceDecision, error) {
+	preference, err := w.preferences.GetPreference(ctx, event.UserUID, eventWorkspace(event))
+	if err != nil {
+		return nil, err
+	}
+	if preference == nil {
+		return nil, nil
+	}
+
+	now := w.now()
+	decision := decidePreference(preference, event, now)
+	held := make([]types.NotificationHeldEvent, 0, len(decision.hold))
+	for _, hold := range decision.hold {
+		heldEvent := *event
+		heldEvent.Methods = []NotificationMethod{hold.method}
+		payload, err := json.Marshal(&heldEvent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal held notification event: %w", err)
+		}
+		held = append(held, types.NotificationHeldEvent{
+			ID:        uuid.New(),
+			UserUID:   event.UserUID,
+			Method:    string(hold.method),
+			Payload:   string(payload),
+			Digest:    hold.digest,
+			ReleaseAt: hold.releaseAt,
+			CreatedAt: now,
+		})
+	}
+	if err := w.preferences.HoldEvents(ctx, held); err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
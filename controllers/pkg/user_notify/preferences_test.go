@@ -0,0 +1,310 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernotify
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	preference := &types.NotificationPreference{
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "08:00",
+		TimeZone:        "Asia/Shanghai",
+	}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{
+			name: "before midnight",
+			now:  time.Date(2024, 1, 1, 23, 30, 0, 0, shanghai),
+			want: time.Date(2024, 1, 2, 8, 0, 0, 0, shanghai),
+		},
+		{
+			name: "after midnight",
+			now:  time.Date(2024, 1, 2, 3, 0, 0, 0, shanghai),
+			want: time.Date(2024, 1, 2, 8, 0, 0, 0, shanghai),
+		},
+		{
+			name: "outside quiet hours",
+			now:  time.Date(2024, 1, 2, 12, 0, 0, 0, shanghai),
+		},
+		{
+			name: "given in UTC",
+			now:  time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 2, 8, 0, 0, 0, shanghai),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quietHoursEnd(preference, tt.now); !got.Equal(tt.want) {
+				t.Errorf("quietHoursEnd() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecidePreference(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 10, 0, 0, time.UTC)
+	event := func(priority NotificationPriority) *NotificationEvent {
+		return &NotificationEvent{
+			EventType: EventTypeTrafficUsageAlert,
+			Priority:  priority,
+			Methods:   []NotificationMethod{NotificationMethodEmail, NotificationMethodSMS, NotificationMethodSlack},
+		}
+	}
+
+	decision := decidePreference(&types.NotificationPreference{
+		EventMethods: map[string][]string{
+			string(EventTypeTrafficUsageAlert): {"email"},
+			AllEventTypes:                      {"sms"},
+		},
+	}, event(NotificationPriorityNormal), now)
+	if len(decision.send) != 1 || decision.send[0] != NotificationMethodEmail || len(decision.hold) != 0 {
+		t.Errorf("event methods decision = %+v, want only email", decision)
+	}
+
+	decision = decidePreference(&types.NotificationPreference{
+		MinPriority: string(NotificationPriorityHigh),
+	}, event(NotificationPriorityNormal), now)
+	if len(decision.send) != 0 || len(decision.hold) != 0 {
+		t.Errorf("decision below min priority = %+v, want nothing sent", decision)
+	}
+
+	quiet := &types.NotificationPreference{QuietHoursStart: "12:00", QuietHoursEnd: "13:00"}
+	decision = decidePreference(quiet, event(NotificationPriorityHigh), now)
+	if len(decision.send) != 0 || len(decision.hold) != 3 ||
+		!decision.hold[0].releaseAt.Equal(time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)) || decision.hold[0].digest {
+		t.Errorf("quiet hours decision = %+v, want all held until 13:00", decision)
+	}
+	decision = decidePreference(quiet, event(NotificationPriorityCritical), now)
+	if len(decision.send) != 3 {
+		t.Errorf("critical decision in quiet hours = %+v, want all sent", decision)
+	}
+
+	digest := &types.NotificationPreference{DigestInterval: 30}
+	decision = decidePreference(digest, event(NotificationPriorityLow), now)
+	if len(decision.send) != 1 || decision.send[0] != NotificationMethodSMS || len(decision.hold) != 2 {
+		t.Fatalf("digest decision = %+v, want sms sent and others held", decision)
+	}
+	for _, hold := range decision.hold {
+		if !hold.digest || !hold.releaseAt.Equal(time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)) {
+			t.Errorf("digest hold = %+v, want digest released at 12:30", hold)
+		}
+	}
+	decision = decidePreference(digest, event(NotificationPriorityHigh), now)
+	if len(decision.send) != 3 {
+		t.Errorf("high priority digest decision = %+v, want all sent", decision)
+	}
+}
+
+func TestNextDigest(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		preference *types.NotificationPreference
+		now        time.Time
+		want       time.Time
+	}{
+		{
+			name:       "utc",
+			preference: &types.NotificationPreference{DigestInterval: 30},
+			now:        time.Date(2024, 1, 1, 12, 10, 0, 0, time.UTC),
+			want:       time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			// UTC+05:30 的每日摘要在当地零点发送，而不是 UTC 零点
+			name:       "daily in a half hour time zone",
+			preference: &types.NotificationPreference{DigestInterval: 24 * 60, TimeZone: "Asia/Kolkata"},
+			now:        time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC),
+			want:       time.Date(2024, 1, 2, 0, 0, 0, 0, kolkata),
+		},
+		{
+			name:       "hourly in a half hour time zone",
+			preference: &types.NotificationPreference{DigestInterval: 60, TimeZone: "Asia/Kolkata"},
+			now:        time.Date(2024, 1, 1, 12, 10, 0, 0, time.UTC),
+			want:       time.Date(2024, 1, 1, 18, 0, 0, 0, kolkata),
+		},
+		{
+			name:       "interval not dividing the day",
+			preference: &types.NotificationPreference{DigestInterval: 7 * 60, TimeZone: "Asia/Kolkata"},
+			now:        time.Date(2024, 1, 1, 23, 30, 0, 0, kolkata),
+			want:       time.Date(2024, 1, 2, 4, 0, 0, 0, kolkata),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDigest(tt.preference, tt.now); !got.Equal(tt.want) {
+				t.Errorf("nextDigest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePreference(t *testing.T) {
+	valid := &types.NotificationPreference{
+		EventMethods:    map[string][]string{AllEventTypes: {"email", "dingtalk"}},
+		MinPriority:     "normal",
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "08:00",
+		DigestInterval:  60,
+	}
+	if err := ValidatePreference(valid); err != nil {
+		t.Errorf("ValidatePreference() = %v, want nil", err)
+	}
+
+	invalid := []*types.NotificationPreference{
+		{EventMethods: map[string][]string{AllEventTypes: {"fax"}}},
+		{MinPriority: "urgent"},
+		{QuietHoursStart: "22:00"},
+		{QuietHoursStart: "25:00", QuietHoursEnd: "08:00"},
+		{TimeZone: "Mars/Olympus"},
+		{DigestInterval: -1},
+	}
+	for _, preference := range invalid {
+		if err := ValidatePreference(preference); err == nil {
+			t.Errorf("ValidatePreference(%+v) = nil, want error", preference)
+		}
+	}
+}
+
+type fakePreferenceStore struct {
+	preference *types.NotificationPreference
+	held       []types.NotificationHeldEvent
+}
+
+func (s *fakePreferenceStore) GetPreference(
+	context.Context,
+	uuid.UUID,
+	string,
+) (*types.NotificationPreference, error) {
+	return s.preference, nil
+}
+
+func (s *fakePreferenceStore) HoldEvents(_ context.Context, events []types.NotificationHeldEvent) error {
+	s.held = append(s.held, events...)
+	return nil
+}
+
+func TestGroupHeldEvents(t *testing.T) {
+	now := time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)
+	userUID := uuid.New()
+	held := func(method NotificationMethod, digest bool, priority NotificationPriority) types.NotificationHeldEvent {
+		payload, err := json.Marshal(&NotificationEvent{
+			UserUID:   userUID,
+			EventType: EventTypeCustom,
+			EventData: map[string]any{"title": "title " + string(priority), "content": "content"},
+			Priority:  priority,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.NotificationHeldEvent{
+			ID:      uuid.New(),
+			UserUID: userUID,
+			Method:  string(method),
+			Payload: string(payload),
+			Digest:  digest,
+		}
+	}
+
+	releases := groupHeldEvents([]types.NotificationHeldEvent{
+		held(NotificationMethodEmail, true, NotificationPriorityLow),
+		held(NotificationMethodSMS, false, NotificationPriorityHigh),
+		held(NotificationMethodEmail, true, NotificationPriorityNormal),
+		held(NotificationMethodSlack, true, NotificationPriorityLow),
+	}, now)
+	if len(releases) != 3 {
+		t.Fatalf("got %d releases, want 3", len(releases))
+	}
+	if e := releases[0].event; e.EventType != EventTypeCustom || e.Methods[0] != NotificationMethodSMS ||
+		!e.SkipPreferences {
+		t.Errorf("held sms release = %+v", e)
+	}
+	digest := releases[1].event
+	if digest.EventType != EventTypeNotificationDigest || digest.Priority != NotificationPriorityNormal ||
+		digest.Methods[0] != NotificationMethodEmail || !digest.SkipPreferences {
+		t.Errorf("email digest release = %+v", digest)
+	}
+	if e := releases[2].event; e.EventType != EventTypeCustom || e.Methods[0] != NotificationMethodSlack {
+		t.Errorf("single slack digest release = %+v, want the original event", e)
+	}
+
+	// 摘要事件经过发件箱序列化后仍能生成内容
+	payload, err := json.Marshal(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event NotificationEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		t.Fatal(err)
+	}
+	title, content, _, err := NewDefaultContentGenerator(nil).GenerateContent(&event, NotificationMethodEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(title, "2 notifications") ||
+		!strings.Contains(content, "1. title low") || !strings.Contains(content, "2. title normal") ||
+		strings.Count(content, emailFooter) != 1 {
+		t.Errorf("digest content = %q, %q", title, content)
+	}
+}
+
+func TestOutboxWorker_DeliverPreferences(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	store := &fakePreferenceStore{preference: &types.NotificationPreference{
+		EventMethods:   map[string][]string{AllEventTypes: {"email"}},
+		DigestInterval: 60,
+	}}
+	service := &fakeNotificationService{}
+	worker := NewOutboxWorker(nil, service, store, nil, OutboxConfig{})
+	worker.now = func() time.Time { return now }
+
+	entry := outboxEntry(t, NotificationMethodEmail)
+	worker.deliver(context.Background(), entry)
+	if entry.Status != types.NotificationOutboxStatusHeld || !entry.NextAttemptAt.Equal(now.Add(30*time.Minute)) {
+		t.Errorf("held entry = %+v", entry)
+	}
+	if len(store.held) != 1 || !store.held[0].Digest || store.held[0].Method != string(NotificationMethodEmail) {
+		t.Errorf("held events = %+v, want one email digest", store.held)
+	}
+
+	entry = outboxEntry(t, NotificationMethodSMS)
+	worker.deliver(context.Background(), entry)
+	if entry.Status != types.NotificationOutboxStatusSkipped {
+		t.Errorf("status of a method not allowed by the preference = %s, want skipped", entry.Status)
+	}
+	if len(service.events) != 0 {
+		t.Errorf("sent events = %+v, want none", service.events)
+	}
+}
@@ -56,6 +56,14 @@ func (p *BaseProvider) GetSupportedMethods() []NotificationMethod {
 		return []NotificationMethod{NotificationMethodEmail}
 	case "sms":
 		return []NotificationMethod{NotificationMethodSMS}
+	case "webhook":
+		return []NotificationMethod{NotificationMethodWebhook}
+	case "slack":
+		return []NotificationMethod{NotificationMethodSlack}
+	case "dingtalk":
+		return []NotificationMethod{NotificationMethodDingTalk}
+	case "wecom":
+		return []NotificationMethod{NotificationMethodWeCom}
 	default:
 		return []NotificationMethod{}
 	}
@@ -966,6 +974,22 @@ func NewProviderManager(
 		manager.providers[NotificationMethodSMS] = NewSMSProvider(smsConfig)
 	}
 
+	if webhookConfig, exists := configs[NotificationMethodWebhook]; exists {
+		manager.providers[NotificationMethodWebhook] = NewWebhookProvider(webhookConfig)
+	}
+
+	if slackConfig, exists := configs[NotificationMethodSlack]; exists {
+		manager.providers[NotificationMethodSlack] = NewSlackProvider(slackConfig)
+	}
+
+	if dingTalkConfig, exists := configs[NotificationMethodDingTalk]; exists {
+		manager.providers[NotificationMethodDingTalk] = NewDingTalkProvider(dingTalkConfig)
+	}
+
+	if weComConfig, exists := configs[NotificationMethodWeCom]; exists {
+		manager.providers[NotificationMethodWeCom] = NewWeComProvider(weComConfig)
+	}
+
 	return manager
 }
 
@@ -1029,6 +1053,11 @@ func (m *ProviderManager) SendEvent(
 			if event.Recipient.PhoneNumber == "" && !event.NotIgnoreIfNoContact {
 				continue
 			}
+		case "webhook", "slack", "dingtalk", "wecom":
+			if len(event.Recipient.ChannelsOf(string(method))) == 0 &&
+				!event.NotIgnoreIfNoContact {
+				continue
+			}
 		}
 
 		// 生成通知内容
@@ -0,0 +1,454 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernotify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+)
+
+const (
+	// WebhookSignatureHeader 通用Webhook请求体签名的请求头，值为 sha256=<hex>
+	WebhookSignatureHeader = "X-Sealos-Signature"
+	// WebhookTimestampHeader 通用Webhook签名时间戳（Unix秒）的请求头
+	WebhookTimestampHeader = "X-Sealos-Timestamp"
+
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// ErrForbiddenAddress 用户通知渠道地址指向集群内网、回环或链路本地地址
+var ErrForbiddenAddress = errors.New("notification channel address is not allowed")
+
+// sharedAddressSpace 运营商级NAT地址段（100.64.0.0/10），常被用作集群Pod网段
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// WebhookPayload 通用Webhook请求体
+type WebhookPayload struct {
+	UserUID   uuid.UUID            `json:"user_uid"`
+	EventType EventType            `json:"event_type"`
+	Priority  NotificationPriority `json:"priority"`
+	Title     string               `json:"title"`
+	Content   string               `json:"content"`
+	EventData map[string]any       `json:"event_data,omitempty"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// SignWebhookPayload 计算通用Webhook签名：HMAC-SHA256(secret, timestamp + "." + body)
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// channelSender 将消息发送到用户的一个通知渠道
+type channelSender func(
+	ctx context.Context,
+	channel types.UserNotificationChannel,
+	message *NotificationMessage,
+) error
+
+// sendToChannels 将消息发送到用户该通知方式的所有渠道，全部成功才视为成功
+func (p *BaseProvider) sendToChannels(
+	ctx context.Context,
+	message *NotificationMessage,
+	send channelSender,
+) (*NotificationResult, error) {
+	if !p.IsAvailable() {
+		return nil, fmt.Errorf("%s provider is not available", p.Name)
+	}
+
+	channels := message.Recipient.ChannelsOf(string(message.Method))
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("notification channel is required for %s notification", p.Name)
+	}
+
+	result := &NotificationResult{
+		UserUID:   message.UserUID,
+		EventType: message.EventType,
+		Method:    message.Method,
+		SentAt:    time.Now(),
+	}
+
+	log.Printf("Sending %s notification to %d channels: %s", p.Name, len(channels), message.Title)
+
+	var errs []string
+	sent := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		if err := send(ctx, channel, message); err != nil {
+			errs = append(errs, fmt.Sprintf("channel %s: %v", channel.ID, err))
+			continue
+		}
+		sent = append(sent, channel.ID.String())
+	}
+
+	responseData := map[string]any{
+		"provider": p.Name,
+		"title":    message.Title,
+		"channels": sent,
+		"sent_at":  result.SentAt,
+	}
+	if responseBytes, err := json.Marshal(responseData); err == nil {
+		result.ProviderResponse = string(responseBytes)
+	}
+
+	if len(errs) > 0 {
+		result.Success = false
+		result.Error = fmt.Sprintf("failed to send %s: %s", p.Name, strings.Join(errs, "; "))
+		return result, errors.New(result.Error)
+	}
+
+	result.Success = true
+	log.Printf("%s notification sent successfully to %d channels", p.Name, len(sent))
+	return result, nil
+}
+
+// ValidateChannelURL 检查用户通知渠道地址，只允许http和https，且主机不能是禁止的IP地址。
+// 域名在发送时才解析，由拨号钩子检查实际连接的地址
+func ValidateChannelURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid channel url: %s", rawURL)
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && isForbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, u.Hostname())
+	}
+	return nil
+}
+
+// isForbiddenIP 判断是否为内网、回环、链路本地或未指定地址
+func isForbiddenIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
+
+// forbidPrivateAddress 拨号的Control钩子，检查DNS解析后实际连接的地址，
+// 避免DNS重绑定绕过保存渠道时的检查
+func forbidPrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isForbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// newWebhookClient 创建发送Webhook的HTTP客户端，渠道地址由用户配置，
+// 因此直接连接而不经过代理，并拒绝连接内网地址
+func newWebhookClient(config ProviderConfig) *http.Client {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   forbidPrivateAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// postJSON 发送JSON请求，返回2xx响应的响应体
+func postJSON(
+	ctx context.Context,
+	client *http.Client,
+	rawURL string,
+	body []byte,
+	headers map[string]string,
+) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	return respBody, nil
+}
+
+// botResponse 钉钉和企业微信机器人的响应
+type botResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// postBotMessage 发送机器人消息并检查响应中的错误码
+func postBotMessage(ctx context.Context, client *http.Client, rawURL string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	respBody, err := postJSON(ctx, client, rawURL, body, nil)
+	if err != nil {
+		return err
+	}
+	var resp botResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("bot API error %d: %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
+// markdownMessage 生成机器人Markdown消息文本
+func markdownMessage(message *NotificationMessage) string {
+	return fmt.Sprintf("### %s\n\n%s", message.Title, message.Content)
+}
+
+// WebhookProvider 通用Webhook通知提供者，请求体使用HMAC-SHA256签名
+type WebhookProvider struct {
+	BaseProvider
+	client *http.Client
+}
+
+// NewWebhookProvider 创建通用Webhook提供者
+func NewWebhookProvider(config ProviderConfig) *WebhookProvider {
+	provider := &WebhookProvider{
+		BaseProvider: BaseProvider{
+			Name:      "webhook",
+			Available: true,
+			Config:    config,
+		},
+		client: newWebhookClient(config),
+	}
+	log.Printf("Webhook provider initialized successfully")
+	return provider
+}
+
+func (p *WebhookProvider) Send(
+	ctx context.Context,
+	message *NotificationMessage,
+) (*NotificationResult, error) {
+	return p.sendToChannels(ctx, message, p.send)
+}
+
+func (p *WebhookProvider) send(
+	ctx context.Context,
+	channel types.UserNotificationChannel,
+	message *NotificationMessage,
+) error {
+	body, err := json.Marshal(WebhookPayload{
+		UserUID:   message.UserUID,
+		EventType: message.EventType,
+		Priority:  message.Priority,
+		Title:     message.Title,
+		Content:   message.Content,
+		EventData: message.EventData,
+		Timestamp: message.Timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	// 地址由用户配置，只用渠道自己的密钥签名，不附带运维配置的凭据
+	headers := make(map[string]string, 2)
+	if channel.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[WebhookTimestampHeader] = timestamp
+		headers[WebhookSignatureHeader] = SignWebhookPayload(channel.Secret, timestamp, body)
+	}
+
+	_, err = postJSON(ctx, p.client, channel.URL, body, headers)
+	return err
+}
+
+// SlackProvider Slack Incoming Webhook通知提供者
+type SlackProvider struct {
+	BaseProvider
+	client *http.Client
+}
+
+// NewSlackProvider 创建Slack提供者
+func NewSlackProvider(config ProviderConfig) *SlackProvider {
+	provider := &SlackProvider{
+		BaseProvider: BaseProvider{
+			Name:      "slack",
+			Available: true,
+			Config:    config,
+		},
+		client: newWebhookClient(config),
+	}
+	log.Printf("Slack provider initialized successfully")
+	return provider
+}
+
+func (p *SlackProvider) Send(
+	ctx context.Context,
+	message *NotificationMessage,
+) (*NotificationResult, error) {
+	return p.sendToChannels(ctx, message, p.send)
+}
+
+func (p *SlackProvider) send(
+	ctx context.Context,
+	channel types.UserNotificationChannel,
+	message *NotificationMessage,
+) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", message.Title, message.Content),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+	_, err = postJSON(ctx, p.client, channel.URL, body, nil)
+	return err
+}
+
+// DingTalkProvider 钉钉群机器人通知提供者，渠道配置密钥时使用加签
+type DingTalkProvider struct {
+	BaseProvider
+	client *http.Client
+}
+
+// NewDingTalkProvider 创建钉钉提供者
+func NewDingTalkProvider(config ProviderConfig) *DingTalkProvider {
+	provider := &DingTalkProvider{
+		BaseProvider: BaseProvider{
+			Name:      "dingtalk",
+			Available: true,
+			Config:    config,
+		},
+		client: newWebhookClient(config),
+	}
+	log.Printf("DingTalk provider initialized successfully")
+	return provider
+}
+
+func (p *DingTalkProvider) Send(
+	ctx context.Context,
+	message *NotificationMessage,
+) (*NotificationResult, error) {
+	return p.sendToChannels(ctx, message, p.send)
+}
+
+func (p *DingTalkProvider) send(
+	ctx context.Context,
+	channel types.UserNotificationChannel,
+	message *NotificationMessage,
+) error {
+	rawURL := channel.URL
+	if channel.Secret != "" {
+		signed, err := signDingTalkURL(rawURL, channel.Secret, time.Now())
+		if err != nil {
+			return err
+		}
+		rawURL = signed
+	}
+	return postBotMessage(ctx, p.client, rawURL, map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": message.Title,
+			"text":  markdownMessage(message),
+		},
+	})
+}
+
+// signDingTalkURL 为钉钉机器人地址添加加签参数
+func signDingTalkURL(rawURL, secret string, now time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid dingtalk webhook url: %w", err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// WeComProvider 企业微信群机器人通知提供者
+type WeComProvider struct {
+	BaseProvider
+	client *http.Client
+}
+
+// NewWeComProvider 创建企业微信提供者
+func NewWeComProvider(config ProviderConfig) *WeComProvider {
+	provider := &WeComProvider{
+		BaseProvider: BaseProvider{
+			Name:      "wecom",
+			Available: true,
+			Config:    config,
+		},
+		client: newWebhookClient(config),
+	}
+	log.Printf("WeCom provider initialized successfully")
+	return provider
+}
+
+func (p *WeComProvider) Send(
+	ctx context.Context,
+	message *NotificationMessage,
+) (*NotificationResult, error) {
+	return p.sendToChannels(ctx, message, p.send)
+}
+
+func (p *WeComProvider) send(
+	ctx context.Context,
+	channel types.UserNotificationChannel,
+	message *NotificationMessage,
+) error {
+	return postBotMessage(ctx, p.client, channel.URL, map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": markdownMessage(message),
+		},
+	})
+}
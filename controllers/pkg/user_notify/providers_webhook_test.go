@@ -0,0 +1,192 @@
+// Copyright © 2024 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+)
+
+func channelMessage(method NotificationMethod, channels ...types.UserNotificationChannel) *NotificationMessage {
+	return &NotificationMessage{
+		UserUID:   uuid.New(),
+		EventType: EventTypeDebtStatusChange,
+		Method:    method,
+		Priority:  NotificationPriorityHigh,
+		Title:     "账户欠费通知",
+		Content:   "您的账户余额不足，请及时充值。",
+		Recipient: types.NotificationRecipient{Channels: channels},
+	}
+}
+
+func TestWebhookProvider_Signature(t *testing.T) {
+	var (
+		payload   WebhookPayload
+		signature string
+		timestamp string
+		body      []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ = io.ReadAll(req.Body)
+		signature = req.Header.Get(WebhookSignatureHeader)
+		timestamp = req.Header.Get(WebhookTimestampHeader)
+		_ = json.Unmarshal(body, &payload)
+	}))
+	defer server.Close()
+
+	provider := NewWebhookProvider(ProviderConfig{IsEnabled: true})
+	provider.client = server.Client()
+	message := channelMessage(NotificationMethodWebhook,
+		types.UserNotificationChannel{ID: uuid.New(), Method: "webhook", URL: server.URL, Secret: "secret"},
+		types.UserNotificationChannel{ID: uuid.New(), Method: "slack", URL: "http://127.0.0.1:1"},
+	)
+	result, err := provider.Send(context.Background(), message)
+	if err != nil || !result.Success {
+		t.Fatalf("Send() = %+v, %v", result, err)
+	}
+	if payload.Title != message.Title || payload.EventType != EventTypeDebtStatusChange {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if timestamp == "" || signature != SignWebhookPayload("secret", timestamp, body) {
+		t.Errorf("signature %q does not match the channel secret", signature)
+	}
+}
+
+func TestWebhookProvider_NoSecret(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		header = req.Header
+	}))
+	defer server.Close()
+
+	provider := NewWebhookProvider(ProviderConfig{IsEnabled: true})
+	provider.client = server.Client()
+	message := channelMessage(NotificationMethodWebhook,
+		types.UserNotificationChannel{ID: uuid.New(), Method: "webhook", URL: server.URL},
+	)
+	if _, err := provider.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if header.Get(WebhookSignatureHeader) != "" || header.Get(WebhookTimestampHeader) != "" {
+		t.Errorf("channel without secret got signature headers %v", header)
+	}
+}
+
+func TestWebhookProvider_NoChannel(t *testing.T) {
+	provider := NewWebhookProvider(ProviderConfig{IsEnabled: true})
+	if _, err := provider.Send(context.Background(), channelMessage(NotificationMethodWebhook)); err == nil {
+		t.Error("Send() without channel succeeded")
+	}
+}
+
+func TestDingTalkProvider_Sign(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		query = req.URL.Query()
+		_, _ = rw.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer server.Close()
+
+	provider := NewDingTalkProvider(ProviderConfig{IsEnabled: true})
+	provider.client = server.Client()
+	message := channelMessage(NotificationMethodDingTalk, types.UserNotificationChannel{
+		ID: uuid.New(), Method: "dingtalk", URL: server.URL + "?access_token=token", Secret: "SEC",
+	})
+	if _, err := provider.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if query["access_token"][0] != "token" || query["timestamp"] == nil || query["sign"] == nil {
+		t.Errorf("unexpected query %v", query)
+	}
+}
+
+func TestWeComProvider_ErrCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`{"errcode":93000,"errmsg":"invalid webhook url"}`))
+	}))
+	defer server.Close()
+
+	provider := NewWeComProvider(ProviderConfig{IsEnabled: true})
+	provider.client = server.Client()
+	message := channelMessage(NotificationMethodWeCom, types.UserNotificationChannel{
+		ID: uuid.New(), Method: "wecom", URL: server.URL,
+	})
+	result, err := provider.Send(context.Background(), message)
+	if err == nil || result.Success {
+		t.Errorf("Send() = %+v, %v, want bot API error", result, err)
+	}
+}
+
+func TestWebhookProvider_ForbiddenAddress(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	// 地址经过域名解析到回环地址时同样被拒绝
+	localhostURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	provider := NewWebhookProvider(ProviderConfig{IsEnabled: true})
+	for _, rawURL := range []string{server.URL, localhostURL} {
+		message := channelMessage(NotificationMethodWebhook,
+			types.UserNotificationChannel{ID: uuid.New(), Method: "webhook", URL: rawURL},
+		)
+		result, err := provider.Send(context.Background(), message)
+		if err == nil || result.Success || !strings.Contains(result.Error, ErrForbiddenAddress.Error()) {
+			t.Errorf("Send(%s) = %+v, %v, want forbidden address", rawURL, result, err)
+		}
+	}
+	if requested {
+		t.Error("the loopback server was requested")
+	}
+}
+
+func TestValidateChannelURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantErr   bool
+		forbidden bool
+	}{
+		{url: "https://hooks.slack.com/services/T/B/X"},
+		{url: "http://example.com:8080/hook"},
+		{url: "ftp://example.com/hook", wantErr: true},
+		{url: "https://", wantErr: true},
+		{url: "http://127.0.0.1:8080/hook", wantErr: true, forbidden: true},
+		{url: "http://10.96.0.1/hook", wantErr: true, forbidden: true},
+		{url: "http://172.16.0.10/hook", wantErr: true, forbidden: true},
+		{url: "http://192.168.1.1/hook", wantErr: true, forbidden: true},
+		{url: "http://100.64.0.1/hook", wantErr: true, forbidden: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true, forbidden: true},
+		{url: "http://[::1]/hook", wantErr: true, forbidden: true},
+		{url: "http://[fe80::1]/hook", wantErr: true, forbidden: true},
+		{url: "http://[fd00::1]/hook", wantErr: true, forbidden: true},
+		{url: "http://0.0.0.0/hook", wantErr: true, forbidden: true},
+	}
+	for _, tt := range tests {
+		err := ValidateChannelURL(tt.url)
+		if (err != nil) != tt.wantErr || errors.Is(err, ErrForbiddenAddress) != tt.forbidden {
+			t.Errorf("ValidateChannelURL(%s) = %v, wantErr %v, forbidden %v", tt.url, err, tt.wantErr, tt.forbidden)
+		}
+	}
+}
@@ -170,6 +170,19 @@ func (s *eventNotificationServiceImpl) HandleWorkspaceSubscriptionEvent(
 	operator types.SubscriptionOperator,
 	methods []NotificationMethod,
 ) ([]*NotificationResult, error) {
+	return s.SendEventNotification(
+		ctx,
+		NewWorkspaceSubscriptionEvent(userUID, eventData, operator, methods),
+	)
+}
+
+// NewWorkspaceSubscriptionEvent 构建订阅相关事件，未指定事件类型时根据操作类型和支付状态确定
+func NewWorkspaceSubscriptionEvent(
+	userUID uuid.UUID,
+	eventData EventData,
+	operator types.SubscriptionOperator,
+	methods []NotificationMethod,
+) *NotificationEvent {
 	// 构建事件数据
 	// eventData := map[string]interface{}{
 	//	"workspace_name": workspace,
@@ -226,7 +239,7 @@ func (s *eventNotificationServiceImpl) HandleWorkspaceSubscriptionEvent(
 		}
 	}
 
-	event := &NotificationEvent{
+	return &NotificationEvent{
 		UserUID:   userUID,
 		EventType: eventType,
 		EventData: eventData.ToMap(),
@@ -234,8 +247,6 @@ func (s *eventNotificationServiceImpl) HandleWorkspaceSubscriptionEvent(
 		Priority:  priority,
 		Timestamp: time.Now(),
 	}
-
-	return s.SendEventNotification(ctx, event)
 }
 
 // HandleTrafficEvent 处理流量相关事件
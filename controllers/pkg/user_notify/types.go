@@ -33,8 +33,27 @@ const (
 	NotificationMethodVMS   NotificationMethod = "vms"
 	NotificationMethodEmail NotificationMethod = "email"
 	NotificationMethodSMS   NotificationMethod = "sms"
+
+	// 用户在通知渠道中配置地址的通知方式
+	NotificationMethodWebhook  NotificationMethod = "webhook"
+	NotificationMethodSlack    NotificationMethod = "slack"
+	NotificationMethodDingTalk NotificationMethod = "dingtalk"
+	NotificationMethodWeCom    NotificationMethod = "wecom"
 )
 
+// IsChannelMethod 是否为发送到用户通知渠道的通知方式
+func (m NotificationMethod) IsChannelMethod() bool {
+	switch m {
+	case NotificationMethodWebhook,
+		NotificationMethodSlack,
+		NotificationMethodDingTalk,
+		NotificationMethodWeCom:
+		return true
+	default:
+		return false
+	}
+}
+
 // NotificationPriority 通知优先级枚举
 type NotificationPriority string
 
@@ -87,6 +106,9 @@ const (
 
 	// 自定义事件
 	EventTypeCustom EventType = "custom"
+
+	// 通知摘要，合并摘要间隔内延迟发送的多条通知
+	EventTypeNotificationDigest EventType = "notification_digest"
 )
 
 // NotificationEvent 通知事件结构（输入）
@@ -100,6 +122,8 @@ type NotificationEvent struct {
 	Timestamp time.Time                   `json:"timestamp"`
 	// Whether to ignore users without contact information, the default is false to ignore
 	NotIgnoreIfNoContact bool `json:"ignore_if_no_contact,omitempty"`
+	// SkipPreferences 不再应用用户通知偏好，用于发送已按偏好延迟的通知
+	SkipPreferences bool `json:"skip_preferences,omitempty"`
 }
 
 type EventData interface {
@@ -393,3 +417,8 @@ type CustomEventData struct {
 	Content   string         `json:"content"`
 	ExtraData map[string]any `json:"extra_data,omitempty"`
 }
+
+// DigestEventData 通知摘要事件数据
+type DigestEventData struct {
+	Events []NotificationEvent `json:"events"`
+}
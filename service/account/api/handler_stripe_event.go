@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -207,15 +206,14 @@ func handleSubscriptionUpdate(
 		return err
 	}
 	if err := dao.DBClient.GlobalTransactionHandler(func(tx *gorm.DB) error {
-		return finalizeWorkspaceSubscriptionSuccess(tx, sub, wsTransaction, &payment)
+		if err := finalizeWorkspaceSubscriptionSuccess(tx, sub, wsTransaction, &payment); err != nil {
+			return err
+		}
+		return sendUpgradeNotification(tx, nr, userUID, wsTransaction, sub, invoice)
 	}); err != nil {
 		return fmt.Errorf("failed to finalize upgrade payment: %w", err)
 	}
 
-	if err := sendUpgradeNotification(nr, userUID, wsTransaction, sub, invoice); err != nil {
-		dao.Logger.Errorf("failed to send upgrade notification for user %s: %v", userUID, err)
-	}
-
 	logrus.Infof(
 		"Successfully processed upgrade payment for %s/%s",
 		meta.Workspace,
@@ -330,12 +328,8 @@ func handleSubscriptionCreateOrRenew(
 			return err
 		}
 
-		if err := sendNotification(nr, userUID, wsTransaction, ws, meta.Operator, invoice); err != nil {
-			dao.Logger.Errorf(
-				"failed to send subscription success notification for user %s: %v",
-				userUID,
-				err,
-			)
+		if err := sendNotification(tx, nr, userUID, wsTransaction, ws, meta.Operator, invoice); err != nil {
+			return fmt.Errorf("failed to enqueue subscription success notification: %w", err)
 		}
 
 		logrus.Infof(
@@ -495,6 +489,7 @@ func preparePayment(
 
 // sendUpgradeNotification
 func sendUpgradeNotification(
+	tx *gorm.DB,
 	nr *types.NotificationRecipient,
 	userUID uuid.UUID,
 	wsTransaction *types.WorkspaceSubscriptionTransaction,
@@ -505,6 +500,7 @@ func sendUpgradeNotification(
 		return nil
 	}
 	return sendNotification(
+		tx,
 		nr,
 		userUID,
 		wsTransaction,
@@ -514,8 +510,9 @@ func sendUpgradeNotification(
 	)
 }
 
-// sendNotification
+// sendNotification enqueues the email notification of the paid invoice in tx
 func sendNotification(
+	tx *gorm.DB,
 	nr *types.NotificationRecipient,
 	userUID uuid.UUID,
 	wsTransaction *types.WorkspaceSubscriptionTransaction,
@@ -565,14 +562,13 @@ func sendNotification(
 	case types.SubscriptionTransactionTypeCreated,
 		types.SubscriptionTransactionTypeUpgraded,
 		types.SubscriptionTransactionTypeRenewed:
-		_, err = dao.UserNotificationService.HandleWorkspaceSubscriptionEvent(
-			context.Background(),
+		return enqueueWorkspaceSubscriptionNotification(
+			tx,
+			"stripe-invoice/"+invoice.ID,
 			userUID,
 			eventData,
 			operator,
-			[]usernotify.NotificationMethod{usernotify.NotificationMethodEmail},
 		)
-		return err
 	default:
 		logrus.Errorf("unsupported subscription operator: %s", operator)
 		return nil
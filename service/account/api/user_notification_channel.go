@@ -0,0 +1,256 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+	usernotify "github.com/labring/sealos/controllers/pkg/user_notify"
+	"github.com/labring/sealos/service/account/dao"
+	"github.com/labring/sealos/service/account/helper"
+)
+
+// CreateUserNotificationChannel
+// @Summary Create user notification channel
+// @Description Create a generic webhook, Slack, DingTalk or WeCom channel which receives the notifications of the user
+// @Tags UserNotificationChannel
+// @Accept json
+// @Produce json
+// @Param request body helper.CreateUserNotificationChannelReq true "Create user notification channel request"
+// @Success 200 {object} helper.CreateUserNotificationChannelResp "Successfully created user notification channel"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse create user notification channel request or unsupported method"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 500 {object} helper.ErrorMessage "Failed to create user notification channel"
+// @Router /account/v1alpha1/user-notification-channel/create [post]
+func CreateUserNotificationChannel(c *gin.Context) {
+	req, err := helper.ParseCreateUserNotificationChannelReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse create user notification channel request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	if !usernotify.NotificationMethod(req.Method).IsChannelMethod() {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("unsupported notification method: %s. Only webhook, slack, dingtalk and wecom are supported", req.Method),
+			},
+		)
+		return
+	}
+	if err := usernotify.ValidateChannelURL(req.URL); err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{Error: err.Error()},
+		)
+		return
+	}
+
+	now := time.Now()
+	channel := &types.UserNotificationChannel{
+		ID:        uuid.New(),
+		UserUID:   req.UserUID,
+		Method:    req.Method,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		IsEnabled: true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := dao.DBClient.CreateUserNotificationChannel(channel); err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to create user notification channel: %v", err),
+			},
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.CreateUserNotificationChannelResp{
+		Data:    userNotificationChannelData(channel),
+		Message: "Successfully created user notification channel",
+	})
+}
+
+// ListUserNotificationChannels
+// @Summary List user notification channels
+// @Description List the notification channels of the user, secrets are not returned
+// @Tags UserNotificationChannel
+// @Accept json
+// @Produce json
+// @Param request body helper.ListUserNotificationChannelsReq true "List user notification channels request"
+// @Success 200 {object} helper.ListUserNotificationChannelsResp "Successfully listed user notification channels"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse list user notification channels request"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 500 {object} helper.ErrorMessage "Failed to list user notification channels"
+// @Router /account/v1alpha1/user-notification-channel/list [post]
+func ListUserNotificationChannels(c *gin.Context) {
+	req, err := helper.ParseListUserNotificationChannelsReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse list user notification channels request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	channels, err := dao.DBClient.ListUserNotificationChannels(req.UserUID)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to list user notification channels: %v", err),
+			},
+		)
+		return
+	}
+
+	channelList := make([]helper.UserNotificationChannelData, len(channels))
+	for i, channel := range channels {
+		channelList[i] = userNotificationChannelData(channel)
+	}
+	c.JSON(http.StatusOK, helper.ListUserNotificationChannelsResp{
+		Data:    channelList,
+		Message: "Successfully listed user notification channels",
+	})
+}
+
+// DeleteUserNotificationChannels
+// @Summary Delete user notification channels
+// @Description Delete multiple notification channels of the user
+// @Tags UserNotificationChannel
+// @Accept json
+// @Produce json
+// @Param request body helper.DeleteUserNotificationChannelsReq true "Delete user notification channels request"
+// @Success 200 {object} helper.DeleteUserNotificationChannelsResp "Successfully deleted user notification channels"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse delete user notification channels request"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 500 {object} helper.ErrorMessage "Failed to delete user notification channels"
+// @Router /account/v1alpha1/user-notification-channel/delete [post]
+func DeleteUserNotificationChannels(c *gin.Context) {
+	req, err := helper.ParseDeleteUserNotificationChannelsReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse delete user notification channels request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	deletedCount, deletedIDs, err := dao.DBClient.DeleteUserNotificationChannels(req.IDs, req.UserUID)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to delete user notification channels: %v", err),
+			},
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.DeleteUserNotificationChannelsResp{
+		Data: helper.DeleteUserNotificationChannelsRespData{
+			DeletedCount: deletedCount,
+			DeletedIDs:   deletedIDs,
+		},
+		Message: fmt.Sprintf("Successfully deleted %d user notification channels", deletedCount),
+	})
+}
+
+// ToggleUserNotificationChannels
+// @Summary Toggle user notification channels
+// @Description Enable or disable multiple notification channels of the user
+// @Tags UserNotificationChannel
+// @Accept json
+// @Produce json
+// @Param request body helper.ToggleUserNotificationChannelsReq true "Toggle user notification channels request"
+// @Success 200 {object} helper.ToggleUserNotificationChannelsResp "Successfully toggled user notification channels"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse toggle user notification channels request"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 500 {object} helper.ErrorMessage "Failed to toggle user notification channels"
+// @Router /account/v1alpha1/user-notification-channel/toggle [post]
+func ToggleUserNotificationChannels(c *gin.Context) {
+	req, err := helper.ParseToggleUserNotificationChannelsReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse toggle user notification channels request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	updatedCount, updatedIDs, err := dao.DBClient.ToggleUserNotificationChannels(req.IDs, req.UserUID, *req.IsEnabled)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to toggle user notification channels: %v", err),
+			},
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.ToggleUserNotificationChannelsResp{
+		Data: helper.ToggleUserNotificationChannelsRespData{
+			UpdatedCount: updatedCount,
+			UpdatedIDs:   updatedIDs,
+		},
+		Message: fmt.Sprintf("Successfully toggled %d user notification channels", updatedCount),
+	})
+}
+
+func userNotificationChannelData(channel *types.UserNotificationChannel) helper.UserNotificationChannelData {
+	return helper.UserNotificationChannelData{
+		ID:        channel.ID,
+		UserUID:   channel.UserUID,
+		Method:    channel.Method,
+		URL:       channel.URL,
+		HasSecret: channel.Secret != "",
+		IsEnabled: channel.IsEnabled,
+		CreatedAt: channel.CreatedAt,
+		UpdatedAt: channel.UpdatedAt,
+	}
+}
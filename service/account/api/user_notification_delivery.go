@@ -0,0 +1,109 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+	usernotify "github.com/labring/sealos/controllers/pkg/user_notify"
+	"github.com/labring/sealos/service/account/dao"
+	"github.com/labring/sealos/service/account/helper"
+	"gorm.io/gorm"
+)
+
+// ListNotificationDeliveries
+// @Summary List notification deliveries
+// @Description List the delivery history of the notifications of the user, newest first
+// @Tags UserNotification
+// @Accept json
+// @Produce json
+// @Param request body helper.ListNotificationDeliveriesReq true "List notification deliveries request"
+// @Success 200 {object} helper.ListNotificationDeliveriesResp "Successfully listed notification deliveries"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse list notification deliveries request"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 500 {object} helper.ErrorMessage "Failed to list notification deliveries"
+// @Router /account/v1alpha1/user-notification/deliveries [post]
+func ListNotificationDeliveries(c *gin.Context) {
+	req, err := helper.ParseListNotificationDeliveriesReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse list notification deliveries request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	deliveries, total, err := dao.DBClient.ListNotificationDeliveries(
+		req.UserUID,
+		req.Status,
+		req.Page,
+		req.PageSize,
+	)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to list notification deliveries: %v", err),
+			},
+		)
+		return
+	}
+
+	data := helper.ListNotificationDeliveriesRespData{
+		Deliveries: make([]helper.NotificationDeliveryData, len(deliveries)),
+		Total:      total,
+	}
+	for i, delivery := range deliveries {
+		data.Deliveries[i] = helper.NotificationDeliveryData{
+			ID:          delivery.ID,
+			EventType:   delivery.EventType,
+			Method:      delivery.Method,
+			Priority:    delivery.Priority,
+			Status:      delivery.Status,
+			Attempts:    delivery.Attempts,
+			ErrorClass:  usernotify.DeliveryErrorClass(delivery.LastError),
+			DeliveredAt: delivery.DeliveredAt,
+			CreatedAt:   delivery.CreatedAt,
+		}
+		if delivery.Status == types.NotificationOutboxStatusPending {
+			data.Deliveries[i].NextAttemptAt = &delivery.NextAttemptAt
+		}
+	}
+	c.JSON(http.StatusOK, helper.ListNotificationDeliveriesResp{
+		Data:    data,
+		Message: "Successfully listed notification deliveries",
+	})
+}
+
+// enqueueWorkspaceSubscriptionNotification enqueues the email notification of a workspace
+// subscription event in tx, it is delivered by the outbox worker once tx is committed.
+// The idempotency key identifies the state change which caused the event.
+func enqueueWorkspaceSubscriptionNotification(
+	tx *gorm.DB,
+	idempotencyKey string,
+	userUID uuid.UUID,
+	eventData usernotify.EventData,
+	operator types.SubscriptionOperator,
+) error {
+	if dao.UserNotificationService == nil {
+		return nil
+	}
+	event := usernotify.NewWorkspaceSubscriptionEvent(
+		userUID,
+		eventData,
+		operator,
+		[]usernotify.NotificationMethod{usernotify.NotificationMethodEmail},
+	)
+	return usernotify.EnqueueEvent(tx, event, idempotencyKey)
+}
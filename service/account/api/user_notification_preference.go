@@ -0,0 +1,219 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+	usernotify "github.com/labring/sealos/controllers/pkg/user_notify"
+	"github.com/labring/sealos/service/account/dao"
+	"github.com/labring/sealos/service/account/helper"
+)
+
+// SetUserNotificationPreference
+// @Summary Set user notification preference
+// @Description Create or replace the notification preference of the user, or of the user in a workspace. The workspace preference takes precedence over the default preference for the events of that workspace
+// @Tags UserNotificationPreference
+// @Accept json
+// @Produce json
+// @Param request body helper.SetUserNotificationPreferenceReq true "Set user notification preference request"
+// @Success 200 {object} helper.SetUserNotificationPreferenceResp "Successfully set user notification preference"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse set user notification preference request or invalid preference"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 403 {object} helper.ErrorMessage "User is not a member of the workspace"
+// @Failure 500 {object} helper.ErrorMessage "Failed to set user notification preference"
+// @Router /account/v1alpha1/user-notification-preference/set [post]
+func SetUserNotificationPreference(c *gin.Context) {
+	req, err := helper.ParseSetUserNotificationPreferenceReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse set user notification preference request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	if req.Workspace != "" {
+		role, err := dao.DBClient.GetUserWorkspaceRole(req.UserUID, req.Workspace)
+		if err != nil {
+			c.JSON(
+				http.StatusInternalServerError,
+				helper.ErrorMessage{Error: fmt.Sprintf("failed to get user role: %v", err)},
+			)
+			return
+		}
+		if role == "" {
+			c.JSON(
+				http.StatusForbidden,
+				helper.ErrorMessage{Error: "there is no permission find this workspace"},
+			)
+			return
+		}
+	}
+
+	now := time.Now()
+	preference := &types.NotificationPreference{
+		ID:              uuid.New(),
+		UserUID:         req.UserUID,
+		Workspace:       req.Workspace,
+		EventMethods:    req.EventMethods,
+		MinPriority:     req.MinPriority,
+		QuietHoursStart: req.QuietHoursStart,
+		QuietHoursEnd:   req.QuietHoursEnd,
+		TimeZone:        req.TimeZone,
+		DigestInterval:  req.DigestInterval,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := usernotify.ValidatePreference(preference); err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{Error: fmt.Sprintf("invalid notification preference: %v", err)},
+		)
+		return
+	}
+	if err := dao.DBClient.SetNotificationPreference(preference); err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to set user notification preference: %v", err),
+			},
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SetUserNotificationPreferenceResp{
+		Data:    userNotificationPreferenceData(preference),
+		Message: "Successfully set user notification preference",
+	})
+}
+
+// ListUserNotificationPreferences
+// @Summary List user notification preferences
+// @Description List the default and workspace notification preferences of the user
+// @Tags UserNotificationPreference
+// @Accept json
+// @Produce json
+// @Param request body helper.ListUserNotificationPreferencesReq true "List user notification preferences request"
+// @Success 200 {object} helper.ListUserNotificationPreferencesResp "Successfully listed user notification preferences"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse list user notification preferences request"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 500 {object} helper.ErrorMessage "Failed to list user notification preferences"
+// @Router /account/v1alpha1/user-notification-preference/list [post]
+func ListUserNotificationPreferences(c *gin.Context) {
+	req, err := helper.ParseListUserNotificationPreferencesReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse list user notification preferences request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	preferences, err := dao.DBClient.ListNotificationPreferences(req.UserUID)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to list user notification preferences: %v", err),
+			},
+		)
+		return
+	}
+
+	preferenceList := make([]helper.UserNotificationPreferenceData, len(preferences))
+	for i, preference := range preferences {
+		preferenceList[i] = userNotificationPreferenceData(preference)
+	}
+	c.JSON(http.StatusOK, helper.ListUserNotificationPreferencesResp{
+		Data:    preferenceList,
+		Message: "Successfully listed user notification preferences",
+	})
+}
+
+// DeleteUserNotificationPreference
+// @Summary Delete user notification preference
+// @Description Delete the default or a workspace notification preference of the user, events are then sent with every method
+// @Tags UserNotificationPreference
+// @Accept json
+// @Produce json
+// @Param request body helper.DeleteUserNotificationPreferenceReq true "Delete user notification preference request"
+// @Success 200 {object} helper.DeleteUserNotificationPreferenceResp "Successfully deleted user notification preference"
+// @Failure 400 {object} helper.ErrorMessage "Failed to parse delete user notification preference request"
+// @Failure 401 {object} helper.ErrorMessage "Authentication error"
+// @Failure 500 {object} helper.ErrorMessage "Failed to delete user notification preference"
+// @Router /account/v1alpha1/user-notification-preference/delete [post]
+func DeleteUserNotificationPreference(c *gin.Context) {
+	req, err := helper.ParseDeleteUserNotificationPreferenceReq(c)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to parse delete user notification preference request: %v", err),
+			},
+		)
+		return
+	}
+	if err := authenticateRequest(c, req); err != nil {
+		c.JSON(
+			http.StatusUnauthorized,
+			helper.ErrorMessage{Error: fmt.Sprintf("authenticate error : %v", err)},
+		)
+		return
+	}
+
+	deleted, err := dao.DBClient.DeleteNotificationPreference(req.UserUID, req.Workspace)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError,
+			helper.ErrorMessage{
+				Error: fmt.Sprintf("failed to delete user notification preference: %v", err),
+			},
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.DeleteUserNotificationPreferenceResp{
+		Data:    helper.DeleteUserNotificationPreferenceRespData{Deleted: deleted},
+		Message: "Successfully deleted user notification preference",
+	})
+}
+
+func userNotificationPreferenceData(
+	preference *types.NotificationPreference,
+) helper.UserNotificationPreferenceData {
+	return helper.UserNotificationPreferenceData{
+		ID:              preference.ID,
+		UserUID:         preference.UserUID,
+		Workspace:       preference.Workspace,
+		EventMethods:    preference.EventMethods,
+		MinPriority:     preference.MinPriority,
+		QuietHoursStart: preference.QuietHoursStart,
+		QuietHoursEnd:   preference.QuietHoursEnd,
+		TimeZone:        preference.TimeZone,
+		DigestInterval:  preference.DigestInterval,
+		CreatedAt:       preference.CreatedAt,
+		UpdatedAt:       preference.UpdatedAt,
+	}
+}
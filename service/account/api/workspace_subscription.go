@@ -2389,16 +2389,13 @@ func handleWorkspaceSubscriptionRenewalFailure(event *stripe.Event) error {
 		}
 
 		// send notifycation
-		if _, err = dao.UserNotificationService.HandleWorkspaceSubscriptionEvent(context.Background(), userUID, notifyEventData, types.SubscriptionOperator(operator), []usernotify.NotificationMethod{usernotify.NotificationMethodEmail}); err != nil {
-			// logrus.Errorf("failed to send subscription failure notification to user %s: %v", userUID, err)
-			dao.Logger.Errorf(
-				"failed to send subscription failure notification for user %s: %v",
-				userUID,
-				err,
-			)
-			// return fmt.Errorf("failed to send subscription success notification to user %s: %w", userUID, err)
-		}
-		return nil
+		return enqueueWorkspaceSubscriptionNotification(
+			tx,
+			"stripe-event/"+event.ID,
+			userUID,
+			notifyEventData,
+			types.SubscriptionOperator(operator),
+		)
 	})
 }
 
@@ -2607,13 +2604,14 @@ func handleWorkspaceSubscriptionDeleted(event *stripe.Event) error {
 			logrus.Warnf("Renewal failed for %s/%s, set to debt status", workspace, regionDomain)
 		}
 
-		if _, err = dao.UserNotificationService.HandleWorkspaceSubscriptionEvent(context.Background(), userUID, notifyEventData, types.SubscriptionTransactionTypeRenewed, []usernotify.NotificationMethod{usernotify.NotificationMethodEmail}); err != nil {
-			logrus.Errorf(
-				"failed to send subscription failure notification to user %s: %v",
-				userUID,
-				err,
-			)
-			// return fmt.Errorf("failed to send subscription success notification to user %s: %w", userUID, err)
+		if err := enqueueWorkspaceSubscriptionNotification(
+			tx,
+			"stripe-event/"+event.ID,
+			userUID,
+			notifyEventData,
+			types.SubscriptionTransactionTypeRenewed,
+		); err != nil {
+			return err
 		}
 
 		if err := tx.Save(workspaceSubscription).Error; err != nil {
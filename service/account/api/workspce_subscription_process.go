@@ -949,13 +949,14 @@ func (wsp *WorkspaceSubscriptionProcessor) handleInsufficientBalance(
 			err,
 		)
 	}
-	if _, err := dao.UserNotificationService.HandleWorkspaceSubscriptionEvent(context.Background(), sub.UserUID, eventData, types.SubscriptionTransactionTypeDebt, []usernotify.NotificationMethod{usernotify.NotificationMethodEmail}); err != nil {
-		dao.Logger.Errorf(
-			"failed to send subscription success notification for user %s: %v",
-			sub.UserUID,
-			err,
-		)
-		// return fmt.Errorf("failed to send subscription success notification to user %s: %w", userUID, err)
+	if err := enqueueWorkspaceSubscriptionNotification(
+		dbTx,
+		"workspace-subscription-transaction/"+failedTransaction.ID.String(),
+		sub.UserUID,
+		eventData,
+		types.SubscriptionTransactionTypeDebt,
+	); err != nil {
+		return err
 	}
 
 	logrus.Infof(
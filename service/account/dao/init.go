@@ -63,6 +63,8 @@ var (
 	// TODO: need init
 	UserContactProvider     usernotify.UserContactProvider
 	UserNotificationService usernotify.EventNotificationService
+	// NotificationOutboxWorker delivers the notifications enqueued with usernotify.EnqueueEvent
+	NotificationOutboxWorker *usernotify.OutboxWorker
 
 	SendDebtStatusEmailBody map[types.DebtStatusType]string
 	// Debug                bool
@@ -219,11 +221,21 @@ func Init(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("parse notify config error: %w", err)
 		}
-		UserContactProvider = usernotify.NewMemoryContactProvider()
+		UserContactProvider = usernotify.NewDBContactProvider(DBClient)
 		UserNotificationService = usernotify.NewEventNotificationService(
 			notifyConfig,
 			UserContactProvider,
 		)
+		NotificationOutboxWorker = usernotify.NewOutboxWorker(
+			DBClient.GetGlobalDB(),
+			UserNotificationService,
+			usernotify.NewDBPreferenceStore(DBClient.GetGlobalDB()),
+			notifyConfig,
+			usernotify.OutboxConfig{
+				PollInterval: env.GetDurationEnvWithDefault(helper.EnvNotifyOutboxPollInterval, 10*time.Second),
+				BatchSize:    env.GetIntEnvWithDefault(helper.EnvNotifyOutboxBatchSize, 50),
+			},
+		)
 	} else {
 		logrus.Errorf("empty notify config env: %s, user notification service disabled", helper.EnvNotifyConfig)
 	}
@@ -22,6 +22,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Interface interface {
@@ -110,6 +111,22 @@ type Interface interface {
 	DeleteUserAlertNotificationAccounts(ids []uuid.UUID, userUID uuid.UUID) (int, []string, error)
 	ToggleUserAlertNotificationAccounts(ids []uuid.UUID, isEnabled bool) (int, []string, error)
 
+	// UserNotificationChannel methods
+	CreateUserNotificationChannel(channel *types.UserNotificationChannel) error
+	ListUserNotificationChannels(userUID uuid.UUID) ([]*types.UserNotificationChannel, error)
+	DeleteUserNotificationChannels(ids []uuid.UUID, userUID uuid.UUID) (int, []string, error)
+	ToggleUserNotificationChannels(ids []uuid.UUID, userUID uuid.UUID, isEnabled bool) (int, []string, error)
+	ListNotificationDeliveries(
+		userUID uuid.UUID,
+		status types.NotificationOutboxStatus,
+		page, pageSize int,
+	) ([]*types.NotificationOutbox, int64, error)
+
+	// NotificationPreference methods
+	SetNotificationPreference(preference *types.NotificationPreference) error
+	ListNotificationPreferences(userUID uuid.UUID) ([]*types.NotificationPreference, error)
+	DeleteNotificationPreference(userUID uuid.UUID, workspace string) (bool, error)
+
 	GetUserWorkspaceRole(userUID uuid.UUID, workspace string) (types.Role, error)
 	// WorkspaceSubscription methods
 	GetWorkspaceSubscription(workspace, regionDomain string) (*types.WorkspaceSubscription, error)
@@ -3218,3 +3235,143 @@ func (g *Cockroach) ToggleUserAlertNotificationAccounts(ids []uuid.UUID, isEnabl
 
 	return len(updatedIDs), updatedIDs, nil
 }
+
+// UserNotificationChannel implementations
+
+func (g *Cockroach) CreateUserNotificationChannel(channel *types.UserNotificationChannel) error {
+	if err := g.ck.GetGlobalDB().Create(channel).Error; err != nil {
+		return fmt.Errorf("failed to create user notification channel: %w", err)
+	}
+	return nil
+}
+
+func (g *Cockroach) ListUserNotificationChannels(userUID uuid.UUID) ([]*types.UserNotificationChannel, error) {
+	var channels []*types.UserNotificationChannel
+	err := g.ck.GetGlobalDB().Where("user_uid = ?", userUID).Order("created_at").Find(&channels).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+func (g *Cockroach) DeleteUserNotificationChannels(ids []uuid.UUID, userUID uuid.UUID) (int, []string, error) {
+	return g.updateUserNotificationChannels(ids, userUID, func(tx *gorm.DB) error {
+		return tx.Delete(&types.UserNotificationChannel{}).Error
+	})
+}
+
+func (g *Cockroach) ToggleUserNotificationChannels(
+	ids []uuid.UUID,
+	userUID uuid.UUID,
+	isEnabled bool,
+) (int, []string, error) {
+	return g.updateUserNotificationChannels(ids, userUID, func(tx *gorm.DB) error {
+		return tx.Model(&types.UserNotificationChannel{}).
+			Updates(map[string]any{"is_enabled": isEnabled, "updated_at": time.Now()}).Error
+	})
+}
+
+// updateUserNotificationChannels applies update to the channels of the user among ids
+// and returns the IDs of the updated channels
+func (g *Cockroach) updateUserNotificationChannels(
+	ids []uuid.UUID,
+	userUID uuid.UUID,
+	update func(tx *gorm.DB) error,
+) (int, []string, error) {
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+
+	var updatedIDs []string
+	err := g.ck.GetGlobalDB().Transaction(func(tx *gorm.DB) error {
+		var channels []types.UserNotificationChannel
+		if err := tx.Where("id IN ? AND user_uid = ?", ids, userUID).Find(&channels).Error; err != nil {
+			return fmt.Errorf("failed to find user notification channels: %w", err)
+		}
+		if len(channels) == 0 {
+			return nil
+		}
+
+		updatedIDs = make([]string, len(channels))
+		channelIDs := make([]uuid.UUID, len(channels))
+		for i, channel := range channels {
+			updatedIDs[i] = channel.ID.String()
+			channelIDs[i] = channel.ID
+		}
+		if err := update(tx.Where("id IN ?", channelIDs)); err != nil {
+			return fmt.Errorf("failed to update user notification channels: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(updatedIDs), updatedIDs, nil
+}
+
+func (g *Cockroach) ListNotificationDeliveries(
+	userUID uuid.UUID,
+	status types.NotificationOutboxStatus,
+	page, pageSize int,
+) ([]*types.NotificationOutbox, int64, error) {
+	query := g.ck.GetGlobalDB().Model(&types.NotificationOutbox{}).Where("user_uid = ?", userUID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notification deliveries: %w", err)
+	}
+	var deliveries []*types.NotificationOutbox
+	err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// NotificationPreference implementations
+
+// SetNotificationPreference creates the preference or replaces the existing preference
+// of the same user and workspace
+func (g *Cockroach) SetNotificationPreference(preference *types.NotificationPreference) error {
+	err := g.ck.GetGlobalDB().Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_uid"}, {Name: "workspace"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"event_methods",
+			"min_priority",
+			"quiet_hours_start",
+			"quiet_hours_end",
+			"time_zone",
+			"digest_interval",
+			"updated_at",
+		}),
+	}).Create(preference).Error
+	if err != nil {
+		return fmt.Errorf("failed to set notification preference: %w", err)
+	}
+	return nil
+}
+
+func (g *Cockroach) ListNotificationPreferences(userUID uuid.UUID) ([]*types.NotificationPreference, error) {
+	var preferences []*types.NotificationPreference
+	err := g.ck.GetGlobalDB().Where("user_uid = ?", userUID).Order("workspace").Find(&preferences).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification preferences: %w", err)
+	}
+	return preferences, nil
+}
+
+func (g *Cockroach) DeleteNotificationPreference(userUID uuid.UUID, workspace string) (bool, error) {
+	result := g.ck.GetGlobalDB().
+		Where("user_uid = ? AND workspace = ?", userUID, workspace).
+		Delete(&types.NotificationPreference{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete notification preference: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -41,6 +41,18 @@ const (
 	UserAlertNotificationAccountList   = "/user-alert-notification-account/list"
 	UserAlertNotificationAccountDelete = "/user-alert-notification-account/delete"
 	UserAlertNotificationAccountToggle = "/user-alert-notification-account/toggle"
+
+	// UserNotificationChannel routes
+	UserNotificationChannelCreate = "/user-notification-channel/create"
+	UserNotificationChannelList   = "/user-notification-channel/list"
+	UserNotificationChannelDelete = "/user-notification-channel/delete"
+	UserNotificationChannelToggle = "/user-notification-channel/toggle"
+	UserNotificationDeliveryList  = "/user-notification/deliveries"
+
+	// UserNotificationPreference routes
+	UserNotificationPreferenceSet    = "/user-notification-preference/set"
+	UserNotificationPreferenceList   = "/user-notification-preference/list"
+	UserNotificationPreferenceDelete = "/user-notification-preference/delete"
 )
 
 const (
@@ -124,4 +136,8 @@ const (
 	EnvPaymentCurrency = "PAYMENT_CURRENCY"
 
 	EnvNotifyConfig = "NOTIFY_CONFIG"
+	// EnvNotifyOutboxPollInterval is how often the notification outbox is polled for due notifications
+	EnvNotifyOutboxPollInterval = "NOTIFY_OUTBOX_POLL_INTERVAL"
+	// EnvNotifyOutboxBatchSize is the number of notifications delivered per poll
+	EnvNotifyOutboxBatchSize = "NOTIFY_OUTBOX_BATCH_SIZE"
 )
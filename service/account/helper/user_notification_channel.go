@@ -0,0 +1,138 @@
+package helper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserNotificationChannel request and response structures
+
+// CreateUserNotificationChannelReq represents the request to create a user notification channel
+type CreateUserNotificationChannelReq struct {
+	// @Summary Notification method
+	// @Description Notification method (webhook, slack, dingtalk or wecom)
+	// @JSONSchema required
+	Method string `json:"method" bson:"method" binding:"required" example:"dingtalk"`
+
+	// @Summary Channel URL
+	// @Description Generic webhook URL, Slack incoming webhook URL, DingTalk or WeCom bot webhook URL
+	// @JSONSchema required
+	URL string `json:"url" bson:"url" binding:"required" example:"https://oapi.dingtalk.com/robot/send?access_token=xxx"`
+
+	// @Summary Signing secret
+	// @Description Secret used to sign generic webhook requests or DingTalk bot messages
+	Secret string `json:"secret,omitempty" bson:"secret,omitempty" example:"SECxxx"`
+
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseCreateUserNotificationChannelReq(c *gin.Context) (*CreateUserNotificationChannelReq, error) {
+	var req CreateUserNotificationChannelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	return &req, nil
+}
+
+// CreateUserNotificationChannelResp represents the response for creating a user notification channel
+type CreateUserNotificationChannelResp struct {
+	Data    UserNotificationChannelData `json:"data"`
+	Message string                      `json:"message"`
+}
+
+// ListUserNotificationChannelsReq represents the request to list user notification channels
+type ListUserNotificationChannelsReq struct {
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseListUserNotificationChannelsReq(c *gin.Context) (*ListUserNotificationChannelsReq, error) {
+	var req ListUserNotificationChannelsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	return &req, nil
+}
+
+// ListUserNotificationChannelsResp represents the response for listing user notification channels
+type ListUserNotificationChannelsResp struct {
+	Data    []UserNotificationChannelData `json:"data"`
+	Message string                        `json:"message"`
+}
+
+// UserNotificationChannelData is a user notification channel, the secret is never returned
+type UserNotificationChannelData struct {
+	ID        uuid.UUID `json:"id"`
+	UserUID   uuid.UUID `json:"userUid"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	HasSecret bool      `json:"hasSecret"`
+	IsEnabled bool      `json:"isEnabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DeleteUserNotificationChannelsReq represents the request to delete user notification channels
+type DeleteUserNotificationChannelsReq struct {
+	// @Summary Channel IDs
+	// @Description List of channel IDs to delete
+	// @JSONSchema required
+	IDs []uuid.UUID `json:"ids" bson:"ids" binding:"required" example:"[\"550e8400-e29b-41d4-a716-446655440000\"]"`
+
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseDeleteUserNotificationChannelsReq(c *gin.Context) (*DeleteUserNotificationChannelsReq, error) {
+	var req DeleteUserNotificationChannelsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	return &req, nil
+}
+
+// DeleteUserNotificationChannelsResp represents the response for deleting user notification channels
+type DeleteUserNotificationChannelsResp struct {
+	Data    DeleteUserNotificationChannelsRespData `json:"data"`
+	Message string                                 `json:"message"`
+}
+
+type DeleteUserNotificationChannelsRespData struct {
+	DeletedCount int      `json:"deletedCount"`
+	DeletedIDs   []string `json:"deletedIds"`
+}
+
+// ToggleUserNotificationChannelsReq represents the request to enable or disable user notification channels
+type ToggleUserNotificationChannelsReq struct {
+	// @Summary Channel IDs
+	// @Description List of channel IDs to toggle
+	// @JSONSchema required
+	IDs []uuid.UUID `json:"ids" bson:"ids" binding:"required" example:"[\"550e8400-e29b-41d4-a716-446655440000\"]"`
+
+	// @Summary Enable flag
+	// @Description Set to true to enable, false to disable
+	// @JSONSchema required
+	IsEnabled *bool `json:"isEnabled" bson:"isEnabled" binding:"required" example:"true"`
+
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseToggleUserNotificationChannelsReq(c *gin.Context) (*ToggleUserNotificationChannelsReq, error) {
+	var req ToggleUserNotificationChannelsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	return &req, nil
+}
+
+// ToggleUserNotificationChannelsResp represents the response for toggling user notification channels
+type ToggleUserNotificationChannelsResp struct {
+	Data    ToggleUserNotificationChannelsRespData `json:"data"`
+	Message string                                 `json:"message"`
+}
+
+type ToggleUserNotificationChannelsRespData struct {
+	UpdatedCount int      `json:"updatedCount"`
+	UpdatedIDs   []string `json:"updatedIds"`
+}
@@ -0,0 +1,67 @@
+package helper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/labring/sealos/controllers/pkg/types"
+)
+
+// ListNotificationDeliveriesReq represents the request to list the notification delivery history of a user
+type ListNotificationDeliveriesReq struct {
+	// @Summary Page
+	// @Description Page, starting from 1
+	Page int `json:"page" bson:"page" example:"1"`
+
+	// @Summary Page Size
+	// @Description Page Size, 20 by default and at most 100
+	PageSize int `json:"pageSize" bson:"pageSize" example:"20"`
+
+	// @Summary Status
+	// @Description Only list the deliveries with this status (pending, delivered, skipped or failed)
+	Status types.NotificationOutboxStatus `json:"status,omitempty" bson:"status,omitempty" example:"failed"`
+
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseListNotificationDeliveriesReq(c *gin.Context) (*ListNotificationDeliveriesReq, error) {
+	var req ListNotificationDeliveriesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	req.PageSize = min(req.PageSize, 100)
+	return &req, nil
+}
+
+// ListNotificationDeliveriesResp represents the response for listing the notification delivery history
+type ListNotificationDeliveriesResp struct {
+	Data    ListNotificationDeliveriesRespData `json:"data"`
+	Message string                             `json:"message"`
+}
+
+type ListNotificationDeliveriesRespData struct {
+	Deliveries []NotificationDeliveryData `json:"deliveries"`
+	Total      int64                      `json:"total"`
+}
+
+// NotificationDeliveryData is the delivery of a notification event with one method
+type NotificationDeliveryData struct {
+	ID            uuid.UUID                      `json:"id"`
+	EventType     string                         `json:"eventType"`
+	Method        string                         `json:"method"`
+	Priority      string                         `json:"priority"`
+	Status        types.NotificationOutboxStatus `json:"status"`
+	Attempts      int                            `json:"attempts"`
+	NextAttemptAt *time.Time                     `json:"nextAttemptAt,omitempty"`
+	ErrorClass    string                         `json:"errorClass,omitempty"`
+	DeliveredAt   *time.Time                     `json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time                      `json:"createdAt"`
+}
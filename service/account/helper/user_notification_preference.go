@@ -0,0 +1,119 @@
+package helper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserNotificationPreference request and response structures
+
+// SetUserNotificationPreferenceReq represents the request to create or replace a notification preference
+type SetUserNotificationPreferenceReq struct {
+	// @Summary Workspace
+	// @Description Workspace the preference applies to, empty for the default preference of the user
+	Workspace string `json:"workspace,omitempty" bson:"workspace,omitempty" example:"ns-admin"`
+
+	// @Summary Event methods
+	// @Description Notification methods allowed for each event type, "*" matches the other event types. Event types not listed are sent with every method
+	EventMethods map[string][]string `json:"eventMethods,omitempty" bson:"eventMethods,omitempty" example:"{\"*\":[\"email\",\"dingtalk\"],\"workspace_subscription_debt\":[\"sms\",\"email\"]}"`
+
+	// @Summary Minimum priority
+	// @Description Events below this priority (low, normal, high or critical) are not notified
+	MinPriority string `json:"minPriority,omitempty" bson:"minPriority,omitempty" example:"normal"`
+
+	// @Summary Quiet hours start
+	// @Description Start of the quiet hours in HH:MM, notifications below critical priority are deferred until the quiet hours end
+	QuietHoursStart string `json:"quietHoursStart,omitempty" bson:"quietHoursStart,omitempty" example:"22:00"`
+
+	// @Summary Quiet hours end
+	// @Description End of the quiet hours in HH:MM, may be earlier than the start to span midnight
+	QuietHoursEnd string `json:"quietHoursEnd,omitempty" bson:"quietHoursEnd,omitempty" example:"08:00"`
+
+	// @Summary Time zone
+	// @Description IANA time zone of the quiet hours, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty" bson:"timeZone,omitempty" example:"Asia/Shanghai"`
+
+	// @Summary Digest interval
+	// @Description Minutes during which low and normal priority email and channel notifications are batched into one digest, 0 disables digests
+	DigestInterval int `json:"digestInterval,omitempty" bson:"digestInterval,omitempty" example:"60"`
+
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseSetUserNotificationPreferenceReq(c *gin.Context) (*SetUserNotificationPreferenceReq, error) {
+	var req SetUserNotificationPreferenceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	return &req, nil
+}
+
+// SetUserNotificationPreferenceResp represents the response for setting a notification preference
+type SetUserNotificationPreferenceResp struct {
+	Data    UserNotificationPreferenceData `json:"data"`
+	Message string                         `json:"message"`
+}
+
+// ListUserNotificationPreferencesReq represents the request to list the notification preferences of the user
+type ListUserNotificationPreferencesReq struct {
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseListUserNotificationPreferencesReq(c *gin.Context) (*ListUserNotificationPreferencesReq, error) {
+	var req ListUserNotificationPreferencesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	return &req, nil
+}
+
+// ListUserNotificationPreferencesResp represents the response for listing notification preferences
+type ListUserNotificationPreferencesResp struct {
+	Data    []UserNotificationPreferenceData `json:"data"`
+	Message string                           `json:"message"`
+}
+
+// UserNotificationPreferenceData is a notification preference of the user
+type UserNotificationPreferenceData struct {
+	ID              uuid.UUID           `json:"id"`
+	UserUID         uuid.UUID           `json:"userUid"`
+	Workspace       string              `json:"workspace"`
+	EventMethods    map[string][]string `json:"eventMethods,omitempty"`
+	MinPriority     string              `json:"minPriority,omitempty"`
+	QuietHoursStart string              `json:"quietHoursStart,omitempty"`
+	QuietHoursEnd   string              `json:"quietHoursEnd,omitempty"`
+	TimeZone        string              `json:"timeZone,omitempty"`
+	DigestInterval  int                 `json:"digestInterval"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+}
+
+// DeleteUserNotificationPreferenceReq represents the request to delete a notification preference
+type DeleteUserNotificationPreferenceReq struct {
+	// @Summary Workspace
+	// @Description Workspace of the preference to delete, empty for the default preference of the user
+	Workspace string `json:"workspace,omitempty" bson:"workspace,omitempty" example:"ns-admin"`
+
+	AuthBase `json:",inline" bson:",inline"`
+}
+
+func ParseDeleteUserNotificationPreferenceReq(c *gin.Context) (*DeleteUserNotificationPreferenceReq, error) {
+	var req DeleteUserNotificationPreferenceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, fmt.Errorf("failed to bind request: %w", err)
+	}
+	return &req, nil
+}
+
+// DeleteUserNotificationPreferenceResp represents the response for deleting a notification preference
+type DeleteUserNotificationPreferenceResp struct {
+	Data    DeleteUserNotificationPreferenceRespData `json:"data"`
+	Message string                                   `json:"message"`
+}
+
+type DeleteUserNotificationPreferenceRespData struct {
+	Deleted bool `json:"deleted"`
+}
@@ -87,6 +87,16 @@ func RegisterPayRouter() {
 		POST(helper.UserAlertNotificationAccountList, api.ListUserAlertNotificationAccounts).
 		POST(helper.UserAlertNotificationAccountDelete, api.DeleteUserAlertNotificationAccount).
 		POST(helper.UserAlertNotificationAccountToggle, api.ToggleUserAlertNotificationAccounts).
+		// UserNotificationChannel routes
+		POST(helper.UserNotificationChannelCreate, api.CreateUserNotificationChannel).
+		POST(helper.UserNotificationChannelList, api.ListUserNotificationChannels).
+		POST(helper.UserNotificationChannelDelete, api.DeleteUserNotificationChannels).
+		POST(helper.UserNotificationChannelToggle, api.ToggleUserNotificationChannels).
+		POST(helper.UserNotificationDeliveryList, api.ListNotificationDeliveries).
+		// UserNotificationPreference routes
+		POST(helper.UserNotificationPreferenceSet, api.SetUserNotificationPreference).
+		POST(helper.UserNotificationPreferenceList, api.ListUserNotificationPreferences).
+		POST(helper.UserNotificationPreferenceDelete, api.DeleteUserNotificationPreference).
 		// WorkspaceSubscription routes
 		POST(helper.WorkspaceSubscriptionInfo, api.GetWorkspaceSubscriptionInfo).
 		POST(helper.WorkspaceSubscriptionList, api.GetWorkspaceSubscriptionList).
@@ -188,6 +198,11 @@ func RegisterPayRouter() {
 	workspaceSub := api.NewWorkspaceSubscriptionProcessor()
 	workspaceSub.Start(ctx)
 
+	// deliver the notifications of the outbox
+	if dao.NotificationOutboxWorker != nil {
+		go dao.NotificationOutboxWorker.Run(ctx)
+	}
+
 	// Wait for interrupt signal.
 	<-rootCtx.Done()
 
@@ -125,6 +125,9 @@ var (
 	DatabaseMemMessageIDMap           = make(map[string]string)
 	DatabaseBackupMessageIDMap        = make(map[string]string)
 	QuotaMessageIDMap                 = make(map[string]string)
+	AlertmanagerURL                   string
+	AlertmanagerWebhookToken          string
+	SilencesToken                     string
 )
 
 func GetENV() error {
@@ -150,6 +153,11 @@ func GetENV() error {
 	DatabaseCPUMonitorThreshold, _ = strconv.ParseFloat(getEnvWithCheck("DatabaseCPUMonitorThreshold", &missingEnvVars), 64)
 	DatabaseMemMonitorThreshold, _ = strconv.ParseFloat(getEnvWithCheck("DatabaseMemMonitorThreshold", &missingEnvVars), 64)
 	QuotaThreshold, _ = strconv.ParseFloat(getEnvWithCheck("QuotaThreshold", &missingEnvVars), 64)
+	// Optional, the alerts are forwarded to Alertmanager and its webhooks are authenticated if set
+	AlertmanagerURL = os.Getenv("AlertmanagerURL")
+	AlertmanagerWebhookToken = os.Getenv("AlertmanagerWebhookToken")
+	// Optional, silences can only be created and expired over HTTP if set
+	SilencesToken = os.Getenv("SilencesToken")
 
 	if clusterNS != "" {
 		ClusterNS = strings.Split(clusterNS, ",")
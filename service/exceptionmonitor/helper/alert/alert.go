@@ -0,0 +1,189 @@
+package alert
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+)
+
+// States of an alert
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Severities of an alert
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Labels set on the alerts of the monitors
+const (
+	LabelAlertName    = "alertname"
+	LabelSeverity     = "severity"
+	LabelCluster      = "cluster"
+	LabelNamespace    = "namespace"
+	LabelName         = "name"
+	LabelDatabaseType = "database_type"
+	LabelStatus       = "status"
+)
+
+// Annotations of an alert, the zh ones are used by the Chinese notifications
+const (
+	AnnotationSummary       = "summary"
+	AnnotationDescription   = "description"
+	AnnotationSummaryZh     = "summary_zh"
+	AnnotationDescriptionZh = "description_zh"
+)
+
+// Alert is an alert raised by a monitor. It is identified by its labels, an alert
+// with the same labels is the same alert.
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	State       string            `json:"status"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt,omitempty"`
+
+	// Card is the Feishu card of the alert, a generic card is built from the
+	// annotations if empty
+	Card string `json:"-"`
+	// Info is the database info of the alerts of the database monitors, the
+	// Feishu cards of the same database are updated through it
+	Info *api.Info `json:"-"`
+}
+
+// New creates a firing alert with the labels
+func New(name, severity string, labels map[string]string) *Alert {
+	a := &Alert{
+		Labels: map[string]string{
+			LabelAlertName: name,
+			LabelSeverity:  severity,
+			LabelCluster:   api.ClusterName,
+		},
+		Annotations: make(map[string]string),
+		State:       StateFiring,
+	}
+	for k, v := range labels {
+		a.Labels[k] = v
+	}
+	return a
+}
+
+// Resolve marks the alert as resolved
+func (a *Alert) Resolve() *Alert {
+	a.State = StateResolved
+	return a
+}
+
+func (a *Alert) Name() string {
+	return a.Labels[LabelAlertName]
+}
+
+func (a *Alert) Severity() string {
+	return a.Labels[LabelSeverity]
+}
+
+func (a *Alert) Resolved() bool {
+	return a.State == StateResolved
+}
+
+// ended tells whether the alert is firing but was not notified again before its end
+func (a *Alert) ended(now time.Time) bool {
+	return !a.Resolved() && !a.EndsAt.IsZero() && !now.Before(a.EndsAt)
+}
+
+// Fingerprint hashes the sorted labels of the alert
+func (a *Alert) Fingerprint() string {
+	return fingerprint(a.Labels, nil)
+}
+
+// Summary returns the summary of the alert in Chinese if zh is set and available
+func (a *Alert) Summary(zh bool) string {
+	return a.annotation(AnnotationSummary, AnnotationSummaryZh, zh, a.Name())
+}
+
+// Description returns the description of the alert in Chinese if zh is set and available
+func (a *Alert) Description(zh bool) string {
+	return a.annotation(AnnotationDescription, AnnotationDescriptionZh, zh, a.Summary(zh))
+}
+
+func (a *Alert) annotation(key, zhKey string, zh bool, def string) string {
+	if zh && a.Annotations[zhKey] != "" {
+		return a.Annotations[zhKey]
+	}
+	if a.Annotations[key] != "" {
+		return a.Annotations[key]
+	}
+	if a.Annotations[zhKey] != "" {
+		return a.Annotations[zhKey]
+	}
+	return def
+}
+
+// fingerprint hashes the labels, or only the given names of them if not nil
+func fingerprint(labels map[string]string, names []string) string {
+	if names == nil {
+		names = make([]string, 0, len(labels))
+		for name := range labels {
+			names = append(names, name)
+		}
+	}
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	h := fnv.New64a()
+	for _, name := range sorted {
+		h.Write([]byte(name))
+		h.Write([]byte{0xff})
+		h.Write([]byte(labels[name]))
+		h.Write([]byte{0xff})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// Matchers select alerts by their labels, all of them must match
+type Matchers struct {
+	// Match are the labels which must be equal
+	Match map[string]string `json:"match,omitempty"`
+	// MatchRE are the labels which must fully match a regular expression
+	MatchRE map[string]string `json:"matchRE,omitempty"`
+
+	compiled map[string]*regexp.Regexp
+}
+
+func (m *Matchers) compile() error {
+	m.compiled = make(map[string]*regexp.Regexp, len(m.MatchRE))
+	for name, expr := range m.MatchRE {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid regular expression of label %s: %w", name, err)
+		}
+		m.compiled[name] = re
+	}
+	return nil
+}
+
+func (m *Matchers) Matches(labels map[string]string) bool {
+	for name, value := range m.Match {
+		if labels[name] != value {
+			return false
+		}
+	}
+	for name, re := range m.compiled {
+		if !re.MatchString(labels[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Matchers) empty() bool {
+	return len(m.Match) == 0 && len(m.MatchRE) == 0
+}
@@ -0,0 +1,167 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// LabelSource is set on the alerts which were not raised by the monitors
+	LabelSource = "source"
+	// SourceAlertmanager is the source of the alerts received from Alertmanager
+	SourceAlertmanager = "alertmanager"
+	// AnnotationOwner is the owner of the namespace of the alerts received from Alertmanager
+	AnnotationOwner = "owner"
+
+	alertmanagerAlertsPath = "/api/v2/alerts"
+	// alertmanagerResendInterval is how often the firing alerts are sent again, so that
+	// Alertmanager does not resolve them after its resolve timeout
+	alertmanagerResendInterval = time.Minute
+	// alertmanagerFiringTimeout is how long a firing alert is sent again after it was
+	// last notified, so that the alerts which are never resolved expire in the end
+	alertmanagerFiringTimeout = 24 * time.Hour
+)
+
+// FromWebhook converts the alerts of an Alertmanager webhook notification, the
+// alerts without severity are warnings
+func FromWebhook(msg *WebhookMessage) []*Alert {
+	alerts := make([]*Alert, 0, len(msg.Alerts))
+	for _, wa := range msg.Alerts {
+		severity := wa.Labels[LabelSeverity]
+		if severity == "" {
+			severity = SeverityWarning
+		}
+		a := New(wa.Labels[LabelAlertName], severity, wa.Labels)
+		a.Labels[LabelSource] = SourceAlertmanager
+		for k, v := range wa.Annotations {
+			a.Annotations[k] = v
+		}
+		a.StartsAt = wa.StartsAt
+		if wa.Status == StateResolved {
+			a.Resolve()
+			a.EndsAt = wa.EndsAt
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+// AlertmanagerConfig forwards the alerts to the API of an Alertmanager
+type AlertmanagerConfig struct {
+	NotifierConfig `json:",inline"`
+	// URL is the base URL of the Alertmanager, like http://alertmanager.monitoring:9093
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type postableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt,omitempty"`
+	EndsAt       time.Time         `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// alertmanagerNotifier posts the alerts to Alertmanager. The monitors notify an alert
+// once, so the firing alerts are posted again until they are resolved or expire.
+type alertmanagerNotifier struct {
+	config AlertmanagerConfig
+	url    string
+	now    func() time.Time
+
+	mu     sync.Mutex
+	firing map[string]*firingAlert
+	start  sync.Once
+}
+
+// firingAlert is a firing alert sent again until expiresAt
+type firingAlert struct {
+	alert     *Alert
+	expiresAt time.Time
+}
+
+func newAlertmanagerNotifier(config AlertmanagerConfig) *alertmanagerNotifier {
+	return &alertmanagerNotifier{
+		config: config,
+		url:    strings.TrimSuffix(config.URL, "/") + alertmanagerAlertsPath,
+		now:    time.Now,
+		firing: make(map[string]*firingAlert),
+	}
+}
+
+func (n *alertmanagerNotifier) SendResolved() bool {
+	return n.config.sendResolved(true)
+}
+
+func (n *alertmanagerNotifier) Notify(ctx context.Context, notice *Notification) error {
+	now := n.now()
+	n.mu.Lock()
+	for _, a := range notice.Alerts {
+		if a.Resolved() {
+			delete(n.firing, a.Fingerprint())
+			continue
+		}
+		// An alert with an end, like the ones received from Alertmanager, expires then
+		expiresAt := a.EndsAt
+		if expiresAt.IsZero() {
+			expiresAt = now.Add(alertmanagerFiringTimeout)
+		}
+		n.firing[a.Fingerprint()] = &firingAlert{alert: a, expiresAt: expiresAt}
+	}
+	n.mu.Unlock()
+	n.start.Do(func() { go n.resend() })
+
+	return n.post(ctx, notice.Alerts)
+}
+
+func (n *alertmanagerNotifier) post(ctx context.Context, alerts []*Alert) error {
+	body := make([]postableAlert, 0, len(alerts))
+	for _, a := range alerts {
+		pa := postableAlert{
+			Labels:      a.Labels,
+			Annotations: a.Annotations,
+			StartsAt:    a.StartsAt,
+		}
+		if a.Resolved() {
+			pa.EndsAt = a.EndsAt
+		}
+		body = append(body, pa)
+	}
+	return postJSON(ctx, n.url, n.config.Headers, body)
+}
+
+func (n *alertmanagerNotifier) resend() {
+	ticker := time.NewTicker(alertmanagerResendInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		alerts := n.pending()
+		if len(alerts) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		if err := n.post(ctx, alerts); err != nil {
+			log.Printf("Failed to resend alerts to %s: %v", n.url, err)
+		}
+		cancel()
+	}
+}
+
+// pending returns the firing alerts to send again and forgets the expired ones
+func (n *alertmanagerNotifier) pending() []*Alert {
+	now := n.now()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	alerts := make([]*Alert, 0, len(n.firing))
+	for fp, f := range n.firing {
+		if !now.Before(f.expiresAt) {
+			delete(n.firing, fp)
+			continue
+		}
+		alerts = append(alerts, f.alert)
+	}
+	return alerts
+}
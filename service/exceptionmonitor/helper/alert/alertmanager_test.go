@@ -0,0 +1,147 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+)
+
+func TestFromWebhook(t *testing.T) {
+	startsAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	msg := &WebhookMessage{
+		Receiver: "exceptionmonitor",
+		Alerts: []WebhookAlert{
+			{
+				Status:      StateFiring,
+				Labels:      map[string]string{LabelAlertName: "PodCrashLooping", LabelNamespace: "ns-a"},
+				Annotations: map[string]string{AnnotationSummary: "Pod is crash looping"},
+				StartsAt:    startsAt,
+			},
+			{
+				Status:   StateResolved,
+				Labels:   map[string]string{LabelAlertName: "NodeDown", LabelSeverity: SeverityCritical},
+				StartsAt: startsAt,
+				EndsAt:   startsAt.Add(time.Hour),
+			},
+		},
+	}
+
+	alerts := FromWebhook(msg)
+	if len(alerts) != 2 {
+		t.Fatalf("FromWebhook() returned %d alerts, want 2", len(alerts))
+	}
+	firing, resolved := alerts[0], alerts[1]
+	if firing.Resolved() || firing.Severity() != SeverityWarning || firing.Labels[LabelSource] != SourceAlertmanager ||
+		firing.Summary(true) != "Pod is crash looping" || !firing.StartsAt.Equal(startsAt) {
+		t.Errorf("unexpected firing alert %+v", firing)
+	}
+	if !resolved.Resolved() || resolved.Severity() != SeverityCritical || !resolved.EndsAt.Equal(startsAt.Add(time.Hour)) {
+		t.Errorf("unexpected resolved alert %+v", resolved)
+	}
+}
+
+func TestAlertmanagerNotifier(t *testing.T) {
+	posted := make(chan []postableAlert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != alertmanagerAlertsPath {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var alerts []postableAlert
+		if err := json.NewDecoder(req.Body).Decode(&alerts); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		posted <- alerts
+	}))
+	defer server.Close()
+
+	notifier := newAlertmanagerNotifier(AlertmanagerConfig{URL: server.URL + "/"})
+	a := New(AlertDatabaseBackup, SeverityWarning, map[string]string{LabelNamespace: "ns-a"})
+	a.StartsAt = time.Now()
+	if err := notifier.Notify(context.Background(), &Notification{Alerts: []*Alert{a}}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	alerts := <-posted
+	if len(alerts) != 1 || alerts[0].Labels[LabelAlertName] != AlertDatabaseBackup || !alerts[0].EndsAt.IsZero() {
+		t.Errorf("unexpected firing alerts %+v", alerts)
+	}
+	if len(notifier.firing) != 1 {
+		t.Errorf("the firing alert is not kept to be sent again")
+	}
+
+	a.Resolve().EndsAt = time.Now()
+	if err := notifier.Notify(context.Background(), &Notification{Alerts: []*Alert{a}}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if alerts := <-posted; alerts[0].EndsAt.IsZero() {
+		t.Errorf("resolved alert posted without end")
+	}
+	if len(notifier.firing) != 0 {
+		t.Errorf("the resolved alert is still sent again")
+	}
+}
+
+func TestAlertmanagerNotifier_Expire(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notifier := newAlertmanagerNotifier(AlertmanagerConfig{URL: server.URL})
+	notifier.now = func() time.Time { return now }
+
+	monitorAlert := New(AlertDatabaseBackup, SeverityWarning, map[string]string{LabelNamespace: "ns-a"})
+	received := New("PodCrashLooping", SeverityWarning, map[string]string{LabelNamespace: "ns-b"})
+	received.EndsAt = now.Add(time.Hour)
+	if err := notifier.Notify(context.Background(), &Notification{Alerts: []*Alert{monitorAlert, received}}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if alerts := notifier.pending(); len(alerts) != 2 {
+		t.Errorf("pending() returned %d alerts, want 2", len(alerts))
+	}
+	now = now.Add(time.Hour)
+	if alerts := notifier.pending(); len(alerts) != 1 || alerts[0] != monitorAlert {
+		t.Errorf("pending() after the end of the received alert = %v, want the monitor alert", alerts)
+	}
+	now = now.Add(alertmanagerFiringTimeout)
+	if alerts := notifier.pending(); len(alerts) != 0 || len(notifier.firing) != 0 {
+		t.Errorf("pending() after the firing timeout = %v, want none", alerts)
+	}
+}
+
+func TestDefaultConfig_Alertmanager(t *testing.T) {
+	api.AlertmanagerURL = "http://alertmanager:9093"
+	defer func() { api.AlertmanagerURL = "" }()
+	config := DefaultConfig()
+
+	receivers := func(a *Alert) map[string]bool {
+		routed := make(map[string]bool)
+		for _, route := range config.Route.match(a.Labels) {
+			routed[route.Receiver] = true
+		}
+		return routed
+	}
+	monitorAlert := New(AlertDatabaseBackup, SeverityWarning, map[string]string{LabelNamespace: "ns-a"})
+	if routed := receivers(monitorAlert); !routed["alertmanager"] || !routed["feishu-important"] {
+		t.Errorf("monitor alert routed to %v, want alertmanager and feishu-important", routed)
+	}
+	received := FromWebhook(&WebhookMessage{Alerts: []WebhookAlert{{
+		Status: StateFiring,
+		Labels: map[string]string{LabelAlertName: "PodCrashLooping", LabelNamespace: "ns-a"},
+	}}})[0]
+	if routed := receivers(received); routed["alertmanager"] || !routed["alertmanager-owner"] || !routed["feishu-important"] {
+		t.Errorf("Alertmanager alert routed to %v, want alertmanager-owner and feishu-important", routed)
+	}
+	for _, route := range config.Route.match(received.Labels) {
+		if route.Receiver == "alertmanager-owner" &&
+			(route.RepeatInterval == nil || route.RepeatInterval.Duration != alertmanagerOwnerRepeatInterval) {
+			t.Errorf("alertmanager-owner repeat interval = %v, want %v", route.RepeatInterval, alertmanagerOwnerRepeatInterval)
+		}
+	}
+}
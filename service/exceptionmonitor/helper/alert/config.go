@@ -0,0 +1,371 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Names of the alerts of the monitors
+const (
+	AlertDatabaseStatus         = "DatabaseStatusException"
+	AlertDatabaseDiskFull       = "DatabaseDiskFull"
+	AlertDatabaseQuotaExceeded  = "DatabaseQuotaExceeded"
+	AlertDatabaseCPUHigh        = "DatabaseCPUUsageHigh"
+	AlertDatabaseMemoryHigh     = "DatabaseMemoryUsageHigh"
+	AlertDatabaseDiskHigh       = "DatabaseDiskUsageHigh"
+	AlertDatabaseBackup         = "DatabaseBackupException"
+	AlertNamespaceQuotaHigh     = "NamespaceQuotaUsageHigh"
+	AlertCockroachDBUnavailable = "CockroachDBUnavailable"
+)
+
+const (
+	defaultGroupInterval = 5 * time.Minute
+	// defaultResolveTimeout is longer than the interval of the slowest monitor
+	defaultResolveTimeout = 24 * time.Hour
+	// alertmanagerOwnerRepeatInterval is how often the owners are notified by SMS and
+	// email of an alert received from Alertmanager which keeps firing
+	alertmanagerOwnerRepeatInterval = 24 * time.Hour
+)
+
+// Config routes the alerts to the receivers
+type Config struct {
+	Route        *Route           `json:"route"`
+	Receivers    []ReceiverConfig `json:"receivers"`
+	InhibitRules []InhibitRule    `json:"inhibitRules,omitempty"`
+	Silences     []Silence        `json:"silences,omitempty"`
+	// ResolveTimeout is how long a firing alert which is not notified again keeps
+	// firing before it is resolved, 24h by default
+	ResolveTimeout *metav1.Duration `json:"resolveTimeout,omitempty"`
+}
+
+// Route sends the alerts it matches to its receiver, unless one of its routes
+// matches them. The routes are tried in order and the first matching one is used,
+// or all the matching ones until one without continue. The unset fields of a route
+// are inherited from its parent.
+type Route struct {
+	Matchers `json:",inline"`
+
+	Receiver string `json:"receiver,omitempty"`
+	// GroupBy are the labels the alerts are grouped by in a notification, alertname by
+	// default, "..." groups by all the labels
+	GroupBy []string `json:"groupBy,omitempty"`
+	// GroupWait is how long the first alerts of a group are waited for before the
+	// group is notified, the alerts are notified immediately by default
+	GroupWait *metav1.Duration `json:"groupWait,omitempty"`
+	// GroupInterval is how long the new alerts of a notified group are waited for, 5m by default
+	GroupInterval *metav1.Duration `json:"groupInterval,omitempty"`
+	// RepeatInterval is how long a notified alert which fires again is not notified,
+	// the alerts are notified every time they fire by default
+	RepeatInterval *metav1.Duration `json:"repeatInterval,omitempty"`
+	Continue       bool             `json:"continue,omitempty"`
+	Routes         []*Route         `json:"routes,omitempty"`
+
+	id string
+}
+
+// InhibitRule mutes the firing alerts matching target while a firing alert matching
+// source has the same values for the equal labels
+type InhibitRule struct {
+	Source Matchers `json:"source"`
+	Target Matchers `json:"target"`
+	Equal  []string `json:"equal,omitempty"`
+}
+
+// ReceiverConfig is a named set of notifiers, all of them are notified
+type ReceiverConfig struct {
+	Name    string          `json:"name"`
+	Feishu  []FeishuConfig  `json:"feishu,omitempty"`
+	Webhook []WebhookConfig `json:"webhook,omitempty"`
+	Slack   []SlackConfig   `json:"slack,omitempty"`
+	Email   []EmailConfig   `json:"email,omitempty"`
+	SMS     []SMSConfig     `json:"sms,omitempty"`
+	Desktop []DesktopConfig `json:"desktop,omitempty"`
+	// Alertmanager forwards the alerts, the alerts received from Alertmanager must
+	// not be routed to it
+	Alertmanager []AlertmanagerConfig `json:"alertmanager,omitempty"`
+}
+
+// NotifierConfig are the options shared by the notifiers
+type NotifierConfig struct {
+	// SendResolved tells whether the resolved alerts are notified, the default
+	// depends on the notifier
+	SendResolved *bool `json:"sendResolved,omitempty"`
+}
+
+func (c NotifierConfig) sendResolved(def bool) bool {
+	if c.SendResolved == nil {
+		return def
+	}
+	return *c.SendResolved
+}
+
+// FeishuConfig sends the Feishu cards of the alerts to a chat
+type FeishuConfig struct {
+	NotifierConfig `json:",inline"`
+	ChatID         string `json:"chatID,omitempty"`
+	// ChatIDFrom is the environment variable holding the chat id, like FeishuWebhookURLUFA
+	ChatIDFrom string `json:"chatIDFrom,omitempty"`
+}
+
+// WebhookConfig posts the notifications as JSON
+type WebhookConfig struct {
+	NotifierConfig `json:",inline"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers,omitempty"`
+}
+
+// SlackConfig posts the notifications to a Slack-compatible incoming webhook
+type SlackConfig struct {
+	NotifierConfig `json:",inline"`
+	URL            string `json:"url"`
+	Channel        string `json:"channel,omitempty"`
+	Username       string `json:"username,omitempty"`
+}
+
+// EmailConfig sends the alerts by email
+type EmailConfig struct {
+	NotifierConfig `json:",inline"`
+	To             []string `json:"to,omitempty"`
+	// ToOwner sends the alerts to the owner of their namespace
+	ToOwner bool `json:"toOwner,omitempty"`
+}
+
+// SMSConfig sends the alerts by SMS
+type SMSConfig struct {
+	NotifierConfig `json:",inline"`
+	To             []string `json:"to,omitempty"`
+	// ToOwner sends the alerts to the owner of their namespace
+	ToOwner bool `json:"toOwner,omitempty"`
+}
+
+// DesktopConfig creates a desktop Notification in the namespace of the alerts
+type DesktopConfig struct {
+	NotifierConfig `json:",inline"`
+}
+
+// LoadConfig reads the YAML config at path
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse alert config %s: %w", path, err)
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the config and prepares its routes and matchers
+func (c *Config) Validate() error {
+	if c.Route == nil {
+		return errors.New("the alert config has no route")
+	}
+	receivers := make(map[string]bool, len(c.Receivers))
+	for _, r := range c.Receivers {
+		if r.Name == "" {
+			return errors.New("receiver without name")
+		}
+		if receivers[r.Name] {
+			return fmt.Errorf("duplicate receiver %s", r.Name)
+		}
+		receivers[r.Name] = true
+	}
+	if c.ResolveTimeout == nil {
+		c.ResolveTimeout = &metav1.Duration{Duration: defaultResolveTimeout}
+	}
+	if c.ResolveTimeout.Duration <= 0 {
+		return errors.New("the resolve timeout must be positive")
+	}
+	if c.Route.Receiver == "" {
+		return errors.New("the root route has no receiver")
+	}
+	if !c.Route.empty() {
+		return errors.New("the root route must match all the alerts")
+	}
+	root := Route{
+		GroupBy:        []string{LabelAlertName},
+		GroupWait:      &metav1.Duration{},
+		GroupInterval:  &metav1.Duration{Duration: defaultGroupInterval},
+		RepeatInterval: &metav1.Duration{},
+	}
+	if err := c.Route.compile(&root, "0", receivers); err != nil {
+		return err
+	}
+	for i := range c.InhibitRules {
+		if err := c.InhibitRules[i].Source.compile(); err != nil {
+			return err
+		}
+		if err := c.InhibitRules[i].Target.compile(); err != nil {
+			return err
+		}
+	}
+	for i := range c.Silences {
+		if err := c.Silences[i].validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Route) compile(parent *Route, id string, receivers map[string]bool) error {
+	r.id = id
+	if r.Receiver == "" {
+		r.Receiver = parent.Receiver
+	}
+	if !receivers[r.Receiver] {
+		return fmt.Errorf("route %s uses unknown receiver %s", id, r.Receiver)
+	}
+	if r.GroupBy == nil {
+		r.GroupBy = parent.GroupBy
+	}
+	if r.GroupWait == nil {
+		r.GroupWait = parent.GroupWait
+	}
+	if r.GroupInterval == nil {
+		r.GroupInterval = parent.GroupInterval
+	}
+	if r.RepeatInterval == nil {
+		r.RepeatInterval = parent.RepeatInterval
+	}
+	if err := r.Matchers.compile(); err != nil {
+		return err
+	}
+	for i, child := range r.Routes {
+		if err := child.compile(r, id+"."+strconv.Itoa(i), receivers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns the routes the labels are sent to
+func (r *Route) match(labels map[string]string) []*Route {
+	if !r.Matches(labels) {
+		return nil
+	}
+	var routes []*Route
+	for _, child := range r.Routes {
+		matched := child.match(labels)
+		routes = append(routes, matched...)
+		if len(matched) > 0 && !child.Continue {
+			break
+		}
+	}
+	if len(routes) == 0 {
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// groupKey returns the key of the group of the labels in the route
+func (r *Route) groupKey(labels map[string]string) string {
+	if len(r.GroupBy) == 1 && r.GroupBy[0] == "..." {
+		return r.id + "/" + fingerprint(labels, nil)
+	}
+	return r.id + "/" + fingerprint(labels, r.GroupBy)
+}
+
+// DefaultConfig routes the alerts of the monitors like they were before the routing
+// was configurable: each kind of alert goes to its Feishu chat, or all of them to the
+// important chat when only some namespaces are monitored. The alerts received from
+// Alertmanager go to the important chat, and to the owner of their namespace. The
+// alerts of the monitors are forwarded to the Alertmanager at AlertmanagerURL if set.
+func DefaultConfig() *Config {
+	feishu := func(name, env string) ReceiverConfig {
+		return ReceiverConfig{Name: name, Feishu: []FeishuConfig{{ChatIDFrom: env}}}
+	}
+	config := &Config{
+		Receivers: []ReceiverConfig{
+			feishu("feishu-ufa", "FeishuWebhookURLUFA"),
+			feishu("feishu-csd", "FeishuWebhookURLCSD"),
+			feishu("feishu-other", "FeishuWebhookURLOther"),
+			feishu("feishu-important", "FeishuWebhookURLImportant"),
+			feishu("feishu-backup", "FeishuWebhookURLBackup"),
+			feishu("feishu-quota", "FeishuWebhookURLQuota"),
+			feishu("feishu-cockroachdb", "FeishuWebhookURLCockroachDB"),
+			{Name: "desktop", Desktop: []DesktopConfig{{}}},
+			{Name: "owner", SMS: []SMSConfig{{ToOwner: true}}, Email: []EmailConfig{{ToOwner: true}}},
+			{
+				Name:    "alertmanager-owner",
+				Desktop: []DesktopConfig{{}},
+				SMS:     []SMSConfig{{ToOwner: true}},
+				Email:   []EmailConfig{{ToOwner: true}},
+			},
+		},
+	}
+	route := func(receiver string, match ...string) *Route {
+		r := &Route{Receiver: receiver, Matchers: Matchers{Match: make(map[string]string)}}
+		for i := 0; i+1 < len(match); i += 2 {
+			r.Match[match[i]] = match[i+1]
+		}
+		return r
+	}
+	userFacing := &Route{
+		Matchers: Matchers{MatchRE: map[string]string{
+			LabelAlertName: AlertDatabaseDiskFull + "|" + AlertDatabaseQuotaExceeded,
+		}},
+		Receiver: "desktop",
+		Continue: true,
+	}
+	owner := route("owner", LabelAlertName, AlertDatabaseDiskHigh)
+	owner.Continue = true
+	alertmanagerOwner := &Route{
+		Matchers: Matchers{
+			Match:   map[string]string{LabelSource: SourceAlertmanager},
+			MatchRE: map[string]string{LabelNamespace: "ns-.+"},
+		},
+		Receiver:       "alertmanager-owner",
+		RepeatInterval: &metav1.Duration{Duration: alertmanagerOwnerRepeatInterval},
+		Continue:       true,
+	}
+	// The alerts matched by the routes above only are sent to the important chat too
+	important := route("feishu-important")
+
+	var forward []*Route
+	if api.AlertmanagerURL != "" {
+		config.Receivers = append(config.Receivers, ReceiverConfig{
+			Name:         "alertmanager",
+			Alertmanager: []AlertmanagerConfig{{URL: api.AlertmanagerURL}},
+		})
+		// The alerts received from Alertmanager have a source
+		f := route("alertmanager", LabelSource, "")
+		f.Continue = true
+		forward = append(forward, f)
+	}
+
+	if api.MonitorType != api.MonitorTypeALL {
+		config.Route = &Route{
+			Receiver: "feishu-important",
+			Routes:   append(forward, userFacing, owner, alertmanagerOwner, important),
+		}
+	} else {
+		config.Route = &Route{
+			Receiver: "feishu-important",
+			Routes: append(forward,
+				userFacing,
+				owner,
+				alertmanagerOwner,
+				route("feishu-csd", LabelAlertName, AlertDatabaseStatus, LabelStatus, api.StatusCreating),
+				route("feishu-ufa", LabelAlertName, AlertDatabaseStatus),
+				route("feishu-other", LabelAlertName, AlertDatabaseDiskFull),
+				route("feishu-other", LabelAlertName, AlertDatabaseQuotaExceeded),
+				route("feishu-backup", LabelAlertName, AlertDatabaseBackup),
+				route("feishu-quota", LabelAlertName, AlertNamespaceQuotaHigh),
+				route("feishu-cockroachdb", LabelAlertName, AlertCockroachDBUnavailable),
+				important,
+			),
+		}
+	}
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+	return config
+}
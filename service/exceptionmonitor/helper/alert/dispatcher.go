@@ -0,0 +1,390 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	notifyTimeout = 30 * time.Second
+	// expireInterval is how often the firing alerts which ended are resolved
+	expireInterval = time.Minute
+)
+
+// Notifier sends the notifications of a receiver
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+	// SendResolved tells whether the resolved alerts are sent to the notifier
+	SendResolved() bool
+}
+
+// Notification is a group of alerts sent to a receiver
+type Notification struct {
+	Receiver    string
+	GroupKey    string
+	GroupLabels map[string]string
+	Alerts      []*Alert
+}
+
+// State is firing if any of the alerts is firing
+func (n *Notification) State() string {
+	for _, a := range n.Alerts {
+		if !a.Resolved() {
+			return StateFiring
+		}
+	}
+	return StateResolved
+}
+
+// Dispatcher routes the alerts to the receivers, after dropping the silenced and
+// inhibited ones, and groups them into notifications
+type Dispatcher struct {
+	route          *Route
+	receivers      map[string][]Notifier
+	inhibitRules   []InhibitRule
+	silences       *Silences
+	resolveTimeout time.Duration
+	now            func() time.Time
+
+	mu sync.Mutex
+	// alerts are the firing alerts matching the source of an inhibit rule
+	alerts map[string]*Alert
+	groups map[string]*group
+	start  sync.Once
+}
+
+type group struct {
+	key    string
+	route  *Route
+	labels map[string]string
+	// alerts are the alerts waiting to be notified
+	alerts map[string]*Alert
+	// notified are the firing alerts notified to the group
+	notified  map[string]*notifiedAlert
+	lastFlush time.Time
+	timer     *time.Timer
+}
+
+// notifiedAlert is the last firing alert of a group and when it was notified
+type notifiedAlert struct {
+	alert *Alert
+	at    time.Time
+}
+
+// NewDispatcher creates a dispatcher from a validated config
+func NewDispatcher(config *Config) (*Dispatcher, error) {
+	receivers := make(map[string][]Notifier, len(config.Receivers))
+	for _, r := range config.Receivers {
+		notifiers, err := newNotifiers(r)
+		if err != nil {
+			return nil, err
+		}
+		receivers[r.Name] = notifiers
+	}
+	silences, err := NewSilences(config.Silences)
+	if err != nil {
+		return nil, err
+	}
+	return &Dispatcher{
+		route:          config.Route,
+		receivers:      receivers,
+		inhibitRules:   config.InhibitRules,
+		silences:       silences,
+		resolveTimeout: config.ResolveTimeout.Duration,
+		now:            time.Now,
+		alerts:         make(map[string]*Alert),
+		groups:         make(map[string]*group),
+	}, nil
+}
+
+func (d *Dispatcher) Silences() *Silences {
+	return d.silences
+}
+
+// Notify routes the alert. The alerts of the routes without group wait are sent
+// right away, the others when their group is flushed. A firing alert which is not
+// notified again before the resolve timeout is resolved.
+func (d *Dispatcher) Notify(a *Alert) {
+	d.start.Do(func() { go d.run() })
+	now := d.now()
+	if a.StartsAt.IsZero() {
+		a.StartsAt = now
+	}
+	if a.EndsAt.IsZero() {
+		if a.Resolved() {
+			a.EndsAt = now
+		} else {
+			a.EndsAt = now.Add(d.resolveTimeout)
+		}
+	}
+	fp := a.Fingerprint()
+
+	d.mu.Lock()
+	if a.Resolved() || !d.inhibiting(a) {
+		delete(d.alerts, fp)
+	} else {
+		d.alerts[fp] = a
+	}
+	if id, ok := d.silences.Silenced(a.Labels); ok {
+		d.mu.Unlock()
+		log.Printf("Alert %s %s is silenced by %s", a.Name(), fp, id)
+		return
+	}
+	if !a.Resolved() && d.inhibited(a, fp, now) {
+		d.mu.Unlock()
+		log.Printf("Alert %s %s is inhibited", a.Name(), fp)
+		return
+	}
+
+	var notifications []*Notification
+	for _, route := range d.route.match(a.Labels) {
+		if n := d.add(d.group(route, a.Labels), fp, a, now); n != nil {
+			notifications = append(notifications, n)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, n := range notifications {
+		d.send(n)
+	}
+}
+
+// add adds the alert to the group, the notification of the group is returned if
+// its route has no group wait
+func (d *Dispatcher) add(g *group, fp string, a *Alert, now time.Time) *Notification {
+	g.alerts[fp] = a
+	if g.route.GroupWait.Duration <= 0 {
+		return d.take(g, now)
+	}
+	if g.timer == nil {
+		wait := g.route.GroupWait.Duration
+		if !g.lastFlush.IsZero() {
+			wait = g.lastFlush.Add(g.route.GroupInterval.Duration).Sub(now)
+		}
+		key := g.key
+		g.timer = time.AfterFunc(wait, func() { d.flush(key) })
+	}
+	return nil
+}
+
+func (d *Dispatcher) run() {
+	ticker := time.NewTicker(expireInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		d.expire()
+	}
+}
+
+// expire drops the firing alerts which ended, the ones notified to a group are
+// notified as resolved
+func (d *Dispatcher) expire() {
+	now := d.now()
+	d.mu.Lock()
+	for fp, a := range d.alerts {
+		if a.ended(now) {
+			delete(d.alerts, fp)
+		}
+	}
+	var notifications []*Notification
+	for _, g := range d.groups {
+		for fp, notified := range g.notified {
+			if !notified.alert.ended(now) {
+				continue
+			}
+			// the alert fired again and waits for the group to be flushed
+			if waiting, ok := g.alerts[fp]; ok && !waiting.ended(now) {
+				continue
+			}
+			resolved := *notified.alert
+			resolved.Resolve()
+			if n := d.add(g, fp, &resolved, now); n != nil {
+				notifications = append(notifications, n)
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	for _, n := range notifications {
+		d.send(n)
+	}
+}
+
+func (d *Dispatcher) group(route *Route, labels map[string]string) *group {
+	key := route.groupKey(labels)
+	g, ok := d.groups[key]
+	if !ok {
+		g = &group{
+			key:      key,
+			route:    route,
+			labels:   make(map[string]string),
+			alerts:   make(map[string]*Alert),
+			notified: make(map[string]*notifiedAlert),
+		}
+		for _, name := range route.GroupBy {
+			if value, ok := labels[name]; ok {
+				g.labels[name] = value
+			}
+		}
+		d.groups[key] = g
+	}
+	return g
+}
+
+func (d *Dispatcher) flush(key string) {
+	d.mu.Lock()
+	var n *Notification
+	if g, ok := d.groups[key]; ok {
+		n = d.take(g, d.now())
+	}
+	d.mu.Unlock()
+	if n != nil {
+		d.send(n)
+	}
+}
+
+// take returns the notification of the waiting alerts of the group, the firing
+// alerts notified less than the repeat interval ago and the resolved alerts which
+// were not notified are dropped
+func (d *Dispatcher) take(g *group, now time.Time) *Notification {
+	repeat := g.route.RepeatInterval.Duration
+	var alerts []*Alert
+	for fp, a := range g.alerts {
+		last, notified := g.notified[fp]
+		if a.Resolved() {
+			if notified {
+				alerts = append(alerts, a)
+				delete(g.notified, fp)
+			}
+			continue
+		}
+		if notified && repeat > 0 && now.Sub(last.at) < repeat {
+			// the alert keeps firing until the end of the last one
+			last.alert = a
+			continue
+		}
+		g.notified[fp] = &notifiedAlert{alert: a, at: now}
+		alerts = append(alerts, a)
+	}
+	g.alerts = make(map[string]*Alert)
+	g.lastFlush = now
+	g.timer = nil
+	if len(g.notified) == 0 {
+		delete(d.groups, g.key)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+	})
+	return &Notification{
+		Receiver:    g.route.Receiver,
+		GroupKey:    g.key,
+		GroupLabels: g.labels,
+		Alerts:      alerts,
+	}
+}
+
+func (d *Dispatcher) send(n *Notification) {
+	for _, notifier := range d.receivers[n.Receiver] {
+		sent := n
+		if !notifier.SendResolved() {
+			sent = &Notification{
+				Receiver:    n.Receiver,
+				GroupKey:    n.GroupKey,
+				GroupLabels: n.GroupLabels,
+			}
+			for _, a := range n.Alerts {
+				if !a.Resolved() {
+					sent.Alerts = append(sent.Alerts, a)
+				}
+			}
+			if len(sent.Alerts) == 0 {
+				continue
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		if err := notifier.Notify(ctx, sent); err != nil {
+			log.Printf("Failed to notify receiver %s: %v", n.Receiver, err)
+		}
+		cancel()
+	}
+}
+
+// inhibiting tells whether the alert matches the source of an inhibit rule
+func (d *Dispatcher) inhibiting(a *Alert) bool {
+	for i := range d.inhibitRules {
+		if d.inhibitRules[i].Source.Matches(a.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// inhibited tells whether a firing alert matching the source of an inhibit rule
+// mutes the alert
+func (d *Dispatcher) inhibited(a *Alert, fp string, now time.Time) bool {
+	for i := range d.inhibitRules {
+		rule := &d.inhibitRules[i]
+		if !rule.Target.Matches(a.Labels) {
+			continue
+		}
+		for sourceFP, source := range d.alerts {
+			if sourceFP == fp || source.ended(now) || !rule.Source.Matches(source.Labels) {
+				continue
+			}
+			if equalLabels(a.Labels, source.Labels, rule.Equal) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalLabels(a, b map[string]string, names []string) bool {
+	for _, name := range names {
+		if a[name] != b[name] {
+			return false
+		}
+	}
+	return true
+}
+
+var defaultDispatcher *Dispatcher
+
+// Init creates the dispatcher of the monitors from the config at AlertConfigPath,
+// or from the default config if it is not set
+func Init() error {
+	config := DefaultConfig()
+	if path := os.Getenv("AlertConfigPath"); path != "" {
+		var err error
+		if config, err = LoadConfig(path); err != nil {
+			return err
+		}
+	}
+	dispatcher, err := NewDispatcher(config)
+	if err != nil {
+		return err
+	}
+	defaultDispatcher = dispatcher
+	return nil
+}
+
+// Default returns the dispatcher of the monitors, nil before Init
+func Default() *Dispatcher {
+	return defaultDispatcher
+}
+
+// Notify routes the alert with the dispatcher of the monitors
+func Notify(a *Alert) {
+	if defaultDispatcher == nil {
+		log.Printf("Alert %s dropped, the alert dispatcher is not initialized", a.Name())
+		return
+	}
+	defaultDispatcher.Notify(a)
+}
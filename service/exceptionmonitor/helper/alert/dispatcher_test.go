@@ -0,0 +1,343 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu            sync.Mutex
+	notifications []*Notification
+	sendResolved  bool
+	notified      chan struct{}
+}
+
+func (r *recorder) Notify(_ context.Context, n *Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, n)
+	if r.notified != nil {
+		r.notified <- struct{}{}
+	}
+	return nil
+}
+
+func (r *recorder) SendResolved() bool {
+	return r.sendResolved
+}
+
+func (r *recorder) take() []*Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notifications := r.notifications
+	r.notifications = nil
+	return notifications
+}
+
+const testConfig = `
+route:
+  receiver: default
+  routes:
+    - matchRE:
+        alertname: DatabaseDiskFull|DatabaseQuotaExceeded
+      receiver: desktop
+      continue: true
+    - match:
+        alertname: DatabaseStatusException
+        status: Creating
+      receiver: creating
+    - match:
+        severity: critical
+      receiver: critical
+      repeatInterval: 1h
+    - match:
+        alertname: NamespaceQuotaUsageHigh
+      receiver: grouped
+      groupBy: [alertname]
+      groupWait: 20ms
+inhibitRules:
+  - source:
+      match:
+        alertname: DatabaseDiskFull
+    target:
+      match:
+        alertname: DatabaseDiskUsageHigh
+    equal: [namespace, name]
+receivers:
+  - name: default
+  - name: desktop
+  - name: creating
+  - name: critical
+  - name: grouped
+`
+
+func newTestDispatcher(t *testing.T) (*Dispatcher, map[string]*recorder) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "alert.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	d, err := NewDispatcher(config)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+	recorders := make(map[string]*recorder)
+	for _, r := range config.Receivers {
+		recorders[r.Name] = &recorder{sendResolved: r.Name != "desktop"}
+		d.receivers[r.Name] = []Notifier{recorders[r.Name]}
+	}
+	return d, recorders
+}
+
+func databaseTestAlert(name, severity, status string) *Alert {
+	return New(name, severity, map[string]string{
+		LabelNamespace: "ns-a",
+		LabelName:      "db",
+		LabelStatus:    status,
+	})
+}
+
+func TestDispatcher_Routes(t *testing.T) {
+	d, recorders := newTestDispatcher(t)
+
+	tests := []struct {
+		alert     *Alert
+		receivers []string
+	}{
+		{databaseTestAlert(AlertDatabaseStatus, SeverityWarning, "Creating"), []string{"creating"}},
+		{databaseTestAlert(AlertDatabaseStatus, SeverityWarning, "Failed"), []string{"default"}},
+		{databaseTestAlert(AlertDatabaseDiskFull, SeverityCritical, "Failed"), []string{"desktop", "critical"}},
+		{databaseTestAlert(AlertDatabaseQuotaExceeded, SeverityWarning, "Failed"), []string{"desktop"}},
+	}
+	for _, tt := range tests {
+		d.Notify(tt.alert)
+		for name, r := range recorders {
+			want := false
+			for _, receiver := range tt.receivers {
+				want = want || receiver == name
+			}
+			if got := len(r.take()) == 1; got != want {
+				t.Errorf("alert %s %s notified to %s = %v, want %v", tt.alert.Name(), tt.alert.Labels[LabelStatus], name, got, want)
+			}
+		}
+	}
+}
+
+func TestDispatcher_RepeatAndResolve(t *testing.T) {
+	d, recorders := newTestDispatcher(t)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	critical := recorders["critical"]
+
+	d.Notify(databaseTestAlert(AlertCockroachDBUnavailable, SeverityCritical, ""))
+	if n := critical.take(); len(n) != 1 || n[0].State() != StateFiring {
+		t.Fatalf("first firing notifications = %v, want one", n)
+	}
+	now = now.Add(5 * time.Minute)
+	d.Notify(databaseTestAlert(AlertCockroachDBUnavailable, SeverityCritical, ""))
+	if n := critical.take(); len(n) != 0 {
+		t.Errorf("firing again within the repeat interval notified %d times", len(n))
+	}
+	now = now.Add(time.Hour)
+	d.Notify(databaseTestAlert(AlertCockroachDBUnavailable, SeverityCritical, ""))
+	if n := critical.take(); len(n) != 1 {
+		t.Errorf("firing again after the repeat interval notified %d times, want 1", len(n))
+	}
+
+	d.Notify(databaseTestAlert(AlertCockroachDBUnavailable, SeverityCritical, "").Resolve())
+	n := critical.take()
+	if len(n) != 1 || n[0].State() != StateResolved || n[0].Alerts[0].EndsAt != now {
+		t.Fatalf("resolved notifications = %v, want one resolved", n)
+	}
+	// An alert which was not notified is not resolved
+	d.Notify(databaseTestAlert(AlertCockroachDBUnavailable, SeverityCritical, "").Resolve())
+	if n := critical.take(); len(n) != 0 {
+		t.Errorf("resolving an alert which is not firing notified %d times", len(n))
+	}
+}
+
+func TestDispatcher_Expire(t *testing.T) {
+	d, recorders := newTestDispatcher(t)
+	start := time.Now()
+	now := start
+	d.now = func() time.Time { return now }
+	critical := recorders["critical"]
+
+	d.Notify(databaseTestAlert(AlertCockroachDBUnavailable, SeverityCritical, ""))
+	if n := critical.take(); len(n) != 1 {
+		t.Fatalf("firing notifications = %v, want one", n)
+	}
+	// Firing again within the repeat interval is not notified but keeps the alert firing
+	now = start.Add(30 * time.Minute)
+	d.Notify(databaseTestAlert(AlertCockroachDBUnavailable, SeverityCritical, ""))
+	now = start.Add(defaultResolveTimeout)
+	d.expire()
+	if n := critical.take(); len(n) != 0 {
+		t.Errorf("alert notified again was resolved: %v", n)
+	}
+
+	now = start.Add(30*time.Minute + defaultResolveTimeout)
+	d.expire()
+	n := critical.take()
+	if len(n) != 1 || n[0].State() != StateResolved || !n[0].Alerts[0].EndsAt.Equal(now) {
+		t.Fatalf("notifications = %v, want the alert resolved at its end", n)
+	}
+	if len(d.groups) != 0 {
+		t.Errorf("groups = %v, want the resolved group pruned", d.groups)
+	}
+}
+
+func TestDispatcher_ExpireInhibitingAlerts(t *testing.T) {
+	d, recorders := newTestDispatcher(t)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	// Only the alerts which can inhibit other alerts are kept
+	d.Notify(databaseTestAlert(AlertDatabaseStatus, SeverityWarning, "Failed"))
+	d.Notify(databaseTestAlert(AlertDatabaseDiskFull, SeverityWarning, "Failed"))
+	if len(d.alerts) != 1 {
+		t.Errorf("alerts = %v, want the inhibiting one", d.alerts)
+	}
+
+	now = now.Add(defaultResolveTimeout)
+	d.expire()
+	if len(d.alerts) != 0 {
+		t.Errorf("alerts = %v, want the ended alert pruned", d.alerts)
+	}
+	recorders["default"].take()
+	d.Notify(databaseTestAlert(AlertDatabaseDiskHigh, SeverityWarning, "Failed"))
+	if n := recorders["default"].take(); len(n) != 1 {
+		t.Errorf("alert notified %d times once the inhibiting alert ended, want 1", len(n))
+	}
+}
+
+func TestDispatcher_SendResolved(t *testing.T) {
+	d, recorders := newTestDispatcher(t)
+
+	d.Notify(databaseTestAlert(AlertDatabaseQuotaExceeded, SeverityWarning, "Failed"))
+	d.Notify(databaseTestAlert(AlertDatabaseQuotaExceeded, SeverityWarning, "Failed").Resolve())
+	if n := recorders["desktop"].take(); len(n) != 1 || n[0].State() != StateFiring {
+		t.Errorf("desktop notifications = %v, want only the firing one", n)
+	}
+}
+
+func TestDispatcher_SilencesAndInhibitions(t *testing.T) {
+	d, recorders := newTestDispatcher(t)
+
+	id, err := d.Silences().Add(Silence{
+		Matchers: Matchers{Match: map[string]string{LabelNamespace: "ns-a"}},
+		EndsAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Notify(databaseTestAlert(AlertDatabaseStatus, SeverityWarning, "Failed"))
+	if n := recorders["default"].take(); len(n) != 0 {
+		t.Errorf("silenced alert notified %d times", len(n))
+	}
+	if err := d.Silences().Expire(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Silences().Expire(id); err != ErrSilenceNotFound {
+		t.Errorf("Expire() of an expired silence error = %v, want %v", err, ErrSilenceNotFound)
+	}
+
+	d.Notify(databaseTestAlert(AlertDatabaseDiskFull, SeverityWarning, "Failed"))
+	d.Notify(databaseTestAlert(AlertDatabaseDiskHigh, SeverityWarning, "Failed"))
+	if n := recorders["default"].take(); len(n) != 0 {
+		t.Errorf("inhibited alert notified %d times", len(n))
+	}
+	d.Notify(databaseTestAlert(AlertDatabaseDiskFull, SeverityWarning, "Failed").Resolve())
+	d.Notify(databaseTestAlert(AlertDatabaseDiskHigh, SeverityWarning, "Failed"))
+	if n := recorders["default"].take(); len(n) != 1 {
+		t.Errorf("alert notified %d times once the inhibiting alert is resolved, want 1", len(n))
+	}
+}
+
+func TestDispatcher_Groups(t *testing.T) {
+	d, recorders := newTestDispatcher(t)
+	grouped := recorders["grouped"]
+	grouped.notified = make(chan struct{}, 1)
+
+	d.Notify(New(AlertNamespaceQuotaHigh, SeverityWarning, map[string]string{LabelNamespace: "ns-a"}))
+	d.Notify(New(AlertNamespaceQuotaHigh, SeverityWarning, map[string]string{LabelNamespace: "ns-b"}))
+	select {
+	case <-grouped.notified:
+	case <-time.After(time.Second):
+		t.Fatal("the group was not notified")
+	}
+	n := grouped.take()
+	if len(n) != 1 || len(n[0].Alerts) != 2 || n[0].GroupLabels[LabelAlertName] != AlertNamespaceQuotaHigh {
+		t.Errorf("notifications = %v, want one with both alerts", n)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var msg WebhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	notifier := &webhookNotifier{config: WebhookConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}}
+	a := databaseTestAlert(AlertDatabaseStatus, SeverityCritical, "Failed")
+	b := databaseTestAlert(AlertDatabaseStatus, SeverityCritical, "Abnormal").Resolve()
+	err := notifier.Notify(context.Background(), &Notification{Receiver: "ops", Alerts: []*Alert{a, b}})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if msg.Status != StateFiring || len(msg.Alerts) != 2 || msg.Alerts[1].Status != StateResolved ||
+		msg.Alerts[0].Fingerprint != a.Fingerprint() {
+		t.Errorf("unexpected webhook message %+v", msg)
+	}
+	if _, ok := msg.CommonLabels[LabelStatus]; ok || msg.CommonLabels[LabelName] != "db" {
+		t.Errorf("common labels = %v", msg.CommonLabels)
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	config := DefaultConfig()
+	tests := []struct {
+		alert     *Alert
+		receivers []string
+	}{
+		{databaseTestAlert(AlertDatabaseDiskFull, SeverityCritical, "Failed"), []string{"desktop", "feishu-important"}},
+		{databaseTestAlert(AlertDatabaseDiskHigh, SeverityWarning, "Running"), []string{"owner", "feishu-important"}},
+		{databaseTestAlert(AlertDatabaseCPUHigh, SeverityWarning, "Running"), []string{"feishu-important"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, route := range config.Route.match(tt.alert.Labels) {
+			got = append(got, route.Receiver)
+		}
+		if len(got) != len(tt.receivers) {
+			t.Errorf("alert %s routed to %v, want %v", tt.alert.Name(), got, tt.receivers)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.receivers[i] {
+				t.Errorf("alert %s routed to %v, want %v", tt.alert.Name(), got, tt.receivers)
+			}
+		}
+	}
+}
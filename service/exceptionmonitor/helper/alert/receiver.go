@@ -0,0 +1,406 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+var httpClient = &http.Client{Timeout: notifyTimeout}
+
+func newNotifiers(c ReceiverConfig) ([]Notifier, error) {
+	var notifiers []Notifier
+	for _, fc := range c.Feishu {
+		if fc.ChatID == "" && fc.ChatIDFrom == "" {
+			return nil, fmt.Errorf("feishu notifier of receiver %s has no chat", c.Name)
+		}
+		notifiers = append(notifiers, &feishuNotifier{config: fc})
+	}
+	for _, wc := range c.Webhook {
+		if wc.URL == "" {
+			return nil, fmt.Errorf("webhook notifier of receiver %s has no url", c.Name)
+		}
+		notifiers = append(notifiers, &webhookNotifier{config: wc})
+	}
+	for _, sc := range c.Slack {
+		if sc.URL == "" {
+			return nil, fmt.Errorf("slack notifier of receiver %s has no url", c.Name)
+		}
+		notifiers = append(notifiers, &slackNotifier{config: sc})
+	}
+	for _, ec := range c.Email {
+		notifiers = append(notifiers, &emailNotifier{config: ec})
+	}
+	for _, sc := range c.SMS {
+		notifiers = append(notifiers, &smsNotifier{config: sc})
+	}
+	for _, dc := range c.Desktop {
+		notifiers = append(notifiers, &desktopNotifier{config: dc})
+	}
+	for _, ac := range c.Alertmanager {
+		if ac.URL == "" {
+			return nil, fmt.Errorf("alertmanager notifier of receiver %s has no url", c.Name)
+		}
+		notifiers = append(notifiers, newAlertmanagerNotifier(ac))
+	}
+	return notifiers, nil
+}
+
+// feishuNotifier sends the cards of the alerts one by one, the cards of the
+// database alerts are updated when they are resolved
+type feishuNotifier struct {
+	config FeishuConfig
+}
+
+func (n *feishuNotifier) SendResolved() bool {
+	return n.config.sendResolved(true)
+}
+
+func (n *feishuNotifier) Notify(_ context.Context, notice *Notification) error {
+	chatID := n.config.ChatID
+	if chatID == "" {
+		chatID = api.FeishuWebhookURLMap[n.config.ChatIDFrom]
+	}
+	if chatID == "" {
+		return fmt.Errorf("%s is not set", n.config.ChatIDFrom)
+	}
+
+	var errs []error
+	for _, a := range notice.Alerts {
+		if a.Info != nil && a.Card != "" {
+			// the info is shared by the receivers of the alert, each sends to its own chat
+			info := *a.Info
+			info.FeishuWebHook = chatID
+			errs = append(errs, notification.SendFeishuNotification(&info, a.Card))
+			continue
+		}
+		card := a.Card
+		if card == "" {
+			card = feishuCard(a)
+		}
+		errs = append(errs, notification.SendFeishuMessage(chatID, card))
+	}
+	return errors.Join(errs...)
+}
+
+func feishuCard(a *Alert) string {
+	title := a.Summary(true)
+	if a.Resolved() {
+		title += " 恢复通知"
+	}
+	elements := []map[string]string{
+		{"label": "集群环境", "value": a.Labels[LabelCluster]},
+		{"label": "告警名称", "value": a.Name()},
+		{"label": "告警级别", "value": a.Severity()},
+	}
+	if ns := a.Labels[LabelNamespace]; ns != "" {
+		elements = append(elements, map[string]string{"label": "命名空间", "value": ns})
+	}
+	if owner := a.Annotations[AnnotationOwner]; owner != "" {
+		elements = append(elements, map[string]string{"label": "用户", "value": owner})
+	}
+	for _, name := range sortedLabels(a.Labels) {
+		switch name {
+		case LabelCluster, LabelAlertName, LabelSeverity, LabelNamespace:
+			continue
+		}
+		elements = append(elements, map[string]string{"label": name, "value": a.Labels[name]})
+	}
+	elements = append(elements,
+		map[string]string{"label": "告警内容", "value": a.Description(true)},
+		map[string]string{"label": "开始时间", "value": a.StartsAt.Format(timeFormat)},
+	)
+	if a.Resolved() {
+		elements = append(elements, map[string]string{"label": "恢复时间", "value": a.EndsAt.Format(timeFormat)})
+	}
+	return notification.GetAlertMessage(title, !a.Resolved(), elements)
+}
+
+func sortedLabels(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WebhookMessage is the JSON body of the webhook notifications, in the format of the
+// Alertmanager webhooks
+type WebhookMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	Alerts            []WebhookAlert    `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+}
+
+type WebhookAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+func newWebhookMessage(notice *Notification) *WebhookMessage {
+	msg := &WebhookMessage{
+		Version:           "4",
+		GroupKey:          notice.GroupKey,
+		Receiver:          notice.Receiver,
+		Status:            notice.State(),
+		GroupLabels:       notice.GroupLabels,
+		CommonLabels:      make(map[string]string),
+		CommonAnnotations: make(map[string]string),
+	}
+	for i, a := range notice.Alerts {
+		msg.Alerts = append(msg.Alerts, WebhookAlert{
+			Status:      a.State,
+			Labels:      a.Labels,
+			Annotations: a.Annotations,
+			StartsAt:    a.StartsAt,
+			EndsAt:      a.EndsAt,
+			Fingerprint: a.Fingerprint(),
+		})
+		if i == 0 {
+			for k, v := range a.Labels {
+				msg.CommonLabels[k] = v
+			}
+			for k, v := range a.Annotations {
+				msg.CommonAnnotations[k] = v
+			}
+			continue
+		}
+		for k, v := range msg.CommonLabels {
+			if a.Labels[k] != v {
+				delete(msg.CommonLabels, k)
+			}
+		}
+		for k, v := range msg.CommonAnnotations {
+			if a.Annotations[k] != v {
+				delete(msg.CommonAnnotations, k)
+			}
+		}
+	}
+	return msg
+}
+
+type webhookNotifier struct {
+	config WebhookConfig
+}
+
+func (n *webhookNotifier) SendResolved() bool {
+	return n.config.sendResolved(true)
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, notice *Notification) error {
+	return postJSON(ctx, n.config.URL, n.config.Headers, newWebhookMessage(notice))
+}
+
+// slackNotifier posts a message with an attachment by alert
+type slackNotifier struct {
+	config SlackConfig
+}
+
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string `json:"color"`
+	Title  string `json:"title"`
+	Text   string `json:"text"`
+	Footer string `json:"footer,omitempty"`
+}
+
+func (n *slackNotifier) SendResolved() bool {
+	return n.config.sendResolved(true)
+}
+
+func (n *slackNotifier) Notify(ctx context.Context, notice *Notification) error {
+	firing := 0
+	for _, a := range notice.Alerts {
+		if !a.Resolved() {
+			firing++
+		}
+	}
+	msg := &slackMessage{
+		Channel:  n.config.Channel,
+		Username: n.config.Username,
+		Text: fmt.Sprintf("[%s:%d] %s (%s)", strings.ToUpper(notice.State()), len(notice.Alerts),
+			notice.Alerts[0].Name(), notice.Alerts[0].Labels[LabelCluster]),
+	}
+	if firing > 0 && firing < len(notice.Alerts) {
+		msg.Text += fmt.Sprintf(", %d resolved", len(notice.Alerts)-firing)
+	}
+	for _, a := range notice.Alerts {
+		attachment := slackAttachment{
+			Color:  "danger",
+			Title:  a.Summary(false),
+			Text:   a.Description(false),
+			Footer: "started at " + a.StartsAt.Format(time.RFC3339),
+		}
+		if a.Severity() == SeverityWarning {
+			attachment.Color = "warning"
+		}
+		if a.Resolved() {
+			attachment.Color = "good"
+			attachment.Footer = "resolved at " + a.EndsAt.Format(time.RFC3339)
+		}
+		if ns := a.Labels[LabelNamespace]; ns != "" {
+			attachment.Text += "\nnamespace: " + ns
+		}
+		msg.Attachments = append(msg.Attachments, attachment)
+	}
+	return postJSON(ctx, n.config.URL, nil, msg)
+}
+
+func postJSON(ctx context.Context, url string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, respBody)
+	}
+	return nil
+}
+
+// ownerContacts returns the phone and the email of the owner of the namespace of the alert
+func ownerContacts(a *Alert) (string, string, error) {
+	ns := a.Labels[LabelNamespace]
+	if ns == "" {
+		return "", "", fmt.Errorf("alert %s has no namespace", a.Name())
+	}
+	owner, err := notification.GetNSOwner(ns)
+	if err != nil {
+		return "", "", err
+	}
+	return notification.GetPhoneNumberByNS(owner)
+}
+
+// smsName is the name of the object of the alert in the SMS and email templates
+func smsName(a *Alert) string {
+	return strings.ReplaceAll(a.Labels[LabelName], "-", "/")
+}
+
+type emailNotifier struct {
+	config EmailConfig
+}
+
+func (n *emailNotifier) SendResolved() bool {
+	return n.config.sendResolved(false)
+}
+
+func (n *emailNotifier) Notify(_ context.Context, notice *Notification) error {
+	var errs []error
+	for _, a := range notice.Alerts {
+		to := append([]string(nil), n.config.To...)
+		if n.config.ToOwner {
+			_, email, err := ownerContacts(a)
+			if err != nil {
+				errs = append(errs, err)
+			} else if email != "" {
+				to = append(to, email)
+			}
+		}
+		for _, email := range to {
+			errs = append(errs, notification.SendToEmail(api.ClusterName, a.Description(true), email, smsName(a)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type smsNotifier struct {
+	config SMSConfig
+}
+
+func (n *smsNotifier) SendResolved() bool {
+	return n.config.sendResolved(false)
+}
+
+func (n *smsNotifier) Notify(_ context.Context, notice *Notification) error {
+	var errs []error
+	for _, a := range notice.Alerts {
+		to := append([]string(nil), n.config.To...)
+		if n.config.ToOwner {
+			phone, _, err := ownerContacts(a)
+			if err != nil {
+				errs = append(errs, err)
+			} else if phone != "" {
+				to = append(to, phone)
+			}
+		}
+		for _, phone := range to {
+			errs = append(errs, notification.SendToSms(api.ClusterName, a.Description(true), phone, smsName(a)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// desktopNotifier creates a desktop Notification in the namespace of each alert
+type desktopNotifier struct {
+	config DesktopConfig
+}
+
+func (n *desktopNotifier) SendResolved() bool {
+	return n.config.sendResolved(false)
+}
+
+func (n *desktopNotifier) Notify(_ context.Context, notice *Notification) error {
+	var errs []error
+	for _, a := range notice.Alerts {
+		ns := a.Labels[LabelNamespace]
+		if ns == "" {
+			continue
+		}
+		importance := "Medium"
+		switch a.Severity() {
+		case SeverityCritical:
+			importance = "High"
+		case SeverityInfo:
+			importance = "Low"
+		}
+		if a.Resolved() {
+			importance = "Low"
+		}
+		errs = append(errs, notification.CreateDesktopNotification(ns, strings.ToLower(a.Name())+"-",
+			a.Summary(false), a.Description(false), a.Summary(true), a.Description(true), importance, !a.Resolved()))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrSilenceNotFound = errors.New("silence not found")
+
+// Silence mutes the alerts it matches between its start and its end
+type Silence struct {
+	ID       string   `json:"id,omitempty"`
+	Matchers Matchers `json:"matchers"`
+	// StartsAt is the creation time by default
+	StartsAt  time.Time `json:"startsAt,omitempty"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+}
+
+func (s *Silence) validate() error {
+	if s.Matchers.empty() {
+		return errors.New("a silence must have matchers")
+	}
+	if s.EndsAt.IsZero() {
+		return errors.New("a silence must have an end")
+	}
+	if !s.StartsAt.IsZero() && !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("silence ends at %s before it starts", s.EndsAt.Format(time.RFC3339))
+	}
+	return s.Matchers.compile()
+}
+
+func (s *Silence) active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Silences keeps the silences of the config and the ones created at runtime,
+// the expired silences are dropped
+type Silences struct {
+	mu       sync.Mutex
+	silences map[string]*Silence
+	now      func() time.Time
+}
+
+func NewSilences(silences []Silence) (*Silences, error) {
+	s := &Silences{
+		silences: make(map[string]*Silence),
+		now:      time.Now,
+	}
+	for i := range silences {
+		if _, err := s.Add(silences[i]); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add adds the silence and returns its id
+func (s *Silences) Add(silence Silence) (string, error) {
+	if err := silence.validate(); err != nil {
+		return "", err
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = s.now()
+	}
+	if silence.ID == "" {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		silence.ID = hex.EncodeToString(b)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silences[silence.ID] = &silence
+	return silence.ID, nil
+}
+
+// Expire removes the silence
+func (s *Silences) Expire(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.silences[id]; !ok {
+		return ErrSilenceNotFound
+	}
+	delete(s.silences, id)
+	return nil
+}
+
+// List returns the silences which are not expired, by start time
+func (s *Silences) List() []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gc()
+	silences := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		silences = append(silences, *silence)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].StartsAt.Before(silences[j].StartsAt)
+	})
+	return silences
+}
+
+// Silenced returns the id of an active silence matching the labels
+func (s *Silences) Silenced(labels map[string]string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gc()
+	now := s.now()
+	for id, silence := range s.silences {
+		if silence.active(now) && silence.Matchers.Matches(labels) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+func (s *Silences) gc() {
+	now := s.now()
+	for id, silence := range s.silences {
+		if !now.Before(silence.EndsAt) {
+			delete(s.silences, id)
+		}
+	}
+}
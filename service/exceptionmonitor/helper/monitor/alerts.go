@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
+)
+
+const diskFullReason = "disk is full"
+
+// databaseAlert returns the alert of the exception of a database, its name depends on
+// the reason of the exception. The labels only use the fields of the info set when the
+// exception is found, so that the recovery resolves the same alert.
+func databaseAlert(notificationInfo *api.Info, card string) *alert.Alert {
+	name, severity := alert.AlertDatabaseStatus, alert.SeverityCritical
+	reason, zhReason := "the database status is abnormal", "数据库状态异常"
+	switch notificationInfo.Reason {
+	case diskFullReason:
+		name = alert.AlertDatabaseDiskFull
+		reason, zhReason = diskFullReason, "磁盘满了"
+	case api.ExceededQuotaException:
+		name, severity = alert.AlertDatabaseQuotaExceeded, alert.SeverityWarning
+		reason, zhReason = api.ExceededQuotaException, "Quota满了"
+	}
+	a := newDatabaseAlert(name, severity, notificationInfo, card)
+	a.Annotations[alert.AnnotationSummary] = "Database Exception"
+	a.Annotations[alert.AnnotationSummaryZh] = "数据库异常告警"
+	a.Annotations[alert.AnnotationDescription], a.Annotations[alert.AnnotationDescriptionZh] =
+		notification.GetDesktopMessage(notificationInfo, reason, zhReason)
+	return a
+}
+
+// performanceAlert returns the alert of the CPU, memory or disk usage of a database
+func performanceAlert(notificationInfo *api.Info, card string) *alert.Alert {
+	name := alert.AlertDatabaseCPUHigh
+	switch notificationInfo.PerformanceType {
+	case api.MemoryChinese:
+		name = alert.AlertDatabaseMemoryHigh
+	case api.DiskChinese:
+		name = alert.AlertDatabaseDiskHigh
+	}
+	a := newDatabaseAlert(name, alert.SeverityWarning, notificationInfo, card)
+	a.Annotations[alert.AnnotationSummary] = "Database Usage Threshold"
+	a.Annotations[alert.AnnotationSummaryZh] = "数据库阀值告警"
+	return a
+}
+
+func newDatabaseAlert(name, severity string, notificationInfo *api.Info, card string) *alert.Alert {
+	a := alert.New(name, severity, map[string]string{
+		alert.LabelNamespace:    notificationInfo.Namespace,
+		alert.LabelName:         notificationInfo.DatabaseClusterName,
+		alert.LabelDatabaseType: notificationInfo.DatabaseType,
+		alert.LabelStatus:       notificationInfo.ExceptionStatus,
+	})
+	a.Card = card
+	a.Info = notificationInfo
+	return a
+}
+
+func backupAlert(namespace, backupName, status, card string) *alert.Alert {
+	a := alert.New(alert.AlertDatabaseBackup, alert.SeverityWarning, map[string]string{
+		alert.LabelNamespace: namespace,
+		alert.LabelName:      backupName,
+		alert.LabelStatus:    status,
+	})
+	a.Card = card
+	a.Annotations[alert.AnnotationSummary] = "Database Backup Exception"
+	a.Annotations[alert.AnnotationSummaryZh] = "备份异常通知"
+	return a
+}
+
+func quotaAlert(namespace, card string) *alert.Alert {
+	a := alert.New(alert.AlertNamespaceQuotaHigh, alert.SeverityWarning, map[string]string{
+		alert.LabelNamespace: namespace,
+		alert.LabelName:      namespace,
+	})
+	a.Card = card
+	a.Annotations[alert.AnnotationSummary] = "Quota Usage Threshold"
+	a.Annotations[alert.AnnotationSummaryZh] = "Quota阀值通知"
+	return a
+}
+
+func cockroachAlert(label, errMessage, card string) *alert.Alert {
+	a := alert.New(alert.AlertCockroachDBUnavailable, alert.SeverityCritical, map[string]string{
+		alert.LabelName: label,
+	})
+	a.Card = card
+	a.Annotations[alert.AnnotationSummary] = "CockroachDB Exception"
+	a.Annotations[alert.AnnotationSummaryZh] = "小强数据库异常告警"
+	a.Annotations[alert.AnnotationDescription] = errMessage
+	return a
+}
@@ -2,10 +2,10 @@ package monitor
 
 import (
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
 	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -14,22 +14,17 @@ import (
 
 func CockroachMonitor() {
 	for api.CockroachMonitor {
-		notificationInfo := &api.Info{
-			FeishuWebHook: api.FeishuWebhookURLMap["FeishuWebhookURLCockroachDB"],
-		}
-		monitorCockroachDB(api.GlobalCockroachURI, "Global", notificationInfo)
-		monitorCockroachDB(api.LocalCockroachURI, "Local", notificationInfo)
+		monitorCockroachDB(api.GlobalCockroachURI, "Global")
+		monitorCockroachDB(api.LocalCockroachURI, "Local")
 
 		time.Sleep(5 * time.Minute)
 	}
 }
 
-func monitorCockroachDB(uri, label string, notificationInfo *api.Info) {
+func monitorCockroachDB(uri, label string) {
 	if err := checkCockroachDB(uri); err != nil {
 		message := notification.GetCockroachMessage(err.Error(), label)
-		if sendErr := notification.SendFeishuNotification(notificationInfo, message); sendErr != nil {
-			log.Printf("Failed to send Feishu notification for %s: %v", label, sendErr)
-		}
+		alert.Notify(cockroachAlert(label, err.Error(), message))
 	}
 }
 
@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
 	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -90,20 +91,9 @@ func processBackup(backup unstructured.Unstructured) {
 }
 
 func SendBackupNotification(backupName, namespace, status, startTimestamp string) {
-	notificationInfo := api.Info{
-		DatabaseClusterName: backupName,
-		Namespace:           namespace,
-		ExceptionStatus:     status,
-		ExceptionType:       "备份",
-		PerformanceType:     "Backup",
-		NotificationType:    notification.ExceptionType,
-		FeishuWebHook:       api.FeishuWebhookURLMap["FeishuWebhookURLBackup"],
-	}
 	if _, ok := api.LastBackupStatusMap[backupName]; !ok {
 		message := notification.GetBackupMessage(notification.ExceptionType, namespace, backupName, status, startTimestamp, "")
-		if err := notification.SendFeishuNotification(&notificationInfo, message); err != nil {
-			log.Printf("Error sending exception notification:%v", err)
-		}
+		alert.Notify(backupAlert(namespace, backupName, status, message))
 		api.LastBackupStatusMap[backupName] = status
 	}
 }
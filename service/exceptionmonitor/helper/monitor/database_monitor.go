@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
 	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -132,9 +133,7 @@ func ProcessCluster(cluster metav1unstructured.Unstructured, notificationInfo *a
 			notificationInfo.DebtLevel = ""
 			if notificationInfo.Send {
 				alertMessage := prepareAlertMessage(notificationInfo, 0)
-				if err := sendAlert(alertMessage, notificationInfo); err != nil {
-					log.Printf("Failed to send feishu %s in ns %s: %v", notificationInfo.DatabaseClusterName, notificationInfo.Namespace, err)
-				}
+				sendAlert(alertMessage, notificationInfo)
 			}
 		} else if _, ok := api.DatabaseNotificationInfoMap[notificationInfo.DatabaseClusterUID]; ok {
 			notificationInfo = api.DatabaseNotificationInfoMap[notificationInfo.DatabaseClusterUID]
@@ -153,9 +152,7 @@ func handleClusterRecovery(notificationInfo *api.Info) {
 	recoveryMessage := notification.GetNotificationMessage(notificationInfo)
 	//getClusterDatabaseStatus应该在上层去做，因为有可能是已经删的数据状态更新信息，在这里获取的话，就没法拿到状态信息
 	//notificationInfo.RecoveryStatus, notificationInfo.RecoveryStatusTime = getClusterDatabaseStatus(cluster, notificationInfo)
-	if err := notification.SendFeishuNotification(notificationInfo, recoveryMessage); err != nil {
-		log.Printf("Error sending recovery notification: %v", err)
-	}
+	alert.Notify(databaseAlert(notificationInfo, recoveryMessage).Resolve())
 	cleanClusterStatus(notificationInfo.DatabaseClusterUID)
 }
 
@@ -198,14 +195,10 @@ func processClusterException(notificationInfo *api.Info) error {
 			notificationInfo.Events = databaseEvents
 			//notificationInfo.DatabaseClusterUID, databaseClusterName, namespace, status, debtLevel, databaseEvents
 			alertMessage := prepareAlertMessage(notificationInfo, maxUsage)
-			if err := sendAlert(alertMessage, notificationInfo); err != nil {
-				return err
-			}
+			sendAlert(alertMessage, notificationInfo)
 		} else {
 			//databaseClusterName, namespace, status, debtLevel
-			if err := notifyQuotaExceeded(notificationInfo); err != nil {
-				return err
-			}
+			notifyQuotaExceeded(notificationInfo)
 		}
 	} else {
 		api.DebtNamespaceMap[notificationInfo.Namespace] = true
@@ -239,39 +232,32 @@ func prepareAlertMessage(notificationInfo *api.Info, maxUsage float64) string {
 	notificationInfo.ExceptionType = "状态"
 	notificationInfo.NotificationType = notification.ExceptionType
 	if maxUsage < api.DatabaseExceptionMonitorThreshold {
-		//status == "Creating" || status == "Deleting" || status == "Stopping"
-		if notificationInfo.ExceptionStatus == "Creating" {
-			notificationInfo.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLCSD"]
-		} else {
-			notificationInfo.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLUFA"]
-		}
 		alertMessage = notification.GetNotificationMessage(notificationInfo)
 	} else {
 		if !api.DiskFullNamespaceMap[notificationInfo.DatabaseClusterUID] {
-			notificationInfo.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLOther"]
-			notificationInfo.Reason = "disk is full"
+			notificationInfo.Reason = diskFullReason
 			alertMessage = notification.GetNotificationMessage(notificationInfo)
-			//namespace, databaseClusterName, status
-			notification.CreateNotification(notificationInfo, "disk is full", "磁盘满了")
 		}
 		api.DiskFullNamespaceMap[notificationInfo.DatabaseClusterUID] = true
 	}
 	return alertMessage
 }
 
-func sendAlert(alertMessage string, notificationInfo *api.Info) error {
-	//api.FeishuWebHookMap[notificationInfo.DatabaseClusterUID] = feishuWebHook
-	return notification.SendFeishuNotification(notificationInfo, alertMessage)
+// sendAlert routes the alert of the database, the chats and the desktop notification
+// of the exceptions are chosen by the routes of the alert config
+func sendAlert(alertMessage string, notificationInfo *api.Info) {
+	if alertMessage == "" {
+		return
+	}
+	alert.Notify(databaseAlert(notificationInfo, alertMessage))
 }
 
-func notifyQuotaExceeded(notificationInfo *api.Info) error {
+func notifyQuotaExceeded(notificationInfo *api.Info) {
 	notificationInfo.ExceptionType = "状态"
 	notificationInfo.Reason = api.ExceededQuotaException
 	notificationInfo.NotificationType = notification.ExceptionType
-	notificationInfo.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLOther"]
 	alertMessage := notification.GetNotificationMessage(notificationInfo)
-	notification.CreateNotification(notificationInfo, api.ExceededQuotaException, "Quato满了")
-	return notification.SendFeishuNotification(notificationInfo, alertMessage)
+	alert.Notify(databaseAlert(notificationInfo, alertMessage))
 }
 
 func getClusterDatabaseInfo(cluster metav1unstructured.Unstructured, notificationInfo *api.Info) {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
 	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -140,10 +141,10 @@ func processUsage(usage float64, threshold float64, performanceType string, noti
 func processException(notificationInfo *api.Info, threshold float64) {
 	notificationInfo.NotificationType = notification.ExceptionType
 	alertMessage := notification.GetNotificationMessage(notificationInfo)
-	notificationInfo.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLImportant"]
-	if err := notification.SendFeishuNotification(notificationInfo, alertMessage); err != nil {
-		log.Printf("Failed to send notification: %v", err)
-	}
+	exceptionAlert := performanceAlert(notificationInfo, alertMessage)
+	// The description is the content of the SMS and email sent to the owner
+	exceptionAlert.Annotations[alert.AnnotationDescriptionZh] = "数据库" + notificationInfo.PerformanceType + "超过百分之" + NumberToChinese(int(threshold))
+	alert.Notify(exceptionAlert)
 	if notificationInfo.PerformanceType == api.CPUChinese {
 		api.CPUNotificationInfoMap[notificationInfo.DatabaseClusterUID] = notificationInfo
 		return
@@ -155,10 +156,6 @@ func processException(notificationInfo *api.Info, threshold float64) {
 	if notificationInfo.PerformanceType == api.DiskChinese {
 		api.DiskNotificationInfoMap[notificationInfo.DatabaseClusterUID] = notificationInfo
 	}
-	ZNThreshold := NumberToChinese(int(threshold))
-	if err := notification.SendExceptionNotification(notificationInfo, api.ClusterName, "数据库"+notificationInfo.PerformanceType+"超过百分之"+ZNThreshold); err != nil {
-		log.Printf("Failed to send Sms: %v", err)
-	}
 }
 
 func processRecovery(notificationInfo *api.Info) {
@@ -166,10 +163,7 @@ func processRecovery(notificationInfo *api.Info) {
 	notificationInfo.RecoveryStatus = notificationInfo.ExceptionStatus
 	notificationInfo.RecoveryTime = time.Now().Add(8 * time.Hour).Format("2006-01-02 15:04:05")
 	alertMessage := notification.GetNotificationMessage(notificationInfo)
-	notificationInfo.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLImportant"]
-	if err := notification.SendFeishuNotification(notificationInfo, alertMessage); err != nil {
-		log.Printf("Failed to send notification: %v", err)
-	}
+	alert.Notify(performanceAlert(notificationInfo, alertMessage).Resolve())
 	if notificationInfo.PerformanceType == api.CPUChinese {
 		delete(api.CPUNotificationInfoMap, notificationInfo.DatabaseClusterUID)
 	}
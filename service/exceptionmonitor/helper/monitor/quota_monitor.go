@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
 	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -51,18 +52,10 @@ func checkQuota(namespaces []string) error {
 		nsQuota := api.NameSpaceQuota{
 			NameSpace: ns.Name,
 		}
-		notificationInfo := api.Info{
-			ExceptionType:    "Quota",
-			PerformanceType:  "Quota",
-			NotificationType: notification.ExceptionType,
-		}
 		send := processQuota(quotaList, &nsQuota)
 		if send {
 			message := notification.GetQuotaMessage(&nsQuota)
-			notificationInfo.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLQuota"]
-			if err := notification.SendFeishuNotification(&notificationInfo, message); err != nil {
-				log.Printf("Error sending exception notification:%v", err)
-			}
+			alert.Notify(quotaAlert(ns.Name, message))
 		}
 	}
 	return nil
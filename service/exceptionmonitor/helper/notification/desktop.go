@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
-	"log"
 	"math/big"
 	"time"
 
@@ -31,7 +30,15 @@ func randString(n int) (string, error) {
 	return string(b), nil
 }
 
-func CreateNotification(notificationInfo *api.Info, notificationMessage, zhNotificationMessage string) {
+// GetDesktopMessage returns the English and Chinese messages of the desktop notification of a database
+func GetDesktopMessage(notificationInfo *api.Info, notificationMessage, zhNotificationMessage string) (string, string) {
+	message := fmt.Sprintf("Because %s , Database %s current status : %s , Please check in time.", notificationMessage, notificationInfo.DatabaseClusterName, notificationInfo.ExceptionStatus)
+	zhMessage := fmt.Sprintf("因为 %s , 数据库 %s 当前状态 : %s , 请及时检查.", zhNotificationMessage, notificationInfo.DatabaseClusterName, notificationInfo.ExceptionStatus)
+	return message, zhMessage
+}
+
+// CreateDesktopNotification creates a Notification in the namespace, its name is the prefix with a random suffix
+func CreateDesktopNotification(namespace, namePrefix, title, message, zhTitle, zhMessage, importance string, desktopPopup bool) error {
 	gvr := schema.GroupVersionResource{
 		Group:    "notification.sealos.io",
 		Version:  "v1",
@@ -40,25 +47,23 @@ func CreateNotification(notificationInfo *api.Info, notificationMessage, zhNotif
 
 	randomSuffix, _ := randString(5)
 	now := time.Now().UTC().Unix()
-	message := fmt.Sprintf("Because %s , Database %s current status : %s , Please check in time.", notificationMessage, notificationInfo.DatabaseClusterName, notificationInfo.ExceptionStatus)
-	zhMessage := fmt.Sprintf("因为 %s , 数据库 %s 当前状态 : %s , 请及时检查.", zhNotificationMessage, notificationInfo.DatabaseClusterName, notificationInfo.ExceptionStatus)
 	notification := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "notification.sealos.io/v1",
 			"kind":       "Notification",
 			"metadata": map[string]interface{}{
-				"name": "database-exception" + notificationInfo.DatabaseClusterName + randomSuffix,
+				"name": namePrefix + randomSuffix,
 			},
 			"spec": map[string]interface{}{
-				"title":        "Database Exception",
+				"title":        title,
 				"message":      message,
 				"timestamp":    now,
 				"from":         "database-monitor-cronjob",
-				"importance":   "High",
-				"desktopPopup": true,
+				"importance":   importance,
+				"desktopPopup": desktopPopup,
 				"i18ns": map[string]interface{}{
 					"zh": map[string]interface{}{
-						"title":   "数据库异常告警",
+						"title":   zhTitle,
 						"message": zhMessage,
 						"from":    "数据库异常",
 					},
@@ -67,8 +72,6 @@ func CreateNotification(notificationInfo *api.Info, notificationMessage, zhNotif
 		},
 	}
 
-	_, err := api.DynamicClient.Resource(gvr).Namespace(notificationInfo.Namespace).Create(context.TODO(), notification, metav1.CreateOptions{})
-	if err != nil {
-		log.Printf("Failed to send desktop notification: %v", err)
-	}
+	_, err := api.DynamicClient.Resource(gvr).Namespace(namespace).Create(context.TODO(), notification, metav1.CreateOptions{})
+	return err
 }
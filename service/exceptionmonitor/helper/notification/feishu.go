@@ -226,10 +226,6 @@ func GetNotificationMessage(notificationInfo *api.Info) string {
 }
 
 func SendFeishuNotification(notification *api.Info, message string) error {
-	if api.MonitorType != "all" {
-		notification.FeishuWebHook = api.FeishuWebhookURLMap["FeishuWebhookURLImportant"]
-	}
-
 	messageIDMap := getMessageIDMap(notification.PerformanceType)
 
 	if messageID, ok := messageIDMap[notification.DatabaseClusterName]; ok {
@@ -313,6 +309,25 @@ func createFeishuNotification(notification *api.Info, message string, messageIDM
 	return nil
 }
 
+// SendFeishuMessage sends the card to the chat, without tracking the message to update it later
+func SendFeishuMessage(chatID, message string) error {
+	req := larkim.NewCreateMessageReqBuilder().
+		ReceiveIdType("chat_id").
+		Body(larkim.NewCreateMessageReqBodyBuilder().
+			ReceiveId(chatID).
+			MsgType("interactive").
+			Content(message).Build()).Build()
+
+	resp, err := feiShuClient.Im.Message.Create(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if !resp.Success() {
+		return fmt.Errorf("feishu error %d: %s (request %s)", resp.Code, resp.Msg, resp.RequestId())
+	}
+	return nil
+}
+
 func extractAndPrintMessageID(str string) string {
 	re := regexp.MustCompile(`MessageId:\s*"([^"]+)"`)
 	match := re.FindStringSubmatch(str)
@@ -350,6 +365,20 @@ func createCard(headerTemplate, headerTitle string, elements []map[string]string
 	return card
 }
 
+// GetAlertMessage returns a card with the label and value elements, red when firing and blue when resolved
+func GetAlertMessage(title string, firing bool, elements []map[string]string) string {
+	headerTemplate := "blue"
+	if firing {
+		headerTemplate = "red"
+	}
+	message, err := marshalCard(createCard(headerTemplate, title, elements))
+	if err != nil {
+		fmt.Println(err)
+		return ""
+	}
+	return message
+}
+
 func GetQuotaMessage(nsQuota *api.NameSpaceQuota) string {
 	var card map[string]interface{}
 	elements := createQuotaElements(nsQuota)
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/alibabacloud-go/dysmsapi-20170525/v3/client"
 	"github.com/alibabacloud-go/tea/tea"
@@ -41,35 +40,6 @@ func GetPhoneNumberByNS(owner string) (string, string, error) {
 	return phone, email, nil
 }
 
-func SendExceptionNotification(notificationInfo *api.Info, clusterName, content string) error {
-	name := strings.ReplaceAll(notificationInfo.DatabaseClusterName, "-", "/")
-	owner, err := GetNSOwner(notificationInfo.Namespace)
-	if err != nil {
-		return err
-	}
-
-	phoneNumbers, userEmail, err := GetPhoneNumberByNS(owner)
-	if err != nil {
-		return err
-	}
-
-	if phoneNumbers != "" {
-		fmt.Println(phoneNumbers)
-		err := SendToSms(clusterName, content, phoneNumbers, name)
-		if err != nil {
-			fmt.Println("Error sending SMS:", err)
-		}
-	}
-	if userEmail != "" {
-		fmt.Println(userEmail)
-		err := SendToEmail(clusterName, content, userEmail, name)
-		if err != nil {
-			fmt.Println("Error sending Email:", err)
-		}
-	}
-	return nil
-}
-
 func SendToSms(clusterName, content, phoneNumbers, name string) error {
 	smsClient, err := utils.CreateSMSClient(
 		os.Getenv("SMSAccessKeyID"),
@@ -7,6 +7,7 @@ import (
 
 	"github.com/labring/sealos/service/exceptionmonitor/api"
 	"github.com/labring/sealos/service/exceptionmonitor/dao"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
 	"github.com/labring/sealos/service/exceptionmonitor/helper/client"
 	"github.com/labring/sealos/service/exceptionmonitor/helper/monitor"
 	"github.com/labring/sealos/service/exceptionmonitor/server"
@@ -33,5 +34,8 @@ func initialize() error {
 		return err
 	}
 	notification.InitFeishuClient()
+	if err := alert.Init(); err != nil {
+		return err
+	}
 	return dao.InitCockroachDB()
 }
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/notification"
+)
+
+// handleAlertmanagerWebhook receives the notifications of an Alertmanager webhook
+// receiver and routes their alerts like the ones of the monitors. The owner of the
+// namespace of the alerts is looked up so that it can be notified, so the requests
+// are rejected unless AlertmanagerWebhookToken is set and given as bearer token.
+func handleAlertmanagerWebhook(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !authorized(req, api.AlertmanagerWebhookToken) {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var msg alert.WebhookMessage
+	if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+		http.Error(rw, fmt.Sprintf("invalid webhook message: %v", err), http.StatusBadRequest)
+		return
+	}
+	for _, a := range alert.FromWebhook(&msg) {
+		if a.Name() == "" {
+			log.Printf("Alertmanager alert without alertname from receiver %s dropped", msg.Receiver)
+			continue
+		}
+		if ns := a.Labels[alert.LabelNamespace]; ns != "" {
+			owner, err := notification.GetNSOwner(ns)
+			if err != nil {
+				log.Printf("Failed to get the owner of namespace %s: %v", ns, err)
+			} else if owner != "" {
+				a.Annotations[alert.AnnotationOwner] = owner
+			}
+		}
+		alert.Notify(a)
+	}
+	rw.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+)
+
+func TestHandleAlertmanagerWebhook_Unauthorized(t *testing.T) {
+	body := `{"receiver":"exceptionmonitor","alerts":[{"status":"firing","labels":{"alertname":"Forged","namespace":"ns-a"}}]}`
+	tests := []struct {
+		name  string
+		token string
+		auth  string
+	}{
+		{name: "no token configured"},
+		{name: "no token configured with empty bearer", auth: "Bearer "},
+		{name: "missing authorization", token: "secret"},
+		{name: "wrong token", token: "secret", auth: "Bearer other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.AlertmanagerWebhookToken = tt.token
+			defer func() { api.AlertmanagerWebhookToken = "" }()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/alertmanager/webhook", strings.NewReader(body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rw := httptest.NewRecorder()
+			handleAlertmanagerWebhook(rw, req)
+			if rw.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rw.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestHandleAlertmanagerWebhook_InvalidMessage(t *testing.T) {
+	api.AlertmanagerWebhookToken = "secret"
+	defer func() { api.AlertmanagerWebhookToken = "" }()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/alertmanager/webhook", strings.NewReader("{"))
+	req.Header.Set("Authorization", "Bearer secret")
+	rw := httptest.NewRecorder()
+	handleAlertmanagerWebhook(rw, req)
+	if rw.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rw.Code, http.StatusBadRequest)
+	}
+}
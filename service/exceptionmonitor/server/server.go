@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/labring/sealos/service/exceptionmonitor/api"
+	"github.com/labring/sealos/service/exceptionmonitor/helper/alert"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -32,6 +34,8 @@ var databaseClusterGVR = schema.GroupVersionResource{
 func StartServer() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/v1/databases", handleListDatabases)
+	mux.HandleFunc("/v1/silences", handleSilences)
+	mux.HandleFunc("/v1/alertmanager/webhook", handleAlertmanagerWebhook)
 
 	addr := ":8000"
 	log.Printf("exceptionmonitor HTTP server listening on %s", addr)
@@ -117,3 +121,69 @@ func deriveReason(status, details string) string {
 	}
 	return ""
 }
+
+// handleSilences lists the silences of the alerts on GET, creates one from the JSON
+// body on POST and expires the one of the id query parameter on DELETE. Silences
+// mute the alerts of every namespace, so POST and DELETE are rejected unless
+// SilencesToken is set and given as bearer token.
+func handleSilences(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodDelete:
+		if !authorized(req, api.SilencesToken) {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	default:
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dispatcher := alert.Default()
+	if dispatcher == nil {
+		http.Error(rw, "the alert dispatcher is not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	silences := dispatcher.Silences()
+
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(rw, http.StatusOK, silences.List())
+	case http.MethodPost:
+		var silence alert.Silence
+		if err := json.NewDecoder(req.Body).Decode(&silence); err != nil {
+			http.Error(rw, fmt.Sprintf("invalid silence: %v", err), http.StatusBadRequest)
+			return
+		}
+		id, err := silences.Add(silence)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("invalid silence: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(rw, http.StatusCreated, map[string]string{"id": id})
+	case http.MethodDelete:
+		if err := silences.Expire(req.URL.Query().Get("id")); err != nil {
+			http.Error(rw, err.Error(), http.StatusNotFound)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authorized checks the bearer token of the request, no request is authorized
+// when the token is not configured
+func authorized(req *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	auth := []byte(req.Header.Get("Authorization"))
+	return subtle.ConstantTimeCompare(auth, []byte("Bearer "+token)) == 1
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labring/sealos/service/exceptionmonitor/api"
+)
+
+func TestHandleSilences_Unauthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		auth   string
+	}{
+		{name: "create without token configured", method: http.MethodPost},
+		{name: "create without token configured with empty bearer", method: http.MethodPost, auth: "Bearer "},
+		{name: "create with missing authorization", method: http.MethodPost, token: "secret"},
+		{name: "create with wrong token", method: http.MethodPost, token: "secret", auth: "Bearer other"},
+		{name: "expire without token configured", method: http.MethodDelete},
+		{name: "expire with wrong token", method: http.MethodDelete, token: "secret", auth: "Bearer other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.SilencesToken = tt.token
+			defer func() { api.SilencesToken = "" }()
+
+			req := httptest.NewRequest(tt.method, "/v1/silences?id=1", strings.NewReader(`{"matchers":[{"name":"alertname","value":"DatabaseDiskFull"}]}`))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rw := httptest.NewRecorder()
+			handleSilences(rw, req)
+			if rw.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rw.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestHandleSilences_MethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/v1/silences", nil)
+	rw := httptest.NewRecorder()
+	handleSilences(rw, req)
+	if rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rw.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/labring/sealos/service/hubble/collector"
 	"github.com/labring/sealos/service/hubble/config"
 	"github.com/labring/sealos/service/hubble/datastore"
+	pkgconfig "github.com/labring/sealos/service/hubble/pkg/config"
 	"github.com/labring/sealos/service/hubble/server"
 )
 
@@ -25,26 +27,27 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.Addr,
-		Username: cfg.Redis.Username,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		cancel()
-		log.Fatalf("Failed to connect to Redis: %v", err)
-	}
-	log.Println("Successfully connected to Redis")
-	dataStore := datastore.NewDataStore(redisClient)
-	ctx, cancel = context.WithCancel(context.Background())
-	collector, err := collector.NewCollector(cfg.Hubble.Addr, dataStore, true)
+	dataStore, err := newDataStore(cfg)
 	if err != nil {
-		cancel()
-		log.Fatalf("Failed to create collector: %v", err)
+		log.Fatalf("Failed to create data store: %v", err)
 	}
+	var source collector.FlowSource
+	if cfg.Hubble.Fixture.Path != "" {
+		log.Printf("Replaying flows of %s", cfg.Hubble.Fixture.Path)
+		source = collector.NewFixtureSource(
+			cfg.Hubble.Fixture.Path,
+			cfg.Hubble.Fixture.Speed,
+			cfg.Hubble.Fixture.Loop,
+		)
+	} else {
+		hubbleSource, err := collector.NewHubbleSource(cfg.Hubble.Addr, true)
+		if err != nil {
+			log.Fatalf("Failed to create collector: %v", err)
+		}
+		source = hubbleSource
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	collector := collector.NewCollector(source, dataStore)
 	go collector.Start(ctx)
 	server := server.NewServer(dataStore, cfg.Auth.WhiteList)
 	httpServer := &http.Server{
@@ -77,3 +80,24 @@ func main() {
 	}
 	log.Println("Graceful shutdown completed")
 }
+
+func newDataStore(cfg *pkgconfig.Config) (datastore.DataStore, error) {
+	if cfg.DataStore.Type == datastore.TypeMemory {
+		log.Println("Using in-memory data store")
+		return datastore.NewMemoryStore(), nil
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Username: cfg.Redis.Username,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	log.Println("Successfully connected to Redis")
+	return datastore.NewRedisStore(redisClient), nil
+}
@@ -2,52 +2,28 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 
-	"google.golang.org/grpc/credentials"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-
 	pb "github.com/cilium/cilium/api/v1/flow"
-	observer "github.com/cilium/cilium/api/v1/observer"
 	"github.com/labring/sealos/service/hubble/datastore"
 	"github.com/labring/sealos/service/hubble/pkg/constants"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 // Collector handles the collection of network flow data from Hubble
 type Collector struct {
-	hubbleAddr string
-	dataStore  *datastore.DataStore
-	k8sClient  kubernetes.Interface
-	tlsConfig  *TLSConfig
+	source    FlowSource
+	dataStore datastore.DataStore
 }
 
-func NewCollector(hubbleAddr string, dataStore *datastore.DataStore, enableTLS bool) (*Collector, error) {
-	var k8sClient kubernetes.Interface
-	var tlsConfig *TLSConfig
-	if enableTLS {
-		config, err := rest.InClusterConfig()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get in-cluster config: %v", err)
-		}
-		clientset, err := kubernetes.NewForConfig(config)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create kubernetes client: %v", err)
-		}
-		k8sClient = clientset
-		tlsConfig = NewDefaultTLSConfig()
-	}
-
+func NewCollector(source FlowSource, dataStore datastore.DataStore) *Collector {
 	return &Collector{
-		hubbleAddr: hubbleAddr,
-		dataStore:  dataStore,
-		k8sClient:  k8sClient,
-		tlsConfig:  tlsConfig,
-	}, nil
+		source:    source,
+		dataStore: dataStore,
+	}
 }
 
 type FlowEndpoints struct {
@@ -59,62 +35,49 @@ type FlowEndpoints struct {
 	DestType        string
 }
 
-// Start begins collecting flow data from Hubble
+// Start begins collecting flow data from the flow source
 func (c *Collector) Start(ctx context.Context) {
-	log.Printf("Loaded hubble address: %s", c.hubbleAddr)
-	var creds credentials.TransportCredentials
-	if c.tlsConfig != nil && c.k8sClient != nil {
-		tlsCreds, err := LoadTLSCredentials(ctx, c.k8sClient, c.tlsConfig)
-		if err != nil {
-			log.Fatalf("Failed to load TLS credentials: %v", err)
-		}
-		creds = tlsCreds
-	} else {
-		creds = insecure.NewCredentials()
-	}
-	conn, err := grpc.NewClient(
-		c.hubbleAddr,
-		grpc.WithTransportCredentials(creds),
-	)
-	if err != nil {
-		log.Fatalf("Failed to connect to Hubble: %v", err)
-	}
-	client := observer.NewObserverClient(conn)
-	req := &observer.GetFlowsRequest{
-		Follow: true,
-	}
-
-	stream, err := client.GetFlows(ctx, req)
+	stream, err := c.source.Open(ctx)
 	if err != nil {
-		conn.Close()
 		log.Fatalf("Failed to get flow data: %v", err)
 	}
-	defer conn.Close()
+	defer stream.Close()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			resp, err := stream.Recv()
+			flow, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				log.Printf("Flow source exhausted")
+				return
+			}
 			if err != nil {
 				log.Printf("Stream closed or error occurred: %v", err)
 				return
 			}
 
-			flow := resp.GetFlow()
-			if flow == nil {
-				continue
-			}
+			c.processFlow(ctx, flow)
+		}
+	}
+}
 
-			flowEndpoint, ok := extractFlowEndpoints(flow)
-			if !ok {
-				continue
-			}
+// processFlow records the relationship of the endpoints of the flow and adds it to the service graph
+func (c *Collector) processFlow(ctx context.Context, flow *pb.Flow) {
+	if flow == nil {
+		return
+	}
 
-			if err := c.updateFlowRelationships(ctx, flowEndpoint); err != nil {
-				log.Printf("Error updating flow relationships: %v", err)
-			}
-		}
+	flowEndpoint, ok := extractFlowEndpoints(flow)
+	if !ok {
+		return
+	}
+
+	if err := c.updateFlowRelationships(ctx, flowEndpoint); err != nil {
+		log.Printf("Error updating flow relationships: %v", err)
+	}
+	if err := c.recordFlow(ctx, flow, flowEndpoint); err != nil {
+		log.Printf("Error recording flow in service graph: %v", err)
 	}
 }
 
@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/hubble/datastore"
+	"github.com/labring/sealos/service/hubble/pkg/graph"
+)
+
+func TestFixtureReplay(t *testing.T) {
+	store := datastore.NewMemoryStore()
+	c := NewCollector(NewFixtureSource("testdata/flows.jsonl", 0, false), store)
+
+	ctx := context.Background()
+	start := time.Now()
+	c.Start(ctx)
+
+	members, err := store.GetSetMembers(ctx, "ns-demo/app/web")
+	if err != nil {
+		t.Fatalf("GetSetMembers() error = %v", err)
+	}
+	want := []string{"ns-demo/app/api", "ns-demo/database/pg", "ns-other/database/cache"}
+	if len(members) != len(want) {
+		t.Fatalf("GetSetMembers() = %v, want %v", members, want)
+	}
+	for i := range want {
+		if members[i] != want[i] {
+			t.Errorf("GetSetMembers() = %v, want %v", members, want)
+		}
+	}
+
+	// The replayed flows happen now, so they are in the current window
+	buckets, err := store.GetHashes(ctx, graph.BucketKeys("ns-demo", start.Add(time.Minute), 5*time.Minute)...)
+	if err != nil {
+		t.Fatalf("GetHashes() error = %v", err)
+	}
+	nodes, edges := graph.Build("ns-demo", buckets)
+	if len(nodes) != 4 || len(edges) != 3 {
+		t.Fatalf("Build() = %v, %v, want 4 nodes and 3 edges", nodes, edges)
+	}
+
+	api, pg, cache := edges[0], edges[1], edges[2]
+	if api.Target != "ns-demo/app/api" || api.Requests != 1 || api.Protocols["http"] != 1 ||
+		api.AvgLatencyMs == nil || *api.AvgLatencyMs != 30 {
+		t.Errorf("web -> api = %+v, want one HTTP request of 30ms", api)
+	}
+	if pg.Target != "ns-demo/database/pg" || pg.Flows != 2 ||
+		pg.Verdicts["FORWARDED"] != 1 || pg.Verdicts["DROPPED"] != 1 {
+		t.Errorf("web -> pg = %+v, want a forwarded and a dropped flow", pg)
+	}
+	if cache.Target != "ns-other/database/cache" || !nodes[3].External {
+		t.Errorf("web -> cache = %+v, nodes = %+v, want an external cache", cache, nodes)
+	}
+
+	// The other namespace sees the edge as well
+	buckets, _ = store.GetHashes(ctx, graph.BucketKeys("ns-other", start.Add(time.Minute), 5*time.Minute)...)
+	if _, edges := graph.Build("ns-other", buckets); len(edges) != 1 {
+		t.Errorf("Build(ns-other) = %v, want 1 edge", edges)
+	}
+}
+
+func TestFixtureLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := NewFixtureSource("testdata/flows.jsonl", 0, true).Open(ctx)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for i := 0; i < 15; i++ {
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+	}
+
+	stream, _ = NewFixtureSource("testdata/flows.jsonl", 0, false).Open(ctx)
+	for i := 0; i < 6; i++ {
+		_, _ = stream.Recv()
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("Recv() error = %v, want EOF", err)
+	}
+}
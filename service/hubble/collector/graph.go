@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/cilium/cilium/api/v1/flow"
+	"github.com/labring/sealos/service/hubble/pkg/graph"
+)
+
+// recordFlow adds the flow to the service graph of the namespaces of both endpoints
+func (c *Collector) recordFlow(ctx context.Context, flow *pb.Flow, flowEndpoint *FlowEndpoints) error {
+	source := fmt.Sprintf(
+		"%s/%s/%s",
+		flowEndpoint.SourceNamespace,
+		flowEndpoint.SourceType,
+		flowEndpoint.SourceName,
+	)
+	dest := fmt.Sprintf(
+		"%s/%s/%s",
+		flowEndpoint.DestNamespace,
+		flowEndpoint.DestType,
+		flowEndpoint.DestName,
+	)
+	if source == dest {
+		return nil
+	}
+
+	l7 := flow.GetL7()
+	// Responses flow from the server back to the client, they are accounted
+	// to the edge of their request so that it gets the latency
+	if l7.GetType() == pb.L7FlowType_RESPONSE {
+		source, dest = dest, source
+	}
+
+	edge := graph.Edge{
+		Source:   source,
+		Target:   dest,
+		Verdict:  flow.GetVerdict().String(),
+		Protocol: l7Protocol(l7),
+	}
+	fields := map[string]int64{
+		edge.Field(graph.MetricFlows): 1,
+	}
+	switch l7.GetType() {
+	case pb.L7FlowType_REQUEST:
+		fields[edge.Field(graph.MetricRequests)] = 1
+	case pb.L7FlowType_RESPONSE:
+		if latency := l7.GetLatencyNs(); latency > 0 {
+			fields[edge.Field(graph.MetricLatencySum)] = int64(latency)
+			fields[edge.Field(graph.MetricLatencyCount)] = 1
+		}
+	}
+
+	t := time.Now()
+	if flow.GetTime() != nil {
+		t = flow.GetTime().AsTime()
+	}
+
+	namespaces := []string{flowEndpoint.SourceNamespace}
+	if flowEndpoint.DestNamespace != flowEndpoint.SourceNamespace {
+		namespaces = append(namespaces, flowEndpoint.DestNamespace)
+	}
+	for _, namespace := range namespaces {
+		if err := c.dataStore.IncrementHash(ctx, graph.BucketKey(namespace, t), fields, graph.Retention); err != nil {
+			return fmt.Errorf("failed to update service graph of %s: %w", namespace, err)
+		}
+	}
+	return nil
+}
+
+// l7Protocol returns the L7 protocol of the flow, or an empty string for L3/L4 flows
+func l7Protocol(l7 *pb.Layer7) string {
+	switch {
+	case l7.GetHttp() != nil:
+		return "http"
+	case l7.GetDns() != nil:
+		return "dns"
+	case l7.GetKafka() != nil:
+		return "kafka"
+	default:
+		return ""
+	}
+}
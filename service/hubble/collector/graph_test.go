@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cilium/cilium/api/v1/flow"
+	"github.com/labring/sealos/service/hubble/datastore"
+	"github.com/labring/sealos/service/hubble/pkg/graph"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var flowTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func httpFlow(flowType pb.L7FlowType, latency uint64) *pb.Flow {
+	return &pb.Flow{
+		Time:    timestamppb.New(flowTime),
+		Verdict: pb.Verdict_FORWARDED,
+		L7: &pb.Layer7{
+			Type:      flowType,
+			LatencyNs: latency,
+			Record:    &pb.Layer7_Http{Http: &pb.HTTP{Method: "GET"}},
+		},
+	}
+}
+
+func endpoints(sourceNamespace, sourceName, destNamespace, destName string) *FlowEndpoints {
+	return &FlowEndpoints{
+		SourceNamespace: sourceNamespace,
+		SourceType:      "app",
+		SourceName:      sourceName,
+		DestNamespace:   destNamespace,
+		DestType:        "app",
+		DestName:        destName,
+	}
+}
+
+func bucket(t *testing.T, store datastore.DataStore, namespace string) map[string]int64 {
+	t.Helper()
+
+	hashes, err := store.GetHashes(context.Background(), graph.BucketKey(namespace, flowTime))
+	if err != nil {
+		t.Fatalf("GetHashes() error = %v", err)
+	}
+	return hashes[0]
+}
+
+func TestRecordFlow_RequestAndResponse(t *testing.T) {
+	store := datastore.NewMemoryStore()
+	c := NewCollector(nil, store)
+	ctx := context.Background()
+
+	if err := c.recordFlow(ctx, httpFlow(pb.L7FlowType_REQUEST, 0), endpoints("ns-a", "web", "ns-a", "api")); err != nil {
+		t.Fatalf("recordFlow() error = %v", err)
+	}
+	// The response flows from api back to web and is accounted to the request edge
+	if err := c.recordFlow(ctx, httpFlow(pb.L7FlowType_RESPONSE, 20_000_000), endpoints("ns-a", "api", "ns-a", "web")); err != nil {
+		t.Fatalf("recordFlow() error = %v", err)
+	}
+	if err := c.recordFlow(ctx, httpFlow(pb.L7FlowType_RESPONSE, 40_000_000), endpoints("ns-a", "api", "ns-a", "web")); err != nil {
+		t.Fatalf("recordFlow() error = %v", err)
+	}
+
+	edge := graph.Edge{Source: "ns-a/app/web", Target: "ns-a/app/api", Verdict: "FORWARDED", Protocol: "http"}
+	want := map[string]int64{
+		edge.Field(graph.MetricFlows):        3,
+		edge.Field(graph.MetricRequests):     1,
+		edge.Field(graph.MetricLatencySum):   60_000_000,
+		edge.Field(graph.MetricLatencyCount): 2,
+	}
+	got := bucket(t, store, "ns-a")
+	if len(got) != len(want) {
+		t.Errorf("bucket = %v, want %v", got, want)
+	}
+	for field, value := range want {
+		if got[field] != value {
+			t.Errorf("bucket[%s] = %d, want %d", field, got[field], value)
+		}
+	}
+
+	_, edges := graph.Build("ns-a", []map[string]int64{got})
+	if len(edges) != 1 || edges[0].AvgLatencyMs == nil || *edges[0].AvgLatencyMs != 30 {
+		t.Errorf("Build() = %+v, want one edge of 30ms", edges)
+	}
+}
+
+func TestRecordFlow_Verdicts(t *testing.T) {
+	store := datastore.NewMemoryStore()
+	c := NewCollector(nil, store)
+	ctx := context.Background()
+
+	dropped := &pb.Flow{Time: timestamppb.New(flowTime), Verdict: pb.Verdict_DROPPED}
+	forwarded := &pb.Flow{Time: timestamppb.New(flowTime), Verdict: pb.Verdict_FORWARDED}
+	for _, flow := range []*pb.Flow{dropped, forwarded, forwarded} {
+		if err := c.recordFlow(ctx, flow, endpoints("ns-a", "web", "ns-a", "pg")); err != nil {
+			t.Fatalf("recordFlow() error = %v", err)
+		}
+	}
+
+	got := bucket(t, store, "ns-a")
+	droppedEdge := graph.Edge{Source: "ns-a/app/web", Target: "ns-a/app/pg", Verdict: "DROPPED"}
+	forwardedEdge := graph.Edge{Source: "ns-a/app/web", Target: "ns-a/app/pg", Verdict: "FORWARDED"}
+	if got[droppedEdge.Field(graph.MetricFlows)] != 1 || got[forwardedEdge.Field(graph.MetricFlows)] != 2 || len(got) != 2 {
+		t.Errorf("bucket = %v, want 1 dropped and 2 forwarded L4 flows", got)
+	}
+}
+
+func TestRecordFlow_Namespaces(t *testing.T) {
+	store := datastore.NewMemoryStore()
+	c := NewCollector(nil, store)
+	ctx := context.Background()
+
+	if err := c.recordFlow(ctx, httpFlow(pb.L7FlowType_REQUEST, 0), endpoints("ns-a", "web", "ns-b", "cache")); err != nil {
+		t.Fatalf("recordFlow() error = %v", err)
+	}
+	// Flows within the same resource are not part of the graph
+	if err := c.recordFlow(ctx, httpFlow(pb.L7FlowType_REQUEST, 0), endpoints("ns-a", "web", "ns-a", "web")); err != nil {
+		t.Fatalf("recordFlow() error = %v", err)
+	}
+
+	edge := graph.Edge{Source: "ns-a/app/web", Target: "ns-b/app/cache", Verdict: "FORWARDED", Protocol: "http"}
+	for _, namespace := range []string{"ns-a", "ns-b"} {
+		got := bucket(t, store, namespace)
+		if len(got) != 2 || got[edge.Field(graph.MetricFlows)] != 1 || got[edge.Field(graph.MetricRequests)] != 1 {
+			t.Errorf("bucket of %s = %v, want the cross namespace edge only", namespace, got)
+		}
+	}
+}
+
+func TestL7Protocol(t *testing.T) {
+	tests := []struct {
+		l7   *pb.Layer7
+		want string
+	}{
+		{l7: nil, want: ""},
+		{l7: &pb.Layer7{Record: &pb.Layer7_Http{Http: &pb.HTTP{}}}, want: "http"},
+		{l7: &pb.Layer7{Record: &pb.Layer7_Dns{Dns: &pb.DNS{}}}, want: "dns"},
+		{l7: &pb.Layer7{Record: &pb.Layer7_Kafka{Kafka: &pb.Kafka{}}}, want: "kafka"},
+	}
+	for _, tt := range tests {
+		if got := l7Protocol(tt.l7); got != tt.want {
+			t.Errorf("l7Protocol(%v) = %q, want %q", tt.l7, got, tt.want)
+		}
+	}
+}
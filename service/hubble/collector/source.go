@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "github.com/cilium/cilium/api/v1/flow"
+	observer "github.com/cilium/cilium/api/v1/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// FlowSource provides the flows collected by the Collector
+type FlowSource interface {
+	// Open starts streaming flows until the context is done
+	Open(ctx context.Context) (FlowStream, error)
+}
+
+// FlowStream is a stream of flows opened by a FlowSource
+type FlowStream interface {
+	// Recv returns the next flow, or io.EOF once the source is exhausted
+	Recv() (*pb.Flow, error)
+	Close() error
+}
+
+// HubbleSource follows the flows of Hubble Relay
+type HubbleSource struct {
+	hubbleAddr string
+	k8sClient  kubernetes.Interface
+	tlsConfig  *TLSConfig
+}
+
+func NewHubbleSource(hubbleAddr string, enableTLS bool) (*HubbleSource, error) {
+	var k8sClient kubernetes.Interface
+	var tlsConfig *TLSConfig
+	if enableTLS {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get in-cluster config: %v", err)
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client: %v", err)
+		}
+		k8sClient = clientset
+		tlsConfig = NewDefaultTLSConfig()
+	}
+
+	return &HubbleSource{
+		hubbleAddr: hubbleAddr,
+		k8sClient:  k8sClient,
+		tlsConfig:  tlsConfig,
+	}, nil
+}
+
+func (s *HubbleSource) Open(ctx context.Context) (FlowStream, error) {
+	log.Printf("Loaded hubble address: %s", s.hubbleAddr)
+	var creds credentials.TransportCredentials
+	if s.tlsConfig != nil && s.k8sClient != nil {
+		tlsCreds, err := LoadTLSCredentials(ctx, s.k8sClient, s.tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS credentials: %w", err)
+		}
+		creds = tlsCreds
+	} else {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(
+		s.hubbleAddr,
+		grpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Hubble: %w", err)
+	}
+	client := observer.NewObserverClient(conn)
+	req := &observer.GetFlowsRequest{
+		Follow: true,
+	}
+
+	stream, err := client.GetFlows(ctx, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &hubbleStream{conn: conn, stream: stream}, nil
+}
+
+type hubbleStream struct {
+	conn   *grpc.ClientConn
+	stream observer.Observer_GetFlowsClient
+}
+
+func (s *hubbleStream) Recv() (*pb.Flow, error) {
+	for {
+		resp, err := s.stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		// Skip node status and lost events
+		if flow := resp.GetFlow(); flow != nil {
+			return flow, nil
+		}
+	}
+}
+
+func (s *hubbleStream) Close() error {
+	return s.conn.Close()
+}
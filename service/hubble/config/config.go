@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/labring/sealos/service/hubble/datastore"
 	"github.com/labring/sealos/service/hubble/pkg/config"
 	"gopkg.in/yaml.v3"
 )
@@ -19,6 +20,12 @@ func LoadConfig(configPath string) (*config.Config, error) {
 		},
 		Hubble: config.HubbleConfig{
 			Addr: "localhost:4245",
+			Fixture: config.FixtureConfig{
+				Speed: 1,
+			},
+		},
+		DataStore: config.DataStoreConfig{
+			Type: datastore.TypeRedis,
 		},
 		Redis: config.RedisConfig{
 			Addr:     "localhost:6379",
@@ -37,5 +44,13 @@ func LoadConfig(configPath string) (*config.Config, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	switch cfg.DataStore.Type {
+	case datastore.TypeRedis, datastore.TypeMemory:
+	default:
+		return nil, fmt.Errorf("unsupported data store type: %s", cfg.DataStore.Type)
+	}
+	if cfg.Hubble.Fixture.Speed < 0 {
+		return nil, fmt.Errorf("invalid fixture speed: %v", cfg.Hubble.Fixture.Speed)
+	}
 	return cfg, nil
 }
@@ -0,0 +1,36 @@
+package datastore
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	DefaultExpiration = 90 * 24 * time.Hour
+)
+
+// Types of data stores
+const (
+	TypeRedis  = "redis"
+	TypeMemory = "memory"
+)
+
+// DataStore stores the flow data collected from Hubble.
+// Keys expire DefaultExpiration after they were last written unless stated otherwise.
+type DataStore interface {
+	Set(ctx context.Context, key, value string) error
+	Get(ctx context.Context, key string) (string, bool, error)
+	// EnsureSetExpiration sets the default expiration of a key which has none
+	EnsureSetExpiration(ctx context.Context, key string) error
+	AddToSet(ctx context.Context, key string, members ...any) error
+	GetSetMembers(ctx context.Context, key string) ([]string, error)
+	// IncrementHash increments the fields of a hash and sets its expiration time
+	IncrementHash(ctx context.Context, key string, fields map[string]int64, expiration time.Duration) error
+	// GetHashes returns the fields of the hashes, missing hashes are empty
+	GetHashes(ctx context.Context, keys ...string) ([]map[string]int64, error)
+}
+
+var (
+	_ DataStore = (*RedisStore)(nil)
+	_ DataStore = (*MemoryStore)(nil)
+)
@@ -0,0 +1,182 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired keys are removed from the memory store
+const sweepInterval = time.Minute
+
+// MemoryStore is the DataStore keeping the data in memory, for development and tests.
+// Like Redis, a key holds a single type of value, expired keys are removed lazily.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	value string
+	set   map[string]struct{}
+	hash  map[string]int64
+	// expires is zero for keys without expiration
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (ms *MemoryStore) Set(_ context.Context, key, value string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+	ms.sweep(now)
+	ms.entries[key] = &memoryEntry{
+		value:   value,
+		expires: now.Add(DefaultExpiration),
+	}
+	return nil
+}
+
+func (ms *MemoryStore) Get(_ context.Context, key string) (string, bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	entry, ok := ms.get(key)
+	if !ok || entry.set != nil || entry.hash != nil {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (ms *MemoryStore) EnsureSetExpiration(_ context.Context, key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if entry, ok := ms.get(key); ok && entry.expires.IsZero() {
+		entry.expires = ms.now().Add(DefaultExpiration)
+	}
+	return nil
+}
+
+// AddToSet adds members to a set and refreshes its expiration time
+func (ms *MemoryStore) AddToSet(_ context.Context, key string, members ...any) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+	ms.sweep(now)
+
+	entry, ok := ms.get(key)
+	if !ok {
+		entry = &memoryEntry{set: make(map[string]struct{})}
+		ms.entries[key] = entry
+	} else if entry.set == nil {
+		return fmt.Errorf("key %s does not hold a set", key)
+	}
+
+	for _, member := range members {
+		entry.set[fmt.Sprint(member)] = struct{}{}
+	}
+	entry.expires = now.Add(DefaultExpiration)
+	return nil
+}
+
+func (ms *MemoryStore) GetSetMembers(_ context.Context, key string) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	entry, ok := ms.get(key)
+	if !ok || entry.set == nil {
+		return []string{}, nil
+	}
+
+	members := make([]string, 0, len(entry.set))
+	for member := range entry.set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func (ms *MemoryStore) IncrementHash(
+	_ context.Context,
+	key string,
+	fields map[string]int64,
+	expiration time.Duration,
+) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+	ms.sweep(now)
+
+	entry, ok := ms.get(key)
+	if !ok {
+		entry = &memoryEntry{hash: make(map[string]int64, len(fields))}
+		ms.entries[key] = entry
+	} else if entry.hash == nil {
+		return fmt.Errorf("key %s does not hold a hash", key)
+	}
+
+	for field, value := range fields {
+		entry.hash[field] += value
+	}
+	entry.expires = now.Add(expiration)
+	return nil
+}
+
+func (ms *MemoryStore) GetHashes(_ context.Context, keys ...string) ([]map[string]int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	hashes := make([]map[string]int64, 0, len(keys))
+	for _, key := range keys {
+		hash := make(map[string]int64)
+		if entry, ok := ms.get(key); ok {
+			for field, value := range entry.hash {
+				hash[field] = value
+			}
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// get returns the entry of the key unless it expired, ms.mu must be held
+func (ms *MemoryStore) get(key string) (*memoryEntry, bool) {
+	entry, ok := ms.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expires.IsZero() && !ms.now().Before(entry.expires) {
+		delete(ms.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// sweep removes the expired keys at most once per sweepInterval, ms.mu must be held
+func (ms *MemoryStore) sweep(now time.Time) {
+	if now.Sub(ms.lastSweep) < sweepInterval {
+		return
+	}
+	ms.lastSweep = now
+
+	for key, entry := range ms.entries {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(ms.entries, key)
+		}
+	}
+}
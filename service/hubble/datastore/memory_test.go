@@ -0,0 +1,43 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreExpiration(t *testing.T) {
+	ms := NewMemoryStore()
+	now := time.Now()
+	ms.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if err := ms.AddToSet(ctx, "set", "a", "b"); err != nil {
+		t.Fatalf("AddToSet() error = %v", err)
+	}
+	if err := ms.IncrementHash(ctx, "hash", map[string]int64{"f": 2}, time.Minute); err != nil {
+		t.Fatalf("IncrementHash() error = %v", err)
+	}
+	_ = ms.IncrementHash(ctx, "hash", map[string]int64{"f": 3}, time.Minute)
+	if err := ms.AddToSet(ctx, "hash", "a"); err == nil {
+		t.Error("AddToSet() on a hash expected error")
+	}
+
+	hashes, _ := ms.GetHashes(ctx, "hash", "missing")
+	if len(hashes) != 2 || hashes[0]["f"] != 5 || len(hashes[1]) != 0 {
+		t.Errorf("GetHashes() = %v, want [map[f:5] map[]]", hashes)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if hashes, _ := ms.GetHashes(ctx, "hash"); len(hashes[0]) != 0 {
+		t.Errorf("GetHashes() = %v, want the hash to be expired", hashes)
+	}
+	if members, _ := ms.GetSetMembers(ctx, "set"); len(members) != 2 {
+		t.Errorf("GetSetMembers() = %v, want [a b]", members)
+	}
+
+	now = now.Add(DefaultExpiration)
+	if members, _ := ms.GetSetMembers(ctx, "set"); len(members) != 0 {
+		t.Errorf("GetSetMembers() = %v, want the set to be expired", members)
+	}
+}
@@ -3,30 +3,28 @@ package datastore
 import (
 	"context"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
-const (
-	DefaultExpiration = 90 * 24 * time.Hour
-)
-
-type DataStore struct {
+// RedisStore is the DataStore backed by Redis
+type RedisStore struct {
 	client *redis.Client
 }
 
-func NewDataStore(client *redis.Client) *DataStore {
-	return &DataStore{
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{
 		client: client,
 	}
 }
 
-func (ds *DataStore) Set(ctx context.Context, key, value string) error {
+func (ds *RedisStore) Set(ctx context.Context, key, value string) error {
 	return ds.client.Set(ctx, key, value, DefaultExpiration).Err()
 }
 
-func (ds *DataStore) Get(ctx context.Context, key string) (string, bool, error) {
+func (ds *RedisStore) Get(ctx context.Context, key string) (string, bool, error) {
 	val, err := ds.client.Get(ctx, key).Result()
 	if errors.Is(err, redis.Nil) {
 		return "", false, nil
@@ -36,7 +34,7 @@ func (ds *DataStore) Get(ctx context.Context, key string) (string, bool, error)
 	return val, true, nil
 }
 
-func (ds *DataStore) EnsureSetExpiration(ctx context.Context, key string) error {
+func (ds *RedisStore) EnsureSetExpiration(ctx context.Context, key string) error {
 	ttl, err := ds.client.TTL(ctx, key).Result()
 	if err != nil {
 		return err
@@ -56,7 +54,7 @@ func (ds *DataStore) EnsureSetExpiration(ctx context.Context, key string) error
 // AddToSet adds members to a Redis set and refreshes its expiration time.
 // Each time new data is added, the set's TTL is reset to DefaultExpiration,
 // ensuring the set remains available as long as it's actively being updated.
-func (ds *DataStore) AddToSet(ctx context.Context, key string, members ...any) error {
+func (ds *RedisStore) AddToSet(ctx context.Context, key string, members ...any) error {
 	pipe := ds.client.Pipeline()
 	pipe.SAdd(ctx, key, members...)
 	pipe.Expire(ctx, key, DefaultExpiration)
@@ -64,7 +62,7 @@ func (ds *DataStore) AddToSet(ctx context.Context, key string, members ...any) e
 	return err
 }
 
-func (ds *DataStore) GetSetMembers(ctx context.Context, key string) ([]string, error) {
+func (ds *RedisStore) GetSetMembers(ctx context.Context, key string) ([]string, error) {
 	members, err := ds.client.SMembers(ctx, key).Result()
 	if errors.Is(err, redis.Nil) {
 		return []string{}, nil
@@ -73,3 +71,45 @@ func (ds *DataStore) GetSetMembers(ctx context.Context, key string) ([]string, e
 	}
 	return members, nil
 }
+
+// IncrementHash increments the fields of a Redis hash and sets its expiration time
+func (ds *RedisStore) IncrementHash(
+	ctx context.Context,
+	key string,
+	fields map[string]int64,
+	expiration time.Duration,
+) error {
+	pipe := ds.client.Pipeline()
+	for field, value := range fields {
+		pipe.HIncrBy(ctx, key, field, value)
+	}
+	pipe.Expire(ctx, key, expiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetHashes returns the integer fields of the Redis hashes, missing hashes are empty
+func (ds *RedisStore) GetHashes(ctx context.Context, keys ...string) ([]map[string]int64, error) {
+	pipe := ds.client.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, pipe.HGetAll(ctx, key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	hashes := make([]map[string]int64, 0, len(keys))
+	for _, cmd := range cmds {
+		hash := make(map[string]int64, len(cmd.Val()))
+		for field, value := range cmd.Val() {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			hash[field] = n
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
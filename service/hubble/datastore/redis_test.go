@@ -0,0 +1,66 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisStore(client), mr
+}
+
+func TestRedisStoreHashes(t *testing.T) {
+	rs, mr := newTestRedisStore(t)
+	ctx := context.Background()
+
+	if err := rs.IncrementHash(ctx, "hash", map[string]int64{"f": 2, "g": 1}, time.Minute); err != nil {
+		t.Fatalf("IncrementHash() error = %v", err)
+	}
+	if err := rs.IncrementHash(ctx, "hash", map[string]int64{"f": 3}, 2*time.Minute); err != nil {
+		t.Fatalf("IncrementHash() error = %v", err)
+	}
+	if ttl := mr.TTL("hash"); ttl != 2*time.Minute {
+		t.Errorf("TTL = %v, want the expiration of the last increment", ttl)
+	}
+	// Fields that are not integers are skipped
+	mr.HSet("hash", "invalid", "x")
+
+	hashes, err := rs.GetHashes(ctx, "hash", "missing")
+	if err != nil {
+		t.Fatalf("GetHashes() error = %v", err)
+	}
+	if len(hashes) != 2 || len(hashes[0]) != 2 || hashes[0]["f"] != 5 || hashes[0]["g"] != 1 || len(hashes[1]) != 0 {
+		t.Errorf("GetHashes() = %v, want [map[f:5 g:1] map[]]", hashes)
+	}
+
+	mr.FastForward(3 * time.Minute)
+	if hashes, _ := rs.GetHashes(ctx, "hash"); len(hashes) != 1 || len(hashes[0]) != 0 {
+		t.Errorf("GetHashes() = %v, want the hash to be expired", hashes)
+	}
+
+	if hashes, err := rs.GetHashes(ctx); err != nil || len(hashes) != 0 {
+		t.Errorf("GetHashes() = %v, %v, want no hashes", hashes, err)
+	}
+}
+
+func TestRedisStoreHashesError(t *testing.T) {
+	rs, mr := newTestRedisStore(t)
+	ctx := context.Background()
+
+	mr.Close()
+	if err := rs.IncrementHash(ctx, "hash", map[string]int64{"f": 1}, time.Minute); err == nil {
+		t.Error("IncrementHash() expected error")
+	}
+	if _, err := rs.GetHashes(ctx, "hash"); err == nil {
+		t.Error("GetHashes() expected error")
+	}
+}
@@ -10,6 +10,19 @@ type HTTPConfig struct {
 
 type HubbleConfig struct {
 	Addr string `yaml:"addr"`
+	// Fixture replays the flows of a file instead of following Hubble Relay
+	Fixture FixtureConfig `yaml:"fixture"`
+}
+
+type FixtureConfig struct {
+	Path  string  `yaml:"path"`
+	Speed float64 `yaml:"speed"`
+	Loop  bool    `yaml:"loop"`
+}
+
+type DataStoreConfig struct {
+	// Type is either redis or memory
+	Type string `yaml:"type"`
 }
 
 type RedisConfig struct {
@@ -20,8 +33,9 @@ type RedisConfig struct {
 }
 
 type Config struct {
-	Auth   AuthConfig   `yaml:"auth"`
-	HTTP   HTTPConfig   `yaml:"http"`
-	Hubble HubbleConfig `yaml:"hubble"`
-	Redis  RedisConfig  `yaml:"redis"`
+	Auth      AuthConfig      `yaml:"auth"`
+	HTTP      HTTPConfig      `yaml:"http"`
+	Hubble    HubbleConfig    `yaml:"hubble"`
+	DataStore DataStoreConfig `yaml:"dataStore"`
+	Redis     RedisConfig     `yaml:"redis"`
 }
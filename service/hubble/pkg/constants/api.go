@@ -3,4 +3,5 @@ package constants
 const (
 	APIBasePath = "/api/v1"
 	FlowsPath   = "/traffic"
+	GraphPath   = "/graph"
 )
@@ -5,4 +5,5 @@ const (
 	DecodingKCFailedMsg = "Failed to decode kubeconfig"
 	GetFlowsSuccessMsg  = "get flows success"
 	InvalidRequestMsg   = "invalid request parameters: %v"
+	GetGraphSuccessMsg  = "get graph success"
 )
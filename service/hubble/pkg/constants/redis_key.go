@@ -3,4 +3,6 @@ package constants
 const (
 	// FlowSetKeyPattern represents the format for flow set keys: "namespace/resourceType/name"
 	FlowSetKeyPattern = "%s/%s/%s"
+	// GraphKeyPattern represents the format for service graph bucket keys: "graph/namespace/unixMinute"
+	GraphKeyPattern = "graph/%s/%d"
 )
@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/service/hubble/pkg/constants"
+	"github.com/labring/sealos/service/hubble/pkg/models"
+)
+
+const (
+	// BucketSize is the resolution of the service graph
+	BucketSize = time.Minute
+	// Retention is how long the service graph is kept, the largest window that can be queried
+	Retention = 24 * time.Hour
+)
+
+// Metrics of an edge, they are the last part of the hash fields of a bucket
+const (
+	MetricFlows        = "flows"
+	MetricRequests     = "requests"
+	MetricLatencySum   = "latency_ns_sum"
+	MetricLatencyCount = "latency_count"
+)
+
+// fieldSeparator separates the parts of the hash fields, it can not appear in resource keys
+const fieldSeparator = "|"
+
+// Edge is the traffic from a resource to another in a bucket.
+// Resources are identified by their "namespace/type/name" key.
+type Edge struct {
+	Source   string
+	Target   string
+	Verdict  string
+	Protocol string
+}
+
+// Field returns the hash field of the metric of the edge
+func (e Edge) Field(metric string) string {
+	return strings.Join([]string{e.Source, e.Target, e.Verdict, e.Protocol, metric}, fieldSeparator)
+}
+
+func parseField(field string) (Edge, string, bool) {
+	parts := strings.Split(field, fieldSeparator)
+	if len(parts) != 5 {
+		return Edge{}, "", false
+	}
+	return Edge{Source: parts[0], Target: parts[1], Verdict: parts[2], Protocol: parts[3]}, parts[4], true
+}
+
+// BucketKey returns the key of the bucket of the namespace holding the time
+func BucketKey(namespace string, t time.Time) string {
+	return fmt.Sprintf(constants.GraphKeyPattern, namespace, t.Truncate(BucketSize).Unix())
+}
+
+// BucketKeys returns the keys of the buckets of the namespace in the window ending at end
+func BucketKeys(namespace string, end time.Time, window time.Duration) []string {
+	keys := make([]string, 0, window/BucketSize)
+	for t := end.Add(-window + BucketSize).Truncate(BucketSize); !t.After(end); t = t.Add(BucketSize) {
+		keys = append(keys, BucketKey(namespace, t))
+	}
+	return keys
+}
+
+// ParseWindow parses the window of a graph query, 1h by default
+func ParseWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return time.Hour, nil
+	}
+	window, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if window < BucketSize || window > Retention {
+		return 0, fmt.Errorf("window must be between %s and %s", BucketSize, Retention)
+	}
+	return window.Truncate(BucketSize), nil
+}
+
+type edgeKey struct {
+	source string
+	target string
+}
+
+type edgeStats struct {
+	edge         models.GraphEdge
+	latencySum   int64
+	latencyCount int64
+}
+
+// Build merges the buckets of the namespace into its service graph
+func Build(namespace string, buckets []map[string]int64) ([]models.GraphNode, []models.GraphEdge) {
+	stats := make(map[edgeKey]*edgeStats)
+	for _, bucket := range buckets {
+		for field, value := range bucket {
+			edge, metric, ok := parseField(field)
+			if !ok {
+				continue
+			}
+
+			key := edgeKey{source: edge.Source, target: edge.Target}
+			s, ok := stats[key]
+			if !ok {
+				s = &edgeStats{edge: models.GraphEdge{
+					Source:   edge.Source,
+					Target:   edge.Target,
+					Verdicts: make(map[string]int64),
+				}}
+				stats[key] = s
+			}
+
+			switch metric {
+			case MetricFlows:
+				s.edge.Flows += value
+				s.edge.Verdicts[edge.Verdict] += value
+			case MetricRequests:
+				s.edge.Requests += value
+				if s.edge.Protocols == nil {
+					s.edge.Protocols = make(map[string]int64)
+				}
+				s.edge.Protocols[edge.Protocol] += value
+			case MetricLatencySum:
+				s.latencySum += value
+			case MetricLatencyCount:
+				s.latencyCount += value
+			}
+		}
+	}
+
+	nodes := make(map[string]models.GraphNode)
+	edges := make([]models.GraphEdge, 0, len(stats))
+	for _, s := range stats {
+		if s.latencyCount > 0 {
+			latency := float64(s.latencySum) / float64(s.latencyCount) / float64(time.Millisecond)
+			s.edge.AvgLatencyMs = &latency
+		}
+		edges = append(edges, s.edge)
+
+		for _, id := range []string{s.edge.Source, s.edge.Target} {
+			if _, ok := nodes[id]; !ok {
+				nodes[id] = newNode(namespace, id)
+			}
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+
+	sortedNodes := make([]models.GraphNode, 0, len(nodes))
+	for _, node := range nodes {
+		sortedNodes = append(sortedNodes, node)
+	}
+	sort.Slice(sortedNodes, func(i, j int) bool {
+		return sortedNodes[i].ID < sortedNodes[j].ID
+	})
+
+	return sortedNodes, edges
+}
+
+func newNode(namespace, id string) models.GraphNode {
+	node := models.GraphNode{ID: id}
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) == 3 {
+		node.Namespace, node.Type, node.Name = parts[0], parts[1], parts[2]
+	}
+	node.External = node.Namespace != namespace
+	return node
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type GraphRequest struct {
+	Window string `form:"window"`
+}
+
+type ServiceGraph struct {
+	Window string      `json:"window"`
+	Start  time.Time   `json:"start"`
+	End    time.Time   `json:"end"`
+	Nodes  []GraphNode `json:"nodes"`
+	Edges  []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	// External is set for the resources of other namespaces
+	External bool `json:"external"`
+}
+
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Flows is the number of flows, Verdicts splits them by verdict, e.g. FORWARDED or DROPPED
+	Flows    int64            `json:"flows"`
+	Verdicts map[string]int64 `json:"verdicts"`
+	// Requests is the number of L7 requests, Protocols splits them by protocol, e.g. http or dns
+	Requests  int64            `json:"requests"`
+	Protocols map[string]int64 `json:"protocols,omitempty"`
+	// AvgLatencyMs is the average latency of the L7 responses, if any
+	AvgLatencyMs *float64 `json:"avgLatencyMs,omitempty"`
+}
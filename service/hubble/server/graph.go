@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labring/sealos/service/hubble/pkg/constants"
+	"github.com/labring/sealos/service/hubble/pkg/graph"
+	"github.com/labring/sealos/service/hubble/pkg/models"
+)
+
+func (s *Server) graphHandler(c *gin.Context) {
+	ns, ok := s.authenticate(c)
+	if !ok {
+		return
+	}
+
+	var req models.GraphRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.TrafficResponse{
+			Message: fmt.Sprintf(constants.InvalidRequestMsg, err.Error()),
+			Data:    nil,
+		})
+		return
+	}
+	window, err := graph.ParseWindow(req.Window)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TrafficResponse{
+			Message: fmt.Sprintf(constants.InvalidRequestMsg, err.Error()),
+			Data:    nil,
+		})
+		return
+	}
+
+	res, err := s.collectGraph(ns, time.Now(), window)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.TrafficResponse{
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TrafficResponse{
+		Message: constants.GetGraphSuccessMsg,
+		Data:    res,
+	})
+}
+
+// collectGraph merges the service graph buckets of the namespace in the window ending at end
+func (s *Server) collectGraph(namespace string, end time.Time, window time.Duration) (*models.ServiceGraph, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	buckets, err := s.dataStore.GetHashes(ctx, graph.BucketKeys(namespace, end, window)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service graph: %w", err)
+	}
+
+	nodes, edges := graph.Build(namespace, buckets)
+	return &models.ServiceGraph{
+		Window: window.String(),
+		Start:  end.Add(-window),
+		End:    end,
+		Nodes:  nodes,
+		Edges:  edges,
+	}, nil
+}
@@ -17,10 +17,10 @@ import (
 type Server struct {
 	Router    *gin.Engine
 	auth      auth.Authenticator
-	dataStore *datastore.DataStore
+	dataStore datastore.DataStore
 }
 
-func NewServer(dataStore *datastore.DataStore, whiteList string) *Server {
+func NewServer(dataStore datastore.DataStore, whiteList string) *Server {
 	server := &Server{
 		Router:    gin.Default(),
 		dataStore: dataStore,
@@ -34,17 +34,20 @@ func (s *Server) setupRoutes() {
 	api := s.Router.Group(constants.APIBasePath)
 	{
 		api.POST(constants.FlowsPath, s.flowsHandler)
+		api.GET(constants.GraphPath, s.graphHandler)
 	}
 }
 
-func (s *Server) flowsHandler(c *gin.Context) {
+// authenticate returns the namespace of the kubeconfig of the request,
+// the response is written if it fails
+func (s *Server) authenticate(c *gin.Context) (string, bool) {
 	kc := c.GetHeader(constants.KubeConfig)
 	if kc == "" {
 		c.JSON(http.StatusBadRequest, models.TrafficResponse{
 			Message: constants.MissingKCMsg,
 			Data:    nil,
 		})
-		return
+		return "", false
 	}
 	kubeConfig, err := url.QueryUnescape(kc)
 	if err != nil {
@@ -52,7 +55,7 @@ func (s *Server) flowsHandler(c *gin.Context) {
 			Message: constants.DecodingKCFailedMsg,
 			Data:    nil,
 		})
-		return
+		return "", false
 	}
 	ns, err := s.auth.Authenticate(context.Background(), "", kubeConfig)
 	if err != nil {
@@ -60,6 +63,14 @@ func (s *Server) flowsHandler(c *gin.Context) {
 			Message: err.Error(),
 			Data:    nil,
 		})
+		return "", false
+	}
+	return ns, true
+}
+
+func (s *Server) flowsHandler(c *gin.Context) {
+	ns, ok := s.authenticate(c)
+	if !ok {
 		return
 	}
 
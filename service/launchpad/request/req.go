@@ -11,6 +11,8 @@ import (
 	"strings"
 
 	"github.com/labring/sealos/service/pkg/api"
+	"github.com/labring/sealos/service/pkg/cache"
+	"github.com/labring/sealos/service/pkg/request"
 )
 
 func Request(addr string, params *bytes.Buffer) ([]byte, error) {
@@ -62,8 +64,18 @@ func getPodName(str string) string {
 	return firstPart
 }
 
-func VMNew(query *api.VMRequest) ([]byte, error) {
-	result, _ := GetQuery(query)
+// VMNew queries the launchpad metric, through the query cache
+func VMNew(qc *cache.QueryCache, query *api.VMRequest) ([]byte, error) {
+	result, err := GetQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The launchpad and pvc names are user input and must not escape the namespace
+	result, err = request.EnforceNamespace(result, query.NS)
+	if err != nil {
+		return nil, err
+	}
 
 	formData := url.Values{}
 	formData.Set("query", result)
@@ -74,7 +86,6 @@ func VMNew(query *api.VMRequest) ([]byte, error) {
 	} else if query.Range.Time != "" {
 		formData.Set("time", query.Range.Time)
 	}
-	bf := bytes.NewBufferString(formData.Encode())
 
 	vmHost := GetVMServerFromEnv()
 
@@ -82,10 +93,14 @@ func VMNew(query *api.VMRequest) ([]byte, error) {
 		return nil, api.ErrNoVMHost
 	}
 
+	path := "/api/v1/query_range"
 	if len(formData.Get("start")) == 0 {
-		return Request(vmHost+"/api/v1/query", bf)
+		path = "/api/v1/query"
 	}
-	return Request(vmHost+"/api/v1/query_range", bf)
+
+	return qc.Do(path, formData, func(form url.Values) ([]byte, error) {
+		return Request(vmHost+path, bytes.NewBufferString(form.Encode()))
+	})
 }
 
 func GetVMServerFromEnv() string {
@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,15 +13,26 @@ import (
 
 	"github.com/labring/sealos/service/launchpad/request"
 	"github.com/labring/sealos/service/pkg/api"
+	"github.com/labring/sealos/service/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type VMServer struct {
-	Config *Config
+	Config  *Config
+	Cache   *cache.QueryCache
+	Metrics http.Handler
 }
 
 func NewVMServer(c *Config) (*VMServer, error) {
+	qc, err := cache.NewFromConfig("victoria_metrics", c.Cache)
+	if err != nil {
+		return nil, err
+	}
+
 	vs := &VMServer{
-		Config: c,
+		Config:  c,
+		Cache:   qc,
+		Metrics: promhttp.Handler(),
 	}
 	return vs, nil
 }
@@ -30,7 +42,7 @@ func (vs *VMServer) Authenticate(vr *api.VMRequest) error {
 }
 
 func (vs *VMServer) DBReq(vr *api.VMRequest) (*api.LaunchpadQueryResult, error) {
-	body, err := request.VMNew(vr)
+	body, err := request.VMNew(vs.Cache, vr)
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +106,8 @@ func (vs *VMServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	switch {
 	case req.URL.Path == pathPrefix+"/query":
 		vs.doReqNew(rw, req)
+	case req.URL.Path == pathPrefix+"/metrics":
+		vs.Metrics.ServeHTTP(rw, req)
 	default:
 		http.Error(rw, "Not found", http.StatusNotFound)
 		return
@@ -115,6 +129,11 @@ func (vs *VMServer) doReqNew(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	res, err := vs.DBReq(vr)
+	if errors.Is(err, api.ErrInvalidQuery) || errors.Is(err, api.ErrLabelConflict) {
+		http.Error(rw, fmt.Sprintf("Bad request (%s)", err), http.StatusBadRequest)
+		log.Printf("Bad request (%s)\n", err)
+		return
+	}
 	if err != nil {
 		http.Error(rw, fmt.Sprintf("Query failed (%s)", err), http.StatusInternalServerError)
 		log.Printf("Query failed (%s)\n", err)
@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+// Results of a cache lookup
+const (
+	ResultHit       = "hit"
+	ResultMiss      = "miss"
+	ResultCoalesced = "coalesced"
+)
+
+var (
+	Requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sealos_monitor_query_cache_requests_total",
+		Help: "Queries served by the query cache by result",
+	}, []string{"cache", "result"})
+
+	Entries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sealos_monitor_query_cache_entries",
+		Help: "Query results held by the query cache",
+	}, []string{"cache"})
+)
+
+// Options of a query cache
+type Options struct {
+	// MaxEntries is the number of results kept, the least recently used are evicted first
+	MaxEntries int
+	// MinTTL and MaxTTL bound the time a result is kept, which is the step of range queries
+	MinTTL time.Duration
+	MaxTTL time.Duration
+}
+
+// QueryCache caches the responses of Prometheus compatible query APIs and
+// coalesces concurrent identical queries into a single upstream request.
+// A nil *QueryCache passes every query through.
+type QueryCache struct {
+	name    string
+	options Options
+	now     func() time.Time
+	group   singleflight.Group
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// New creates a query cache, name labels its metrics
+func New(name string, options Options) *QueryCache {
+	return &QueryCache{
+		name:    name,
+		options: options,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Do aligns the time range of the query form to its step and returns the cached
+// response of the query, calling fetch with the aligned form on a miss. Errors
+// are not cached.
+func (c *QueryCache) Do(path string, form url.Values, fetch func(url.Values) ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return fetch(form)
+	}
+
+	ttl := c.align(form)
+	key := path + "?" + form.Encode()
+
+	if body, ok := c.get(key); ok {
+		Requests.WithLabelValues(c.name, ResultHit).Inc()
+		return body, nil
+	}
+
+	// The callback only runs for the first of concurrent identical queries
+	result := ResultCoalesced
+	body, err, _ := c.group.Do(key, func() (any, error) {
+		result = ResultMiss
+		body, err := fetch(form)
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, body, ttl)
+		return body, nil
+	})
+
+	Requests.WithLabelValues(c.name, result).Inc()
+
+	if err != nil {
+		return nil, err
+	}
+	return body.([]byte), nil
+}
+
+// align rounds the start and end of range queries down to a multiple of the step,
+// and the time of instant queries down to the minimum TTL, so that the queries of
+// dashboards refreshed at different moments share their results. It returns the
+// TTL of the result.
+func (c *QueryCache) align(form url.Values) time.Duration {
+	if form.Get("start") == "" {
+		ttl := c.options.MinTTL
+		if t, ok := parseTime(form.Get("time")); ok && ttl > 0 {
+			form.Set("time", formatTime(floor(t, ttl.Seconds())))
+		}
+		return ttl
+	}
+
+	step, ok := parseStep(form.Get("step"))
+	if !ok || step <= 0 {
+		return c.options.MinTTL
+	}
+
+	if start, ok := parseTime(form.Get("start")); ok {
+		form.Set("start", formatTime(floor(start, step)))
+	}
+	if end, ok := parseTime(form.Get("end")); ok {
+		form.Set("end", formatTime(floor(end, step)))
+	}
+
+	ttl := time.Duration(step * float64(time.Second))
+	if ttl < c.options.MinTTL {
+		ttl = c.options.MinTTL
+	}
+	if c.options.MaxTTL > 0 && ttl > c.options.MaxTTL {
+		ttl = c.options.MaxTTL
+	}
+	return ttl
+}
+
+func (c *QueryCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	if c.now().After(e.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return e.body, true
+}
+
+func (c *QueryCache) set(key string, body []byte, ttl time.Duration) {
+	if ttl <= 0 || c.options.MaxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	for c.lru.Len() >= c.options.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{
+		key:     key,
+		body:    body,
+		expires: c.now().Add(ttl),
+	})
+	Entries.WithLabelValues(c.name).Set(float64(c.lru.Len()))
+}
+
+func (c *QueryCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*entry).key)
+	Entries.WithLabelValues(c.name).Set(float64(c.lru.Len()))
+}
+
+// parseTime parses a unix timestamp or a RFC3339 time in seconds
+func parseTime(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, true
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, false
+	}
+	return float64(t.UnixNano()) / float64(time.Second), true
+}
+
+// parseStep parses a step given as a duration or as seconds
+func parseStep(s string) (float64, bool) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, true
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false
+	}
+	return d.Seconds(), true
+}
+
+func floor(v, step float64) float64 {
+	return math.Floor(v/step) * step
+}
+
+func formatTime(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Config of a query cache in the config file of a monitor
+type Config struct {
+	Disabled   bool   `yaml:"disabled"`
+	MaxEntries int    `yaml:"maxEntries"`
+	MinTTL     string `yaml:"minTTL"`
+	MaxTTL     string `yaml:"maxTTL"`
+}
+
+// NewFromConfig creates a query cache from the config, it returns nil if the cache is disabled.
+// The cache keeps 1000 results for 5s to 5m by default.
+func NewFromConfig(name string, c Config) (*QueryCache, error) {
+	if c.Disabled {
+		return nil, nil
+	}
+
+	options := Options{
+		MaxEntries: c.MaxEntries,
+		MinTTL:     5 * time.Second,
+		MaxTTL:     5 * time.Minute,
+	}
+	if options.MaxEntries == 0 {
+		options.MaxEntries = 1000
+	}
+
+	var err error
+	if c.MinTTL != "" {
+		if options.MinTTL, err = time.ParseDuration(c.MinTTL); err != nil {
+			return nil, fmt.Errorf("invalid cache minTTL: %s", err)
+		}
+	}
+	if c.MaxTTL != "" {
+		if options.MaxTTL, err = time.ParseDuration(c.MaxTTL); err != nil {
+			return nil, fmt.Errorf("invalid cache maxTTL: %s", err)
+		}
+	}
+	if options.MaxEntries < 0 || options.MinTTL < 0 || options.MaxTTL < options.MinTTL {
+		return nil, fmt.Errorf("invalid cache config: %+v", c)
+	}
+
+	return New(name, options), nil
+}
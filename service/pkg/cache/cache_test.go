@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAlign(t *testing.T) {
+	c := New("test", Options{MaxEntries: 10, MinTTL: 5 * time.Second, MaxTTL: time.Minute})
+
+	tests := []struct {
+		name    string
+		form    url.Values
+		want    url.Values
+		wantTTL time.Duration
+	}{
+		{
+			name:    "range",
+			form:    url.Values{"start": {"1700000017"}, "end": {"1700003617.5"}, "step": {"30s"}},
+			want:    url.Values{"start": {"1700000010"}, "end": {"1700003610"}, "step": {"30s"}},
+			wantTTL: 30 * time.Second,
+		},
+		{
+			name:    "step in seconds bounded by max TTL",
+			form:    url.Values{"start": {"1700000017"}, "end": {"1700003617"}, "step": {"300"}},
+			want:    url.Values{"start": {"1699999800"}, "end": {"1700003400"}, "step": {"300"}},
+			wantTTL: time.Minute,
+		},
+		{
+			name:    "RFC3339 bounded by min TTL",
+			form:    url.Values{"start": {"2024-01-01T00:00:01Z"}, "end": {"2024-01-01T01:00:01Z"}, "step": {"1s"}},
+			want:    url.Values{"start": {"1704067201"}, "end": {"1704070801"}, "step": {"1s"}},
+			wantTTL: 5 * time.Second,
+		},
+		{
+			name:    "instant",
+			form:    url.Values{"time": {"1700000017"}},
+			want:    url.Values{"time": {"1700000015"}},
+			wantTTL: 5 * time.Second,
+		},
+		{
+			name:    "invalid step",
+			form:    url.Values{"start": {"1700000017"}, "step": {"1d"}},
+			want:    url.Values{"start": {"1700000017"}, "step": {"1d"}},
+			wantTTL: 5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ttl := c.align(tt.form); ttl != tt.wantTTL {
+				t.Errorf("align() = %v, want %v", ttl, tt.wantTTL)
+			}
+			if tt.form.Encode() != tt.want.Encode() {
+				t.Errorf("align() form = %v, want %v", tt.form, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	c := New("test", Options{MaxEntries: 1, MinTTL: 5 * time.Second, MaxTTL: time.Minute})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var calls int
+	fetch := func(form url.Values) ([]byte, error) {
+		calls++
+		return []byte(form.Get("query")), nil
+	}
+	query := func(q string) url.Values {
+		return url.Values{"query": {q}, "start": {"1700000017"}, "end": {"1700003617"}, "step": {"60"}}
+	}
+
+	for range 2 {
+		if body, err := c.Do("/api/v1/query_range", query("up"), fetch); err != nil || string(body) != "up" {
+			t.Fatalf("Do() = %s, %v", body, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fetch calls = %d, want 1", calls)
+	}
+
+	// Expired results are fetched again
+	now = now.Add(61 * time.Second)
+	_, _ = c.Do("/api/v1/query_range", query("up"), fetch)
+	if calls != 2 {
+		t.Errorf("fetch calls = %d, want 2", calls)
+	}
+
+	// The least recently used result is evicted
+	_, _ = c.Do("/api/v1/query_range", query("down"), fetch)
+	_, _ = c.Do("/api/v1/query_range", query("up"), fetch)
+	if calls != 4 {
+		t.Errorf("fetch calls = %d, want 4", calls)
+	}
+
+	// Errors are not cached
+	failed := errors.New("failed")
+	fail := func(url.Values) ([]byte, error) {
+		calls++
+		return nil, failed
+	}
+	for range 2 {
+		if _, err := c.Do("/api/v1/query", url.Values{"query": {"err"}}, fail); !errors.Is(err, failed) {
+			t.Errorf("Do() error = %v, want %v", err, failed)
+		}
+	}
+	if calls != 6 {
+		t.Errorf("fetch calls = %d, want 6", calls)
+	}
+}
+
+func TestDoCoalesce(t *testing.T) {
+	c := New("test", Options{MaxEntries: 10, MinTTL: 5 * time.Second, MaxTTL: time.Minute})
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(url.Values) ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte("ok"), nil
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := c.Do("/api/v1/query", url.Values{"query": {"up"}}, fetch); err != nil || string(body) != "ok" {
+				t.Errorf("Do() = %s, %v", body, err)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("fetch calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *QueryCache
+
+	var calls int
+	fetch := func(url.Values) ([]byte, error) {
+		calls++
+		return nil, nil
+	}
+	_, _ = c.Do("/api/v1/query", url.Values{}, fetch)
+	_, _ = c.Do("/api/v1/query", url.Values{}, fetch)
+
+	if calls != 2 {
+		t.Errorf("fetch calls = %d, want 2", calls)
+	}
+}
@@ -0,0 +1,131 @@
+package catalog
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VictoriaMetrics/metricsql"
+	"gopkg.in/yaml.v2"
+)
+
+// Version is the version of the catalog format supported by this package
+const Version = "v1"
+
+// Placeholders of the query templates
+const (
+	NamespacePlaceholder             = "$namespace"
+	AppPlaceholder                   = "$app"
+	ObjectStorageInstancePlaceholder = "$objectStorageInstance"
+)
+
+var (
+	ErrUnknownEngine = errors.New("unknown database type")
+	ErrUnknownMetric = errors.New("unknown metric")
+)
+
+// defaultCatalog is the catalog used if none is configured
+//
+//go:embed catalog.yaml
+var defaultCatalog []byte
+
+// Catalog is the set of metrics the monitor can query, by database engine
+type Catalog struct {
+	Version string            `yaml:"version" json:"version"`
+	Engines map[string]Engine `yaml:"engines" json:"engines"`
+}
+
+// Engine is a database engine and its metrics
+type Engine struct {
+	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Metrics     map[string]Metric `yaml:"metrics" json:"metrics"`
+}
+
+// Metric is a PromQL query template
+type Metric struct {
+	Query       string `yaml:"query" json:"query"`
+	Unit        string `yaml:"unit,omitempty" json:"unit,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// Vars are the values of the placeholders
+type Vars struct {
+	Namespace             string
+	App                   string
+	ObjectStorageInstance string
+}
+
+// Default returns the catalog shipped with the monitor
+func Default() *Catalog {
+	c, err := Parse(defaultCatalog)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default catalog: %v", err))
+	}
+	return c
+}
+
+// Load reads and parses the catalog file
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates a catalog
+func Parse(data []byte) (*Catalog, error) {
+	c := &Catalog{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("could not parse catalog: %w", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Catalog) validate() error {
+	if c.Version != Version {
+		return fmt.Errorf("unsupported catalog version %q, expected %q", c.Version, Version)
+	}
+
+	// The templates are checked with placeholder values so that a broken
+	// catalog is rejected when it is loaded rather than when it is queried
+	vars := Vars{Namespace: "ns", App: "app", ObjectStorageInstance: "instance"}
+	for engine, e := range c.Engines {
+		for name, m := range e.Metrics {
+			if m.Query == "" {
+				return fmt.Errorf("metric %s/%s: empty query", engine, name)
+			}
+			if _, err := metricsql.Parse(m.Render(vars)); err != nil {
+				return fmt.Errorf("metric %s/%s: invalid query: %w", engine, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Query returns the query of the metric of the engine with the placeholders replaced
+func (c *Catalog) Query(engine, metric string, vars Vars) (string, error) {
+	e, ok := c.Engines[engine]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownEngine, engine)
+	}
+	m, ok := e.Metrics[metric]
+	if !ok {
+		return "", fmt.Errorf("%w: %s/%s", ErrUnknownMetric, engine, metric)
+	}
+	return m.Render(vars), nil
+}
+
+// Render replaces the placeholders of the query template
+func (m Metric) Render(vars Vars) string {
+	return strings.NewReplacer(
+		ObjectStorageInstancePlaceholder, vars.ObjectStorageInstance,
+		NamespacePlaceholder, vars.Namespace,
+		AppPlaceholder, vars.App,
+	).Replace(m.Query)
+}
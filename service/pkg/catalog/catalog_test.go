@@ -0,0 +1,116 @@
+package catalog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+
+	for _, engine := range []string{"apecloud-mysql", "postgresql", "mongodb", "redis", "minio", "kafka", "milvus"} {
+		if len(c.Engines[engine].Metrics) == 0 {
+			t.Errorf("Default() has no metrics for %s", engine)
+		}
+	}
+
+	got, err := c.Query("redis", "uptime", Vars{Namespace: "ns-a", App: "cache"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := `redis_uptime_in_seconds{namespace=~"ns-a", app_kubernetes_io_instance=~"cache"}`
+	if got != want {
+		t.Errorf("Query() = %v, want %v", got, want)
+	}
+
+	got, _ = c.Query("minio", "minio_bucket_usage_total_bytes", Vars{App: "bucket", ObjectStorageInstance: "minio:9000"})
+	want = `minio_bucket_usage_total_bytes{bucket="bucket", instance="minio:9000"}`
+	if got != want {
+		t.Errorf("Query() = %v, want %v", got, want)
+	}
+
+	if _, err := c.Query("oracle", "cpu", Vars{}); !errors.Is(err, ErrUnknownEngine) {
+		t.Errorf("Query() error = %v, want %v", err, ErrUnknownEngine)
+	}
+	if _, err := c.Query("redis", "qps", Vars{}); !errors.Is(err, ErrUnknownMetric) {
+		t.Errorf("Query() error = %v, want %v", err, ErrUnknownMetric)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			data: "version: v1\nengines:\n  tidb:\n    metrics:\n      up:\n        query: up{namespace=\"$namespace\"}\n",
+		},
+		{
+			name:    "unsupported version",
+			data:    "version: v2\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			data:    "version: v1\nengine: {}\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty query",
+			data:    "version: v1\nengines:\n  tidb:\n    metrics:\n      up: {}\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid query",
+			data:    "version: v1\nengines:\n  tidb:\n    metrics:\n      up:\n        query: sum(up\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.yaml")
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("version: v1\nengines:\n  tidb:\n    metrics:\n      up:\n        query: up\n")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	if changed, err := s.reload(); changed || err != nil {
+		t.Errorf("reload() = %v, %v, want no change", changed, err)
+	}
+
+	write("version: v1\nengines:\n  tidb:\n    metrics:\n      down:\n        query: down\n")
+	if changed, err := s.reload(); !changed || err != nil {
+		t.Fatalf("reload() = %v, %v, want change", changed, err)
+	}
+	if _, ok := s.Get().Engines["tidb"].Metrics["down"]; !ok {
+		t.Errorf("Get() = %v, want the reloaded catalog", s.Get())
+	}
+
+	// A broken catalog keeps the previous one
+	write("version: v1\nengines:\n  tidb:\n    metrics:\n      down:\n        query: sum(\n")
+	if _, err := s.reload(); err == nil {
+		t.Error("reload() expected error")
+	}
+	if _, ok := s.Get().Engines["tidb"].Metrics["down"]; !ok {
+		t.Errorf("Get() = %v, want the previous catalog", s.Get())
+	}
+}
@@ -0,0 +1,89 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Store holds the current catalog and reloads it when its file changes
+type Store struct {
+	path    string
+	data    []byte
+	catalog atomic.Pointer[Catalog]
+}
+
+// NewStore loads the catalog file, the default catalog is used if path is empty
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	if path == "" {
+		s.catalog.Store(Default())
+		return s, nil
+	}
+
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the current catalog
+func (s *Store) Get() *Catalog {
+	return s.catalog.Load()
+}
+
+// Watch reloads the catalog every interval until the context is done.
+// A catalog which fails to load is logged and the previous one is kept.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := s.reload()
+		if err != nil {
+			log.Printf("Failed to reload metric catalog (%s)\n", err)
+			continue
+		}
+		if changed {
+			log.Printf("Reloaded metric catalog %s\n", s.path)
+		}
+	}
+}
+
+// reload loads the catalog file if its content changed since the last load
+func (s *Store) reload() (bool, error) {
+	// The content is compared rather than the modification time since
+	// ConfigMap volumes are updated by swapping symlinks
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	if s.data != nil && bytes.Equal(data, s.data) {
+		return false, nil
+	}
+
+	// A broken content is only reported once, until the file changes again
+	s.data = data
+
+	c, err := Parse(data)
+	if err != nil {
+		return false, err
+	}
+
+	s.catalog.Store(c)
+	return true, nil
+}
@@ -0,0 +1,111 @@
+package request
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/VictoriaMetrics/metricsql"
+	"github.com/labring/sealos/service/pkg/api"
+)
+
+const (
+	// NamespaceLabel is the label isolating the series of tenants
+	NamespaceLabel = "namespace"
+	// BucketLabel is the label isolating the series of object storage buckets
+	BucketLabel = "bucket"
+)
+
+// legacyNamespacePlaceholder matches the "$" placeholder used by the deprecated
+// /query API to stand for the namespace matcher inside curly braces
+var legacyNamespacePlaceholder = regexp.MustCompile(`([{,]\s*)\$(\s*[,}])`)
+
+// EnforceNamespace rewrites the query so that every vector selector only matches
+// the series of the namespace, see EnforceLabel
+func EnforceNamespace(query, ns string) (string, error) {
+	return EnforceLabel(query, NamespaceLabel, ns)
+}
+
+// EnforceBucket rewrites the query so that every vector selector only matches the
+// series of the bucket. Buckets of a namespace are named after the user, "ns-abc"
+// owns the "abc-*" buckets, other buckets are rejected with api.ErrLabelConflict.
+func EnforceBucket(query, ns, bucket string) (string, error) {
+	if !strings.HasPrefix(bucket, strings.TrimPrefix(ns, "ns-")+"-") {
+		return "", fmt.Errorf("%w: bucket %q", api.ErrLabelConflict, bucket)
+	}
+
+	return EnforceLabel(query, BucketLabel, bucket)
+}
+
+// EnforceLabel parses the query and adds the label="value" matcher to every vector
+// selector, the same way as prom-label-proxy does. Selectors already matching the
+// label are rejected with api.ErrLabelConflict unless they select exactly the value.
+func EnforceLabel(query, label, value string) (string, error) {
+	expr, err := metricsql.Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", api.ErrInvalidQuery, err)
+	}
+
+	enforced := metricsql.LabelFilter{
+		Label: label,
+		Value: value,
+	}
+
+	metricsql.VisitAll(expr, func(e metricsql.Expr) {
+		me, ok := e.(*metricsql.MetricExpr)
+		if !ok || err != nil {
+			return
+		}
+
+		if len(me.LabelFilterss) == 0 {
+			me.LabelFilterss = [][]metricsql.LabelFilter{{enforced}}
+			return
+		}
+
+		// Every "or" group of the selector is a selector of its own
+		for i, lfs := range me.LabelFilterss {
+			if me.LabelFilterss[i], err = enforceLabelFilter(lfs, enforced); err != nil {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(expr.AppendString(nil)), nil
+}
+
+// enforceLabelFilter replaces the matchers of the enforced label by the enforced one
+func enforceLabelFilter(lfs []metricsql.LabelFilter, enforced metricsql.LabelFilter) ([]metricsql.LabelFilter, error) {
+	result := make([]metricsql.LabelFilter, 0, len(lfs)+1)
+
+	for _, lf := range lfs {
+		if lf.Label != enforced.Label {
+			result = append(result, lf)
+			continue
+		}
+
+		// namespace=~"ns" is accepted as long as it can only match ns
+		if lf.IsNegative || lf.Value != enforced.Value ||
+			(lf.IsRegexp && regexp.QuoteMeta(lf.Value) != lf.Value) {
+			return nil, fmt.Errorf("%w: %s", api.ErrLabelConflict, lf.AppendString(nil))
+		}
+	}
+
+	return append(result, enforced), nil
+}
+
+// replaceLegacyPlaceholder replaces the "$" placeholder of the deprecated /query API,
+// e.g. up{$} or up{$, job="x"}, by the namespace matcher
+func replaceLegacyPlaceholder(query, ns string) string {
+	matcher := metricsql.LabelFilter{
+		Label: NamespaceLabel,
+		Value: ns,
+	}
+
+	// "$" must be escaped in the replacement template
+	replacement := strings.ReplaceAll(string(matcher.AppendString(nil)), "$", "$$")
+
+	return legacyNamespacePlaceholder.ReplaceAllString(query, "${1}"+replacement+"${2}")
+}
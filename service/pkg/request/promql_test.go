@@ -0,0 +1,186 @@
+package request
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/labring/sealos/service/pkg/api"
+	"github.com/labring/sealos/service/pkg/catalog"
+)
+
+func TestEnforceNamespace(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "metric name only",
+			query: "up",
+			want:  `up{namespace="ns-a"}`,
+		},
+		{
+			name:  "existing matchers",
+			query: `up{job="kubelet"}`,
+			want:  `up{job="kubelet",namespace="ns-a"}`,
+		},
+		{
+			name:  "same namespace",
+			query: `up{namespace="ns-a"}`,
+			want:  `up{namespace="ns-a"}`,
+		},
+		{
+			name:  "literal regexp of the namespace",
+			query: `up{namespace=~"ns-a"}`,
+			want:  `up{namespace="ns-a"}`,
+		},
+		{
+			name:  "nested selectors",
+			query: `sum(rate(a[1m])) by (pod) / sum(b{pod="x"}) by (pod)`,
+			want:  `sum(rate(a{namespace="ns-a"}[1m])) by(pod) / sum(b{pod="x",namespace="ns-a"}) by(pod)`,
+		},
+		{
+			name:  "or groups",
+			query: `up{job="a" or job="b"}`,
+			want:  `up{job="a",namespace="ns-a" or job="b",namespace="ns-a"}`,
+		},
+		{
+			name:    "another namespace",
+			query:   `up{namespace="ns-b"}`,
+			wantErr: api.ErrLabelConflict,
+		},
+		{
+			name:    "namespace regexp",
+			query:   `up{namespace=~"ns-.*"}`,
+			wantErr: api.ErrLabelConflict,
+		},
+		{
+			name:    "negative namespace matcher",
+			query:   `up{namespace!="ns-b"}`,
+			wantErr: api.ErrLabelConflict,
+		},
+		{
+			name:    "conflict in a nested selector",
+			query:   `up or on() sum(up{namespace="ns-b"})`,
+			wantErr: api.ErrLabelConflict,
+		},
+		{
+			name:    "invalid query",
+			query:   `up{namespace="ns-a"`,
+			wantErr: api.ErrInvalidQuery,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EnforceNamespace(tt.query, "ns-a")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EnforceNamespace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EnforceNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnforceNamespace_Catalog(t *testing.T) {
+	c := catalog.Default()
+
+	// The injected app name must not add selectors outside of the namespace
+	query, err := GetQuery(c, &api.PromRequest{
+		NS:      "ns-a",
+		Type:    "redis",
+		Query:   "uptime",
+		Cluster: `x"} or redis_uptime_in_seconds{app_kubernetes_io_instance=~"`,
+	})
+	if err != nil {
+		t.Fatalf("GetQuery() error = %v", err)
+	}
+
+	got, err := EnforceNamespace(query, "ns-a")
+	if err != nil {
+		t.Fatalf("EnforceNamespace() error = %v", err)
+	}
+	want := `redis_uptime_in_seconds{app_kubernetes_io_instance=~"x",namespace="ns-a"} or redis_uptime_in_seconds{app_kubernetes_io_instance=~"",namespace="ns-a"}`
+	if got != want {
+		t.Errorf("EnforceNamespace() = %v, want %v", got, want)
+	}
+
+	for typ, engine := range c.Engines {
+		app := "db"
+		enforce := func(query string) (string, error) { return EnforceNamespace(query, "ns-a") }
+		if typ == "minio" {
+			app = "a-db"
+			enforce = func(query string) (string, error) { return EnforceBucket(query, "ns-a", app) }
+		}
+
+		for name := range engine.Metrics {
+			query, err := GetQuery(c, &api.PromRequest{NS: "ns-a", Type: typ, Query: name, Cluster: app})
+			if err != nil {
+				t.Fatalf("GetQuery(%s/%s) error = %v", typ, name, err)
+			}
+			if _, err := enforce(query); err != nil {
+				t.Errorf("enforce(%s/%s) error = %v", typ, name, err)
+			}
+		}
+	}
+}
+
+func TestEnforceBucket(t *testing.T) {
+	tests := []struct {
+		name    string
+		bucket  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "own bucket",
+			bucket: "abc-db",
+			want:   `minio_bucket_usage_total_bytes{bucket="abc-db"}`,
+		},
+		{
+			name:    "bucket of another tenant",
+			bucket:  "xyz-db",
+			wantErr: api.ErrLabelConflict,
+		},
+		{
+			name:    "bucket of a tenant sharing the prefix",
+			bucket:  "abcd-db",
+			wantErr: api.ErrLabelConflict,
+		},
+		{
+			name:    "empty bucket",
+			bucket:  "",
+			wantErr: api.ErrLabelConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EnforceBucket("minio_bucket_usage_total_bytes", "ns-abc", tt.bucket)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EnforceBucket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EnforceBucket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceLegacyPlaceholder(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: `up{$}`, want: `up{namespace="ns-a"}`},
+		{query: `up{$, job="x"}`, want: `up{namespace="ns-a", job="x"}`},
+		{query: `up{job="x",$}`, want: `up{job="x",namespace="ns-a"}`},
+		{query: `label_replace(up, "a", "$1", "b", "(.*)")`, want: `label_replace(up, "a", "$1", "b", "(.*)")`},
+	}
+	for _, tt := range tests {
+		if got := replaceLegacyPlaceholder(tt.query, "ns-a"); got != tt.want {
+			t.Errorf("replaceLegacyPlaceholder(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
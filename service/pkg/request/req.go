@@ -8,9 +8,10 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"strings"
 
 	"github.com/labring/sealos/service/pkg/api"
+	"github.com/labring/sealos/service/pkg/cache"
+	"github.com/labring/sealos/service/pkg/catalog"
 )
 
 func Request(addr string, params *bytes.Buffer) ([]byte, error) {
@@ -35,8 +36,10 @@ func Request(addr string, params *bytes.Buffer) ([]byte, error) {
 }
 
 func PrometheusPre(query *api.PromRequest) ([]byte, error) {
-	result := strings.ReplaceAll(query.Query, "$", "namespace=~\""+query.NS+"\"")
-	result = strings.ReplaceAll(result, "{", "{namespace=~\""+query.NS+"\",")
+	result, err := EnforceNamespace(replaceLegacyPlaceholder(query.Query, query.NS), query.NS)
+	if err != nil {
+		return nil, err
+	}
 	log.Println(result)
 
 	formData := url.Values{}
@@ -64,39 +67,31 @@ func PrometheusPre(query *api.PromRequest) ([]byte, error) {
 	return Request(prometheusHost+"/api/v1/query_range", bf)
 }
 
-func GetQuery(query *api.PromRequest) (string, error) {
-	var result string
-	switch query.Type {
-	case "apecloud-mysql":
-		result = api.Mysql[query.Query]
-	case "redis":
-		result = api.Redis[query.Query]
-	case "mongodb":
-		result = api.Mongo[query.Query]
-	case "postgresql":
-		result = api.Pgsql[query.Query]
-	case "minio":
-		result = api.Minio[query.Query]
-	case "kafka":
-		result = api.Kafka[query.Query]
-	case "milvus":
-		result = api.Milvus[query.Query]
-	default:
-		fmt.Println(query.Type)
+// GetQuery renders the query of the requested metric from the catalog
+func GetQuery(c *catalog.Catalog, query *api.PromRequest) (string, error) {
+	return c.Query(query.Type, query.Query, catalog.Vars{
+		Namespace:             query.NS,
+		App:                   query.Cluster,
+		ObjectStorageInstance: os.Getenv("OBJECT_STORAGE_INSTANCE"),
+	})
+}
+
+// PrometheusNew queries the metric of the catalog, through the query cache
+func PrometheusNew(c *catalog.Catalog, qc *cache.QueryCache, query *api.PromRequest) ([]byte, error) {
+	result, err := GetQuery(c, query)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println(query.Cluster)
+	// Object storage series belong to the shared instance and are isolated by bucket
 	if query.Type == "minio" {
-		instance := os.Getenv("OBJECT_STORAGE_INSTANCE")
-		result = strings.ReplaceAll(result, "#", instance)
+		result, err = EnforceBucket(result, query.NS, query.Cluster)
+	} else {
+		result, err = EnforceNamespace(result, query.NS)
+	}
+	if err != nil {
+		return nil, err
 	}
-	result = strings.ReplaceAll(result, "#", query.NS)
-	result = strings.ReplaceAll(result, "@", query.Cluster)
-	return result, nil
-}
-
-func PrometheusNew(query *api.PromRequest) ([]byte, error) {
-	result, _ := GetQuery(query)
 	log.Println(result)
 
 	formData := url.Values{}
@@ -108,7 +103,6 @@ func PrometheusNew(query *api.PromRequest) ([]byte, error) {
 	} else if query.Range.Time != "" {
 		formData.Set("time", query.Range.Time)
 	}
-	bf := bytes.NewBufferString(formData.Encode())
 
 	prometheusHost := GetPromServerFromEnv()
 
@@ -116,12 +110,16 @@ func PrometheusNew(query *api.PromRequest) ([]byte, error) {
 		return nil, api.ErrNoPromHost
 	}
 
-	log.Println(bf)
-
+	path := "/api/v1/query_range"
 	if len(formData.Get("start")) == 0 {
-		return Request(prometheusHost+"/api/v1/query", bf)
+		path = "/api/v1/query"
 	}
-	return Request(prometheusHost+"/api/v1/query_range", bf)
+
+	return qc.Do(path, formData, func(form url.Values) ([]byte, error) {
+		bf := bytes.NewBufferString(form.Encode())
+		log.Println(bf)
+		return Request(prometheusHost+path, bf)
+	})
 }
 
 func GetPromServerFromEnv() string {
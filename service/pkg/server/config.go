@@ -3,18 +3,43 @@ package server
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/labring/sealos/service/pkg/cache"
 	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
-	Server ServeConfig `yaml:"server"`
+	Server  ServeConfig   `yaml:"server"`
+	Catalog CatalogConfig `yaml:"catalog"`
+	Cache   cache.Config  `yaml:"cache"`
 }
 
 type ServeConfig struct {
 	ListenAddress string `yaml:"addr"`
 }
 
+// CatalogConfig locates the metric catalog, the built-in catalog is used if Path is empty
+type CatalogConfig struct {
+	Path           string `yaml:"path"`
+	ReloadInterval string `yaml:"reloadInterval"`
+}
+
+// Interval returns the reload interval of the catalog, 30s by default
+func (c CatalogConfig) Interval() (time.Duration, error) {
+	if c.ReloadInterval == "" {
+		return 30 * time.Second, nil
+	}
+	d, err := time.ParseDuration(c.ReloadInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid catalog reload interval: %s", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid catalog reload interval: %s", c.ReloadInterval)
+	}
+	return d, nil
+}
+
 func InitConfig(configPath string) (*Config, error) {
 	configData, err := os.ReadFile(configPath)
 	if err != nil {
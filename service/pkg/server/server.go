@@ -1,7 +1,9 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,16 +13,41 @@ import (
 	"github.com/labring/sealos/service/pkg/auth"
 
 	"github.com/labring/sealos/service/pkg/api"
+	"github.com/labring/sealos/service/pkg/cache"
+	"github.com/labring/sealos/service/pkg/catalog"
 	"github.com/labring/sealos/service/pkg/request"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type PromServer struct {
-	Config *Config
+	Config  *Config
+	Catalog *catalog.Store
+	Cache   *cache.QueryCache
+	Metrics http.Handler
 }
 
 func NewPromServer(c *Config) (*PromServer, error) {
+	interval, err := c.Catalog.Interval()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := catalog.NewStore(c.Catalog.Path)
+	if err != nil {
+		return nil, err
+	}
+	go store.Watch(context.Background(), interval)
+
+	qc, err := cache.NewFromConfig("prometheus", c.Cache)
+	if err != nil {
+		return nil, err
+	}
+
 	ps := &PromServer{
-		Config: c,
+		Config:  c,
+		Catalog: store,
+		Cache:   qc,
+		Metrics: promhttp.Handler(),
 	}
 	return ps, nil
 }
@@ -42,7 +69,7 @@ func (ps *PromServer) Request(pr *api.PromRequest) (*api.QueryResult, error) {
 }
 
 func (ps *PromServer) DBReq(pr *api.PromRequest) (*api.QueryResult, error) {
-	body, err := request.PrometheusNew(pr)
+	body, err := request.PrometheusNew(ps.Catalog.Get(), ps.Cache, pr)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +134,10 @@ func (ps *PromServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		ps.doReqPre(rw, req)
 	case req.URL.Path == pathPrefix+"/q":
 		ps.doReqNew(rw, req)
+	case req.URL.Path == pathPrefix+"/metrics":
+		ps.Metrics.ServeHTTP(rw, req)
+	case req.URL.Path == pathPrefix+"/metrics/catalog":
+		ps.doCatalog(rw, req)
 	default:
 		http.Error(rw, "Not found", http.StatusNotFound)
 		return
@@ -129,8 +160,7 @@ func (ps *PromServer) doReqNew(rw http.ResponseWriter, req *http.Request) {
 
 	res, err := ps.DBReq(pr)
 	if err != nil {
-		http.Error(rw, fmt.Sprintf("Query failed (%s)", err), http.StatusInternalServerError)
-		log.Printf("Query failed (%s)\n", err)
+		queryFailed(rw, err)
 		return
 	}
 
@@ -169,12 +199,12 @@ func (ps *PromServer) doReqPre(rw http.ResponseWriter, req *http.Request) {
 		http.Error(rw, fmt.Sprintf("Authentication failed (%s)", err), http.StatusInternalServerError)
 		log.Printf("Authentication failed (%s)\n", err)
 		log.Printf("Kubeconfig (%s)\n", pr.Pwd)
+		return
 	}
 
 	res, err := ps.Request(pr)
 	if err != nil {
-		http.Error(rw, fmt.Sprintf("Query failed (%s)", err), http.StatusInternalServerError)
-		log.Printf("Query failed (%s)\n", err)
+		queryFailed(rw, err)
 		return
 	}
 
@@ -200,3 +230,43 @@ func (ps *PromServer) doReqPre(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 }
+
+// doCatalog lists the metrics of the catalog, of a single database type if the type is given
+func (ps *PromServer) doCatalog(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	c := ps.Catalog.Get()
+	if typ := req.URL.Query().Get("type"); typ != "" {
+		engine, ok := c.Engines[typ]
+		if !ok {
+			http.Error(rw, fmt.Sprintf("Bad request (%s: %s)", catalog.ErrUnknownEngine, typ), http.StatusBadRequest)
+			return
+		}
+		c = &catalog.Catalog{
+			Version: c.Version,
+			Engines: map[string]catalog.Engine{typ: engine},
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(c); err != nil {
+		log.Printf("Catalog failed: %s\n", err)
+	}
+}
+
+// queryFailed reports the error of a query, unknown metrics and queries rejected
+// by the namespace enforcement are bad requests
+func queryFailed(rw http.ResponseWriter, err error) {
+	if errors.Is(err, api.ErrInvalidQuery) || errors.Is(err, api.ErrLabelConflict) ||
+		errors.Is(err, catalog.ErrUnknownEngine) || errors.Is(err, catalog.ErrUnknownMetric) {
+		http.Error(rw, fmt.Sprintf("Bad request (%s)", err), http.StatusBadRequest)
+		log.Printf("Bad request (%s)\n", err)
+		return
+	}
+
+	http.Error(rw, fmt.Sprintf("Query failed (%s)", err), http.StatusInternalServerError)
+	log.Printf("Query failed (%s)\n", err)
+}
@@ -0,0 +1,126 @@
+// Package admin provides the administrative HTTP API of the SSH gateway
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/gateway"
+	log "github.com/sirupsen/logrus"
+)
+
+// Options holds admin API configuration options
+type Options struct {
+	// ListenAddr is the address of the admin API, empty disables it
+	ListenAddr string `env:"LISTEN_ADDR"`
+	// Token is the bearer token required by every request
+	Token string `env:"TOKEN"`
+}
+
+// Enabled returns true if the admin API is served
+func (o *Options) Enabled() bool {
+	return o.ListenAddr != ""
+}
+
+// SessionManager lists and closes the live sessions of the gateway
+type SessionManager interface {
+	Sessions(namespace, devboxName string) []gateway.SessionInfo
+	KillSession(id string) bool
+}
+
+// Server serves the admin API
+type Server struct {
+	sessions SessionManager
+	token    string
+	logger   *log.Entry
+}
+
+// NewServer creates an admin API server authenticating requests with token
+func NewServer(sessions SessionManager, token string) *Server {
+	return &Server{
+		sessions: sessions,
+		token:    token,
+		logger:   log.WithField("component", "admin"),
+	}
+}
+
+// Handler returns the HTTP handler of the admin API
+//
+//	GET    /api/v1/sessions?namespace=&devbox=  lists the live sessions
+//	DELETE /api/v1/sessions/{id}                 closes a session
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/sessions", s.listSessions)
+	mux.HandleFunc("DELETE /api/v1/sessions/{id}", s.killSession)
+
+	return s.authenticate(mux)
+}
+
+// Run serves the admin API on addr
+func (s *Server) Run(addr string) error {
+	server := http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: time.Second * 5,
+	}
+
+	//nolint:noctx
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("admin API listening on %s", ln.Addr())
+
+	return server.Serve(ln)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"sessions": s.sessions.Sessions(query.Get("namespace"), query.Get("devbox")),
+	})
+}
+
+func (s *Server) killSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if !s.sessions.KillSession(id) {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	s.logger.WithFields(log.Fields{
+		"session_id":  id,
+		"remote_addr": r.RemoteAddr,
+	}).Info("Session killed through admin API")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
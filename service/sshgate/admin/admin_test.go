@@ -0,0 +1,132 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labring/sealos/service/sshgate/admin"
+	"github.com/labring/sealos/service/sshgate/gateway"
+)
+
+type fakeSessions struct {
+	sessions []gateway.SessionInfo
+	killed   []string
+}
+
+func (f *fakeSessions) Sessions(namespace, devboxName string) []gateway.SessionInfo {
+	var sessions []gateway.SessionInfo
+
+	for _, s := range f.sessions {
+		if (namespace == "" || s.Namespace == namespace) &&
+			(devboxName == "" || s.Devbox == devboxName) {
+			sessions = append(sessions, s)
+		}
+	}
+
+	return sessions
+}
+
+func (f *fakeSessions) KillSession(id string) bool {
+	for _, s := range f.sessions {
+		if s.ID == id {
+			f.killed = append(f.killed, id)
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestServer(t *testing.T) {
+	sessions := &fakeSessions{
+		sessions: []gateway.SessionInfo{
+			{ID: "a", Namespace: "ns-a", Devbox: "one"},
+			{ID: "b", Namespace: "ns-a", Devbox: "two"},
+			{ID: "c", Namespace: "ns-b", Devbox: "one"},
+		},
+	}
+
+	server := httptest.NewServer(admin.NewServer(sessions, "secret").Handler())
+	defer server.Close()
+
+	do := func(method, path, token string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantIDs    []string
+	}{
+		{"no token", http.MethodGet, "/api/v1/sessions", "", http.StatusUnauthorized, nil},
+		{"wrong token", http.MethodGet, "/api/v1/sessions", "wrong", http.StatusUnauthorized, nil},
+		{"all", http.MethodGet, "/api/v1/sessions", "secret", http.StatusOK, []string{"a", "b", "c"}},
+		{
+			"namespace", http.MethodGet, "/api/v1/sessions?namespace=ns-a", "secret",
+			http.StatusOK, []string{"a", "b"},
+		},
+		{
+			"devbox", http.MethodGet, "/api/v1/sessions?namespace=ns-a&devbox=two", "secret",
+			http.StatusOK, []string{"b"},
+		},
+		{"kill", http.MethodDelete, "/api/v1/sessions/c", "secret", http.StatusNoContent, nil},
+		{"kill unknown", http.MethodDelete, "/api/v1/sessions/x", "secret", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(tt.method, tt.path, tt.token)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			if tt.wantIDs == nil {
+				return
+			}
+
+			var body struct {
+				Sessions []gateway.SessionInfo `json:"sessions"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if len(body.Sessions) != len(tt.wantIDs) {
+				t.Fatalf("Sessions = %v, want %v", body.Sessions, tt.wantIDs)
+			}
+
+			for i, s := range body.Sessions {
+				if s.ID != tt.wantIDs[i] {
+					t.Errorf("Session %d = %s, want %s", i, s.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+
+	if len(sessions.killed) != 1 || sessions.killed[0] != "c" {
+		t.Errorf("Killed sessions = %v, want [c]", sessions.killed)
+	}
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/caarlos0/env/v9"
 	"github.com/joho/godotenv"
+	"github.com/labring/sealos/service/sshgate/admin"
 	"github.com/labring/sealos/service/sshgate/gateway"
+	"github.com/labring/sealos/service/sshgate/recorder"
 	proxyproto "github.com/pires/go-proxyproto"
 )
 
@@ -31,15 +33,30 @@ type Config struct {
 	// Informer configuration
 	InformerResyncPeriod time.Duration `env:"INFORMER_RESYNC_PERIOD" envDefault:"30s"`
 
+	// Team access configuration
+	EnableTeamAccess bool `env:"ENABLE_TEAM_ACCESS" envDefault:"false"`
+
 	// Security configuration
 	SSHHostKeySeed string `env:"SSH_HOST_KEY_SEED" envDefault:"sealos-devbox"`
+	// TrustedUserCAKeys are the CA keys trusted to sign user certificates, in authorized_keys format
+	TrustedUserCAKeys string `env:"TRUSTED_USER_CA_KEYS"`
 
 	// Pprof configuration
 	PprofEnabled bool `env:"PPROF_ENABLED" envDefault:"true"`
 	PprofPort    int  `env:"PPROF_PORT"    envDefault:"0"`
 
+	// Metrics configuration
+	MetricsEnabled bool `env:"METRICS_ENABLED" envDefault:"true"`
+	MetricsPort    int  `env:"METRICS_PORT"    envDefault:"9095"`
+
 	// Gateway configuration
 	Gateway gateway.Options `envPrefix:""`
+
+	// Session recording configuration
+	Recording recorder.Options `envPrefix:"SESSION_RECORDING_"`
+
+	// Admin API configuration
+	Admin admin.Options `envPrefix:"ADMIN_"`
 }
 
 // Load loads configuration from environment variables
@@ -92,6 +109,31 @@ func (c *Config) validate() error {
 		return fmt.Errorf("invalid pprof port: %d", c.PprofPort)
 	}
 
+	if c.MetricsEnabled && (c.MetricsPort < 1 || c.MetricsPort > 65535) {
+		return fmt.Errorf("invalid metrics port: %d", c.MetricsPort)
+	}
+
+	// Validate connection limits
+	if c.Gateway.MaxConnectionsPerIP < 0 || c.Gateway.MaxConnectionsPerDevbox < 0 {
+		return errors.New(
+			"MAX_CONNECTIONS_PER_IP and MAX_CONNECTIONS_PER_DEVBOX must not be negative",
+		)
+	}
+
+	// Validate authentication bans
+	if c.Gateway.AuthFailureThreshold > 0 {
+		if c.Gateway.AuthBanDuration <= 0 {
+			return fmt.Errorf("invalid auth ban duration: %s", c.Gateway.AuthBanDuration)
+		}
+
+		if c.Gateway.AuthBanMaxDuration < c.Gateway.AuthBanDuration {
+			return fmt.Errorf(
+				"auth ban max duration %s is shorter than the ban duration %s",
+				c.Gateway.AuthBanMaxDuration, c.Gateway.AuthBanDuration,
+			)
+		}
+	}
+
 	// Validate that at least one proxy mode is enabled
 	if !c.Gateway.EnableAgentForward && !c.Gateway.EnableProxyJump {
 		return errors.New(
@@ -99,6 +141,53 @@ func (c *Config) validate() error {
 		)
 	}
 
+	// Validate wake on connect timeouts
+	if c.Gateway.EnableWakeOnConnect {
+		if c.Gateway.WakeTimeout <= 0 {
+			return fmt.Errorf("invalid wake timeout: %s", c.Gateway.WakeTimeout)
+		}
+
+		if c.Gateway.WakeKeepAliveInterval <= 0 {
+			return fmt.Errorf("invalid wake keep-alive interval: %s", c.Gateway.WakeKeepAliveInterval)
+		}
+	}
+
+	// Validate session recording sink
+	if c.Recording.Enabled() {
+		switch c.Recording.Sink {
+		case recorder.SinkLocal:
+			if c.Recording.Dir == "" {
+				return errors.New("SESSION_RECORDING_DIR is required for the local sink")
+			}
+		case recorder.SinkS3:
+			if c.Recording.S3.Endpoint == "" || c.Recording.S3.Bucket == "" {
+				return errors.New(
+					"SESSION_RECORDING_S3_ENDPOINT and SESSION_RECORDING_S3_BUCKET are required for the s3 sink",
+				)
+			}
+		default:
+			return fmt.Errorf(
+				"invalid session recording sink: %s (must be local or s3)",
+				c.Recording.Sink,
+			)
+		}
+	}
+
+	// Validate session idle timeout
+	if c.Gateway.SessionIdleTimeout < 0 {
+		return fmt.Errorf("invalid session idle timeout: %s", c.Gateway.SessionIdleTimeout)
+	}
+
+	// Validate admin API
+	if c.Admin.Enabled() && c.Admin.Token == "" {
+		return errors.New("ADMIN_TOKEN is required when ADMIN_LISTEN_ADDR is set")
+	}
+
+	// Validate trusted user CA keys
+	if _, err := gateway.ParseUserCAKeys([]byte(c.TrustedUserCAKeys)); err != nil {
+		return fmt.Errorf("invalid TRUSTED_USER_CA_KEYS: %w", err)
+	}
+
 	// Validate proxy protocol CIDRs
 	for _, cidr := range c.ProxyProtocolTrustedCIDRs {
 		if _, _, err := net.ParseCIDR(cidr); err != nil {
@@ -123,10 +212,14 @@ func NewDefaultConfig() *Config {
 		LogLevel:             "info",
 		LogFormat:            "text",
 		InformerResyncPeriod: 30 * time.Second,
+		EnableTeamAccess:     false,
 		SSHHostKeySeed:       "sealos-devbox",
 		PprofEnabled:         true,
 		PprofPort:            0,
+		MetricsEnabled:       true,
+		MetricsPort:          9095,
 		Gateway:              gateway.DefaultOptions(),
+		Recording:            recorder.DefaultOptions(),
 	}
 }
 
@@ -45,6 +45,13 @@ func TestLoad(t *testing.T) {
 		if cfg.Gateway.EnableProxyJump != false {
 			t.Errorf("Gateway.EnableProxyJump = %v, want true", cfg.Gateway.EnableProxyJump)
 		}
+
+		if cfg.Gateway.MaxConnectionsPerIP != 0 || cfg.Gateway.AuthFailureThreshold != 0 {
+			t.Errorf(
+				"Gateway.MaxConnectionsPerIP = %d, Gateway.AuthFailureThreshold = %d, want both disabled",
+				cfg.Gateway.MaxConnectionsPerIP, cfg.Gateway.AuthFailureThreshold,
+			)
+		}
 	})
 
 	t.Run("LoadWithCustomValues", func(t *testing.T) {
@@ -411,6 +418,197 @@ func TestPortValidation(t *testing.T) {
 	}
 }
 
+func TestWakeOnConnectValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		shouldFail bool
+	}{
+		{"Disabled", map[string]string{"WAKE_TIMEOUT": "0s"}, false},
+		{"EnabledDefaults", map[string]string{"ENABLE_WAKE_ON_CONNECT": "true"}, false},
+		{
+			"EnabledZeroTimeout",
+			map[string]string{"ENABLE_WAKE_ON_CONNECT": "true", "WAKE_TIMEOUT": "0s"},
+			true,
+		},
+		{
+			"EnabledZeroKeepAlive",
+			map[string]string{"ENABLE_WAKE_ON_CONNECT": "true", "WAKE_KEEPALIVE_INTERVAL": "0s"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := config.Load()
+
+			if tt.shouldFail && err == nil {
+				t.Errorf("Expected error for %v, got none", tt.env)
+			}
+
+			if !tt.shouldFail && err != nil {
+				t.Errorf("Unexpected error for %v: %v", tt.env, err)
+			}
+		})
+	}
+}
+
+func TestSessionRecordingValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		shouldFail bool
+	}{
+		{"Disabled", map[string]string{"SESSION_RECORDING_SINK": "unknown"}, false},
+		{"Local", map[string]string{"SESSION_RECORDING_NAMESPACES": "ns-a,ns-b"}, false},
+		{
+			"UnknownSink",
+			map[string]string{"SESSION_RECORDING_NAMESPACES": "*", "SESSION_RECORDING_SINK": "ftp"},
+			true,
+		},
+		{
+			"S3WithoutBucket",
+			map[string]string{
+				"SESSION_RECORDING_NAMESPACES":  "*",
+				"SESSION_RECORDING_SINK":        "s3",
+				"SESSION_RECORDING_S3_ENDPOINT": "minio:9000",
+			},
+			true,
+		},
+		{
+			"S3",
+			map[string]string{
+				"SESSION_RECORDING_NAMESPACES":  "*",
+				"SESSION_RECORDING_SINK":        "s3",
+				"SESSION_RECORDING_S3_ENDPOINT": "minio:9000",
+				"SESSION_RECORDING_S3_BUCKET":   "recordings",
+			},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := config.Load()
+
+			if tt.shouldFail && err == nil {
+				t.Errorf("Expected error for %v, got none", tt.env)
+			}
+
+			if !tt.shouldFail && err != nil {
+				t.Errorf("Unexpected error for %v: %v", tt.env, err)
+			}
+		})
+	}
+}
+
+func TestConnectionLimitValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		shouldFail bool
+	}{
+		{"Defaults", map[string]string{}, false},
+		{"Unlimited", map[string]string{"MAX_CONNECTIONS_PER_IP": "0"}, false},
+		{"NegativeIPLimit", map[string]string{"MAX_CONNECTIONS_PER_IP": "-1"}, true},
+		{"NegativeDevboxLimit", map[string]string{"MAX_CONNECTIONS_PER_DEVBOX": "-1"}, true},
+		{
+			"BansDisabled",
+			map[string]string{"AUTH_FAILURE_THRESHOLD": "0", "AUTH_BAN_DURATION": "0s"},
+			false,
+		},
+		{
+			"ZeroBanDuration",
+			map[string]string{"AUTH_FAILURE_THRESHOLD": "5", "AUTH_BAN_DURATION": "0s"},
+			true,
+		},
+		{
+			"MaxBanShorterThanBan",
+			map[string]string{
+				"AUTH_FAILURE_THRESHOLD": "5",
+				"AUTH_BAN_DURATION":      "10m",
+				"AUTH_BAN_MAX_DURATION":  "1m",
+			},
+			true,
+		},
+		{"InvalidMetricsPort", map[string]string{"METRICS_PORT": "0"}, true},
+		{"NegativeIdleTimeout", map[string]string{"SESSION_IDLE_TIMEOUT": "-1s"}, true},
+		{"AdminWithoutToken", map[string]string{"ADMIN_LISTEN_ADDR": ":9096"}, true},
+		{
+			"Admin",
+			map[string]string{"ADMIN_LISTEN_ADDR": ":9096", "ADMIN_TOKEN": "secret"},
+			false,
+		},
+		{
+			"MetricsDisabled",
+			map[string]string{"METRICS_ENABLED": "false", "METRICS_PORT": "0"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := config.Load()
+
+			if tt.shouldFail && err == nil {
+				t.Errorf("Expected error for %v, got none", tt.env)
+			}
+
+			if !tt.shouldFail && err != nil {
+				t.Errorf("Unexpected error for %v: %v", tt.env, err)
+			}
+		})
+	}
+}
+
+func TestTrustedUserCAKeysValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		shouldFail bool
+	}{
+		{"Empty", map[string]string{}, false},
+		{
+			"Valid",
+			map[string]string{
+				"TRUSTED_USER_CA_KEYS": "# sso\nssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl ca",
+			},
+			false,
+		},
+		{"Invalid", map[string]string{"TRUSTED_USER_CA_KEYS": "ssh-ed25519 invalid"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := config.Load()
+
+			if tt.shouldFail && err == nil {
+				t.Errorf("Expected error for %v, got none", tt.env)
+			}
+
+			if !tt.shouldFail && err != nil {
+				t.Errorf("Unexpected error for %v: %v", tt.env, err)
+			}
+		})
+	}
+}
+
 func TestProxyProtocolCIDRValidation(t *testing.T) {
 	tests := []struct {
 		name       string
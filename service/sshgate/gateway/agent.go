@@ -15,6 +15,9 @@ func (g *Gateway) handleAgentForwardMode(
 ) {
 	sessionLogger := ctx.logger.WithField("mode", "agent_forwarding")
 
+	tap := g.newChannelTap(ctx.audit, ctx.info, ctx.realUser, newChannel.ChannelType(), sessionLogger)
+	defer tap.close(sessionLogger)
+
 	channel, requests, err := newChannel.Accept()
 	if err != nil {
 		sessionLogger.WithError(err).Error("Failed to accept session channel")
@@ -66,7 +69,7 @@ func (g *Gateway) handleAgentForwardMode(
 	defer backendChannel.Close()
 
 	// Forward cached requests to backend
-	g.forwardCachedRequests(sessionResult.CachedRequests, backendChannel, sessionLogger)
+	g.forwardCachedRequests(sessionResult.CachedRequests, backendChannel, tap, sessionLogger)
 
 	// Use synchronized proxy to ensure exit-status is forwarded before closing
 	g.proxyChannelWithRequests(
@@ -74,6 +77,7 @@ func (g *Gateway) handleAgentForwardMode(
 		backendChannel,
 		requests,
 		backendRequests,
+		tap,
 		sessionLogger,
 	)
 }
@@ -82,9 +86,12 @@ func (g *Gateway) handleAgentForwardMode(
 func (g *Gateway) forwardCachedRequests(
 	cachedRequests []*ssh.Request,
 	backendChannel ssh.Channel,
+	tap *channelTap,
 	logger *log.Entry,
 ) {
 	for _, req := range cachedRequests {
+		tap.observeRequest(req)
+
 		ok, err := backendChannel.SendRequest(req.Type, req.WantReply, req.Payload)
 
 		if req.WantReply {
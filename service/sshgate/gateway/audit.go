@@ -0,0 +1,216 @@
+package gateway
+
+import (
+	"encoding/hex"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/metrics"
+	"github.com/labring/sealos/service/sshgate/recorder"
+	"github.com/labring/sealos/service/sshgate/registry"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// connAudit collects the audit trail of a single SSH connection
+type connAudit struct {
+	start     time.Time
+	sessionID string
+	bytesIn   atomic.Int64
+	bytesOut  atomic.Int64
+	channels  atomic.Int32
+	// lastActivity is the time of the last channel data in unix nanoseconds
+	lastActivity atomic.Int64
+	// closeReason is set if the gateway closed the connection
+	closeReason atomic.Value
+	// active tracks the channels still being proxied
+	active sync.WaitGroup
+
+	mu         sync.Mutex
+	recordings []string
+}
+
+func newConnAudit(conn *ssh.ServerConn, start time.Time) *connAudit {
+	sessionID := hex.EncodeToString(conn.SessionID())
+	if len(sessionID) > 16 {
+		sessionID = sessionID[:16]
+	}
+
+	audit := &connAudit{
+		start:     start,
+		sessionID: sessionID,
+	}
+	audit.lastActivity.Store(start.UnixNano())
+
+	return audit
+}
+
+func (a *connAudit) lastActivityTime() time.Time {
+	return time.Unix(0, a.lastActivity.Load())
+}
+
+func (a *connAudit) addRecording(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.recordings = append(a.recordings, key)
+}
+
+// logAudit writes the audit entry of the connection once all its channels are closed
+func (g *Gateway) logAudit(
+	conn *ssh.ServerConn,
+	audit *connAudit,
+	info *registry.DevboxInfo,
+	authMode AuthMode,
+) {
+	clientIP := remoteIP(conn.RemoteAddr())
+
+	audit.active.Wait()
+
+	audit.mu.Lock()
+	recordings := append([]string(nil), audit.recordings...)
+	audit.mu.Unlock()
+
+	closeReason, _ := audit.closeReason.Load().(string)
+
+	g.auditLogger.WithFields(log.Fields{
+		"session_id":      audit.sessionID,
+		"client_ip":       clientIP,
+		"ssh_user":        conn.User(),
+		"namespace":       info.Namespace,
+		"devbox":          info.DevboxName,
+		"auth_mode":       authMode.String(),
+		"key_fingerprint": conn.Permissions.Extensions["key_fingerprint"],
+		"member":          conn.Permissions.Extensions["member"],
+		"cert_key_id":     conn.Permissions.Extensions["cert_key_id"],
+		"start_time":      audit.start.Format(time.RFC3339),
+		"duration":        time.Since(audit.start).String(),
+		"bytes_in":        audit.bytesIn.Load(),
+		"bytes_out":       audit.bytesOut.Load(),
+		"channels":        audit.channels.Load(),
+		"recordings":      recordings,
+		"close_reason":    closeReason,
+	}).Info("SSH connection closed")
+}
+
+// channelTap observes the traffic proxied through a channel,
+// counting bytes for the audit trail and feeding the session recording
+type channelTap struct {
+	audit     *connAudit
+	recording *recorder.Recording
+}
+
+// newChannelTap creates the tap of a new channel, session channels
+// are recorded if recording is enabled for the namespace
+func (g *Gateway) newChannelTap(
+	audit *connAudit,
+	info *registry.DevboxInfo,
+	user string,
+	channelType string,
+	logger *log.Entry,
+) *channelTap {
+	index := audit.channels.Add(1)
+	audit.active.Add(1)
+
+	tap := &channelTap{audit: audit}
+
+	if channelType != "session" || !g.recorder.Enabled(info.Namespace) {
+		return tap
+	}
+
+	recording, err := g.recorder.Start(recorder.Metadata{
+		Namespace:  info.Namespace,
+		DevboxName: info.DevboxName,
+		User:       user,
+		SessionID:  audit.sessionID,
+		Channel:    int(index),
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to start session recording")
+		return tap
+	}
+
+	tap.recording = recording
+
+	return tap
+}
+
+// observeRequest observes a request sent by the client
+func (t *channelTap) observeRequest(req *ssh.Request) {
+	if t == nil {
+		return
+	}
+
+	t.recording.ObserveRequest(req)
+}
+
+// clientReader wraps the data read from the client
+func (t *channelTap) clientReader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+
+	writers := []io.Writer{counter{
+		n:        &t.audit.bytesIn,
+		activity: &t.audit.lastActivity,
+		metric:   metrics.ProxiedBytes.WithLabelValues(metrics.DirectionIn),
+	}}
+	if t.recording != nil {
+		writers = append(writers, t.recording.Input())
+	}
+
+	return io.TeeReader(r, io.MultiWriter(writers...))
+}
+
+// backendReader wraps the data read from the backend
+func (t *channelTap) backendReader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+
+	writers := []io.Writer{counter{
+		n:        &t.audit.bytesOut,
+		activity: &t.audit.lastActivity,
+		metric:   metrics.ProxiedBytes.WithLabelValues(metrics.DirectionOut),
+	}}
+	if t.recording != nil {
+		writers = append(writers, t.recording.Output())
+	}
+
+	return io.TeeReader(r, io.MultiWriter(writers...))
+}
+
+// close finishes the recording of the channel, it must be called once for every tap
+func (t *channelTap) close(logger *log.Entry) {
+	defer t.audit.active.Done()
+
+	if t.recording == nil {
+		return
+	}
+
+	if err := t.recording.Close(); err != nil {
+		logger.WithError(err).Error("Failed to store session recording")
+		return
+	}
+
+	t.audit.addRecording(t.recording.Key())
+}
+
+// counter counts the bytes written to the audit trail and the metrics,
+// and records the time of the activity
+type counter struct {
+	n        *atomic.Int64
+	activity *atomic.Int64
+	metric   prometheus.Counter
+}
+
+func (c counter) Write(p []byte) (int, error) {
+	c.n.Add(int64(len(p)))
+	c.activity.Store(time.Now().UnixNano())
+	c.metric.Add(float64(len(p)))
+
+	return len(p), nil
+}
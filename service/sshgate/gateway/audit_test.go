@@ -0,0 +1,167 @@
+package gateway_test
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/gateway"
+	"github.com/labring/sealos/service/sshgate/recorder"
+	"github.com/labring/sealos/service/sshgate/registry"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSessionRecordingAndAudit(t *testing.T) {
+	reg := registry.New()
+	hostKey, devboxPub, pubBytes, privBytes := generateTestKeys(t)
+
+	ownerRefs := []metav1.OwnerReference{{Kind: registry.DevboxOwnerKind, Name: "test-devbox"}}
+	labels := map[string]string{registry.DevboxPartOfLabel: registry.DevboxPartOfValue}
+
+	if err := reg.AddSecret(nil, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test-secret",
+			Namespace:       "test-ns",
+			Labels:          labels,
+			OwnerReferences: ownerRefs,
+		},
+		Data: map[string][]byte{
+			registry.DevboxPublicKeyField:  pubBytes,
+			registry.DevboxPrivateKeyField: privBytes,
+		},
+	}); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	if err := reg.UpdatePod(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test-pod",
+			Namespace:       "test-ns",
+			Labels:          labels,
+			OwnerReferences: ownerRefs,
+		},
+		Status: corev1.PodStatus{PodIP: "127.0.0.1"},
+	}); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+
+	var lc net.ListenConfig
+
+	backendListener, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start backend listener: %v", err)
+	}
+	defer backendListener.Close()
+
+	_, backendPort, _ := net.SplitHostPort(backendListener.Addr().String())
+
+	backendKey, _, _, _ := generateTestKeys(t)
+	go runMockBackendServer(t, backendListener, backendKey, privBytes, 0)
+
+	options := recorder.DefaultOptions()
+	options.Namespaces = []string{"test-ns"}
+	options.Dir = t.TempDir()
+
+	gw := gateway.New(hostKey, reg, gateway.WithSSHBackendPort(mustAtoi(t, backendPort)))
+	gw.SetRecorder(recorder.New(recorder.NewLocalSink(options.Dir), options))
+
+	gwListener, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start gateway listener: %v", err)
+	}
+	defer gwListener.Close()
+
+	done := make(chan struct{})
+
+	go func() {
+		conn, err := gwListener.Accept()
+		if err != nil {
+			return
+		}
+
+		gw.HandleConnection(conn)
+		close(done)
+	}()
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	exitCode, err := runSSHCommand(t, gwListener.Addr().String(), privBytes, "exit 3")
+	if err != nil || exitCode != 3 {
+		t.Fatalf("runSSHCommand() = %d, %v, want exit code 3", exitCode, err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Connection was not closed")
+	}
+
+	// The session is recorded with the backend output
+	var casts []string
+
+	_ = filepath.WalkDir(options.Dir, func(name string, d fs.DirEntry, _ error) error {
+		if d != nil && !d.IsDir() {
+			casts = append(casts, name)
+		}
+
+		return nil
+	})
+
+	if len(casts) != 1 || !strings.HasPrefix(casts[0], filepath.Join(options.Dir, "test-ns", "test-devbox")) {
+		t.Fatalf("Expected one recording of test-ns/test-devbox, got %v", casts)
+	}
+
+	cast, err := os.ReadFile(casts[0])
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+
+	if !strings.Contains(string(cast), `"o","exec exit 3\r\n"`) {
+		t.Errorf("Recording misses the session output:\n%s", cast)
+	}
+
+	// One audit entry is written for the connection
+	var entry *log.Entry
+
+	for _, e := range hook.AllEntries() {
+		if e.Data["component"] == "audit" {
+			entry = e
+		}
+	}
+
+	if entry == nil {
+		t.Fatal("No audit entry written")
+	}
+
+	wantFields := map[string]any{
+		"namespace":       "test-ns",
+		"devbox":          "test-devbox",
+		"client_ip":       "127.0.0.1",
+		"auth_mode":       gateway.AuthModePublicKey.String(),
+		"key_fingerprint": ssh.FingerprintSHA256(devboxPub),
+		"channels":        int32(1),
+	}
+	for k, v := range wantFields {
+		if entry.Data[k] != v {
+			t.Errorf("Audit entry %s = %v, want %v", k, entry.Data[k], v)
+		}
+	}
+
+	if bytesOut, _ := entry.Data["bytes_out"].(int64); bytesOut != int64(len("exec exit 3\r\n")) {
+		t.Errorf("Audit entry bytes_out = %v, want %d", entry.Data["bytes_out"], len("exec exit 3\r\n"))
+	}
+
+	if recordings, _ := entry.Data["recordings"].([]string); len(recordings) != 1 {
+		t.Errorf("Audit entry recordings = %v, want one recording", entry.Data["recordings"])
+	}
+}
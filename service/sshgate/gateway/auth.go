@@ -3,6 +3,7 @@ package gateway
 import (
 	"errors"
 
+	"github.com/labring/sealos/service/sshgate/metrics"
 	"github.com/labring/sealos/service/sshgate/registry"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
@@ -12,10 +13,12 @@ import (
 type AuthMode int
 
 const (
-	AuthModeUnknown   AuthMode = iota
-	AuthModePublicKey          // Public key authentication mode
-	AuthModeCustomKey          // Custom key authentication mode (user-defined public keys)
-	AuthModeNoAuth             // No client authentication mode
+	AuthModeUnknown     AuthMode = iota
+	AuthModePublicKey            // Public key authentication mode
+	AuthModeCustomKey            // Custom key authentication mode (user-defined public keys)
+	AuthModeNoAuth               // No client authentication mode
+	AuthModeMemberKey            // Member key authentication mode (keys of workspace members)
+	AuthModeCertificate          // Certificate authentication mode (certificates of trusted user CAs)
 )
 
 func (m AuthMode) String() string {
@@ -26,11 +29,38 @@ func (m AuthMode) String() string {
 		return "custom-key"
 	case AuthModeNoAuth:
 		return "no-auth"
+	case AuthModeMemberKey:
+		return "member-key"
+	case AuthModeCertificate:
+		return "certificate"
 	default:
 		return "unknown"
 	}
 }
 
+// authenticatePublicKey authenticates the key and counts the outcome by auth mode
+func (g *Gateway) authenticatePublicKey(
+	conn ssh.ConnMetadata,
+	key ssh.PublicKey,
+) (*ssh.Permissions, error) {
+	perms, err := g.PublicKeyCallback(conn, key)
+	if err != nil {
+		authMode := AuthModeUnknown
+		if _, ok := g.isTrustedCertificate(key); ok {
+			authMode = AuthModeCertificate
+		}
+
+		metrics.AuthAttempts.WithLabelValues(authMode.String(), metrics.AuthResultFailure).Inc()
+
+		return nil, err
+	}
+
+	metrics.AuthAttempts.WithLabelValues(perms.Extensions["auth_mode"], metrics.AuthResultSuccess).
+		Inc()
+
+	return perms, nil
+}
+
 // publicKeyCallback handles public key authentication
 func (g *Gateway) PublicKeyCallback(
 	conn ssh.ConnMetadata,
@@ -47,6 +77,11 @@ func (g *Gateway) PublicKeyCallback(
 
 	authLogger.Info("authentication attempt")
 
+	// Certificates of trusted user CAs name the devbox in their principals
+	if cert, ok := g.isTrustedCertificate(key); ok {
+		return g.certificateCallback(conn, cert, authLogger)
+	}
+
 	// Look up devbox by public key
 	info, ok := g.registry.GetByPublicKey(key)
 	if !ok {
@@ -56,6 +91,38 @@ func (g *Gateway) PublicKeyCallback(
 			return nil, err
 		}
 
+		info, ok := g.registry.GetDevboxInfo(fullNamespace, devboxName)
+		if !ok {
+			return nil, errors.New("devbox not found")
+		}
+
+		// Keys of workspace members are verified by the gateway,
+		// which then connects to the devbox with the devbox key
+		if member, ok := g.registry.AuthorizeMemberKey(fullNamespace, key); ok &&
+			info.PrivateKey != nil {
+			memberKeyLogger := authLogger.WithFields(log.Fields{
+				"auth_mode": AuthModeMemberKey.String(),
+				"namespace": fullNamespace,
+				"devbox":    devboxName,
+				"member":    member,
+			})
+
+			memberKeyLogger.Info("authentication accept")
+
+			return &ssh.Permissions{
+				Extensions: map[string]string{
+					"username":        username,
+					"auth_mode":       AuthModeMemberKey.String(),
+					"key_fingerprint": ssh.FingerprintSHA256(key),
+					"member":          member,
+				},
+				ExtraData: map[any]any{
+					"devbox_info": info,
+					"logger":      memberKeyLogger,
+				},
+			}, nil
+		}
+
 		// Update logger with devbox info for custom key mode
 		customKeyLogger := authLogger.WithFields(log.Fields{
 			"auth_mode": AuthModeCustomKey.String(),
@@ -63,17 +130,13 @@ func (g *Gateway) PublicKeyCallback(
 			"devbox":    devboxName,
 		})
 
-		info, ok := g.registry.GetDevboxInfo(fullNamespace, devboxName)
-		if !ok {
-			return nil, errors.New("devbox not found")
-		}
-
 		customKeyLogger.Info("authentication accept")
 
 		return &ssh.Permissions{
 			Extensions: map[string]string{
-				"username":  username,
-				"auth_mode": AuthModeCustomKey.String(),
+				"username":        username,
+				"auth_mode":       AuthModeCustomKey.String(),
+				"key_fingerprint": ssh.FingerprintSHA256(key),
 			},
 			ExtraData: map[any]any{
 				"devbox_info": info,
@@ -92,8 +155,9 @@ func (g *Gateway) PublicKeyCallback(
 
 	return &ssh.Permissions{
 		Extensions: map[string]string{
-			"username":  username,
-			"auth_mode": AuthModePublicKey.String(),
+			"username":        username,
+			"auth_mode":       AuthModePublicKey.String(),
+			"key_fingerprint": ssh.FingerprintSHA256(key),
 		},
 		ExtraData: map[any]any{
 			"devbox_info": info,
@@ -160,6 +224,10 @@ func (g *Gateway) determineAuthMode(conn *ssh.ServerConn) AuthMode {
 		return AuthModePublicKey
 	case AuthModeNoAuth.String():
 		return AuthModeNoAuth
+	case AuthModeMemberKey.String():
+		return AuthModeMemberKey
+	case AuthModeCertificate.String():
+		return AuthModeCertificate
 	default:
 		return AuthModeCustomKey
 	}
@@ -210,9 +278,10 @@ func NewPublicKeyCallback(
 	reg *registry.Registry,
 ) func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
 	gw := &Gateway{
-		registry: reg,
-		parser:   &UsernameParser{},
-		logger:   log.WithField("component", "gateway"),
+		registry:    reg,
+		parser:      &UsernameParser{},
+		logger:      log.WithField("component", "gateway"),
+		auditLogger: log.WithField("component", "audit"),
 	}
 
 	return gw.PublicKeyCallback
@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sourceAddressOption is the critical option restricting the client addresses of a certificate
+	sourceAddressOption = "source-address"
+	// principalWildcard as the devbox part of a principal grants every devbox of the namespace
+	principalWildcard = "*"
+)
+
+// ParseUserCAKeys parses the trusted user CA keys in authorized_keys format,
+// empty lines and comments are skipped
+func ParseUserCAKeys(data []byte) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		key, _, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse user CA key: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, scanner.Err()
+}
+
+// SetUserCAKeys sets the CA keys trusted to sign user certificates,
+// certificate authentication is disabled if keys is empty
+func (g *Gateway) SetUserCAKeys(keys []ssh.PublicKey) {
+	if len(keys) == 0 {
+		g.certChecker = nil
+		return
+	}
+
+	trusted := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		trusted[string(key.Marshal())] = struct{}{}
+	}
+
+	g.certChecker = &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			_, ok := trusted[string(auth.Marshal())]
+			return ok
+		},
+		// Any other critical option (e.g. force-command) can not be enforced
+		// by the gateway, so certificates carrying them are rejected
+		SupportedCriticalOptions: []string{sourceAddressOption},
+	}
+}
+
+// isTrustedCertificate reports whether the key is a certificate signed by a trusted user CA
+func (g *Gateway) isTrustedCertificate(key ssh.PublicKey) (*ssh.Certificate, bool) {
+	if g.certChecker == nil {
+		return nil, false
+	}
+
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, false
+	}
+
+	return cert, g.certChecker.IsUserAuthority(cert.SignatureKey)
+}
+
+// certificateCallback authenticates a user certificate signed by a trusted CA.
+// The principals of the certificate name the devboxes it grants access to as
+// "namespace/devbox", or "namespace/*" for every devbox of the namespace.
+func (g *Gateway) certificateCallback(
+	conn ssh.ConnMetadata,
+	cert *ssh.Certificate,
+	authLogger *log.Entry,
+) (*ssh.Permissions, error) {
+	certLogger := authLogger.WithFields(log.Fields{
+		"auth_mode":   AuthModeCertificate.String(),
+		"cert_key_id": cert.KeyId,
+		"cert_serial": cert.Serial,
+	})
+
+	username, namespace, devboxName, err := g.verifyCertificate(conn, cert)
+	if err != nil {
+		certLogger.WithError(err).Warn("authentication reject")
+		return nil, err
+	}
+
+	certLogger = certLogger.WithFields(log.Fields{
+		"namespace": namespace,
+		"devbox":    devboxName,
+	})
+
+	info, ok := g.registry.GetDevboxInfo(namespace, devboxName)
+	if !ok || info.PrivateKey == nil {
+		certLogger.Warn("authentication reject: devbox not found")
+		return nil, errors.New("devbox not found")
+	}
+
+	certLogger.Info("authentication accept")
+
+	return &ssh.Permissions{
+		Extensions: map[string]string{
+			"username":        username,
+			"auth_mode":       AuthModeCertificate.String(),
+			"key_fingerprint": ssh.FingerprintSHA256(cert.Key),
+			"cert_key_id":     cert.KeyId,
+		},
+		ExtraData: map[any]any{
+			"devbox_info": info,
+			"logger":      certLogger,
+		},
+	}, nil
+}
+
+// verifyCertificate checks the certificate and returns the devbox it is used for.
+// The devbox is taken from the username if it follows the username@namespace-devbox
+// format, otherwise the certificate must grant exactly one devbox.
+func (g *Gateway) verifyCertificate(
+	conn ssh.ConnMetadata,
+	cert *ssh.Certificate,
+) (username, namespace, devboxName string, err error) {
+	if cert.CertType != ssh.UserCert {
+		return "", "", "", fmt.Errorf("certificate type %d is not a user certificate", cert.CertType)
+	}
+
+	if err := checkSourceAddress(conn.RemoteAddr(), cert.CriticalOptions[sourceAddressOption]); err != nil {
+		return "", "", "", err
+	}
+
+	var principal string
+
+	username, namespace, devboxName, err = g.parser.Parse(conn.User())
+	if err == nil {
+		principal, err = matchPrincipal(cert, namespace, devboxName)
+	} else {
+		username = conn.User()
+		principal, err = singlePrincipal(cert)
+		if err == nil {
+			namespace, devboxName, _ = strings.Cut(principal, "/")
+		}
+	}
+
+	if err != nil {
+		return "", "", "", err
+	}
+
+	// CheckCert verifies the critical options, the principal, the validity window and the signature
+	if err := g.certChecker.CheckCert(principal, cert); err != nil {
+		return "", "", "", err
+	}
+
+	return username, namespace, devboxName, nil
+}
+
+// matchPrincipal returns the principal of the certificate granting the devbox
+func matchPrincipal(cert *ssh.Certificate, namespace, devboxName string) (string, error) {
+	for _, principal := range []string{
+		namespace + "/" + devboxName,
+		namespace + "/" + principalWildcard,
+	} {
+		for _, p := range cert.ValidPrincipals {
+			if p == principal {
+				return principal, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("certificate does not grant access to devbox %s/%s", namespace, devboxName)
+}
+
+// singlePrincipal returns the only devbox principal of the certificate
+func singlePrincipal(cert *ssh.Certificate) (string, error) {
+	var principals []string
+
+	for _, p := range cert.ValidPrincipals {
+		namespace, devboxName, ok := strings.Cut(p, "/")
+		if ok && namespace != "" && devboxName != "" && devboxName != principalWildcard {
+			principals = append(principals, p)
+		}
+	}
+
+	switch len(principals) {
+	case 0:
+		return "", errors.New("certificate grants no devbox")
+	case 1:
+		return principals[0], nil
+	default:
+		return "", errors.New(
+			"certificate grants several devboxes, choose one with username@namespace-devbox",
+		)
+	}
+}
+
+// checkSourceAddress checks the client address against the source-address critical option
+func checkSourceAddress(addr net.Addr, sourceAddr string) error {
+	if sourceAddr == "" {
+		return nil
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("source-address: unsupported address type %T", addr)
+	}
+
+	for _, source := range strings.Split(sourceAddr, ",") {
+		source = strings.TrimSpace(source)
+		if ip := net.ParseIP(source); ip != nil {
+			if ip.Equal(tcpAddr.IP) {
+				return nil
+			}
+
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(source)
+		if err != nil {
+			return fmt.Errorf("source-address: invalid address %q: %w", source, err)
+		}
+
+		if ipNet.Contains(tcpAddr.IP) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("source-address: %s is not allowed", tcpAddr.IP)
+}
@@ -0,0 +1,320 @@
+package gateway_test
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/gateway"
+	"github.com/labring/sealos/service/sshgate/registry"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCertificateGateway(t *testing.T, caKeys ...ssh.PublicKey) *gateway.Gateway {
+	t.Helper()
+
+	reg := registry.New()
+
+	for _, devboxName := range []string{"devbox", "other"} {
+		_, _, pubBytes, privBytes := generateTestKeys(t)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      devboxName + "-secret",
+				Namespace: "ns-team",
+				Labels: map[string]string{
+					registry.DevboxPartOfLabel: registry.DevboxPartOfValue,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind: registry.DevboxOwnerKind,
+						Name: devboxName,
+					},
+				},
+			},
+			Data: map[string][]byte{
+				registry.DevboxPublicKeyField:  pubBytes,
+				registry.DevboxPrivateKeyField: privBytes,
+			},
+		}
+
+		if err := reg.AddSecret(nil, secret); err != nil {
+			t.Fatalf("Failed to add secret: %v", err)
+		}
+	}
+
+	hostKey, _, _, _ := generateTestKeys(t)
+	gw := gateway.New(hostKey, reg)
+	gw.SetUserCAKeys(caKeys)
+
+	return gw
+}
+
+func signCertificate(t *testing.T, ca ssh.Signer, cert *ssh.Certificate) *ssh.Certificate {
+	t.Helper()
+
+	_, userPub, _, _ := generateTestKeys(t)
+	cert.Key = userPub
+
+	if cert.CertType == 0 {
+		cert.CertType = ssh.UserCert
+	}
+
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatalf("Failed to sign certificate: %v", err)
+	}
+
+	return cert
+}
+
+func TestParseUserCAKeys(t *testing.T) {
+	_, caPub, caPubBytes, _ := generateTestKeys(t)
+
+	keys, err := gateway.ParseUserCAKeys(
+		[]byte("# sso\n\ncert-authority " + string(caPubBytes)),
+	)
+	if err != nil {
+		t.Fatalf("ParseUserCAKeys() error = %v", err)
+	}
+
+	if len(keys) != 1 || string(keys[0].Marshal()) != string(caPub.Marshal()) {
+		t.Errorf("ParseUserCAKeys() = %v, want the CA key", keys)
+	}
+
+	if _, err := gateway.ParseUserCAKeys([]byte("not a key")); err == nil {
+		t.Error("ParseUserCAKeys() expected error for invalid key")
+	}
+}
+
+func TestPublicKeyCallback_Certificate(t *testing.T) {
+	ca, caPub, _, _ := generateTestKeys(t)
+	untrustedCA, _, _, _ := generateTestKeys(t)
+	gw := newCertificateGateway(t, caPub)
+
+	now := time.Now()
+	validAfter := uint64(now.Add(-time.Minute).Unix())
+	validBefore := uint64(now.Add(time.Hour).Unix())
+
+	tests := []struct {
+		name       string
+		user       string
+		signer     ssh.Signer
+		cert       *ssh.Certificate
+		wantDevbox string
+		wantMode   gateway.AuthMode
+		wantErr    bool
+	}{
+		{
+			name:   "principal of the devbox",
+			user:   "root@team-devbox",
+			signer: ca,
+			cert: &ssh.Certificate{
+				KeyId:           "alice@sso",
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantDevbox: "devbox",
+			wantMode:   gateway.AuthModeCertificate,
+		},
+		{
+			name:   "namespace wildcard",
+			user:   "root@team-other",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/*"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantDevbox: "other",
+			wantMode:   gateway.AuthModeCertificate,
+		},
+		{
+			name:   "single principal routes plain username",
+			user:   "root",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/other"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantDevbox: "other",
+			wantMode:   gateway.AuthModeCertificate,
+		},
+		{
+			name:   "several principals with plain username",
+			user:   "root",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox", "ns-team/other"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "principal of another devbox",
+			user:   "root@team-other",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "unknown devbox",
+			user:   "root@team-missing",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/*"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "expired",
+			user:   "root@team-devbox",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      uint64(now.Add(-2 * time.Hour).Unix()),
+				ValidBefore:     uint64(now.Add(-time.Hour).Unix()),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "not yet valid",
+			user:   "root@team-devbox",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      uint64(now.Add(time.Hour).Unix()),
+				ValidBefore:     ssh.CertTimeInfinity,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "unsupported critical option",
+			user:   "root@team-devbox",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+				Permissions: ssh.Permissions{
+					CriticalOptions: map[string]string{"force-command": "/bin/true"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "allowed source address",
+			user:   "root@team-devbox",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+				Permissions: ssh.Permissions{
+					CriticalOptions: map[string]string{"source-address": "10.0.0.0/8,127.0.0.1"},
+				},
+			},
+			wantDevbox: "devbox",
+			wantMode:   gateway.AuthModeCertificate,
+		},
+		{
+			name:   "denied source address",
+			user:   "root@team-devbox",
+			signer: ca,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+				Permissions: ssh.Permissions{
+					CriticalOptions: map[string]string{"source-address": "10.0.0.0/8"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "host certificate",
+			user:   "root@team-devbox",
+			signer: ca,
+			cert: &ssh.Certificate{
+				CertType:        ssh.HostCert,
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "untrusted CA is left to the devbox",
+			user:   "root@team-devbox",
+			signer: untrustedCA,
+			cert: &ssh.Certificate{
+				ValidPrincipals: []string{"ns-team/devbox"},
+				ValidAfter:      validAfter,
+				ValidBefore:     validBefore,
+			},
+			wantDevbox: "devbox",
+			wantMode:   gateway.AuthModeCustomKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := signCertificate(t, tt.signer, tt.cert)
+
+			perms, err := gw.PublicKeyCallback(newMockConnMetadata(tt.user), cert)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PublicKeyCallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if perms.Extensions["auth_mode"] != tt.wantMode.String() {
+				t.Errorf("auth_mode = %s, want %s", perms.Extensions["auth_mode"], tt.wantMode)
+			}
+
+			info, err := gateway.GetDevboxInfoFromPermissions(perms)
+			if err != nil {
+				t.Fatalf("Failed to get devbox info from permissions: %v", err)
+			}
+
+			if info.Namespace != "ns-team" || info.DevboxName != tt.wantDevbox {
+				t.Errorf(
+					"Devbox = %s/%s, want ns-team/%s",
+					info.Namespace, info.DevboxName, tt.wantDevbox,
+				)
+			}
+		})
+	}
+}
+
+func TestPublicKeyCallback_CertificateDisabled(t *testing.T) {
+	ca, _, _, _ := generateTestKeys(t)
+	gw := newCertificateGateway(t)
+
+	cert := signCertificate(t, ca, &ssh.Certificate{
+		ValidPrincipals: []string{"ns-team/devbox"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	})
+
+	perms, err := gw.PublicKeyCallback(newMockConnMetadata("root@team-devbox"), cert)
+	if err != nil {
+		t.Fatalf("PublicKeyCallback() error = %v", err)
+	}
+
+	// Without trusted CAs certificates are verified by the devbox, as before
+	if perms.Extensions["auth_mode"] != gateway.AuthModeCustomKey.String() {
+		t.Errorf("auth_mode = %s, want %s", perms.Extensions["auth_mode"], gateway.AuthModeCustomKey)
+	}
+}
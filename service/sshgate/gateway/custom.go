@@ -11,6 +11,7 @@ type sessionContext struct {
 	info     *registry.DevboxInfo
 	realUser string
 	logger   *log.Entry
+	audit    *connAudit
 }
 
 func (g *Gateway) handleCustomKeyOrNoAuthMode(
@@ -20,11 +21,13 @@ func (g *Gateway) handleCustomKeyOrNoAuthMode(
 	info *registry.DevboxInfo,
 	username string,
 	logger *log.Entry,
+	audit *connAudit,
 ) {
 	ctx := &sessionContext{
 		conn:     conn,
 		info:     info,
 		realUser: username,
+		audit:    audit,
 		logger: logger.WithFields(log.Fields{
 			"namespace": info.Namespace,
 			"devbox":    info.DevboxName,
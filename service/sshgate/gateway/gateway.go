@@ -5,8 +5,11 @@ package gateway
 import (
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
+	"github.com/labring/sealos/service/sshgate/metrics"
+	"github.com/labring/sealos/service/sshgate/recorder"
 	"github.com/labring/sealos/service/sshgate/registry"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
@@ -23,6 +26,15 @@ type Options struct {
 	MaxCachedRequests              int           `env:"MAX_CACHED_REQUESTS"               envDefault:"6"`
 	EnableAgentForward             bool          `env:"ENABLE_AGENT_FORWARD"              envDefault:"true"`
 	EnableProxyJump                bool          `env:"ENABLE_PROXY_JUMP"                 envDefault:"false"`
+	EnableWakeOnConnect            bool          `env:"ENABLE_WAKE_ON_CONNECT"            envDefault:"false"`
+	WakeTimeout                    time.Duration `env:"WAKE_TIMEOUT"                      envDefault:"3m"`
+	WakeKeepAliveInterval          time.Duration `env:"WAKE_KEEPALIVE_INTERVAL"           envDefault:"5s"`
+	MaxConnectionsPerIP            int           `env:"MAX_CONNECTIONS_PER_IP"            envDefault:"0"`
+	MaxConnectionsPerDevbox        int           `env:"MAX_CONNECTIONS_PER_DEVBOX"        envDefault:"32"`
+	AuthFailureThreshold           int           `env:"AUTH_FAILURE_THRESHOLD"            envDefault:"0"`
+	AuthBanDuration                time.Duration `env:"AUTH_BAN_DURATION"                 envDefault:"1m"`
+	AuthBanMaxDuration             time.Duration `env:"AUTH_BAN_MAX_DURATION"             envDefault:"1h"`
+	SessionIdleTimeout             time.Duration `env:"SESSION_IDLE_TIMEOUT"              envDefault:"0s"`
 }
 
 // DefaultOptions returns the default gateway options
@@ -37,6 +49,15 @@ func DefaultOptions() Options {
 		MaxCachedRequests:              6,
 		EnableAgentForward:             true,
 		EnableProxyJump:                false,
+		EnableWakeOnConnect:            false,
+		WakeTimeout:                    3 * time.Minute,
+		WakeKeepAliveInterval:          5 * time.Second,
+		MaxConnectionsPerIP:            0,
+		MaxConnectionsPerDevbox:        32,
+		AuthFailureThreshold:           0,
+		AuthBanDuration:                time.Minute,
+		AuthBanMaxDuration:             time.Hour,
+		SessionIdleTimeout:             0,
 	}
 }
 
@@ -107,13 +128,70 @@ func WithEnableProxyJump(enable bool) Option {
 	}
 }
 
+// WithEnableWakeOnConnect sets whether stopped devboxes are started when a known key connects
+func WithEnableWakeOnConnect(enable bool) Option {
+	return func(o *Options) {
+		o.EnableWakeOnConnect = enable
+	}
+}
+
+// WithWakeTimeouts sets how long a client is held while its devbox starts
+// and the interval of the keep-alive messages sent to it meanwhile
+func WithWakeTimeouts(timeout, keepAliveInterval time.Duration) Option {
+	return func(o *Options) {
+		o.WakeTimeout = timeout
+		o.WakeKeepAliveInterval = keepAliveInterval
+	}
+}
+
+// WithConnectionLimits sets the maximum number of concurrent connections
+// per client IP and per devbox, 0 means unlimited
+func WithConnectionLimits(perIP, perDevbox int) Option {
+	return func(o *Options) {
+		o.MaxConnectionsPerIP = perIP
+		o.MaxConnectionsPerDevbox = perDevbox
+	}
+}
+
+// WithAuthBan sets the number of consecutive authentication failures after which
+// a client IP is banned, 0 disables bans, and the initial and maximum ban durations
+func WithAuthBan(threshold int, duration, maxDuration time.Duration) Option {
+	return func(o *Options) {
+		o.AuthFailureThreshold = threshold
+		o.AuthBanDuration = duration
+		o.AuthBanMaxDuration = maxDuration
+	}
+}
+
+// WithSessionIdleTimeout sets how long a session may go without channel data
+// before it is closed, 0 disables the timeout
+func WithSessionIdleTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.SessionIdleTimeout = timeout
+	}
+}
+
 // Gateway handles SSH connections and routes them to backend devbox pods
 type Gateway struct {
 	sshConfig *ssh.ServerConfig
 	registry  *registry.Registry
 	options   *Options
 	parser    *UsernameParser
-	logger    *log.Entry
+	waker     Waker
+	recorder  *recorder.Recorder
+	// certChecker verifies user certificates, it is nil if no user CA is trusted
+	certChecker *ssh.CertChecker
+	// ipLimiter and devboxLimiter limit the concurrent connections
+	ipLimiter     *connLimiter
+	devboxLimiter *connLimiter
+	// authThrottle bans clients failing to authenticate
+	authThrottle *authThrottle
+	// sessions are the live sessions by session id
+	sessionsMu sync.RWMutex
+	sessions   map[string]*liveSession
+	logger     *log.Entry
+	// auditLogger writes one entry per connection
+	auditLogger *log.Entry
 }
 
 // New creates a new Gateway instance with functional options
@@ -127,10 +205,19 @@ func New(hostKey ssh.Signer, reg *registry.Registry, opts ...Option) *Gateway {
 	}
 
 	gw := &Gateway{
-		registry: reg,
-		options:  &options,
-		parser:   &UsernameParser{},
-		logger:   log.WithField("component", "gateway"),
+		registry:      reg,
+		options:       &options,
+		parser:        &UsernameParser{},
+		logger:        log.WithField("component", "gateway"),
+		auditLogger:   log.WithField("component", "audit"),
+		ipLimiter:     newConnLimiter(options.MaxConnectionsPerIP),
+		devboxLimiter: newConnLimiter(options.MaxConnectionsPerDevbox),
+		authThrottle: newAuthThrottle(
+			options.AuthFailureThreshold,
+			options.AuthBanDuration,
+			options.AuthBanMaxDuration,
+		),
+		sessions: make(map[string]*liveSession),
 	}
 
 	sshConfig := &ssh.ServerConfig{
@@ -139,7 +226,7 @@ func New(hostKey ssh.Signer, reg *registry.Registry, opts ...Option) *Gateway {
 		// because AddKeysToAgent need use public key auth
 		// NoClientAuth: true,
 		// NoClientAuthCallback: gw.NoClientAuthCallback,
-		PublicKeyCallback: gw.PublicKeyCallback,
+		PublicKeyCallback: gw.authenticatePublicKey,
 	}
 	sshConfig.AddHostKey(hostKey)
 
@@ -149,17 +236,31 @@ func New(hostKey ssh.Signer, reg *registry.Registry, opts ...Option) *Gateway {
 }
 
 func (g *Gateway) HandleConnection(nConn net.Conn) {
+	start := time.Now()
+	clientIP := remoteIP(nConn.RemoteAddr())
+
+	if !g.admitConnection(nConn, clientIP) {
+		return
+	}
+	defer g.ipLimiter.release(clientIP)
+
 	_ = nConn.SetDeadline(time.Now().Add(g.options.SSHHandshakeTimeout))
 
 	conn, chans, reqs, err := ssh.NewServerConn(nConn, g.sshConfig)
 	if err != nil {
-		g.logger.WithFields(log.Fields{
-			"remote_addr": nConn.RemoteAddr().String(),
-		}).WithError(err).Warn("SSH handshake failed")
+		metrics.HandshakeDuration.WithLabelValues(metrics.HandshakeResultFailure).
+			Observe(time.Since(start).Seconds())
+
+		g.handleHandshakeError(nConn, clientIP, err)
+
 		return
 	}
 	defer conn.Close()
 
+	metrics.HandshakeDuration.WithLabelValues(metrics.HandshakeResultSuccess).
+		Observe(time.Since(start).Seconds())
+	g.authThrottle.success(clientIP)
+
 	_ = nConn.SetDeadline(time.Time{})
 
 	info, err := g.getDevboxInfoFromPermissions(conn.Permissions)
@@ -191,39 +292,142 @@ func (g *Gateway) HandleConnection(nConn net.Conn) {
 		})
 	}
 
+	// Limit the connections to a single devbox
+	devboxKey := info.Namespace + "/" + info.DevboxName
+	if !g.devboxLimiter.acquire(devboxKey) {
+		metrics.ConnectionsRejected.WithLabelValues(metrics.RejectReasonDevboxLimit).Inc()
+		connLogger.Warn("Too many connections to devbox")
+		g.rejectChannels(chans, reqs, fmt.Sprintf(
+			"too many connections to devbox %s/%s", info.Namespace, info.DevboxName,
+		))
+
+		return
+	}
+	defer g.devboxLimiter.release(devboxKey)
+
+	activeSessions := metrics.ActiveSessions.WithLabelValues(authMode.String())
+	activeSessions.Inc()
+	defer activeSessions.Dec()
+
+	audit := newConnAudit(conn, start)
+	defer g.logAudit(conn, audit, info, authMode)
+
+	untrack := g.trackSession(conn, audit, info, authMode)
+	defer untrack()
+
+	if g.options.SessionIdleTimeout > 0 {
+		stopIdleWatch := g.watchIdle(conn, audit, connLogger)
+		defer stopIdleWatch()
+	}
+
 	// Check if devbox is running
+	var woken *wakeResult
 	if info.PodIP == "" {
-		connLogger.Warn("Devbox not running")
-		// Reject all incoming channels and close connection
+		// Only start devboxes for keys matched by the gateway itself,
+		// custom keys are not verified until the backend connection
+		if g.canWake(authMode) {
+			woken = g.wakeDevbox(conn, chans, reqs, info, connLogger)
+		}
 
-		go ssh.DiscardRequests(reqs)
+		if woken == nil {
+			connLogger.Warn("Devbox not running")
+			g.rejectChannels(chans, reqs, fmt.Sprintf(
+				"devbox %s/%s is not running", info.Namespace, info.DevboxName,
+			))
 
-		for newChannel := range chans {
-			_ = newChannel.Reject(
-				ssh.ConnectionFailed,
-				fmt.Sprintf("devbox %s/%s is not running", info.Namespace, info.DevboxName),
-			)
+			return
 		}
-
-		return
 	}
 
 	connLogger.Info("Connection established")
 
 	switch authMode {
-	case AuthModePublicKey:
-		g.handlePublicKeyMode(conn, chans, reqs, info, username, connLogger)
+	case AuthModePublicKey, AuthModeMemberKey, AuthModeCertificate:
+		g.handlePublicKeyMode(conn, chans, reqs, info, username, connLogger, audit, woken)
 	case AuthModeCustomKey, AuthModeNoAuth:
-		g.handleCustomKeyOrNoAuthMode(conn, chans, reqs, info, username, connLogger)
+		g.handleCustomKeyOrNoAuthMode(conn, chans, reqs, info, username, connLogger, audit)
 	default:
 		connLogger.Warn("Unknown auth mode, closing connection")
 	}
 }
 
+// admitConnection checks the client IP is neither banned nor at its connection limit,
+// the connection is closed otherwise. The caller must release the IP slot if admitted.
+func (g *Gateway) admitConnection(nConn net.Conn, clientIP string) bool {
+	if remaining, banned := g.authThrottle.banned(clientIP); banned {
+		metrics.ConnectionsRejected.WithLabelValues(metrics.RejectReasonBanned).Inc()
+		g.logger.WithFields(log.Fields{
+			"remote_addr": nConn.RemoteAddr().String(),
+			"remaining":   remaining.String(),
+		}).Debug("Rejected connection from banned client")
+
+		_ = nConn.Close()
+
+		return false
+	}
+
+	if !g.ipLimiter.acquire(clientIP) {
+		metrics.ConnectionsRejected.WithLabelValues(metrics.RejectReasonIPLimit).Inc()
+		g.logger.WithFields(log.Fields{
+			"remote_addr": nConn.RemoteAddr().String(),
+		}).Warn("Too many connections from client")
+
+		_ = nConn.Close()
+
+		return false
+	}
+
+	return true
+}
+
+// handleHandshakeError logs the failed handshake and throttles clients failing to authenticate
+func (g *Gateway) handleHandshakeError(nConn net.Conn, clientIP string, err error) {
+	handshakeLogger := g.logger.WithFields(log.Fields{
+		"remote_addr": nConn.RemoteAddr().String(),
+	})
+
+	if !isAuthFailure(err) {
+		handshakeLogger.WithError(err).Warn("SSH handshake failed")
+		return
+	}
+
+	handshakeLogger.WithError(err).Warn("SSH authentication failed")
+
+	if ban := g.authThrottle.failure(clientIP); ban > 0 {
+		metrics.AuthBans.Inc()
+		handshakeLogger.WithField("duration", ban.String()).
+			Warn("Banned client after repeated authentication failures")
+	}
+}
+
+// rejectChannels rejects all incoming channels with the message until the client disconnects
+func (g *Gateway) rejectChannels(
+	chans <-chan ssh.NewChannel,
+	reqs <-chan *ssh.Request,
+	message string,
+) {
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		_ = newChannel.Reject(ssh.ConnectionFailed, message)
+	}
+}
+
 func (g *Gateway) Config() *ssh.ServerConfig {
 	return g.sshConfig
 }
 
+// SetRecorder sets the recorder of sessions, sessions are not recorded if it is nil
+func (g *Gateway) SetRecorder(recorder *recorder.Recorder) {
+	g.recorder = recorder
+}
+
+// SetWaker sets the waker used to start stopped devboxes,
+// it only takes effect when wake on connect is enabled
+func (g *Gateway) SetWaker(waker Waker) {
+	g.waker = waker
+}
+
 // getLoggerFromPermissions extracts the logger from SSH permissions
 // Returns the logger if found, otherwise returns nil
 func (g *Gateway) getLoggerFromPermissions(perms *ssh.Permissions) *log.Entry {
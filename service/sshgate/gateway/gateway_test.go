@@ -14,6 +14,7 @@ import (
 	"github.com/labring/sealos/service/sshgate/registry"
 	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -557,3 +558,100 @@ func TestPublicKeyCallback_WithPodIP(t *testing.T) {
 		t.Errorf("Expected PodIP '10.0.0.1', got: %s", info.PodIP)
 	}
 }
+
+func TestPublicKeyCallback_MemberKey(t *testing.T) {
+	reg := registry.New()
+
+	_, _, devboxPubBytes, devboxPrivBytes := generateTestKeys(t)
+	_, memberPub, memberPubBytes, _ := generateTestKeys(t)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "ns-team",
+			Labels: map[string]string{
+				registry.DevboxPartOfLabel: registry.DevboxPartOfValue,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind: registry.DevboxOwnerKind,
+					Name: "devbox",
+				},
+			},
+		},
+		Data: map[string][]byte{
+			registry.DevboxPublicKeyField:  devboxPubBytes,
+			registry.DevboxPrivateKeyField: devboxPrivBytes,
+		},
+	}
+
+	if err := reg.AddSecret(nil, secret); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	reg.AddAuthorizedKeys(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "authorized-keys",
+			Namespace: "ns-team",
+			Labels: map[string]string{
+				registry.AuthorizedKeysLabel: registry.AuthorizedKeysValue,
+			},
+		},
+		Data: map[string]string{
+			"alice": string(memberPubBytes),
+		},
+	})
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alice-role-binding",
+			Namespace: "ns-team",
+		},
+		RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "Developer"},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      "alice",
+				Namespace: registry.UserServiceAccountNamespace,
+			},
+		},
+	}
+	reg.AddRoleBinding(roleBinding)
+
+	callback := gateway.NewPublicKeyCallback(reg)
+	conn := newMockConnMetadata("root@team-devbox")
+
+	perms, err := callback(conn, memberPub)
+	if err != nil {
+		t.Fatalf("Expected member key to be accepted, got: %v", err)
+	}
+
+	if perms.Extensions["auth_mode"] != gateway.AuthModeMemberKey.String() {
+		t.Errorf("auth_mode = %s, want %s", perms.Extensions["auth_mode"], gateway.AuthModeMemberKey)
+	}
+
+	if perms.Extensions["member"] != "alice" {
+		t.Errorf("member = %s, want alice", perms.Extensions["member"])
+	}
+
+	info, err := gateway.GetDevboxInfoFromPermissions(perms)
+	if err != nil {
+		t.Fatalf("Failed to get devbox info from permissions: %v", err)
+	}
+
+	if info.PrivateKey == nil {
+		t.Error("Expected PrivateKey to be set")
+	}
+
+	// Once the member left, the key is left to the devbox to verify
+	reg.DeleteRoleBinding(roleBinding)
+
+	perms, err = callback(conn, memberPub)
+	if err != nil {
+		t.Fatalf("Expected fallback to custom key mode, got: %v", err)
+	}
+
+	if perms.Extensions["auth_mode"] != gateway.AuthModeCustomKey.String() {
+		t.Errorf("auth_mode = %s, want %s", perms.Extensions["auth_mode"], gateway.AuthModeCustomKey)
+	}
+}
@@ -0,0 +1,195 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// connLimiter limits the number of concurrent connections per key
+type connLimiter struct {
+	limit int
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// newConnLimiter creates a limiter allowing limit connections per key, 0 means unlimited
+func newConnLimiter(limit int) *connLimiter {
+	return &connLimiter{
+		limit:  limit,
+		counts: make(map[string]int),
+	}
+}
+
+// acquire takes a connection slot of the key, it returns false if the key is at its limit
+func (l *connLimiter) acquire(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit > 0 && l.counts[key] >= l.limit {
+		return false
+	}
+
+	l.counts[key]++
+
+	return true
+}
+
+// release returns a connection slot taken by acquire
+func (l *connLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.counts[key]--
+	if l.counts[key] <= 0 {
+		delete(l.counts, key)
+	}
+}
+
+// authThrottle bans client IPs after repeated authentication failures.
+// Every ban of the same client lasts twice as long as the previous one, up to maxBan.
+type authThrottle struct {
+	threshold int
+	ban       time.Duration
+	maxBan    time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*authFailures
+	lastSweep time.Time
+}
+
+// authFailures is the authentication history of a client IP
+type authFailures struct {
+	failures    int
+	bans        int
+	bannedUntil time.Time
+	lastFailure time.Time
+}
+
+// newAuthThrottle creates a throttle banning clients after threshold failures, 0 disables bans
+func newAuthThrottle(threshold int, ban, maxBan time.Duration) *authThrottle {
+	return &authThrottle{
+		threshold: threshold,
+		ban:       ban,
+		maxBan:    maxBan,
+		now:       time.Now,
+		clients:   make(map[string]*authFailures),
+	}
+}
+
+// banned reports whether the client IP is banned and for how long
+func (t *authThrottle) banned(ip string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	client, ok := t.clients[ip]
+	if !ok {
+		return 0, false
+	}
+
+	remaining := client.bannedUntil.Sub(t.now())
+
+	return remaining, remaining > 0
+}
+
+// failure records an authentication failure of the client IP,
+// it returns the duration of the ban if the client got banned
+func (t *authThrottle) failure(ip string) time.Duration {
+	if t.threshold <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	client, ok := t.clients[ip]
+	if !ok {
+		client = &authFailures{}
+		t.clients[ip] = client
+	}
+
+	client.failures++
+	client.lastFailure = now
+
+	if client.failures < t.threshold {
+		return 0
+	}
+
+	ban := t.ban << client.bans
+	if ban <= 0 || ban > t.maxBan {
+		ban = t.maxBan
+	}
+
+	client.failures = 0
+	client.bans++
+	client.bannedUntil = now.Add(ban)
+
+	return ban
+}
+
+// success forgets the failures of the client IP, its past bans are kept
+// so that a client alternating failures and successes is still slowed down
+func (t *authThrottle) success(ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if client, ok := t.clients[ip]; ok {
+		client.failures = 0
+	}
+}
+
+// sweep forgets the clients whose last failure is older than the longest ban,
+// it runs at most once per maxBan
+func (t *authThrottle) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.maxBan {
+		return
+	}
+
+	t.lastSweep = now
+
+	for ip, client := range t.clients {
+		if now.Sub(client.lastFailure) > t.maxBan && now.After(client.bannedUntil) {
+			delete(t.clients, ip)
+		}
+	}
+}
+
+// isAuthFailure reports whether the handshake failed because the client
+// could not authenticate, as opposed to network or protocol errors
+func isAuthFailure(err error) bool {
+	var authErr *ssh.ServerAuthError
+	if !errors.As(err, &authErr) {
+		return false
+	}
+
+	// The first entry is the "none" method probing the server
+	for _, e := range authErr.Errors {
+		if !errors.Is(e, ssh.ErrNoAuth) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remoteIP returns the IP of the address, or the address itself if it has no port
+func remoteIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	return host
+}
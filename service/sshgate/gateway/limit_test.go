@@ -0,0 +1,140 @@
+package gateway_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/gateway"
+	"github.com/labring/sealos/service/sshgate/metrics"
+	"github.com/labring/sealos/service/sshgate/registry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/ssh"
+)
+
+// dialGateway connects to the gateway as user with the private key
+func dialGateway(t *testing.T, addr, user string, privateKey []byte) (*ssh.Client, error) {
+	t.Helper()
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to parse private key: %v", err)
+	}
+
+	return ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(signer),
+		},
+		//nolint:gosec // acceptable for testing
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         2 * time.Second,
+	})
+}
+
+func TestConnectionLimitPerIP(t *testing.T) {
+	// The devbox is stopped, so connections are held until the client leaves
+	waker := &fakeWaker{reg: registry.New()}
+	addr, privBytes := startStoppedDevboxGateway(t, waker,
+		gateway.WithEnableWakeOnConnect(false),
+		gateway.WithConnectionLimits(1, 0),
+	)
+
+	rejected := testutil.ToFloat64(
+		metrics.ConnectionsRejected.WithLabelValues(metrics.RejectReasonIPLimit),
+	)
+
+	first, err := dialGateway(t, addr, "testuser", privBytes)
+	if err != nil {
+		t.Fatalf("First connection failed: %v", err)
+	}
+
+	if _, err := dialGateway(t, addr, "testuser", privBytes); err == nil {
+		t.Fatal("Expected second connection from the same IP to be rejected")
+	}
+
+	if got := testutil.ToFloat64(
+		metrics.ConnectionsRejected.WithLabelValues(metrics.RejectReasonIPLimit),
+	); got != rejected+1 {
+		t.Errorf("Rejected connections = %v, want %v", got, rejected+1)
+	}
+
+	first.Close()
+
+	// The slot is released once the first connection is closed
+	var again *ssh.Client
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		again, err = dialGateway(t, addr, "testuser", privBytes)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err != nil {
+		t.Fatalf("Connection after release failed: %v", err)
+	}
+
+	again.Close()
+}
+
+func TestConnectionLimitPerDevbox(t *testing.T) {
+	waker := &fakeWaker{reg: registry.New()}
+	addr, privBytes := startStoppedDevboxGateway(t, waker,
+		gateway.WithEnableWakeOnConnect(false),
+		gateway.WithConnectionLimits(0, 1),
+	)
+
+	first, err := dialGateway(t, addr, "testuser", privBytes)
+	if err != nil {
+		t.Fatalf("First connection failed: %v", err)
+	}
+	defer first.Close()
+
+	second, err := dialGateway(t, addr, "testuser", privBytes)
+	if err != nil {
+		t.Fatalf("Second connection failed: %v", err)
+	}
+	defer second.Close()
+
+	_, err = second.NewSession()
+	if err == nil || !strings.Contains(err.Error(), "too many connections") {
+		t.Errorf("Expected session to be rejected for too many connections, got: %v", err)
+	}
+}
+
+func TestAuthFailureBan(t *testing.T) {
+	waker := &fakeWaker{reg: registry.New()}
+	addr, privBytes := startStoppedDevboxGateway(t, waker,
+		gateway.WithEnableWakeOnConnect(false),
+		gateway.WithAuthBan(2, time.Minute, time.Hour),
+	)
+
+	_, _, _, unknownPriv := generateTestKeys(t)
+	bans := testutil.ToFloat64(metrics.AuthBans)
+
+	// Unknown keys with an invalid username fail to authenticate
+	for range 2 {
+		if _, err := dialGateway(t, addr, "invalid", unknownPriv); err == nil {
+			t.Fatal("Expected authentication to fail")
+		}
+	}
+
+	// The gateway notices the failure once the client is gone
+	deadline := time.Now().Add(2 * time.Second)
+	for testutil.ToFloat64(metrics.AuthBans) < bans+1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if got := testutil.ToFloat64(metrics.AuthBans); got != bans+1 {
+		t.Fatalf("Bans = %v, want %v", got, bans+1)
+	}
+
+	// Even valid keys are rejected while the client is banned
+	if _, err := dialGateway(t, addr, "testuser", privBytes); err == nil {
+		t.Fatal("Expected banned client to be rejected")
+	}
+}
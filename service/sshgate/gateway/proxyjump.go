@@ -25,6 +25,9 @@ func (g *Gateway) handleProxyJumpMode(
 ) {
 	proxyLogger := ctx.logger.WithField("mode", "proxy_jump")
 
+	tap := g.newChannelTap(ctx.audit, ctx.info, ctx.realUser, newChannel.ChannelType(), proxyLogger)
+	defer tap.close(proxyLogger)
+
 	// Parse the direct-tcpip payload
 	var msg directTCPIPMsg
 	if err := ssh.Unmarshal(newChannel.ExtraData(), &msg); err != nil {
@@ -72,7 +75,7 @@ func (g *Gateway) handleProxyJumpMode(
 	proxyLogger.Info("Tunnel established")
 
 	// Proxy data between client channel and devbox connection
-	g.proxyChannelToConn(channel, conn)
+	g.proxyChannelToConn(channel, conn, tap)
 
 	proxyLogger.Info("Tunnel closed")
 }
@@ -15,6 +15,8 @@ func (g *Gateway) handlePublicKeyMode(
 	info *registry.DevboxInfo,
 	username string,
 	logger *log.Entry,
+	audit *connAudit,
+	woken *wakeResult,
 ) {
 	backendAddr := fmt.Sprintf("%s:%d", info.PodIP, g.options.SSHBackendPort)
 
@@ -33,6 +35,11 @@ func (g *Gateway) handlePublicKeyMode(
 		logger.WithField("backend_addr", backendAddr).
 			WithError(err).
 			Error("Failed to connect to backend")
+
+		if woken != nil {
+			woken.fail(fmt.Sprintf("failed to connect to devbox: %v", err))
+		}
+
 		return
 	}
 	defer backendConn.Close()
@@ -41,8 +48,22 @@ func (g *Gateway) handlePublicKeyMode(
 
 	go g.handleGlobalRequestsPublicKey(reqs, backendConn, logger)
 
+	// Serve the channels opened while the devbox was starting
+	if woken != nil {
+		if woken.session != nil {
+			tap := g.newChannelTap(audit, info, username, "session", logger)
+			go g.handleWokenSessionPublicKey(woken, backendConn, tap, logger)
+		}
+
+		for _, newChannel := range woken.channels {
+			tap := g.newChannelTap(audit, info, username, newChannel.ChannelType(), logger)
+			go g.handleChannelPublicKey(newChannel, backendConn, tap, logger)
+		}
+	}
+
 	for newChannel := range chans {
-		go g.handleChannelPublicKey(newChannel, backendConn, logger)
+		tap := g.newChannelTap(audit, info, username, newChannel.ChannelType(), logger)
+		go g.handleChannelPublicKey(newChannel, backendConn, tap, logger)
 	}
 }
 
@@ -69,9 +90,11 @@ func (g *Gateway) handleGlobalRequestsPublicKey(
 func (g *Gateway) handleChannelPublicKey(
 	newChannel ssh.NewChannel,
 	backendConn *ssh.Client,
+	tap *channelTap,
 	logger *log.Entry,
 ) {
 	channelLogger := logger.WithField("channel_type", newChannel.ChannelType())
+	defer tap.close(channelLogger)
 
 	backendChannel, backendReqs, err := backendConn.OpenChannel(
 		newChannel.ChannelType(),
@@ -100,6 +123,7 @@ func (g *Gateway) handleChannelPublicKey(
 		backendChannel,
 		requests,
 		backendReqs,
+		tap,
 		channelLogger,
 	)
 }
@@ -0,0 +1,174 @@
+package gateway
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/metrics"
+	"github.com/labring/sealos/service/sshgate/registry"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// Reasons for the gateway to close a session
+const (
+	CloseReasonIdleTimeout = "idle_timeout"
+	CloseReasonAdmin       = "admin"
+)
+
+// SessionInfo describes a live SSH connection proxied by the gateway
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	Namespace    string    `json:"namespace"`
+	Devbox       string    `json:"devbox"`
+	User         string    `json:"user"`
+	ClientIP     string    `json:"clientIP"`
+	AuthMode     string    `json:"authMode"`
+	Member       string    `json:"member,omitempty"`
+	StartTime    time.Time `json:"startTime"`
+	LastActivity time.Time `json:"lastActivity"`
+	BytesIn      int64     `json:"bytesIn"`
+	BytesOut     int64     `json:"bytesOut"`
+}
+
+// liveSession is a connection tracked by the gateway
+type liveSession struct {
+	conn     *ssh.ServerConn
+	audit    *connAudit
+	info     *registry.DevboxInfo
+	authMode AuthMode
+}
+
+func (s *liveSession) sessionInfo() SessionInfo {
+	return SessionInfo{
+		ID:           s.audit.sessionID,
+		Namespace:    s.info.Namespace,
+		Devbox:       s.info.DevboxName,
+		User:         s.conn.User(),
+		ClientIP:     remoteIP(s.conn.RemoteAddr()),
+		AuthMode:     s.authMode.String(),
+		Member:       s.conn.Permissions.Extensions["member"],
+		StartTime:    s.audit.start,
+		LastActivity: s.audit.lastActivityTime(),
+		BytesIn:      s.audit.bytesIn.Load(),
+		BytesOut:     s.audit.bytesOut.Load(),
+	}
+}
+
+// trackSession registers the connection as a live session until the returned func is called
+func (g *Gateway) trackSession(
+	conn *ssh.ServerConn,
+	audit *connAudit,
+	info *registry.DevboxInfo,
+	authMode AuthMode,
+) func() {
+	g.sessionsMu.Lock()
+	g.sessions[audit.sessionID] = &liveSession{
+		conn:     conn,
+		audit:    audit,
+		info:     info,
+		authMode: authMode,
+	}
+	g.sessionsMu.Unlock()
+
+	return func() {
+		g.sessionsMu.Lock()
+		delete(g.sessions, audit.sessionID)
+		g.sessionsMu.Unlock()
+	}
+}
+
+// Sessions returns the live sessions sorted by start time, filtered by namespace
+// and devbox if they are not empty
+func (g *Gateway) Sessions(namespace, devboxName string) []SessionInfo {
+	g.sessionsMu.RLock()
+
+	sessions := make([]SessionInfo, 0, len(g.sessions))
+	for _, s := range g.sessions {
+		if namespace != "" && s.info.Namespace != namespace {
+			continue
+		}
+
+		if devboxName != "" && s.info.DevboxName != devboxName {
+			continue
+		}
+
+		sessions = append(sessions, s.sessionInfo())
+	}
+
+	g.sessionsMu.RUnlock()
+
+	slices.SortFunc(sessions, func(a, b SessionInfo) int {
+		if c := a.StartTime.Compare(b.StartTime); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return sessions
+}
+
+// KillSession closes the live session with the id, it returns false if there is none
+func (g *Gateway) KillSession(id string) bool {
+	g.sessionsMu.RLock()
+	s, ok := g.sessions[id]
+	g.sessionsMu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	g.logger.WithFields(log.Fields{
+		"session_id": id,
+		"namespace":  s.info.Namespace,
+		"devbox":     s.info.DevboxName,
+	}).Info("Killing session")
+
+	closeSession(s.conn, s.audit, CloseReasonAdmin)
+
+	return true
+}
+
+// watchIdle closes the connection once no channel data was proxied in either
+// direction for the idle timeout, until the returned func is called
+func (g *Gateway) watchIdle(conn *ssh.ServerConn, audit *connAudit, logger *log.Entry) func() {
+	timeout := g.options.SessionIdleTimeout
+	done := make(chan struct{})
+
+	go func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-timer.C:
+			}
+
+			idle := time.Since(audit.lastActivityTime())
+			if idle < timeout {
+				timer.Reset(timeout - idle)
+				continue
+			}
+
+			logger.WithField("idle", idle.Round(time.Second).String()).Info("Closing idle session")
+			closeSession(conn, audit, CloseReasonIdleTimeout)
+
+			return
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// closeSession closes the connection, recording the reason in the audit trail
+func closeSession(conn *ssh.ServerConn, audit *connAudit, reason string) {
+	if audit.closeReason.CompareAndSwap(nil, reason) {
+		metrics.SessionsClosed.WithLabelValues(reason).Inc()
+	}
+
+	_ = conn.Close()
+}
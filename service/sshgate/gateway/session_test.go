@@ -0,0 +1,110 @@
+package gateway_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/gateway"
+	"github.com/labring/sealos/service/sshgate/metrics"
+	"github.com/labring/sealos/service/sshgate/registry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/ssh"
+)
+
+// waitClosed waits for the gateway to close the client connection
+func waitClosed(t *testing.T, client *ssh.Client, timeout time.Duration) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		_ = client.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("Connection was not closed by the gateway")
+	}
+}
+
+func TestSessionIdleTimeout(t *testing.T) {
+	waker := &fakeWaker{reg: registry.New()}
+	addr, privBytes := startStoppedDevboxGateway(t, waker,
+		gateway.WithEnableWakeOnConnect(false),
+		gateway.WithSessionIdleTimeout(300*time.Millisecond),
+	)
+
+	closed := testutil.ToFloat64(
+		metrics.SessionsClosed.WithLabelValues(gateway.CloseReasonIdleTimeout),
+	)
+
+	client, err := dialGateway(t, addr, "testuser", privBytes)
+	if err != nil {
+		t.Fatalf("Connection failed: %v", err)
+	}
+	defer client.Close()
+
+	waitClosed(t, client, 2*time.Second)
+
+	if got := testutil.ToFloat64(
+		metrics.SessionsClosed.WithLabelValues(gateway.CloseReasonIdleTimeout),
+	); got != closed+1 {
+		t.Errorf("Idle sessions closed = %v, want %v", got, closed+1)
+	}
+}
+
+func TestSessionsAndKillSession(t *testing.T) {
+	waker := &fakeWaker{reg: registry.New()}
+	gw, addr, privBytes := newStoppedDevboxGateway(t, waker,
+		gateway.WithEnableWakeOnConnect(false),
+	)
+
+	client, err := dialGateway(t, addr, "testuser", privBytes)
+	if err != nil {
+		t.Fatalf("Connection failed: %v", err)
+	}
+	defer client.Close()
+
+	// The session is tracked once the gateway handled the handshake
+	var sessions []gateway.SessionInfo
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sessions) == 0 && time.Now().Before(deadline) {
+		sessions = gw.Sessions("test-ns", "")
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if len(sessions) != 1 {
+		t.Fatalf("Sessions() = %v, want a single session", sessions)
+	}
+
+	session := sessions[0]
+	if session.Devbox != "test-devbox" || session.User != "testuser" ||
+		session.AuthMode != gateway.AuthModePublicKey.String() {
+		t.Errorf("Unexpected session: %+v", session)
+	}
+
+	if other := gw.Sessions("test-ns", "other-devbox"); len(other) != 0 {
+		t.Errorf("Sessions() of another devbox = %v, want none", other)
+	}
+
+	if gw.KillSession("unknown") {
+		t.Error("KillSession() of an unknown session returned true")
+	}
+
+	if !gw.KillSession(session.ID) {
+		t.Fatal("KillSession() returned false")
+	}
+
+	waitClosed(t, client, 2*time.Second)
+
+	deadline = time.Now().Add(2 * time.Second)
+	for len(gw.Sessions("", "")) != 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if sessions := gw.Sessions("", ""); len(sessions) != 0 {
+		t.Errorf("Sessions() after kill = %v, want none", sessions)
+	}
+}
@@ -12,9 +12,12 @@ import (
 func (g *Gateway) proxyRequests(
 	in <-chan *ssh.Request,
 	out ssh.Channel,
+	tap *channelTap,
 	logger *log.Entry,
 ) {
 	for req := range in {
+		tap.observeRequest(req)
+
 		ok, err := out.SendRequest(req.Type, req.WantReply, req.Payload)
 		if req.WantReply {
 			_ = req.Reply(ok, nil)
@@ -34,15 +37,16 @@ func (g *Gateway) proxyRequests(
 func (g *Gateway) proxyChannelWithRequests(
 	channel, backendChannel ssh.Channel,
 	clientReqs, backendReqs <-chan *ssh.Request,
+	tap *channelTap,
 	logger *log.Entry,
 ) {
 	// Client to backend: requests and data
 	go func() {
-		g.proxyRequests(clientReqs, backendChannel, logger)
+		g.proxyRequests(clientReqs, backendChannel, tap, logger)
 	}()
 
 	go func() {
-		_, _ = io.Copy(backendChannel, channel)
+		_, _ = io.Copy(backendChannel, tap.clientReader(channel))
 		_ = backendChannel.CloseWrite()
 	}()
 
@@ -50,12 +54,12 @@ func (g *Gateway) proxyChannelWithRequests(
 	var backendToClientWg sync.WaitGroup
 
 	backendToClientWg.Go(func() {
-		_, _ = io.Copy(channel, backendChannel)
+		_, _ = io.Copy(channel, tap.backendReader(backendChannel))
 		_ = channel.CloseWrite()
 	})
 
 	backendToClientWg.Go(func() {
-		g.proxyRequests(backendReqs, channel, logger)
+		g.proxyRequests(backendReqs, channel, nil, logger)
 	})
 
 	// Wait for backend->client to complete (data + exit-status)
@@ -63,14 +67,14 @@ func (g *Gateway) proxyChannelWithRequests(
 }
 
 // proxyChannelToConn proxies data between an SSH channel and a net.Conn
-func (g *Gateway) proxyChannelToConn(channel ssh.Channel, conn net.Conn) {
+func (g *Gateway) proxyChannelToConn(channel ssh.Channel, conn net.Conn, tap *channelTap) {
 	var wg sync.WaitGroup
 	wg.Go(func() {
-		_, _ = io.Copy(channel, conn)
+		_, _ = io.Copy(channel, tap.backendReader(conn))
 		_ = channel.CloseWrite()
 	})
 
-	_, _ = io.Copy(conn, channel)
+	_, _ = io.Copy(conn, tap.clientReader(channel))
 	_ = conn.Close()
 
 	wg.Wait()
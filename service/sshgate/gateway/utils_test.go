@@ -317,6 +317,9 @@ func handleMockBackendConnection(conn net.Conn, config *ssh.ServerConfig, exitCo
 						}
 					}
 
+					// Echo the request so proxied output can be observed
+					_, _ = fmt.Fprintf(ch, "%s exit %d\r\n", req.Type, actualExitCode)
+
 					payload := make([]byte, 4)
 					//nolint:gosec // exit code is always 0-255 in tests
 					binary.BigEndian.PutUint32(payload, uint32(actualExitCode))
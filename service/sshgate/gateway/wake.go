@@ -0,0 +1,259 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/registry"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// wakeDialInterval is the interval between attempts to reach the sshd of a starting devbox
+const wakeDialInterval = 500 * time.Millisecond
+
+// Waker starts devboxes that are not running
+type Waker interface {
+	// Wake makes sure the devbox is set to running, it returns nil if the
+	// devbox is already running or starting
+	Wake(ctx context.Context, namespace, devboxName string) error
+}
+
+// wakeResult holds the client channels opened while the devbox was starting
+type wakeResult struct {
+	// session is the first session channel, accepted early to show the progress
+	session  ssh.Channel
+	requests <-chan *ssh.Request
+	// channels are the other channels, not answered yet
+	channels []ssh.NewChannel
+}
+
+func (g *Gateway) canWake(authMode AuthMode) bool {
+	return g.options.EnableWakeOnConnect && g.waker != nil &&
+		(authMode == AuthModePublicKey || authMode == AuthModeMemberKey ||
+			authMode == AuthModeCertificate)
+}
+
+// wakeDevbox starts the devbox and holds the client until the devbox sshd is reachable.
+// While waiting, keep-alive requests are sent to the client and the progress is written
+// to the first session channel. It returns nil if the devbox failed to start in time or
+// the client went away.
+func (g *Gateway) wakeDevbox(
+	conn *ssh.ServerConn,
+	chans <-chan ssh.NewChannel,
+	reqs <-chan *ssh.Request,
+	info *registry.DevboxInfo,
+	logger *log.Entry,
+) *wakeResult {
+	wakeLogger := logger.WithField("mode", "wake_on_connect")
+	wakeLogger.Info("Devbox not running, waking it up")
+
+	start := time.Now()
+
+	// Unanswered global requests of the client, like its keep-alives, would block
+	// the connection and with it the replies to the keep-alives sent below
+	stopDiscard := discardRequestsUntilStopped(reqs)
+	defer stopDiscard()
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.options.WakeTimeout)
+	defer cancel()
+
+	ready := make(chan error, 1)
+	go func() {
+		ready <- g.waitForDevbox(ctx, info)
+	}()
+
+	keepAlive := time.NewTicker(g.options.WakeKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	result := &wakeResult{}
+
+	for {
+		select {
+		case err := <-ready:
+			if err != nil {
+				wakeLogger.WithError(err).Warn("Failed to wake devbox")
+				result.fail(fmt.Sprintf(
+					"failed to start devbox %s/%s: %v",
+					info.Namespace, info.DevboxName, err,
+				))
+
+				return nil
+			}
+
+			wakeLogger.WithField("duration", time.Since(start).String()).Info("Devbox woken up")
+			result.printf("\r\nDevbox %s/%s is ready.\r\n", info.Namespace, info.DevboxName)
+
+			return result
+
+		case newChannel, ok := <-chans:
+			if !ok {
+				wakeLogger.Info("Client disconnected while waking devbox")
+				result.close()
+
+				return nil
+			}
+
+			if result.session != nil || newChannel.ChannelType() != "session" {
+				result.channels = append(result.channels, newChannel)
+				continue
+			}
+
+			channel, requests, err := newChannel.Accept()
+			if err != nil {
+				wakeLogger.WithError(err).Warn("Failed to accept session channel")
+				continue
+			}
+
+			result.session = channel
+			result.requests = requests
+			result.printf(
+				"Devbox %s/%s is not running, starting it. Please wait...",
+				info.Namespace, info.DevboxName,
+			)
+
+		case <-keepAlive.C:
+			// Keep idle connections through NATs and load balancers alive
+			if _, _, err := conn.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				wakeLogger.WithError(err).Info("Client disconnected while waking devbox")
+				result.close()
+
+				return nil
+			}
+
+			result.printf(".")
+		}
+	}
+}
+
+// waitForDevbox wakes the devbox and waits until its sshd accepts connections
+func (g *Gateway) waitForDevbox(ctx context.Context, info *registry.DevboxInfo) error {
+	if err := g.waker.Wake(ctx, info.Namespace, info.DevboxName); err != nil {
+		return err
+	}
+
+	podIP, err := g.registry.WaitForPodIP(ctx, info.Namespace, info.DevboxName)
+	if err != nil {
+		return fmt.Errorf("timed out waiting for devbox pod: %w", err)
+	}
+
+	// The pod gets an IP before sshd is listening
+	backendAddr := net.JoinHostPort(podIP, strconv.Itoa(g.options.SSHBackendPort))
+	for {
+		var dialer net.Dialer
+
+		dialCtx, cancel := context.WithTimeout(ctx, g.options.BackendConnectTimeoutPublicKey)
+		backendConn, err := dialer.DialContext(dialCtx, "tcp", backendAddr)
+
+		cancel()
+
+		if err == nil {
+			_ = backendConn.Close()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for devbox sshd: %w", err)
+		case <-time.After(wakeDialInterval):
+		}
+	}
+}
+
+// discardRequestsUntilStopped rejects the requests until the returned function is
+// called, the requests received after are left to the caller
+func discardRequestsUntilStopped(reqs <-chan *ssh.Request) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		for {
+			select {
+			case <-done:
+				return
+			case req, ok := <-reqs:
+				if !ok {
+					return
+				}
+
+				if req.WantReply {
+					_ = req.Reply(false, nil)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// printf writes a progress message to the stderr of the session channel, if any
+func (r *wakeResult) printf(format string, args ...any) {
+	if r.session == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(r.session.Stderr(), format, args...)
+}
+
+// fail reports the message to the client and rejects all held channels
+func (r *wakeResult) fail(message string) {
+	r.printf("\r\n%s\r\n", message)
+
+	for _, newChannel := range r.channels {
+		_ = newChannel.Reject(ssh.ConnectionFailed, message)
+	}
+
+	r.close()
+}
+
+func (r *wakeResult) close() {
+	if r.session == nil {
+		return
+	}
+
+	go ssh.DiscardRequests(r.requests)
+
+	_ = r.session.Close()
+}
+
+// handleWokenSessionPublicKey proxies the session channel accepted while the devbox was starting
+func (g *Gateway) handleWokenSessionPublicKey(
+	woken *wakeResult,
+	backendConn *ssh.Client,
+	tap *channelTap,
+	logger *log.Entry,
+) {
+	channelLogger := logger.WithField("channel_type", "session")
+	defer tap.close(channelLogger)
+
+	backendChannel, backendReqs, err := backendConn.OpenChannel("session", nil)
+	if err != nil {
+		channelLogger.WithError(err).Warn("Failed to open backend channel")
+		woken.printf("Failed to open session on devbox: %v\r\n", err)
+		woken.close()
+
+		return
+	}
+	defer backendChannel.Close()
+	defer woken.session.Close()
+
+	channelLogger.Debug("Channel established")
+
+	// Requests sent by the client while waiting are still queued and forwarded in order
+	g.proxyChannelWithRequests(
+		woken.session,
+		backendChannel,
+		woken.requests,
+		backendReqs,
+		tap,
+		channelLogger,
+	)
+}
@@ -0,0 +1,245 @@
+package gateway_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/sshgate/gateway"
+	"github.com/labring/sealos/service/sshgate/registry"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeWaker simulates the devbox controller starting the pod after a delay
+type fakeWaker struct {
+	reg   *registry.Registry
+	podIP string
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (w *fakeWaker) Wake(_ context.Context, namespace, devboxName string) error {
+	w.calls.Add(1)
+
+	if w.podIP == "" {
+		return nil
+	}
+
+	time.AfterFunc(w.delay, func() {
+		_ = w.reg.UpdatePod(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      devboxName + "-pod",
+				Namespace: namespace,
+				Labels: map[string]string{
+					registry.DevboxPartOfLabel: registry.DevboxPartOfValue,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: registry.DevboxOwnerKind, Name: devboxName},
+				},
+			},
+			Status: corev1.PodStatus{PodIP: w.podIP},
+		})
+	})
+
+	return nil
+}
+
+// startStoppedDevboxGateway starts a gateway for a devbox with keys but no pod
+func startStoppedDevboxGateway(
+	t *testing.T,
+	waker *fakeWaker,
+	opts ...gateway.Option,
+) (string, []byte) {
+	t.Helper()
+
+	_, addr, privBytes := newStoppedDevboxGateway(t, waker, opts...)
+
+	return addr, privBytes
+}
+
+// newStoppedDevboxGateway is startStoppedDevboxGateway also returning the gateway
+func newStoppedDevboxGateway(
+	t *testing.T,
+	waker *fakeWaker,
+	opts ...gateway.Option,
+) (*gateway.Gateway, string, []byte) {
+	t.Helper()
+
+	hostKey, _, pubBytes, privBytes := generateTestKeys(t)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				registry.DevboxPartOfLabel: registry.DevboxPartOfValue,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: registry.DevboxOwnerKind, Name: "test-devbox"},
+			},
+		},
+		Data: map[string][]byte{
+			registry.DevboxPublicKeyField:  pubBytes,
+			registry.DevboxPrivateKeyField: privBytes,
+		},
+	}
+	if err := waker.reg.AddSecret(nil, secret); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	var lc net.ListenConfig
+
+	backendListener, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start backend listener: %v", err)
+	}
+	t.Cleanup(func() { backendListener.Close() })
+
+	_, backendPort, _ := net.SplitHostPort(backendListener.Addr().String())
+
+	backendKey, _, _, _ := generateTestKeys(t)
+	go runMockBackendServer(t, backendListener, backendKey, privBytes, 0)
+
+	gw := gateway.New(hostKey, waker.reg, append([]gateway.Option{
+		gateway.WithSSHBackendPort(mustAtoi(t, backendPort)),
+		gateway.WithSSHHandshakeTimeout(5 * time.Second),
+		gateway.WithEnableWakeOnConnect(true),
+		gateway.WithWakeTimeouts(3*time.Second, 100*time.Millisecond),
+	}, opts...)...)
+	gw.SetWaker(waker)
+
+	gwListener, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start gateway listener: %v", err)
+	}
+	t.Cleanup(func() { gwListener.Close() })
+
+	go func() {
+		for {
+			conn, err := gwListener.Accept()
+			if err != nil {
+				return
+			}
+
+			go gw.HandleConnection(conn)
+		}
+	}()
+
+	return gw, gwListener.Addr().String(), privBytes
+}
+
+func TestWakeOnConnect(t *testing.T) {
+	waker := &fakeWaker{reg: registry.New(), podIP: "127.0.0.1", delay: 500 * time.Millisecond}
+	addr, privBytes := startStoppedDevboxGateway(t, waker)
+
+	exitCode, err := runSSHCommand(t, addr, privBytes, "exit 7")
+	if err != nil {
+		t.Fatalf("SSH error: %v", err)
+	}
+
+	if exitCode != 7 {
+		t.Errorf("Expected exit code 7, got %d", exitCode)
+	}
+
+	if calls := waker.calls.Load(); calls != 1 {
+		t.Errorf("Expected devbox to be woken once, got %d", calls)
+	}
+
+	// The devbox is running now, no more wake up is needed
+	if _, err := runSSHCommand(t, addr, privBytes, "exit 0"); err != nil {
+		t.Fatalf("SSH error: %v", err)
+	}
+
+	if calls := waker.calls.Load(); calls != 1 {
+		t.Errorf("Expected devbox to be woken once, got %d", calls)
+	}
+}
+
+func TestWakeOnConnect_ClientGlobalRequests(t *testing.T) {
+	waker := &fakeWaker{reg: registry.New(), podIP: "127.0.0.1", delay: time.Second}
+	addr, privBytes := startStoppedDevboxGateway(t, waker)
+
+	signer, err := ssh.ParsePrivateKey(privBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse private key: %v", err)
+	}
+
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User: "testuser",
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		//nolint:gosec // acceptable for testing
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	// More keep-alives than the connection buffers, each one must be answered
+	// while the devbox is starting
+	replied := make(chan error, 1)
+	go func() {
+		for range 64 {
+			if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				replied <- err
+				return
+			}
+		}
+		replied <- nil
+	}()
+
+	select {
+	case err := <-replied:
+		if err != nil {
+			t.Fatalf("Keep-alive failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Keep-alives not answered while waking devbox")
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	if err := session.Run("exit 0"); err != nil {
+		t.Fatalf("SSH error: %v", err)
+	}
+}
+
+func TestWakeOnConnect_Timeout(t *testing.T) {
+	// The pod never starts
+	waker := &fakeWaker{reg: registry.New()}
+	addr, privBytes := startStoppedDevboxGateway(t, waker,
+		gateway.WithWakeTimeouts(500*time.Millisecond, 100*time.Millisecond),
+	)
+
+	if _, err := runSSHCommand(t, addr, privBytes, "exit 0"); err == nil {
+		t.Fatal("Expected error when devbox fails to start in time")
+	}
+
+	if calls := waker.calls.Load(); calls != 1 {
+		t.Errorf("Expected devbox to be woken once, got %d", calls)
+	}
+}
+
+func TestWakeOnConnect_Disabled(t *testing.T) {
+	waker := &fakeWaker{reg: registry.New(), podIP: "127.0.0.1"}
+	addr, privBytes := startStoppedDevboxGateway(t, waker,
+		gateway.WithEnableWakeOnConnect(false),
+	)
+
+	if _, err := runSSHCommand(t, addr, privBytes, "exit 0"); err == nil {
+		t.Fatal("Expected error when devbox is not running")
+	}
+
+	if calls := waker.calls.Load(); calls != 0 {
+		t.Errorf("Expected devbox not to be woken, got %d calls", calls)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/labring/sealos/service/sshgate/registry"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -18,9 +20,12 @@ type Manager struct {
 	clientset    kubernetes.Interface
 	registry     *registry.Registry
 	resyncPeriod time.Duration
+	teamAccess   bool
 	factory      informers.SharedInformerFactory
-	cancel       context.CancelFunc
-	logger       *log.Entry
+	// keysFactory watches the labeled authorized keys ConfigMaps only
+	keysFactory informers.SharedInformerFactory
+	cancel      context.CancelFunc
+	logger      *log.Entry
 }
 
 // Option configures the informer manager
@@ -33,6 +38,13 @@ func WithResyncPeriod(d time.Duration) Option {
 	}
 }
 
+// WithTeamAccess enables watching the authorized keys and RoleBindings of workspace members
+func WithTeamAccess(enabled bool) Option {
+	return func(m *Manager) {
+		m.teamAccess = enabled
+	}
+}
+
 // New creates a new informer manager
 func New(clientset kubernetes.Interface, reg *registry.Registry, opts ...Option) *Manager {
 	m := &Manager{
@@ -82,11 +94,22 @@ func (m *Manager) Start(ctx context.Context) error {
 		return err
 	}
 
+	synced := []cache.InformerSynced{secretInformer.HasSynced, podInformer.HasSynced}
+
+	if m.teamAccess {
+		teamSynced, err := m.startTeamAccessInformers(ctx)
+		if err != nil {
+			return err
+		}
+
+		synced = append(synced, teamSynced...)
+	}
+
 	// Start informers
 	m.factory.Start(ctx.Done())
 
 	// Wait for cache sync
-	if !cache.WaitForCacheSync(ctx.Done(), secretInformer.HasSynced, podInformer.HasSynced) {
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
 		return ErrCacheSyncFailed
 	}
 
@@ -105,6 +128,47 @@ func (m *Manager) Stop() {
 	if m.factory != nil {
 		m.factory.Shutdown()
 	}
+
+	if m.keysFactory != nil {
+		m.keysFactory.Shutdown()
+	}
+}
+
+// startTeamAccessInformers sets up the informers of workspace member keys and roles
+func (m *Manager) startTeamAccessInformers(ctx context.Context) ([]cache.InformerSynced, error) {
+	m.keysFactory = informers.NewSharedInformerFactoryWithOptions(
+		m.clientset,
+		m.resyncPeriod,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = registry.AuthorizedKeysLabel + "=" + registry.AuthorizedKeysValue
+		}),
+	)
+
+	configMapInformer := m.keysFactory.Core().V1().ConfigMaps().Informer()
+
+	_, err := configMapInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.handleConfigMapAdd,
+		UpdateFunc: m.handleConfigMapUpdate,
+		DeleteFunc: m.handleConfigMapDelete,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	roleBindingInformer := m.factory.Rbac().V1().RoleBindings().Informer()
+
+	_, err = roleBindingInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.handleRoleBindingAdd,
+		UpdateFunc: m.handleRoleBindingUpdate,
+		DeleteFunc: m.handleRoleBindingDelete,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.keysFactory.Start(ctx.Done())
+
+	return []cache.InformerSynced{configMapInformer.HasSynced, roleBindingInformer.HasSynced}, nil
 }
 
 // IsStarted returns true if the manager has been started and factory is initialized
@@ -142,6 +206,34 @@ func (m *Manager) ProcessPod(pod *corev1.Pod, action string) error {
 	return nil
 }
 
+// ProcessConfigMap processes an authorized keys configmap (for testing)
+func (m *Manager) ProcessConfigMap(configMap *corev1.ConfigMap, action string) error {
+	switch action {
+	case "add", "update":
+		m.handleConfigMapAdd(configMap)
+	case "delete":
+		m.handleConfigMapDelete(configMap)
+	default:
+		return fmt.Errorf("unknown action: %s", action)
+	}
+
+	return nil
+}
+
+// ProcessRoleBinding processes a rolebinding (for testing)
+func (m *Manager) ProcessRoleBinding(roleBinding *rbacv1.RoleBinding, action string) error {
+	switch action {
+	case "add", "update":
+		m.handleRoleBindingAdd(roleBinding)
+	case "delete":
+		m.handleRoleBindingDelete(roleBinding)
+	default:
+		return fmt.Errorf("unknown action: %s", action)
+	}
+
+	return nil
+}
+
 // Event handlers for secrets
 func (m *Manager) handleSecretAdd(obj any) {
 	secret, ok := obj.(*corev1.Secret)
@@ -224,3 +316,62 @@ func (m *Manager) handlePodDelete(obj any) {
 
 	m.registry.DeletePod(pod)
 }
+
+// Event handlers for authorized keys configmaps
+func (m *Manager) handleConfigMapAdd(obj any) {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		m.logger.WithField("type", fmt.Sprintf("%T", obj)).Error("Expected *corev1.ConfigMap")
+		return
+	}
+
+	m.registry.AddAuthorizedKeys(configMap)
+}
+
+func (m *Manager) handleConfigMapUpdate(_, newObj any) {
+	m.handleConfigMapAdd(newObj)
+}
+
+func (m *Manager) handleConfigMapDelete(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		m.logger.WithField("type", fmt.Sprintf("%T", obj)).Error("Expected *corev1.ConfigMap")
+		return
+	}
+
+	m.registry.DeleteAuthorizedKeys(configMap)
+}
+
+// Event handlers for rolebindings
+func (m *Manager) handleRoleBindingAdd(obj any) {
+	roleBinding, ok := obj.(*rbacv1.RoleBinding)
+	if !ok {
+		m.logger.WithField("type", fmt.Sprintf("%T", obj)).Error("Expected *rbacv1.RoleBinding")
+		return
+	}
+
+	m.registry.AddRoleBinding(roleBinding)
+}
+
+func (m *Manager) handleRoleBindingUpdate(_, newObj any) {
+	m.handleRoleBindingAdd(newObj)
+}
+
+func (m *Manager) handleRoleBindingDelete(obj any) {
+	// Missing a revocation would keep the access, so handle tombstones
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	roleBinding, ok := obj.(*rbacv1.RoleBinding)
+	if !ok {
+		m.logger.WithField("type", fmt.Sprintf("%T", obj)).Error("Expected *rbacv1.RoleBinding")
+		return
+	}
+
+	m.registry.DeleteRoleBinding(roleBinding)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/labring/sealos/service/sshgate/registry"
 	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
@@ -420,3 +421,64 @@ func TestStartWithExistingResources(t *testing.T) {
 		t.Error("Manager not started after Start()")
 	}
 }
+
+func TestProcessTeamAccess(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	reg := registry.New()
+	mgr := informer.New(clientset, reg, informer.WithTeamAccess(true))
+
+	pubBytes, _ := generateTestKeys(t)
+	pubKey, _, _, _, _ := ssh.ParseAuthorizedKey(pubBytes)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "authorized-keys",
+			Namespace: "ns-team",
+			Labels: map[string]string{
+				registry.AuthorizedKeysLabel: registry.AuthorizedKeysValue,
+			},
+		},
+		Data: map[string]string{
+			"alice": string(pubBytes),
+		},
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alice-role-binding",
+			Namespace: "ns-team",
+		},
+		RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "Manager"},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      "alice",
+				Namespace: registry.UserServiceAccountNamespace,
+			},
+		},
+	}
+
+	if err := mgr.ProcessConfigMap(configMap, "add"); err != nil {
+		t.Fatalf("ProcessConfigMap failed: %v", err)
+	}
+
+	if err := mgr.ProcessRoleBinding(roleBinding, "add"); err != nil {
+		t.Fatalf("ProcessRoleBinding failed: %v", err)
+	}
+
+	if user, ok := reg.AuthorizeMemberKey("ns-team", pubKey); !ok || user != "alice" {
+		t.Fatalf("AuthorizeMemberKey() = %s, %v, want alice, true", user, ok)
+	}
+
+	if err := mgr.ProcessRoleBinding(roleBinding, "delete"); err != nil {
+		t.Fatalf("ProcessRoleBinding failed: %v", err)
+	}
+
+	if _, ok := reg.AuthorizeMemberKey("ns-team", pubKey); ok {
+		t.Error("Member key still accepted after rolebinding deletion")
+	}
+
+	if err := mgr.ProcessConfigMap(configMap, "unknown"); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+}
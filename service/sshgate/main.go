@@ -8,14 +8,19 @@ import (
 	"time"
 	_ "time/tzdata"
 
+	"github.com/labring/sealos/service/sshgate/admin"
 	"github.com/labring/sealos/service/sshgate/config"
 	"github.com/labring/sealos/service/sshgate/gateway"
 	"github.com/labring/sealos/service/sshgate/hostkey"
 	"github.com/labring/sealos/service/sshgate/informer"
 	"github.com/labring/sealos/service/sshgate/logger"
+	"github.com/labring/sealos/service/sshgate/metrics"
 	"github.com/labring/sealos/service/sshgate/pprof"
+	"github.com/labring/sealos/service/sshgate/recorder"
 	"github.com/labring/sealos/service/sshgate/registry"
+	"github.com/labring/sealos/service/sshgate/waker"
 	proxyproto "github.com/pires/go-proxyproto"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -56,8 +61,22 @@ func main() {
 		}()
 	}
 
+	// Start metrics server if enabled
+	if cfg.MetricsEnabled {
+		go func() {
+			if err := metrics.RunMetricsServer(cfg.MetricsPort); err != nil {
+				log.Printf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
 	// Create Kubernetes client
-	clientset, err := createKubernetesClient()
+	kubeConfig, err := createKubernetesConfig()
+	if err != nil {
+		log.Fatalf("Failed to create Kubernetes config: %v", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
@@ -68,6 +87,7 @@ func main() {
 	// Setup and start informers
 	infMgr := informer.New(clientset, reg,
 		informer.WithResyncPeriod(cfg.InformerResyncPeriod),
+		informer.WithTeamAccess(cfg.EnableTeamAccess),
 	)
 
 	ctx := context.Background()
@@ -84,6 +104,39 @@ func main() {
 	// Create gateway with embedded options
 	gw := gateway.New(hostKey, reg, gateway.WithOptions(cfg.Gateway))
 
+	// Accept certificates of the trusted user CAs, keys are validated by config.Load
+	userCAKeys, _ := gateway.ParseUserCAKeys([]byte(cfg.TrustedUserCAKeys))
+	gw.SetUserCAKeys(userCAKeys)
+
+	// Record sessions of the configured namespaces
+	if cfg.Recording.Enabled() {
+		sink, err := recorder.NewSink(cfg.Recording)
+		if err != nil {
+			log.Fatalf("Failed to create session recording sink: %v", err)
+		}
+
+		gw.SetRecorder(recorder.New(sink, cfg.Recording))
+	}
+
+	// Start stopped devboxes when their users connect
+	if cfg.Gateway.EnableWakeOnConnect {
+		dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+		if err != nil {
+			log.Fatalf("Failed to create Kubernetes dynamic client: %v", err)
+		}
+
+		gw.SetWaker(waker.New(dynamicClient))
+	}
+
+	// Serve the admin API if enabled
+	if cfg.Admin.Enabled() {
+		go func() {
+			if err := admin.NewServer(gw, cfg.Admin.Token).Run(cfg.Admin.ListenAddr); err != nil {
+				log.Printf("Admin API error: %v", err)
+			}
+		}()
+	}
+
 	// Start SSH server
 	//nolint:noctx
 	listener, err := net.Listen("tcp", cfg.SSHListenAddr)
@@ -113,8 +166,8 @@ func main() {
 	}
 }
 
-// createKubernetesClient creates a Kubernetes clientset
-func createKubernetesClient() (*kubernetes.Clientset, error) {
+// createKubernetesConfig creates the Kubernetes client config
+func createKubernetesConfig() (*rest.Config, error) {
 	// Try in-cluster config first
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -130,5 +183,5 @@ func createKubernetesClient() (*kubernetes.Clientset, error) {
 		}
 	}
 
-	return kubernetes.NewForConfig(config)
+	return config, nil
 }
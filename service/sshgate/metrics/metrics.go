@@ -0,0 +1,117 @@
+// Package metrics provides the Prometheus metrics of the SSH gateway
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const namespace = "sshgate"
+
+// Auth results
+const (
+	AuthResultSuccess = "success"
+	AuthResultFailure = "failure"
+)
+
+// Handshake results
+const (
+	HandshakeResultSuccess = "success"
+	HandshakeResultFailure = "failure"
+)
+
+// Reasons for rejecting a connection
+const (
+	RejectReasonBanned      = "banned"
+	RejectReasonIPLimit     = "ip_limit"
+	RejectReasonDevboxLimit = "devbox_limit"
+)
+
+// Directions of proxied bytes
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+var (
+	// ActiveSessions is the number of authenticated connections being proxied
+	ActiveSessions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Number of authenticated SSH connections being proxied.",
+	}, []string{"auth_mode"})
+
+	// AuthAttempts counts the authentication attempts by auth mode and result
+	AuthAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_attempts_total",
+		Help:      "Number of SSH authentication attempts by auth mode and result.",
+	}, []string{"auth_mode", "result"})
+
+	// HandshakeDuration observes the duration of SSH handshakes, authentication included
+	HandshakeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handshake_duration_seconds",
+		Help:      "Duration of SSH handshakes including authentication.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"result"})
+
+	// ProxiedBytes counts the bytes proxied through channels by direction
+	ProxiedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "proxied_bytes_total",
+		Help:      "Number of bytes proxied between clients and devboxes.",
+	}, []string{"direction"})
+
+	// ConnectionsRejected counts the connections rejected by the gateway by reason
+	ConnectionsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "connections_rejected_total",
+		Help:      "Number of SSH connections rejected by the gateway.",
+	}, []string{"reason"})
+
+	// SessionsClosed counts the sessions closed by the gateway by reason
+	SessionsClosed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_closed_total",
+		Help:      "Number of SSH sessions closed by the gateway.",
+	}, []string{"reason"})
+
+	// AuthBans counts the client IPs banned after repeated authentication failures
+	AuthBans = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_bans_total",
+		Help:      "Number of client IPs banned after repeated authentication failures.",
+	})
+)
+
+// RunMetricsServer starts the metrics server on port, serving /metrics
+func RunMetricsServer(port int) error {
+	addr := fmt.Sprintf(":%d", port)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 5,
+	}
+
+	//nolint:noctx
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithField("component", "metrics").Infof("metrics listening on %s", ln.Addr())
+
+	return server.Serve(ln)
+}
@@ -0,0 +1,39 @@
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalSink stores recordings in a local directory
+type LocalSink struct {
+	dir string
+}
+
+// NewLocalSink creates a sink storing recordings under dir
+func NewLocalSink(dir string) *LocalSink {
+	return &LocalSink{dir: dir}
+}
+
+// Put writes the recording to dir/key
+func (s *LocalSink) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	name := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,393 @@
+// Package recorder records SSH sessions in asciicast v2 format
+// Ref: https://docs.asciinema.org/manual/asciicast/v2/
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SinkLocal stores recordings in a local directory
+	SinkLocal = "local"
+	// SinkS3 stores recordings in S3-compatible object storage
+	SinkS3 = "s3"
+
+	// AllNamespaces enables recording in every namespace
+	AllNamespaces = "*"
+
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Options holds session recording configuration options
+type Options struct {
+	// Namespaces whose sessions are recorded, "*" records all namespaces
+	Namespaces    []string      `env:"NAMESPACES"     envSeparator:","`
+	RecordInput   bool          `env:"RECORD_INPUT"   envDefault:"false"`
+	Sink          string        `env:"SINK"           envDefault:"local"`
+	Dir           string        `env:"DIR"            envDefault:"/var/lib/sshgate/recordings"`
+	UploadTimeout time.Duration `env:"UPLOAD_TIMEOUT" envDefault:"1m"`
+	S3            S3Options     `envPrefix:"S3_"`
+}
+
+// DefaultOptions returns the default recording options
+func DefaultOptions() Options {
+	return Options{
+		RecordInput:   false,
+		Sink:          SinkLocal,
+		Dir:           "/var/lib/sshgate/recordings",
+		UploadTimeout: time.Minute,
+		S3:            DefaultS3Options(),
+	}
+}
+
+// Enabled returns true if any namespace is recorded
+func (o *Options) Enabled() bool {
+	return len(o.Namespaces) > 0
+}
+
+// Sink stores finished recordings
+type Sink interface {
+	// Put stores the recording read from r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+}
+
+// Recorder starts recordings for sessions in the configured namespaces
+type Recorder struct {
+	sink    Sink
+	options Options
+	logger  *log.Entry
+}
+
+// New creates a new Recorder writing to sink
+func New(sink Sink, options Options) *Recorder {
+	return &Recorder{
+		sink:    sink,
+		options: options,
+		logger:  log.WithField("component", "recorder"),
+	}
+}
+
+// NewSink creates the sink configured by the options
+func NewSink(options Options) (Sink, error) {
+	switch options.Sink {
+	case SinkLocal:
+		return NewLocalSink(options.Dir), nil
+	case SinkS3:
+		return NewS3Sink(options.S3)
+	default:
+		return nil, fmt.Errorf("unknown recording sink: %s", options.Sink)
+	}
+}
+
+// Enabled returns true if sessions in namespace are recorded
+func (r *Recorder) Enabled(namespace string) bool {
+	if r == nil {
+		return false
+	}
+
+	return slices.Contains(r.options.Namespaces, AllNamespaces) ||
+		slices.Contains(r.options.Namespaces, namespace)
+}
+
+// Metadata describes a recorded session
+type Metadata struct {
+	Namespace  string
+	DevboxName string
+	User       string
+	// SessionID identifies the SSH connection
+	SessionID string
+	// Channel is the index of the session channel in the connection
+	Channel int
+}
+
+// Key returns the object key of the recording
+func (m *Metadata) Key(start time.Time) string {
+	return path.Join(
+		m.Namespace,
+		m.DevboxName,
+		start.Format(time.DateOnly),
+		fmt.Sprintf("%d-%s-%d.cast", start.Unix(), m.SessionID, m.Channel),
+	)
+}
+
+// Start starts recording a session, it returns nil if the namespace is not recorded.
+func (r *Recorder) Start(metadata Metadata) (*Recording, error) {
+	if !r.Enabled(metadata.Namespace) {
+		return nil, nil
+	}
+
+	events, err := os.CreateTemp("", "sshgate-recording-*.cast")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+
+	start := time.Now()
+
+	return &Recording{
+		recorder:    r,
+		key:         metadata.Key(start),
+		start:       start,
+		recordInput: r.options.RecordInput,
+		events:      events,
+		header: header{
+			Version:   2,
+			Width:     defaultWidth,
+			Height:    defaultHeight,
+			Timestamp: start.Unix(),
+			Title:     fmt.Sprintf("%s@%s/%s", metadata.User, metadata.Namespace, metadata.DevboxName),
+			Env:       map[string]string{},
+		},
+	}, nil
+}
+
+// header is the first line of an asciicast v2 file
+type header struct {
+	Version   int               `json:"version"`
+	Width     uint32            `json:"width"`
+	Height    uint32            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Duration  float64           `json:"duration,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// ptyRequestMsg is the payload of a pty-req request (RFC 4254 6.2)
+type ptyRequestMsg struct {
+	Term     string
+	Columns  uint32
+	Rows     uint32
+	Width    uint32
+	Height   uint32
+	Modelist string
+}
+
+// windowChangeMsg is the payload of a window-change request (RFC 4254 6.7)
+type windowChangeMsg struct {
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+}
+
+// envRequestMsg is the payload of an env request (RFC 4254 6.4)
+type envRequestMsg struct {
+	Name  string
+	Value string
+}
+
+// Recording records a single session channel.
+// The events are buffered in a temporary file and stored when the recording is closed,
+// since the terminal size in the header is only known after the pty-req.
+type Recording struct {
+	recorder    *Recorder
+	key         string
+	start       time.Time
+	recordInput bool
+
+	mu     sync.Mutex
+	header header
+	events *os.File
+	// pending holds incomplete UTF-8 sequences split across reads, by event type
+	pending map[string][]byte
+	closed  bool
+}
+
+// Key returns the object key of the recording
+func (rec *Recording) Key() string {
+	if rec == nil {
+		return ""
+	}
+
+	return rec.key
+}
+
+// ObserveRequest records the terminal size and environment from channel requests
+func (rec *Recording) ObserveRequest(req *ssh.Request) {
+	if rec == nil {
+		return
+	}
+
+	switch req.Type {
+	case "pty-req":
+		var msg ptyRequestMsg
+		if err := ssh.Unmarshal(req.Payload, &msg); err != nil {
+			return
+		}
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+
+		rec.header.Width = msg.Columns
+		rec.header.Height = msg.Rows
+		rec.header.Env["TERM"] = msg.Term
+
+	case "window-change":
+		var msg windowChangeMsg
+		if err := ssh.Unmarshal(req.Payload, &msg); err != nil {
+			return
+		}
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+
+		rec.writeEventLocked("r", fmt.Sprintf("%dx%d", msg.Columns, msg.Rows))
+
+	case "env":
+		var msg envRequestMsg
+		if err := ssh.Unmarshal(req.Payload, &msg); err != nil {
+			return
+		}
+
+		if msg.Name != "SHELL" && msg.Name != "TERM" {
+			return
+		}
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+
+		rec.header.Env[msg.Name] = msg.Value
+	}
+}
+
+// Output returns a writer recording the output sent to the client
+func (rec *Recording) Output() io.Writer {
+	return eventWriter{rec: rec, eventType: "o"}
+}
+
+// Input returns a writer recording the input received from the client,
+// the input is discarded unless input recording is enabled
+func (rec *Recording) Input() io.Writer {
+	if !rec.recordInput {
+		return io.Discard
+	}
+
+	return eventWriter{rec: rec, eventType: "i"}
+}
+
+type eventWriter struct {
+	rec       *Recording
+	eventType string
+}
+
+func (w eventWriter) Write(p []byte) (int, error) {
+	w.rec.mu.Lock()
+	defer w.rec.mu.Unlock()
+
+	if w.rec.pending == nil {
+		w.rec.pending = make(map[string][]byte)
+	}
+
+	data := append(w.rec.pending[w.eventType], p...)
+
+	// Keep an incomplete trailing rune for the next write
+	n := len(data)
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				n = len(data) - i
+			}
+
+			break
+		}
+	}
+
+	w.rec.pending[w.eventType] = slices.Clone(data[n:])
+	if n > 0 {
+		w.rec.writeEventLocked(w.eventType, string(data[:n]))
+	}
+
+	// Never fail the proxied stream because of the recording
+	return len(p), nil
+}
+
+func (rec *Recording) writeEventLocked(eventType, data string) {
+	if rec.closed {
+		return
+	}
+
+	elapsed := time.Since(rec.start).Seconds()
+
+	event, err := json.Marshal([]any{elapsed, eventType, data})
+	if err != nil {
+		return
+	}
+
+	_, _ = rec.events.Write(append(event, '\n'))
+}
+
+// Close stores the recording in the sink and removes the temporary file
+func (rec *Recording) Close() error {
+	if rec == nil {
+		return nil
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.closed {
+		return nil
+	}
+
+	for eventType, data := range rec.pending {
+		if len(data) > 0 {
+			rec.writeEventLocked(eventType, string(data))
+		}
+	}
+
+	rec.closed = true
+
+	defer func() {
+		_ = rec.events.Close()
+		_ = os.Remove(rec.events.Name())
+	}()
+
+	rec.header.Duration = math.Round(time.Since(rec.start).Seconds()*1e6) / 1e6
+
+	headerLine, err := json.Marshal(rec.header)
+	if err != nil {
+		return err
+	}
+
+	headerLine = append(headerLine, '\n')
+
+	size, err := rec.events.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	if _, err := rec.events.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rec.recorder.options.UploadTimeout)
+	defer cancel()
+
+	err = rec.recorder.sink.Put(
+		ctx,
+		rec.key,
+		io.MultiReader(bytes.NewReader(headerLine), rec.events),
+		int64(len(headerLine))+size,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store recording %s: %w", rec.key, err)
+	}
+
+	rec.recorder.logger.WithField("key", rec.key).Debug("Recording stored")
+
+	return nil
+}
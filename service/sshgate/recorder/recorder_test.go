@@ -0,0 +1,209 @@
+package recorder_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labring/sealos/service/sshgate/recorder"
+	"golang.org/x/crypto/ssh"
+)
+
+// readCast reads the header and events of an asciicast v2 file
+func readCast(t *testing.T, name string) (map[string]any, [][]any) {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Failed to open recording: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("Recording is empty")
+	}
+
+	var header map[string]any
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("Failed to parse header: %v", err)
+	}
+
+	var events [][]any
+
+	for scanner.Scan() {
+		var event []any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Failed to parse event %q: %v", scanner.Text(), err)
+		}
+
+		events = append(events, event)
+	}
+
+	return header, events
+}
+
+func newRecorder(t *testing.T, options recorder.Options) (*recorder.Recorder, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	options.Dir = dir
+
+	sink, err := recorder.NewSink(options)
+	if err != nil {
+		t.Fatalf("NewSink() error = %v", err)
+	}
+
+	return recorder.New(sink, options), dir
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		namespace  string
+		want       bool
+	}{
+		{"disabled", nil, "ns-a", false},
+		{"listed", []string{"ns-a", "ns-b"}, "ns-b", true},
+		{"not listed", []string{"ns-a"}, "ns-b", false},
+		{"all", []string{recorder.AllNamespaces}, "ns-b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := recorder.DefaultOptions()
+			options.Namespaces = tt.namespaces
+
+			r, _ := newRecorder(t, options)
+			if got := r.Enabled(tt.namespace); got != tt.want {
+				t.Errorf("Enabled(%s) = %v, want %v", tt.namespace, got, tt.want)
+			}
+		})
+	}
+
+	var r *recorder.Recorder
+	if r.Enabled("ns-a") {
+		t.Error("nil Recorder should not be enabled")
+	}
+}
+
+func TestRecording(t *testing.T) {
+	options := recorder.DefaultOptions()
+	options.Namespaces = []string{recorder.AllNamespaces}
+
+	r, dir := newRecorder(t, options)
+
+	rec, err := r.Start(recorder.Metadata{
+		Namespace:  "ns-test",
+		DevboxName: "devbox",
+		User:       "devbox",
+		SessionID:  "0123456789abcdef",
+		Channel:    1,
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	rec.ObserveRequest(&ssh.Request{
+		Type: "pty-req",
+		Payload: ssh.Marshal(struct {
+			Term                         string
+			Columns, Rows, Width, Height uint32
+			Modelist                     string
+		}{"xterm-256color", 120, 40, 0, 0, ""}),
+	})
+	rec.ObserveRequest(&ssh.Request{
+		Type:    "window-change",
+		Payload: ssh.Marshal(struct{ Columns, Rows, Width, Height uint32 }{100, 30, 0, 0}),
+	})
+
+	// A multi-byte rune split across two writes is kept in one event
+	euro := []byte("€")
+	_, _ = rec.Output().Write(append([]byte("price: "), euro[:1]...))
+	_, _ = rec.Output().Write(append(euro[1:], []byte("\r\n")...))
+	// Input is not recorded by default
+	_, _ = rec.Input().Write([]byte("secret\r"))
+
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	header, events := readCast(t, filepath.Join(dir, filepath.FromSlash(rec.Key())))
+
+	if header["version"] != float64(2) || header["width"] != float64(120) || header["height"] != float64(40) {
+		t.Errorf("Unexpected header: %v", header)
+	}
+
+	if env, _ := header["env"].(map[string]any); env["TERM"] != "xterm-256color" {
+		t.Errorf("Unexpected header env: %v", header["env"])
+	}
+
+	want := [][]any{
+		{"r", "100x30"},
+		{"o", "price: "},
+		{"o", "€\r\n"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Got %d events, want %d: %v", len(events), len(want), events)
+	}
+
+	for i, event := range events {
+		if event[1] != want[i][0] || event[2] != want[i][1] {
+			t.Errorf("Event %d = %v, want %v", i, event, want[i])
+		}
+	}
+}
+
+func TestRecording_Input(t *testing.T) {
+	options := recorder.DefaultOptions()
+	options.Namespaces = []string{"ns-test"}
+	options.RecordInput = true
+
+	r, dir := newRecorder(t, options)
+
+	rec, err := r.Start(recorder.Metadata{Namespace: "ns-test", DevboxName: "devbox", SessionID: "id"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	_, _ = rec.Input().Write([]byte("ls\r"))
+
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	_, events := readCast(t, filepath.Join(dir, filepath.FromSlash(rec.Key())))
+	if len(events) != 1 || events[0][1] != "i" || events[0][2] != "ls\r" {
+		t.Errorf("Unexpected events: %v", events)
+	}
+
+	// Sessions of other namespaces are not recorded
+	rec, err = r.Start(recorder.Metadata{Namespace: "ns-other", DevboxName: "devbox", SessionID: "id"})
+	if err != nil || rec != nil {
+		t.Errorf("Start() = %v, %v, want nil recording", rec, err)
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	options := recorder.DefaultOptions()
+
+	options.Sink = "unknown"
+	if _, err := recorder.NewSink(options); err == nil {
+		t.Error("Expected error for unknown sink")
+	}
+
+	options.Sink = recorder.SinkS3
+	if _, err := recorder.NewSink(options); err == nil {
+		t.Error("Expected error for s3 sink without endpoint")
+	}
+
+	options.S3.Endpoint = "127.0.0.1:9000"
+	options.S3.Bucket = "recordings"
+
+	if _, err := recorder.NewSink(options); err != nil {
+		t.Errorf("NewSink() error = %v", err)
+	}
+}
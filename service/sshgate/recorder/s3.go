@@ -0,0 +1,67 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options holds the S3-compatible object storage configuration
+type S3Options struct {
+	Endpoint  string `env:"ENDPOINT"`
+	Region    string `env:"REGION"`
+	Bucket    string `env:"BUCKET"`
+	Prefix    string `env:"PREFIX"`
+	AccessKey string `env:"ACCESS_KEY"`
+	SecretKey string `env:"SECRET_KEY"`
+	UseSSL    bool   `env:"USE_SSL"    envDefault:"true"`
+}
+
+// DefaultS3Options returns the default S3 options
+func DefaultS3Options() S3Options {
+	return S3Options{
+		UseSSL: true,
+	}
+}
+
+// S3Sink stores recordings in S3-compatible object storage
+type S3Sink struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Sink creates a sink storing recordings in the configured bucket
+func NewS3Sink(options S3Options) (*S3Sink, error) {
+	if options.Endpoint == "" || options.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+
+	client, err := minio.New(options.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(options.AccessKey, options.SecretKey, ""),
+		Secure: options.UseSSL,
+		Region: options.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Sink{
+		client: client,
+		bucket: options.Bucket,
+		prefix: options.Prefix,
+	}, nil
+}
+
+// Put uploads the recording to bucket/prefix/key
+func (s *S3Sink) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, path.Join(s.prefix, key), r, size, minio.PutObjectOptions{
+		ContentType: "application/x-asciicast",
+	})
+
+	return err
+}
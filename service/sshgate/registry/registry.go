@@ -2,6 +2,7 @@ package registry
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sync"
 
@@ -40,7 +41,13 @@ type Registry struct {
 	publicKeyToNamespaceDevbox map[string]string
 	// namespace/devboxName -> DevboxInfo
 	devboxToInfo map[string]*DevboxInfo
-	logger       *log.Entry
+	// namespace/devboxName -> closed once the devbox pod gets an IP
+	podIPWaiters map[string]chan struct{}
+	// namespace -> configMapName -> authorized key (string) -> user
+	authorizedKeys map[string]map[string]map[string]string
+	// namespace -> roleBindingName -> users with a member role
+	members map[string]map[string][]string
+	logger  *log.Entry
 }
 
 // New creates a new Registry instance
@@ -48,6 +55,9 @@ func New() *Registry {
 	return &Registry{
 		publicKeyToNamespaceDevbox: make(map[string]string),
 		devboxToInfo:               make(map[string]*DevboxInfo),
+		podIPWaiters:               make(map[string]chan struct{}),
+		authorizedKeys:             make(map[string]map[string]map[string]string),
+		members:                    make(map[string]map[string][]string),
 		logger:                     log.WithField("component", "registry"),
 	}
 }
@@ -199,6 +209,12 @@ func (r *Registry) UpdatePod(pod *corev1.Pod) error {
 	// Update PodIP even if empty (pod may be restarting)
 	info.PodIP = pod.Status.PodIP
 
+	// Wake up connections waiting for the devbox to start
+	if waiter, ok := r.podIPWaiters[key]; ok && info.PodIP != "" {
+		close(waiter)
+		delete(r.podIPWaiters, key)
+	}
+
 	return nil
 }
 
@@ -250,6 +266,38 @@ func (r *Registry) GetDevboxInfo(namespace, devboxName string) (*DevboxInfo, boo
 	return info, ok
 }
 
+// WaitForPodIP blocks until the devbox pod has an IP and returns it,
+// or returns the context error if ctx is done first
+func (r *Registry) WaitForPodIP(ctx context.Context, namespace, devboxName string) (string, error) {
+	key := fmt.Sprintf("%s/%s", namespace, devboxName)
+
+	for {
+		r.mu.Lock()
+
+		if info, ok := r.devboxToInfo[key]; ok && info.PodIP != "" {
+			podIP := info.PodIP
+			r.mu.Unlock()
+
+			return podIP, nil
+		}
+
+		waiter, ok := r.podIPWaiters[key]
+		if !ok {
+			waiter = make(chan struct{})
+			r.podIPWaiters[key] = waiter
+		}
+
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-waiter:
+			// The pod IP may be cleared again before we read it, check again
+		}
+	}
+}
+
 func getDevboxNameFromOwnerReferences(refs []metav1.OwnerReference) string {
 	for _, ref := range refs {
 		if ref.Kind == DevboxOwnerKind {
@@ -1,10 +1,13 @@
 package registry_test
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/pem"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/labring/sealos/service/sshgate/registry"
 	"golang.org/x/crypto/ssh"
@@ -244,6 +247,69 @@ func TestUpdatePod(t *testing.T) {
 	}
 }
 
+func TestWaitForPodIP(t *testing.T) {
+	r := registry.New()
+
+	newPod := func(podIP string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-pod",
+				Namespace: "test-ns",
+				Labels: map[string]string{
+					registry.DevboxPartOfLabel: registry.DevboxPartOfValue,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: registry.DevboxOwnerKind, Name: "test-devbox"},
+				},
+			},
+			Status: corev1.PodStatus{PodIP: podIP},
+		}
+	}
+
+	// Times out while the devbox has no pod IP
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := r.WaitForPodIP(ctx, "test-ns", "test-devbox"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForPodIP() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Returns once the pod gets an IP
+	result := make(chan string, 1)
+
+	go func() {
+		podIP, err := r.WaitForPodIP(context.Background(), "test-ns", "test-devbox")
+		if err != nil {
+			t.Errorf("WaitForPodIP() error = %v", err)
+		}
+
+		result <- podIP
+	}()
+
+	if err := r.UpdatePod(newPod("")); err != nil {
+		t.Fatalf("UpdatePod() error = %v", err)
+	}
+
+	if err := r.UpdatePod(newPod("10.0.0.1")); err != nil {
+		t.Fatalf("UpdatePod() error = %v", err)
+	}
+
+	select {
+	case podIP := <-result:
+		if podIP != "10.0.0.1" {
+			t.Errorf("WaitForPodIP() = %s, want 10.0.0.1", podIP)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForPodIP() did not return after pod got an IP")
+	}
+
+	// Returns immediately if the pod already has an IP
+	podIP, err := r.WaitForPodIP(context.Background(), "test-ns", "test-devbox")
+	if err != nil || podIP != "10.0.0.1" {
+		t.Errorf("WaitForPodIP() = %s, %v, want 10.0.0.1", podIP, err)
+	}
+}
+
 func TestGetByPublicKey(t *testing.T) {
 	r := registry.New()
 	pubKey, pubBytes, privBytes := generateTestKeyPair(t)
@@ -0,0 +1,176 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const (
+	// AuthorizedKeysLabel marks the ConfigMaps holding the authorized keys of workspace members.
+	// Each data field is named after a user and holds the user's keys in authorized_keys format.
+	AuthorizedKeysLabel = "devbox.sealos.io/authorized-keys"
+	// AuthorizedKeysValue is the expected label value for authorized keys ConfigMaps
+	AuthorizedKeysValue = "true"
+	// UserServiceAccountNamespace is the namespace of the ServiceAccounts representing users
+	UserServiceAccountNamespace = "user-system"
+)
+
+// memberRoles are the workspace roles allowed to access devboxes
+var memberRoles = []string{"Owner", "Manager", "Developer"}
+
+// AddAuthorizedKeys indexes the keys of workspace members from a ConfigMap
+func (r *Registry) AddAuthorizedKeys(configMap *corev1.ConfigMap) {
+	if configMap.Labels[AuthorizedKeysLabel] != AuthorizedKeysValue {
+		r.DeleteAuthorizedKeys(configMap)
+		return
+	}
+
+	// authorized key (string) -> user
+	keys := make(map[string]string)
+
+	for user, data := range configMap.Data {
+		scanner := bufio.NewScanner(bytes.NewBufferString(data))
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 || line[0] == '#' {
+				continue
+			}
+
+			publicKey, _, _, _, err := ssh.ParseAuthorizedKey(line)
+			if err != nil {
+				r.logger.WithFields(log.Fields{
+					"namespace": configMap.Namespace,
+					"configmap": configMap.Name,
+					"user":      user,
+				}).WithError(err).Warn("Failed to parse authorized key")
+
+				continue
+			}
+
+			keys[string(publicKey.Marshal())] = user
+		}
+	}
+
+	r.logger.WithFields(log.Fields{
+		"namespace": configMap.Namespace,
+		"configmap": configMap.Name,
+		"keys":      len(keys),
+	}).Info("Adding authorized keys")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.authorizedKeys[configMap.Namespace] == nil {
+		r.authorizedKeys[configMap.Namespace] = make(map[string]map[string]string)
+	}
+
+	r.authorizedKeys[configMap.Namespace][configMap.Name] = keys
+}
+
+// DeleteAuthorizedKeys removes the keys indexed from a ConfigMap
+func (r *Registry) DeleteAuthorizedKeys(configMap *corev1.ConfigMap) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.authorizedKeys[configMap.Namespace][configMap.Name]; !ok {
+		return
+	}
+
+	r.logger.WithFields(log.Fields{
+		"namespace": configMap.Namespace,
+		"configmap": configMap.Name,
+	}).Info("Removing authorized keys")
+
+	delete(r.authorizedKeys[configMap.Namespace], configMap.Name)
+
+	if len(r.authorizedKeys[configMap.Namespace]) == 0 {
+		delete(r.authorizedKeys, configMap.Namespace)
+	}
+}
+
+// AddRoleBinding records the users granted a member role by a RoleBinding
+func (r *Registry) AddRoleBinding(roleBinding *rbacv1.RoleBinding) {
+	users := getMemberUsers(roleBinding)
+	if len(users) == 0 {
+		r.DeleteRoleBinding(roleBinding)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.members[roleBinding.Namespace] == nil {
+		r.members[roleBinding.Namespace] = make(map[string][]string)
+	}
+
+	r.members[roleBinding.Namespace][roleBinding.Name] = users
+}
+
+// DeleteRoleBinding revokes the member roles granted by a RoleBinding
+func (r *Registry) DeleteRoleBinding(roleBinding *rbacv1.RoleBinding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[roleBinding.Namespace][roleBinding.Name]; !ok {
+		return
+	}
+
+	r.logger.WithFields(log.Fields{
+		"namespace":   roleBinding.Namespace,
+		"rolebinding": roleBinding.Name,
+	}).Info("Removing workspace members")
+
+	delete(r.members[roleBinding.Namespace], roleBinding.Name)
+
+	if len(r.members[roleBinding.Namespace]) == 0 {
+		delete(r.members, roleBinding.Namespace)
+	}
+}
+
+// AuthorizeMemberKey returns the user owning publicKey in the authorized keys of the namespace,
+// if the user currently has a member role in the namespace
+func (r *Registry) AuthorizeMemberKey(namespace string, publicKey ssh.PublicKey) (string, bool) {
+	keyStr := string(publicKey.Marshal())
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, keys := range r.authorizedKeys[namespace] {
+		user, ok := keys[keyStr]
+		if !ok {
+			continue
+		}
+
+		for _, users := range r.members[namespace] {
+			if slices.Contains(users, user) {
+				return user, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// getMemberUsers returns the users bound to a member role by the RoleBinding
+func getMemberUsers(roleBinding *rbacv1.RoleBinding) []string {
+	if roleBinding.RoleRef.Kind != "Role" || !slices.Contains(memberRoles, roleBinding.RoleRef.Name) {
+		return nil
+	}
+
+	var users []string
+
+	for _, subject := range roleBinding.Subjects {
+		if subject.Kind == rbacv1.ServiceAccountKind &&
+			subject.Namespace == UserServiceAccountNamespace {
+			users = append(users, subject.Name)
+		}
+	}
+
+	return users
+}
@@ -0,0 +1,127 @@
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/labring/sealos/service/sshgate/registry"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newAuthorizedKeysConfigMap(namespace string, keys map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "authorized-keys",
+			Namespace: namespace,
+			Labels: map[string]string{
+				registry.AuthorizedKeysLabel: registry.AuthorizedKeysValue,
+			},
+		},
+		Data: keys,
+	}
+}
+
+func newMemberRoleBinding(namespace, role, user string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user + "-role-binding",
+			Namespace: namespace,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      user,
+				Namespace: registry.UserServiceAccountNamespace,
+			},
+		},
+	}
+}
+
+func TestAuthorizeMemberKey(t *testing.T) {
+	r := registry.New()
+
+	alicePub, aliceBytes, _ := generateTestKeyPair(t)
+	bobPub, bobBytes, _ := generateTestKeyPair(t)
+	strangerPub, _, _ := generateTestKeyPair(t)
+
+	configMap := newAuthorizedKeysConfigMap("ns-team", map[string]string{
+		"alice": "# laptop\n" + string(aliceBytes),
+		"bob":   string(bobBytes),
+	})
+	r.AddAuthorizedKeys(configMap)
+
+	// Keys are only accepted for members
+	if _, ok := r.AuthorizeMemberKey("ns-team", alicePub); ok {
+		t.Error("Expected key to be rejected without a role binding")
+	}
+
+	aliceBinding := newMemberRoleBinding("ns-team", "Developer", "alice")
+	r.AddRoleBinding(aliceBinding)
+	r.AddRoleBinding(newMemberRoleBinding("ns-team", "Viewer", "bob"))
+
+	user, ok := r.AuthorizeMemberKey("ns-team", alicePub)
+	if !ok {
+		t.Fatal("Expected key of member to be accepted")
+	}
+
+	if user != "alice" {
+		t.Errorf("User = %s, want alice", user)
+	}
+
+	if _, ok := r.AuthorizeMemberKey("ns-team", bobPub); ok {
+		t.Error("Expected key of user without a member role to be rejected")
+	}
+
+	if _, ok := r.AuthorizeMemberKey("ns-team", strangerPub); ok {
+		t.Error("Expected unknown key to be rejected")
+	}
+
+	if _, ok := r.AuthorizeMemberKey("ns-other", alicePub); ok {
+		t.Error("Expected key to be rejected in another namespace")
+	}
+
+	// Removing the user from the workspace revokes the access
+	r.DeleteRoleBinding(aliceBinding)
+
+	if _, ok := r.AuthorizeMemberKey("ns-team", alicePub); ok {
+		t.Error("Expected key to be rejected after role binding deletion")
+	}
+
+	r.AddRoleBinding(aliceBinding)
+	r.DeleteAuthorizedKeys(configMap)
+
+	if _, ok := r.AuthorizeMemberKey("ns-team", alicePub); ok {
+		t.Error("Expected key to be rejected after configmap deletion")
+	}
+}
+
+func TestAddAuthorizedKeys_LabelRemoved(t *testing.T) {
+	r := registry.New()
+
+	pub, pubBytes, _ := generateTestKeyPair(t)
+
+	configMap := newAuthorizedKeysConfigMap("ns-team", map[string]string{
+		"alice": "invalid key\n" + string(pubBytes),
+	})
+	r.AddAuthorizedKeys(configMap)
+	r.AddRoleBinding(newMemberRoleBinding("ns-team", "Owner", "alice"))
+
+	// Invalid lines are skipped
+	if _, ok := r.AuthorizeMemberKey("ns-team", pub); !ok {
+		t.Fatal("Expected key to be accepted")
+	}
+
+	updated := configMap.DeepCopy()
+	delete(updated.Labels, registry.AuthorizedKeysLabel)
+	r.AddAuthorizedKeys(updated)
+
+	if _, ok := r.AuthorizeMemberKey("ns-team", pub); ok {
+		t.Error("Expected key to be rejected after the label was removed")
+	}
+}
@@ -0,0 +1,87 @@
+// Package waker starts stopped devboxes by patching their state to Running
+package waker
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// DevboxStateRunning is the state of a running devbox
+	DevboxStateRunning = "Running"
+	// DevboxStatePending is the state of a devbox being started
+	DevboxStatePending = "Pending"
+	// DevboxStateStopped is the state of a stopped devbox
+	DevboxStateStopped = "Stopped"
+	// DevboxStateShutdown is the state of a stopped devbox whose network is released
+	DevboxStateShutdown = "Shutdown"
+)
+
+// DevboxGVR is the resource of the Devbox custom resource
+var DevboxGVR = schema.GroupVersionResource{
+	Group:    "devbox.sealos.io",
+	Version:  "v1alpha1",
+	Resource: "devboxes",
+}
+
+// runningPatch sets the devbox state to Running
+var runningPatch = fmt.Appendf(nil, `{"spec":{"state":%q}}`, DevboxStateRunning)
+
+// Waker starts stopped devboxes
+type Waker struct {
+	client dynamic.Interface
+	logger *log.Entry
+}
+
+// New creates a new Waker
+func New(client dynamic.Interface) *Waker {
+	return &Waker{
+		client: client,
+		logger: log.WithField("component", "waker"),
+	}
+}
+
+// Wake patches the devbox to Running if it is Stopped or Shutdown.
+// It returns nil if the devbox is already Running or Pending.
+func (w *Waker) Wake(ctx context.Context, namespace, devboxName string) error {
+	devboxes := w.client.Resource(DevboxGVR).Namespace(namespace)
+
+	devbox, err := devboxes.Get(ctx, devboxName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("devbox %s/%s not found", namespace, devboxName)
+		}
+
+		return fmt.Errorf("failed to get devbox %s/%s: %w", namespace, devboxName, err)
+	}
+
+	state, _, _ := unstructured.NestedString(devbox.Object, "spec", "state")
+	switch state {
+	case DevboxStateRunning, DevboxStatePending:
+		return nil
+	case DevboxStateStopped, DevboxStateShutdown:
+	default:
+		return fmt.Errorf("devbox %s/%s can not be started in state %q", namespace, devboxName, state)
+	}
+
+	w.logger.WithFields(log.Fields{
+		"namespace": namespace,
+		"devbox":    devboxName,
+		"state":     state,
+	}).Info("Waking devbox")
+
+	_, err = devboxes.Patch(ctx, devboxName, types.MergePatchType, runningPatch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to start devbox %s/%s: %w", namespace, devboxName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package waker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/labring/sealos/service/sshgate/waker"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newDevbox(name, state string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "devbox.sealos.io/v1alpha1",
+			"kind":       "Devbox",
+			"metadata": map[string]any{
+				"name":      name,
+				"namespace": "test-ns",
+			},
+			"spec": map[string]any{
+				"state": state,
+			},
+		},
+	}
+}
+
+// newFakeClient creates a fake dynamic client holding the devboxes,
+// objects are created through the client because the fake tracker
+// can not guess the plural of Devbox
+func newFakeClient(t *testing.T, devboxes ...*unstructured.Unstructured) *dynamicfake.FakeDynamicClient {
+	t.Helper()
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{waker.DevboxGVR: "DevboxList"},
+	)
+	for _, devbox := range devboxes {
+		_, err := client.Resource(waker.DevboxGVR).
+			Namespace(devbox.GetNamespace()).
+			Create(context.Background(), devbox, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create devbox: %v", err)
+		}
+	}
+
+	return client
+}
+
+func TestWake(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		wantState string
+		wantErr   bool
+	}{
+		{name: "stopped", state: waker.DevboxStateStopped, wantState: waker.DevboxStateRunning},
+		{name: "shutdown", state: waker.DevboxStateShutdown, wantState: waker.DevboxStateRunning},
+		{name: "running", state: waker.DevboxStateRunning, wantState: waker.DevboxStateRunning},
+		{name: "pending", state: waker.DevboxStatePending, wantState: waker.DevboxStatePending},
+		{name: "unknown state", state: "Deleting", wantState: "Deleting", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(t, newDevbox("test-devbox", tt.state))
+			w := waker.New(client)
+
+			err := w.Wake(context.Background(), "test-ns", "test-devbox")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Wake() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			devbox, err := client.Resource(waker.DevboxGVR).
+				Namespace("test-ns").
+				Get(context.Background(), "test-devbox", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get devbox: %v", err)
+			}
+
+			state, _, _ := unstructured.NestedString(devbox.Object, "spec", "state")
+			if state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestWake_NotFound(t *testing.T) {
+	client := newFakeClient(t)
+	w := waker.New(client)
+
+	if err := w.Wake(context.Background(), "test-ns", "missing"); err == nil {
+		t.Error("Expected error for missing devbox")
+	}
+}
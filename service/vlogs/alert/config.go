@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/labring/sealos/controllers/pkg/database/cockroach"
+	usernotify "github.com/labring/sealos/controllers/pkg/user_notify"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Config of the log alerts in the config file of the vlogs service
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is how often the rules are evaluated, 1m by default
+	Interval string `yaml:"interval"`
+	// Kubeconfig is the path of the kubeconfig, the in-cluster config is used if empty
+	Kubeconfig string `yaml:"kubeconfig"`
+	// Providers is the JSON config of the user_notify providers, the notices are
+	// only sent as desktop notifications without it
+	Providers          string `yaml:"providers"`
+	GlobalCockroachURI string `yaml:"globalCockroachURI"`
+	LocalCockroachURI  string `yaml:"localCockroachURI"`
+}
+
+// NewFromConfig creates the evaluator of the log alert rules and returns its interval,
+// the evaluator is nil if the alerts are disabled
+func NewFromConfig(c Config, fetch Fetcher) (*Evaluator, time.Duration, error) {
+	if !c.Enabled {
+		return nil, 0, nil
+	}
+
+	interval, err := parseDuration(c.Interval, time.Minute)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid alert interval: %s", err)
+	}
+
+	var restConfig *rest.Config
+	if c.Kubeconfig != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", c.Kubeconfig)
+	} else {
+		restConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get kubernetes config: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	notifiers := []Notifier{NewDesktopNotifier(dynamicClient)}
+	if c.Providers != "" {
+		providers, err := usernotify.ParseConfigsWithJSON(c.Providers)
+		if err != nil {
+			return nil, 0, err
+		}
+		clientSet, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, 0, err
+		}
+		db, err := cockroach.NewCockRoach(c.GlobalCockroachURI, c.LocalCockroachURI)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to connect to the account database: %w", err)
+		}
+		contacts := NewAccountContacts(clientSet, db)
+		service := usernotify.NewEventNotificationService(providers, contacts)
+		notifiers = append(notifiers, NewUserNotifier(service, contacts))
+	}
+
+	return NewEvaluator(NewKubeRuleStore(dynamicClient), NewCounter(fetch), notifiers...), interval, nil
+}
@@ -0,0 +1,224 @@
+package alert
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/labring/sealos/service/vlogs/query"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds of notices
+const (
+	NoticeFiring   = "firing"
+	NoticeResolved = "resolved"
+)
+
+// Notice tells that a rule started firing, keeps firing or was resolved
+type Notice struct {
+	Rule      *Rule
+	Kind      string
+	Count     int64
+	Threshold int64
+	Window    time.Duration
+	Severity  string
+	// Repeat is set on the reminders of a rule which keeps firing
+	Repeat bool
+}
+
+// Notifier sends the notices of the rules
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, notice *Notice) error
+}
+
+// Counter returns the number of logs matched by the query between start and end
+type Counter func(ctx context.Context, query string, start, end time.Time) (int64, error)
+
+// Fetcher queries the logs matched by the query between start and end
+type Fetcher func(ctx context.Context, query string, start, end time.Time) (io.ReadCloser, error)
+
+// NewCounter creates a counter running the count queries of the rules with fetch
+func NewCounter(fetch Fetcher) Counter {
+	return func(ctx context.Context, query string, start, end time.Time) (int64, error) {
+		body, err := fetch(ctx, query, start, end)
+		if err != nil {
+			return 0, err
+		}
+		defer body.Close()
+
+		// The stats are returned on a single line, without line if no log matched
+		scanner := bufio.NewScanner(body)
+		if !scanner.Scan() {
+			return 0, scanner.Err()
+		}
+		var stats struct {
+			Total string `json:"logs_total"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &stats); err != nil {
+			return 0, fmt.Errorf("invalid count response: %w", err)
+		}
+		if stats.Total == "" {
+			return 0, nil
+		}
+		return strconv.ParseInt(stats.Total, 10, 64)
+	}
+}
+
+// Evaluator periodically counts the logs matched by the rules and notifies the
+// rules which start firing, keep firing for longer than their repeat interval or
+// are resolved. The state of the rules is kept in their status so that the
+// notices are not sent again on restarts.
+type Evaluator struct {
+	store     RuleStore
+	count     Counter
+	notifiers []Notifier
+	now       func() time.Time
+}
+
+func NewEvaluator(store RuleStore, count Counter, notifiers ...Notifier) *Evaluator {
+	return &Evaluator{
+		store:     store,
+		count:     count,
+		notifiers: notifiers,
+		now:       time.Now,
+	}
+}
+
+// Run evaluates the rules every interval until the context is done
+func (e *Evaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Evaluate(ctx); err != nil {
+			log.Printf("Failed to evaluate log alert rules (%s)\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate evaluates all the rules once
+func (e *Evaluator) Evaluate(ctx context.Context) error {
+	rules, err := e.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range rules {
+		rule := &rules[i]
+		e.evaluate(ctx, rule)
+		if err := e.store.UpdateStatus(ctx, rule); err != nil {
+			log.Printf("Failed to update log alert rule %s (%s)\n", rule.Fingerprint(), err)
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) evaluate(ctx context.Context, rule *Rule) {
+	now := metav1.NewTime(e.now())
+	status := &rule.Status
+	status.LastEvaluationTime = &now
+	status.Error = ""
+	if status.State == "" {
+		status.State = StateInactive
+	}
+
+	if rule.Spec.Suspend {
+		status.State = StateInactive
+		status.FiringSince = nil
+		return
+	}
+
+	window, err := rule.window()
+	if err != nil {
+		status.Error = fmt.Sprintf("invalid window: %s", err)
+		return
+	}
+	repeat, err := rule.repeatInterval()
+	if err != nil {
+		status.Error = fmt.Sprintf("invalid repeat interval: %s", err)
+		return
+	}
+
+	req := rule.Spec.Query
+	req.Namespace = rule.Namespace
+	var vlogs query.VLogsQuery
+	q, err := vlogs.GetCountQuery(&req)
+	if err != nil {
+		status.Error = fmt.Sprintf("invalid query: %s", err)
+		return
+	}
+	// The state is kept when the logs cannot be counted, so that a failure
+	// of VictoriaLogs neither resolves nor fires the rules
+	count, err := e.count(ctx, q, now.Add(-window), now.Time)
+	if err != nil {
+		status.Error = fmt.Sprintf("failed to count logs: %s", err)
+		return
+	}
+	status.Count = count
+
+	notice := &Notice{
+		Rule:      rule,
+		Count:     count,
+		Threshold: rule.threshold(),
+		Window:    window,
+		Severity:  rule.severity(),
+	}
+	// notified tells whether the current firing was notified
+	notified := status.FiringSince != nil && status.LastNotifiedTime != nil &&
+		!status.LastNotifiedTime.Before(status.FiringSince)
+
+	if count >= notice.Threshold {
+		if status.State != StateFiring {
+			status.State = StateFiring
+			status.FiringSince = &now
+			notified = false
+		}
+		// A firing which failed to be notified is notified again on the next evaluation
+		if notified && now.Sub(status.LastNotifiedTime.Time) < repeat {
+			return
+		}
+		notice.Kind = NoticeFiring
+		notice.Repeat = notified
+		if e.notify(ctx, notice) {
+			status.LastNotifiedTime = &now
+		}
+		return
+	}
+
+	if status.State == StateFiring {
+		status.State = StateInactive
+		status.FiringSince = nil
+		// Nobody needs to know that an alert they did not receive was resolved
+		if notified {
+			notice.Kind = NoticeResolved
+			if e.notify(ctx, notice) {
+				status.LastNotifiedTime = &now
+			}
+		}
+	}
+}
+
+// notify sends the notice with all the notifiers, it returns whether any of them succeeded
+func (e *Evaluator) notify(ctx context.Context, notice *Notice) bool {
+	sent := false
+	for _, notifier := range e.notifiers {
+		if err := notifier.Notify(ctx, notice); err != nil {
+			log.Printf("Failed to send %s notice of log alert rule %s with %s (%s)\n",
+				notice.Kind, notice.Rule.Fingerprint(), notifier.Name(), err)
+			continue
+		}
+		sent = true
+	}
+	return sent
+}
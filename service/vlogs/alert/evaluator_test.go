@@ -0,0 +1,223 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/pkg/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+type memoryStore struct {
+	rules []Rule
+}
+
+func (s *memoryStore) List(context.Context) ([]Rule, error) {
+	rules := make([]Rule, len(s.rules))
+	copy(rules, s.rules)
+	return rules, nil
+}
+
+func (s *memoryStore) UpdateStatus(_ context.Context, rule *Rule) error {
+	for i := range s.rules {
+		if s.rules[i].Fingerprint() == rule.Fingerprint() {
+			s.rules[i].Status = rule.Status
+		}
+	}
+	return nil
+}
+
+type recordingNotifier struct {
+	notices []Notice
+	err     error
+}
+
+func (n *recordingNotifier) Name() string {
+	return "recording"
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notice *Notice) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notices = append(n.notices, *notice)
+	return nil
+}
+
+func TestEvaluator(t *testing.T) {
+	store := &memoryStore{rules: []Rule{{
+		ObjectMeta: metav1.ObjectMeta{Name: "panics", Namespace: "ns-a"},
+		Spec: RuleSpec{
+			// The namespace of the query is replaced by the one of the rule
+			Query:          api.VlogsLaunchpadRequest{Namespace: "ns-b", App: "web", Keyword: "panic:"},
+			Threshold:      3,
+			RepeatInterval: "1h",
+		},
+	}}}
+	notifier := &recordingNotifier{}
+	var count int64
+	var queries []string
+	counter := func(_ context.Context, query string, start, end time.Time) (int64, error) {
+		if end.Sub(start) != defaultWindow {
+			t.Errorf("window = %s, want %s", end.Sub(start), defaultWindow)
+		}
+		queries = append(queries, query)
+		return count, nil
+	}
+	e := NewEvaluator(store, counter, notifier)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	steps := []struct {
+		name    string
+		after   time.Duration
+		count   int64
+		state   string
+		notices []string
+	}{
+		{name: "below threshold", count: 2, state: StateInactive},
+		{name: "fires", after: time.Minute, count: 3, state: StateFiring, notices: []string{NoticeFiring}},
+		{name: "deduplicated", after: time.Minute, count: 10, state: StateFiring},
+		{name: "repeated", after: time.Hour, count: 10, state: StateFiring, notices: []string{NoticeFiring}},
+		{name: "resolves", after: time.Minute, count: 0, state: StateInactive, notices: []string{NoticeResolved}},
+		{name: "stays resolved", after: time.Minute, count: 0, state: StateInactive},
+	}
+	for _, step := range steps {
+		now = now.Add(step.after)
+		count = step.count
+		notifier.notices = nil
+
+		if err := e.Evaluate(context.Background()); err != nil {
+			t.Fatalf("%s: Evaluate() error = %v", step.name, err)
+		}
+
+		status := store.rules[0].Status
+		if status.State != step.state || status.Count != step.count || status.Error != "" {
+			t.Errorf("%s: status = %+v, want state %s", step.name, status, step.state)
+		}
+		var kinds []string
+		for _, notice := range notifier.notices {
+			kinds = append(kinds, notice.Kind)
+		}
+		if strings.Join(kinds, ",") != strings.Join(step.notices, ",") {
+			t.Errorf("%s: notices = %v, want %v", step.name, kinds, step.notices)
+		}
+	}
+
+	if !strings.HasPrefix(queries[0], "'panic:' {namespace='ns-a'} app:='web' ") ||
+		!strings.HasSuffix(queries[0], " | stats count() logs_total") {
+		t.Errorf("unexpected count query %q", queries[0])
+	}
+}
+
+func TestEvaluator_RetriesFailedNotices(t *testing.T) {
+	store := &memoryStore{rules: []Rule{{
+		ObjectMeta: metav1.ObjectMeta{Name: "errors", Namespace: "ns-a"},
+	}}}
+	notifier := &recordingNotifier{err: errors.New("unavailable")}
+	counter := func(context.Context, string, time.Time, time.Time) (int64, error) {
+		return 1, nil
+	}
+	e := NewEvaluator(store, counter, notifier)
+
+	if err := e.Evaluate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if status := store.rules[0].Status; status.State != StateFiring || status.LastNotifiedTime != nil {
+		t.Fatalf("status = %+v, want firing without notice", status)
+	}
+
+	notifier.err = nil
+	if err := e.Evaluate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.notices) != 1 || notifier.notices[0].Repeat {
+		t.Errorf("notices = %+v, want the first firing notice", notifier.notices)
+	}
+}
+
+func TestEvaluator_KeepsStateOnCountFailure(t *testing.T) {
+	since := metav1.NewTime(time.Now().Add(-time.Hour))
+	store := &memoryStore{rules: []Rule{{
+		ObjectMeta: metav1.ObjectMeta{Name: "errors", Namespace: "ns-a"},
+		Status:     RuleStatus{State: StateFiring, FiringSince: &since, LastNotifiedTime: &since},
+	}}}
+	notifier := &recordingNotifier{}
+	counter := func(context.Context, string, time.Time, time.Time) (int64, error) {
+		return 0, errors.New("victoria logs unavailable")
+	}
+	e := NewEvaluator(store, counter, notifier)
+
+	if err := e.Evaluate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status := store.rules[0].Status
+	if status.State != StateFiring || !strings.Contains(status.Error, "unavailable") {
+		t.Errorf("status = %+v, want firing with the error", status)
+	}
+	if len(notifier.notices) != 0 {
+		t.Errorf("notices = %+v, want none", notifier.notices)
+	}
+}
+
+func TestNewCounter(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int64
+		wantErr bool
+	}{
+		{name: "count", body: `{"logs_total":"42"}` + "\n", want: 42},
+		{name: "no logs", body: "", want: 0},
+		{name: "invalid", body: "oops\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := NewCounter(func(context.Context, string, time.Time, time.Time) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(tt.body)), nil
+			})
+			got, err := count(context.Background(), "", time.Time{}, time.Time{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("count() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("count() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDesktopNotifier(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{notificationGVR: "NotificationList"})
+	notifier := NewDesktopNotifier(client)
+	rule := &Rule{ObjectMeta: metav1.ObjectMeta{Name: "panics", Namespace: "ns-a"}}
+
+	err := notifier.Notify(context.Background(), &Notice{
+		Rule:      rule,
+		Kind:      NoticeFiring,
+		Count:     5,
+		Threshold: 3,
+		Window:    5 * time.Minute,
+		Severity:  SeverityCritical,
+	})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	list, err := client.Resource(notificationGVR).Namespace("ns-a").List(context.Background(), metav1.ListOptions{})
+	if err != nil || len(list.Items) != 1 {
+		t.Fatalf("List() = %v, %v, want one notification", list, err)
+	}
+	spec := list.Items[0].Object["spec"].(map[string]any)
+	if spec["title"] != "Log alert firing: panics" || spec["importance"] != "High" || spec["desktopPopup"] != true {
+		t.Errorf("unexpected notification spec %v", spec)
+	}
+}
@@ -0,0 +1,244 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	ntfv1 "github.com/labring/sealos/controllers/pkg/notification/api/v1"
+	pkgtypes "github.com/labring/sealos/controllers/pkg/types"
+	usernotify "github.com/labring/sealos/controllers/pkg/user_notify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// OwnerLabel is the label of the namespaces holding the name of their owner
+	OwnerLabel = "user.sealos.io/owner"
+
+	languageZh      = "zh"
+	readStatusLabel = "isRead"
+	falseStatus     = "false"
+	fromEn          = "Log Alert"
+	fromZh          = "日志告警"
+)
+
+var notificationGVR = schema.GroupVersionResource{
+	Group:    "notification.sealos.io",
+	Version:  "v1",
+	Resource: "notifications",
+}
+
+// DesktopNotifier creates a Notification in the namespace of the rule, which is
+// shown as a desktop popup
+type DesktopNotifier struct {
+	client dynamic.Interface
+}
+
+func NewDesktopNotifier(client dynamic.Interface) *DesktopNotifier {
+	return &DesktopNotifier{client: client}
+}
+
+func (n *DesktopNotifier) Name() string {
+	return "desktop"
+}
+
+func (n *DesktopNotifier) Notify(ctx context.Context, notice *Notice) error {
+	title, message := notice.Text()
+	zhTitle, zhMessage := notice.TextZh()
+	importance := ntfv1.Medium
+	switch notice.Severity {
+	case SeverityCritical:
+		importance = ntfv1.High
+	case SeverityInfo:
+		importance = ntfv1.Low
+	}
+	if notice.Kind == NoticeResolved {
+		importance = ntfv1.Low
+	}
+
+	ntf := &ntfv1.Notification{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: notificationGVR.GroupVersion().String(),
+			Kind:       "Notification",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "log-alert-" + notice.Rule.Name + "-",
+			Namespace:    notice.Rule.Namespace,
+			Labels:       map[string]string{readStatusLabel: falseStatus},
+		},
+		Spec: ntfv1.NotificationSpec{
+			Title:        title,
+			Message:      message,
+			Timestamp:    time.Now().UTC().Unix(),
+			From:         fromEn,
+			Importance:   importance,
+			DesktopPopup: notice.Kind == NoticeFiring,
+			I18n: map[string]ntfv1.I18n{
+				languageZh: {
+					Title:   zhTitle,
+					Message: zhMessage,
+					From:    fromZh,
+				},
+			},
+		},
+	}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ntf)
+	if err != nil {
+		return err
+	}
+	_, err = n.client.Resource(notificationGVR).Namespace(notice.Rule.Namespace).
+		Create(ctx, &unstructured.Unstructured{Object: object}, metav1.CreateOptions{})
+	return err
+}
+
+// AccountDB is the part of the account database used to find the users to notify
+type AccountDB interface {
+	GetUserUID(ops *pkgtypes.UserQueryOpts) (uuid.UUID, error)
+	GetUserOauthProvider(ops *pkgtypes.UserQueryOpts) ([]pkgtypes.OauthProvider, error)
+}
+
+// UserNotifier sends the notices to the owner of the namespace of the rule with the
+// user_notify methods of the rule
+type UserNotifier struct {
+	service usernotify.EventNotificationService
+	owners  OwnerResolver
+}
+
+func NewUserNotifier(service usernotify.EventNotificationService, owners OwnerResolver) *UserNotifier {
+	return &UserNotifier{
+		service: service,
+		owners:  owners,
+	}
+}
+
+func (n *UserNotifier) Name() string {
+	return "user_notify"
+}
+
+func (n *UserNotifier) Notify(ctx context.Context, notice *Notice) error {
+	if len(notice.Rule.Spec.Methods) == 0 {
+		return nil
+	}
+	userUID, err := n.owners.Owner(ctx, notice.Rule.Namespace)
+	if err != nil {
+		return err
+	}
+	title, message := notice.Text()
+	_, err = n.service.HandleCustomEvent(ctx, userUID, title, message, map[string]any{
+		"namespace": notice.Rule.Namespace,
+		"rule":      notice.Rule.Name,
+		"state":     notice.Kind,
+		"count":     notice.Count,
+		"severity":  notice.Severity,
+	}, notice.Rule.Spec.Methods)
+	return err
+}
+
+// OwnerResolver finds the user owning a namespace
+type OwnerResolver interface {
+	Owner(ctx context.Context, namespace string) (uuid.UUID, error)
+}
+
+// AccountContacts resolves the owners of the namespaces from their owner label and
+// provides their contacts to user_notify from the account database
+type AccountContacts struct {
+	client kubernetes.Interface
+	db     AccountDB
+
+	mu       sync.Mutex
+	contacts map[uuid.UUID]*pkgtypes.NotificationRecipient
+}
+
+func NewAccountContacts(client kubernetes.Interface, db AccountDB) *AccountContacts {
+	return &AccountContacts{
+		client:   client,
+		db:       db,
+		contacts: make(map[uuid.UUID]*pkgtypes.NotificationRecipient),
+	}
+}
+
+func (c *AccountContacts) Owner(ctx context.Context, namespace string) (uuid.UUID, error) {
+	ns, err := c.client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	owner := ns.Labels[OwnerLabel]
+	if owner == "" {
+		return uuid.Nil, fmt.Errorf("namespace %s has no owner", namespace)
+	}
+	return c.db.GetUserUID(&pkgtypes.UserQueryOpts{Owner: owner})
+}
+
+// GetUserContact returns the phone number and the email of the user
+func (c *AccountContacts) GetUserContact(
+	_ context.Context,
+	userUID uuid.UUID,
+) (*pkgtypes.NotificationRecipient, error) {
+	c.mu.Lock()
+	contact, ok := c.contacts[userUID]
+	c.mu.Unlock()
+	if ok {
+		return contact, nil
+	}
+
+	providers, err := c.db.GetUserOauthProvider(&pkgtypes.UserQueryOpts{UID: userUID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user contact: %w", err)
+	}
+	contact = &pkgtypes.NotificationRecipient{UserUID: userUID}
+	for _, provider := range providers {
+		switch provider.ProviderType {
+		case pkgtypes.OauthProviderTypePhone:
+			contact.PhoneNumber = provider.ProviderID
+		case pkgtypes.OauthProviderTypeEmail:
+			contact.Email = provider.ProviderID
+		}
+	}
+	return contact, nil
+}
+
+// SetUserContact overrides the contact of the user found in the account database
+func (c *AccountContacts) SetUserContact(userUID uuid.UUID, recipient *pkgtypes.NotificationRecipient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contacts[userUID] = recipient
+}
+
+func (c *AccountContacts) RemoveUserContact(userUID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.contacts, userUID)
+}
+
+// Text returns the title and the message of the notice
+func (n *Notice) Text() (string, string) {
+	rule := n.Rule.Name
+	if n.Kind == NoticeResolved {
+		return "Log alert resolved: " + rule, fmt.Sprintf(
+			"The logs of namespace %s matched by the alert rule %s are back below %d in %s (%d).",
+			n.Rule.Namespace, rule, n.Threshold, n.Window, n.Count)
+	}
+	return "Log alert firing: " + rule, fmt.Sprintf(
+		"%d logs of namespace %s matched the alert rule %s in the last %s (threshold %d), please check in time.",
+		n.Count, n.Rule.Namespace, rule, n.Window, n.Threshold)
+}
+
+// TextZh returns the title and the message of the notice in Chinese
+func (n *Notice) TextZh() (string, string) {
+	rule := n.Rule.Name
+	if n.Kind == NoticeResolved {
+		return "日志告警已恢复: " + rule, fmt.Sprintf(
+			"命名空间 %s 中匹配告警规则 %s 的日志在 %s 内已低于 %d 条 (%d 条).",
+			n.Rule.Namespace, rule, n.Window, n.Threshold, n.Count)
+	}
+	return "日志告警: " + rule, fmt.Sprintf(
+		"命名空间 %s 中最近 %s 内有 %d 条日志匹配告警规则 %s (阈值 %d 条), 请及时检查.",
+		n.Rule.Namespace, n.Window, n.Count, rule, n.Threshold)
+}
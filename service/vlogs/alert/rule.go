@@ -0,0 +1,166 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	usernotify "github.com/labring/sealos/controllers/pkg/user_notify"
+	"github.com/labring/sealos/service/pkg/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// RuleGVR is the resource of the log alert rules
+var RuleGVR = schema.GroupVersionResource{
+	Group:    "vlogs.sealos.io",
+	Version:  "v1",
+	Resource: "logalertrules",
+}
+
+// States of a rule
+const (
+	StateInactive = "inactive"
+	StateFiring   = "firing"
+)
+
+// Severities of a rule
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+const (
+	defaultWindow         = 5 * time.Minute
+	defaultRepeatInterval = 4 * time.Hour
+)
+
+// Rule is a LogAlertRule, it fires when the logs matched by its query over
+// its window reach its threshold
+type Rule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RuleSpec   `json:"spec,omitempty"`
+	Status RuleStatus `json:"status,omitempty"`
+}
+
+type RuleSpec struct {
+	// Query selects the logs like the body of /queryLogsByParams, its namespace
+	// is always the namespace of the rule
+	Query api.VlogsLaunchpadRequest `json:"query"`
+	// Window is the time range the logs are counted over, 5m by default
+	Window string `json:"window,omitempty"`
+	// Threshold is the number of logs from which the rule fires, 1 by default
+	Threshold int64 `json:"threshold,omitempty"`
+	// Severity is info, warning or critical, warning by default
+	Severity string `json:"severity,omitempty"`
+	// Methods are the user_notify methods used besides the desktop notification
+	Methods []usernotify.NotificationMethod `json:"methods,omitempty"`
+	// RepeatInterval is how often a firing rule is notified again, 4h by default
+	RepeatInterval string `json:"repeatInterval,omitempty"`
+	// Suspend stops the evaluation of the rule
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+type RuleStatus struct {
+	State string `json:"state,omitempty"`
+	// Count is the number of logs matched over the window at the last evaluation
+	Count              int64        `json:"count,omitempty"`
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+	FiringSince        *metav1.Time `json:"firingSince,omitempty"`
+	LastNotifiedTime   *metav1.Time `json:"lastNotifiedTime,omitempty"`
+	Error              string       `json:"error,omitempty"`
+}
+
+// Fingerprint identifies the alerts of the rule
+func (r *Rule) Fingerprint() string {
+	return r.Namespace + "/" + r.Name
+}
+
+func (r *Rule) window() (time.Duration, error) {
+	return parseDuration(r.Spec.Window, defaultWindow)
+}
+
+func (r *Rule) repeatInterval() (time.Duration, error) {
+	return parseDuration(r.Spec.RepeatInterval, defaultRepeatInterval)
+}
+
+func (r *Rule) threshold() int64 {
+	if r.Spec.Threshold <= 0 {
+		return 1
+	}
+	return r.Spec.Threshold
+}
+
+func (r *Rule) severity() string {
+	if r.Spec.Severity == "" {
+		return SeverityWarning
+	}
+	return r.Spec.Severity
+}
+
+func parseDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %s must be positive", s)
+	}
+	return d, nil
+}
+
+// RuleStore lists the rules and records their status
+type RuleStore interface {
+	List(ctx context.Context) ([]Rule, error)
+	UpdateStatus(ctx context.Context, rule *Rule) error
+}
+
+// KubeRuleStore keeps the rules as LogAlertRule resources
+type KubeRuleStore struct {
+	client dynamic.Interface
+}
+
+func NewKubeRuleStore(client dynamic.Interface) *KubeRuleStore {
+	return &KubeRuleStore{client: client}
+}
+
+// List returns the rules of all the namespaces
+func (s *KubeRuleStore) List(ctx context.Context) ([]Rule, error) {
+	list, err := s.client.Resource(RuleGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log alert rules: %w", err)
+	}
+	rules := make([]Rule, 0, len(list.Items))
+	for _, item := range list.Items {
+		var rule Rule
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &rule); err != nil {
+			// A broken rule must not prevent the evaluation of the others
+			log.Printf("Invalid log alert rule %s/%s (%s)\n", item.GetNamespace(), item.GetName(), err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (s *KubeRuleStore) UpdateStatus(ctx context.Context, rule *Rule) error {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rule)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{Object: object}
+	u.SetAPIVersion(RuleGVR.GroupVersion().String())
+	u.SetKind("LogAlertRule")
+	_, err = s.client.Resource(RuleGVR).Namespace(rule.Namespace).UpdateStatus(ctx, u, metav1.UpdateOptions{})
+	return err
+}
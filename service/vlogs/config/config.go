@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/labring/sealos/service/vlogs/alert"
+	"github.com/labring/sealos/service/vlogs/export"
 	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
-	Server ServeConfig `yaml:"server"`
+	Server ServeConfig   `yaml:"server"`
+	Export export.Config `yaml:"export"`
+	Tail   TailConfig    `yaml:"tail"`
+	Alert  alert.Config  `yaml:"alert"`
 }
 
 type ServeConfig struct {
@@ -18,6 +23,17 @@ type ServeConfig struct {
 	Password      string `yaml:"password"`
 }
 
+// TailConfig limits the live tailing of the logs, durations are strings such as 30m
+type TailConfig struct {
+	// LinesPerSecond and Burst limit the lines sent to a connection, the lines
+	// over the limit are dropped and reported to the client
+	LinesPerSecond float64 `yaml:"linesPerSecond"`
+	Burst          int     `yaml:"burst"`
+	MaxConnections int     `yaml:"maxConnections"`
+	MaxDuration    string  `yaml:"maxDuration"`
+	Heartbeat      string  `yaml:"heartbeat"`
+}
+
 func InitConfig(configPath string) (*Config, error) {
 	configData, err := os.ReadFile(configPath)
 	if err != nil {
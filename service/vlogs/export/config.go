@@ -0,0 +1,144 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config of the log exports in the config file of the vlogs service
+type Config struct {
+	Disabled bool `yaml:"disabled"`
+	// Storage is local or s3
+	Storage             string   `yaml:"storage"`
+	Dir                 string   `yaml:"dir"`
+	S3                  S3Config `yaml:"s3"`
+	Window              string   `yaml:"window"`
+	MaxRange            string   `yaml:"maxRange"`
+	Timeout             string   `yaml:"timeout"`
+	Retention           string   `yaml:"retention"`
+	LinkExpiry          string   `yaml:"linkExpiry"`
+	Concurrency         int      `yaml:"concurrency"`
+	MaxJobsPerNamespace int      `yaml:"maxJobsPerNamespace"`
+}
+
+// NewFromConfig creates the export manager from the config, it returns nil if the exports
+// are disabled. By default the exports are kept in a local directory for 24h, fetched by
+// windows of 1h and at most 7 days long, with 2 jobs running at the same time and 3
+// jobs in progress per namespace.
+func NewFromConfig(c Config, fetch Fetcher) (*Manager, error) {
+	if c.Disabled {
+		return nil, nil
+	}
+
+	options := Options{
+		Window:              time.Hour,
+		MaxRange:            7 * 24 * time.Hour,
+		Timeout:             time.Hour,
+		Retention:           24 * time.Hour,
+		LinkExpiry:          time.Hour,
+		Concurrency:         c.Concurrency,
+		MaxJobsPerNamespace: c.MaxJobsPerNamespace,
+	}
+	if options.Concurrency == 0 {
+		options.Concurrency = 2
+	}
+	if options.MaxJobsPerNamespace == 0 {
+		options.MaxJobsPerNamespace = 3
+	}
+
+	for _, d := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"window", c.Window, &options.Window},
+		{"maxRange", c.MaxRange, &options.MaxRange},
+		{"timeout", c.Timeout, &options.Timeout},
+		{"retention", c.Retention, &options.Retention},
+		{"linkExpiry", c.LinkExpiry, &options.LinkExpiry},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid export %s: %s", d.name, err)
+		}
+		*d.dest = v
+	}
+	if options.Window <= 0 || options.Concurrency < 0 || options.MaxJobsPerNamespace < 0 {
+		return nil, fmt.Errorf("invalid export config: %+v", c)
+	}
+
+	var storage Storage
+	switch c.Storage {
+	case "", StorageLocal:
+		dir := c.Dir
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "vlogs-exports")
+		}
+		storage = NewLocalStorage(dir)
+	case StorageS3:
+		s3, err := NewS3Storage(c.S3)
+		if err != nil {
+			return nil, err
+		}
+		storage = s3
+	default:
+		return nil, fmt.Errorf("invalid export storage: %s (must be local or s3)", c.Storage)
+	}
+
+	return NewManager(storage, fetch, options), nil
+}
+
+// ParseDuration parses a duration, days are supported with the d unit as in LogsQL
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// ParseTime parses a unix timestamp in seconds or a RFC3339 time
+func ParseTime(s string) (time.Time, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(0, int64(v*float64(time.Second))), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return t, nil
+}
+
+// TimeRange returns the time range of the start and end times of a query request,
+// or of the last duration if they are empty
+func TimeRange(start, end, last string, now time.Time) (time.Time, time.Time, error) {
+	if start == "" && end == "" {
+		if last == "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: missing time range", ErrInvalidRange)
+		}
+		d, err := ParseDuration(last)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidRange, err)
+		}
+		return now.Add(-d), now, nil
+	}
+	s, err := ParseTime(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidRange, err)
+	}
+	e, err := ParseTime(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidRange, err)
+	}
+	return s, e, nil
+}
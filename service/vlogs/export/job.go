@@ -0,0 +1,433 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/labring/sealos/service/pkg/api"
+)
+
+// Formats of the exports
+const (
+	// FormatNDJSON writes the log entries as gzipped JSON lines, as returned by VictoriaLogs
+	FormatNDJSON = "ndjson"
+	// FormatText writes the time, the pod, the container and the message of the log entries
+	FormatText = "text"
+)
+
+// States of an export job
+const (
+	StatePending   = "pending"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+var (
+	ErrJobNotFound  = errors.New("export job not found")
+	ErrJobNotReady  = errors.New("export job has not succeeded")
+	ErrTooManyJobs  = errors.New("too many export jobs in progress in the namespace")
+	ErrInvalidRange = errors.New("invalid export time range")
+	ErrUnknownFmt   = errors.New("unknown export format")
+)
+
+// maxLineSize is the size of the longest log entry read from VictoriaLogs
+const maxLineSize = 4 * 1024 * 1024
+
+// Fetcher queries the logs matched by the query between start and end, both included
+type Fetcher func(ctx context.Context, query string, start, end time.Time) (io.ReadCloser, error)
+
+// Options of the export jobs
+type Options struct {
+	// Window is the time range of the logs fetched by a single query
+	Window time.Duration
+	// MaxRange is the longest time range of an export
+	MaxRange time.Duration
+	// Timeout bounds the time a job runs
+	Timeout time.Duration
+	// Retention is the time the finished jobs and their exports are kept
+	Retention time.Duration
+	// LinkExpiry is the validity of the presigned download links
+	LinkExpiry time.Duration
+	// Concurrency is the number of jobs running at the same time
+	Concurrency int
+	// MaxJobsPerNamespace is the number of pending and running jobs of a namespace
+	MaxJobsPerNamespace int
+}
+
+// Request is an export of the logs matched by a query
+type Request struct {
+	Namespace string
+	Query     string
+	Format    string
+	Start     time.Time
+	End       time.Time
+}
+
+// Status of an export job
+type Status struct {
+	ID          string    `json:"id"`
+	Namespace   string    `json:"namespace"`
+	Format      string    `json:"format"`
+	State       string    `json:"state"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Windows     int       `json:"windows"`
+	DoneWindows int       `json:"doneWindows"`
+	// Progress is the percentage of the time range exported
+	Progress    float64    `json:"progress"`
+	Lines       int64      `json:"lines"`
+	Size        int64      `json:"size"`
+	Error       string     `json:"error,omitempty"`
+	DownloadURL string     `json:"downloadURL,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Filename is the name of the downloaded export
+func (s *Status) Filename() string {
+	if s.Format == FormatText {
+		return s.ID + ".log"
+	}
+	return s.ID + ".ndjson.gz"
+}
+
+// key is where the export is stored
+func (s *Status) key() string {
+	return path.Join(s.Namespace, s.Filename())
+}
+
+// Manager runs the export jobs in the background and keeps their status in memory
+type Manager struct {
+	storage Storage
+	fetch   Fetcher
+	options Options
+	now     func() time.Time
+	slots   chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*Status
+}
+
+func NewManager(storage Storage, fetch Fetcher, options Options) *Manager {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	return &Manager{
+		storage: storage,
+		fetch:   fetch,
+		options: options,
+		now:     time.Now,
+		slots:   make(chan struct{}, options.Concurrency),
+		jobs:    make(map[string]*Status),
+	}
+}
+
+// Submit validates the request and starts its export job
+func (m *Manager) Submit(req *Request) (Status, error) {
+	if req.Format == "" {
+		req.Format = FormatNDJSON
+	}
+	if req.Format != FormatNDJSON && req.Format != FormatText {
+		return Status{}, fmt.Errorf("%w: %s", ErrUnknownFmt, req.Format)
+	}
+	if !req.Start.Before(req.End) {
+		return Status{}, fmt.Errorf("%w: start must be before end", ErrInvalidRange)
+	}
+	if m.options.MaxRange > 0 && req.End.Sub(req.Start) > m.options.MaxRange {
+		return Status{}, fmt.Errorf("%w: longer than %s", ErrInvalidRange, m.options.MaxRange)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Status{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := 0
+	for _, job := range m.jobs {
+		if job.Namespace == req.Namespace && job.FinishedAt == nil {
+			active++
+		}
+	}
+	if m.options.MaxJobsPerNamespace > 0 && active >= m.options.MaxJobsPerNamespace {
+		return Status{}, ErrTooManyJobs
+	}
+
+	job := &Status{
+		ID:        id,
+		Namespace: req.Namespace,
+		Format:    req.Format,
+		State:     StatePending,
+		Start:     req.Start,
+		End:       req.End,
+		Windows:   windows(req.Start, req.End, m.options.Window),
+		CreatedAt: m.now(),
+	}
+	m.jobs[id] = job
+
+	go m.run(job.ID, req)
+	return *job, nil
+}
+
+// Get returns the status of the job
+func (m *Manager) Get(id string) (Status, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Status{}, false
+	}
+	return *job, true
+}
+
+// Link returns a presigned download link of the export of the job, or an
+// empty link if the storage does not support them
+func (m *Manager) Link(ctx context.Context, job Status) (string, error) {
+	presigner, ok := m.storage.(Presigner)
+	if !ok || job.State != StateSucceeded {
+		return "", nil
+	}
+	return presigner.PresignedURL(ctx, job.key(), job.Filename(), m.options.LinkExpiry)
+}
+
+// Open reads the export of the job
+func (m *Manager) Open(ctx context.Context, job Status) (io.ReadCloser, error) {
+	if job.State != StateSucceeded {
+		return nil, ErrJobNotReady
+	}
+	return m.storage.Open(ctx, job.key())
+}
+
+// Run removes the jobs finished for longer than the retention and their exports
+// until the context is done
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		m.cleanup(ctx)
+	}
+}
+
+func (m *Manager) cleanup(ctx context.Context) {
+	m.mu.Lock()
+	var expired []Status
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && m.now().Sub(*job.FinishedAt) > m.options.Retention {
+			expired = append(expired, *job)
+			delete(m.jobs, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, job := range expired {
+		if job.State != StateSucceeded {
+			continue
+		}
+		if err := m.storage.Delete(ctx, job.key()); err != nil {
+			log.Printf("Failed to delete export %s (%s)\n", job.key(), err)
+		}
+	}
+}
+
+func (m *Manager) run(id string, req *Request) {
+	m.slots <- struct{}{}
+	defer func() { <-m.slots }()
+
+	ctx := context.Background()
+	if m.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.options.Timeout)
+		defer cancel()
+	}
+
+	m.update(id, func(job *Status) { job.State = StateRunning })
+
+	err := m.export(ctx, id, req)
+
+	m.update(id, func(job *Status) {
+		finished := m.now()
+		job.FinishedAt = &finished
+		if err != nil {
+			job.State = StateFailed
+			job.Error = err.Error()
+			return
+		}
+		job.State = StateSucceeded
+		job.Progress = 100
+	})
+	if err != nil {
+		log.Printf("Export %s of namespace %s failed (%s)\n", id, req.Namespace, err)
+	}
+}
+
+// export writes the logs to a temporary file window by window, then stores it
+func (m *Manager) export(ctx context.Context, id string, req *Request) error {
+	spool, err := os.CreateTemp("", "vlogs-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	buffered := bufio.NewWriter(spool)
+	var out io.Writer = buffered
+	var zw *gzip.Writer
+	if req.Format == FormatNDJSON {
+		zw = gzip.NewWriter(buffered)
+		out = zw
+	}
+
+	total := req.End.Sub(req.Start)
+	for start := req.Start; start.Before(req.End); start = start.Add(m.options.Window) {
+		end := start.Add(m.options.Window)
+		if end.After(req.End) {
+			end = req.End
+		}
+		// The end of a query is included, the next window starts right after
+		last := end
+		if end.Before(req.End) {
+			last = end.Add(-time.Nanosecond)
+		}
+		lines, err := m.exportWindow(ctx, out, req, start, last)
+		if err != nil {
+			return fmt.Errorf("failed to export logs from %s to %s: %w",
+				start.Format(time.RFC3339), end.Format(time.RFC3339), err)
+		}
+		m.update(id, func(job *Status) {
+			job.DoneWindows++
+			job.Lines += lines
+			job.Progress = float64(end.Sub(req.Start)) / float64(total) * 100
+		})
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	size, err := spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	stored := Status{ID: id, Namespace: req.Namespace, Format: req.Format}
+	if err := m.storage.Put(ctx, stored.key(), spool, size); err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+	m.update(id, func(job *Status) { job.Size = size })
+	return nil
+}
+
+func (m *Manager) exportWindow(
+	ctx context.Context,
+	out io.Writer,
+	req *Request,
+	start, end time.Time,
+) (int64, error) {
+	body, err := m.fetch(ctx, req.Query, start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	var lines int64
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := writeLine(out, req.Format, line); err != nil {
+			return lines, err
+		}
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		return lines, fmt.Errorf("error reading response: %w", err)
+	}
+	return lines, nil
+}
+
+func writeLine(out io.Writer, format string, line []byte) error {
+	if format == FormatNDJSON {
+		if _, err := out.Write(line); err != nil {
+			return err
+		}
+		_, err := io.WriteString(out, "\n")
+		return err
+	}
+
+	var entry api.VlogsLaunchpadResponse
+	if err := json.Unmarshal(line, &entry); err != nil {
+		// Lines which are not log entries are kept as is
+		entry = api.VlogsLaunchpadResponse{Message: string(line)}
+	}
+	source := entry.Pod
+	if entry.Container != "" {
+		source = path.Join(source, entry.Container)
+	}
+	var err error
+	if source != "" {
+		_, err = fmt.Fprintf(out, "%s [%s] %s\n", entry.Time, source, entry.Message)
+	} else {
+		_, err = fmt.Fprintf(out, "%s %s\n", entry.Time, entry.Message)
+	}
+	return err
+}
+
+func (m *Manager) update(id string, f func(job *Status)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if job, ok := m.jobs[id]; ok {
+		f(job)
+	}
+}
+
+// windows is the number of queries needed to export the time range
+func windows(start, end time.Time, window time.Duration) int {
+	n := end.Sub(start) / window
+	if end.Sub(start)%window != 0 {
+		n++
+	}
+	return int(n)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,304 @@
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeLogs returns one log entry per window queried
+type fakeLogs struct {
+	mu      sync.Mutex
+	windows [][2]time.Time
+	err     error
+}
+
+func (f *fakeLogs) fetch(_ context.Context, query string, start, end time.Time) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.windows = append(f.windows, [2]time.Time{start, end})
+	line := fmt.Sprintf(`{"_time":%q,"_msg":"%s","pod":"web-0","container":"app"}`,
+		start.Format(time.RFC3339), query)
+	return io.NopCloser(strings.NewReader(line + "\n\n")), nil
+}
+
+func newTestManager(t *testing.T, logs *fakeLogs) *Manager {
+	t.Helper()
+	return NewManager(NewLocalStorage(t.TempDir()), logs.fetch, Options{
+		Window:              time.Hour,
+		MaxRange:            24 * time.Hour,
+		Concurrency:         1,
+		MaxJobsPerNamespace: 1,
+	})
+}
+
+func wait(t *testing.T, m *Manager, id string) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := m.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.FinishedAt != nil {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Status{}
+}
+
+func readExport(t *testing.T, m *Manager, job Status) string {
+	t.Helper()
+	f, err := m.Open(context.Background(), job)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if job.Format == FormatNDJSON {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("gzip.NewReader() error = %v", err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestManager_ExportNDJSON(t *testing.T) {
+	logs := &fakeLogs{}
+	m := newTestManager(t, logs)
+
+	job, err := m.Submit(&Request{
+		Namespace: "ns-a",
+		Query:     "q",
+		Start:     t0,
+		End:       t0.Add(150 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if job.Windows != 3 {
+		t.Errorf("Windows = %d, want 3", job.Windows)
+	}
+
+	job = wait(t, m, job.ID)
+	if job.State != StateSucceeded {
+		t.Fatalf("State = %s (%s), want %s", job.State, job.Error, StateSucceeded)
+	}
+	if job.DoneWindows != 3 || job.Lines != 3 || job.Progress != 100 || job.Size == 0 {
+		t.Errorf("unexpected progress %+v", job)
+	}
+
+	// The windows follow each other without overlapping and end at the end of the range
+	want := [][2]time.Time{
+		{t0, t0.Add(time.Hour - time.Nanosecond)},
+		{t0.Add(time.Hour), t0.Add(2*time.Hour - time.Nanosecond)},
+		{t0.Add(2 * time.Hour), t0.Add(150 * time.Minute)},
+	}
+	for i, w := range want {
+		if logs.windows[i] != w {
+			t.Errorf("window %d = %v, want %v", i, logs.windows[i], w)
+		}
+	}
+
+	got := readExport(t, m, job)
+	if lines := strings.Split(strings.TrimSpace(got), "\n"); len(lines) != 3 ||
+		lines[2] != `{"_time":"2024-01-01T02:00:00Z","_msg":"q","pod":"web-0","container":"app"}` {
+		t.Errorf("unexpected export:\n%s", got)
+	}
+}
+
+func TestManager_ExportText(t *testing.T) {
+	m := newTestManager(t, &fakeLogs{})
+
+	job, err := m.Submit(&Request{
+		Namespace: "ns-a",
+		Query:     "q",
+		Format:    FormatText,
+		Start:     t0,
+		End:       t0.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	job = wait(t, m, job.ID)
+
+	if got, want := readExport(t, m, job), "2024-01-01T00:00:00Z [web-0/app] q\n"; got != want {
+		t.Errorf("export = %q, want %q", got, want)
+	}
+	if job.Filename() != job.ID+".log" {
+		t.Errorf("Filename() = %s", job.Filename())
+	}
+}
+
+func TestManager_ExportFailed(t *testing.T) {
+	m := newTestManager(t, &fakeLogs{err: errors.New("unavailable")})
+
+	job, err := m.Submit(&Request{Namespace: "ns-a", Start: t0, End: t0.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	job = wait(t, m, job.ID)
+
+	if job.State != StateFailed || !strings.Contains(job.Error, "unavailable") {
+		t.Errorf("unexpected job %+v", job)
+	}
+	if _, err := m.Open(context.Background(), job); !errors.Is(err, ErrJobNotReady) {
+		t.Errorf("Open() error = %v, want %v", err, ErrJobNotReady)
+	}
+}
+
+func TestManager_Submit(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		want error
+	}{
+		{
+			name: "unknown format",
+			req:  Request{Format: "csv", Start: t0, End: t0.Add(time.Hour)},
+			want: ErrUnknownFmt,
+		},
+		{
+			name: "empty range",
+			req:  Request{Start: t0, End: t0},
+			want: ErrInvalidRange,
+		},
+		{
+			name: "range too long",
+			req:  Request{Start: t0, End: t0.Add(25 * time.Hour)},
+			want: ErrInvalidRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, &fakeLogs{})
+			if _, err := m.Submit(&tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("Submit() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_TooManyJobs(t *testing.T) {
+	m := newTestManager(t, &fakeLogs{})
+	// Occupy the only slot so that the jobs stay pending
+	m.slots <- struct{}{}
+
+	req := Request{Namespace: "ns-a", Start: t0, End: t0.Add(time.Hour)}
+	if _, err := m.Submit(&req); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := m.Submit(&req); !errors.Is(err, ErrTooManyJobs) {
+		t.Errorf("Submit() error = %v, want %v", err, ErrTooManyJobs)
+	}
+	other := req
+	other.Namespace = "ns-b"
+	if _, err := m.Submit(&other); err != nil {
+		t.Errorf("Submit() in another namespace error = %v", err)
+	}
+}
+
+func TestManager_Cleanup(t *testing.T) {
+	m := newTestManager(t, &fakeLogs{})
+	m.options.Retention = time.Hour
+
+	job, err := m.Submit(&Request{Namespace: "ns-a", Start: t0, End: t0.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	job = wait(t, m, job.ID)
+
+	m.cleanup(context.Background())
+	if _, ok := m.Get(job.ID); !ok {
+		t.Fatal("job removed before the end of the retention")
+	}
+
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	m.cleanup(context.Background())
+	if _, ok := m.Get(job.ID); ok {
+		t.Error("job kept after the end of the retention")
+	}
+	if _, err := m.storage.Open(context.Background(), job.key()); err == nil {
+		t.Error("export kept after the end of the retention")
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	now := t0.Add(24 * time.Hour)
+	tests := []struct {
+		name             string
+		start, end, last string
+		wantStart        time.Time
+		wantEnd          time.Time
+		wantErr          bool
+	}{
+		{
+			name:      "rfc3339",
+			start:     "2024-01-01T00:00:00Z",
+			end:       "2024-01-01T06:00:00Z",
+			wantStart: t0,
+			wantEnd:   t0.Add(6 * time.Hour),
+		},
+		{
+			name:      "unix timestamps",
+			start:     "1704067200",
+			end:       "1704067200.5",
+			wantStart: t0,
+			wantEnd:   t0.Add(500 * time.Millisecond),
+		},
+		{
+			name:      "last duration",
+			last:      "1h",
+			wantStart: now.Add(-time.Hour),
+			wantEnd:   now,
+		},
+		{
+			name:      "last days",
+			last:      "1d",
+			wantStart: t0,
+			wantEnd:   now,
+		},
+		{
+			name:    "no range",
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			start:   "yesterday",
+			end:     "today",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := TimeRange(tt.start, tt.end, tt.last, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TimeRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("TimeRange() = %v, %v, want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config is the S3-compatible object storage configuration
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	Prefix    string `yaml:"prefix"`
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+	UseSSL    bool   `yaml:"useSSL"`
+}
+
+// S3Storage stores the exports in S3-compatible object storage
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Storage creates a storage keeping the exports in the configured bucket
+func NewS3Storage(c S3Config) (*S3Storage, error) {
+	if c.Endpoint == "" || c.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+
+	client, err := minio.New(c.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(c.AccessKey, c.SecretKey, ""),
+		Secure: c.UseSSL,
+		Region: c.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Storage{
+		client: client,
+		bucket: c.Bucket,
+		prefix: c.Prefix,
+	}, nil
+}
+
+// Put uploads the export to bucket/prefix/key
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.object(key), r, size, minio.PutObjectOptions{
+		ContentType: contentType(key),
+	})
+	return err
+}
+
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, s.bucket, s.object(key), minio.GetObjectOptions{})
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, s.object(key), minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) PresignedURL(
+	ctx context.Context,
+	key, filename string,
+	expiry time.Duration,
+) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, s.object(key), expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func contentType(key string) string {
+	if strings.HasSuffix(key, ".gz") {
+		return "application/gzip"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func (s *S3Storage) object(key string) string {
+	return path.Join(s.prefix, key)
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// StorageLocal stores the exports in a local directory
+	StorageLocal = "local"
+	// StorageS3 stores the exports in S3-compatible object storage
+	StorageS3 = "s3"
+)
+
+// Storage stores the finished exports
+type Storage interface {
+	// Put stores the export read from r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Open reads the export stored under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the export stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner is implemented by the storages the exports can be downloaded from directly
+type Presigner interface {
+	// PresignedURL returns a download link of the export valid for expiry
+	PresignedURL(ctx context.Context, key, filename string, expiry time.Duration) (string, error)
+}
+
+// LocalStorage stores the exports in a local directory
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage creates a storage keeping the exports under dir
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+// Put writes the export to dir/key
+func (s *LocalStorage) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	name := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return f.Close()
+}
+
+func (s *LocalStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(s.path(key))
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
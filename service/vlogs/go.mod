@@ -3,8 +3,18 @@ module github.com/labring/sealos/service/vlogs
 go 1.22.7
 
 require (
+	github.com/google/uuid v1.6.0
+	github.com/labring/sealos/controllers/pkg v0.0.0-00010101000000-000000000000
 	github.com/labring/sealos/service v0.0.0
+	github.com/minio/minio-go/v7 v7.0.95
+	golang.org/x/net v0.47.0
+	golang.org/x/time v0.14.0
 	gopkg.in/yaml.v2 v2.4.0
+	k8s.io/apimachinery v0.28.4
+	k8s.io/client-go v0.28.4
 )
 
-replace github.com/labring/sealos/service => ../
+replace (
+	github.com/labring/sealos/controllers/pkg => ../../controllers/pkg
+	github.com/labring/sealos/service => ../
+)
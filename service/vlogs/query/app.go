@@ -35,6 +35,43 @@ func (v *VLogsQuery) GetQuery(req *api.VlogsLaunchpadRequest) (string, error) {
 	return v.query, nil
 }
 
+// GetExportQuery returns the query of all the logs matched by the request, without
+// limit and sorted by time. The time range is given along with the query.
+func (v *VLogsQuery) GetExportQuery(req *api.VlogsLaunchpadRequest) (string, error) {
+	query, err := v.getUnlimitedQuery(req)
+	if err != nil {
+		return "", err
+	}
+	return query + " | sort by (_time)", nil
+}
+
+// GetTailQuery returns the query of the live tailing of the logs matched by the request.
+// The tail API only supports filters and pipes which do not need all the logs.
+func (v *VLogsQuery) GetTailQuery(req *api.VlogsLaunchpadRequest) (string, error) {
+	return v.getUnlimitedQuery(req)
+}
+
+// GetCountQuery returns the query of the number of logs matched by the request, the
+// number is returned in the logs_total field. The time range is given along with the query.
+func (v *VLogsQuery) GetCountQuery(req *api.VlogsLaunchpadRequest) (string, error) {
+	query, err := v.getUnlimitedQuery(req)
+	if err != nil {
+		return "", err
+	}
+	return query + " | stats count() logs_total", nil
+}
+
+// getUnlimitedQuery returns the filters of the request without time range, limit nor stats
+func (v *VLogsQuery) getUnlimitedQuery(req *api.VlogsLaunchpadRequest) (string, error) {
+	unlimitedReq := *req
+	unlimitedReq.Time = ""
+	unlimitedReq.Limit = ""
+	unlimitedReq.PodQuery = ""
+	unlimitedReq.NumberMode = ""
+	v.query = ""
+	return v.GetQuery(&unlimitedReq)
+}
+
 func EscapeSingleQuoted(s string) string {
 	s = strings.ReplaceAll(s, `\`, `\\`)
 	s = strings.ReplaceAll(s, `'`, `\'`)
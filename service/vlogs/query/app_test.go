@@ -2,6 +2,8 @@ package query
 
 import (
 	"testing"
+
+	"github.com/labring/sealos/service/pkg/api"
 )
 
 func TestEscapeSingleQuoted(t *testing.T) {
@@ -57,3 +59,91 @@ func TestEscapeSingleQuoted(t *testing.T) {
 		})
 	}
 }
+
+func TestVLogsQuery_GetExportQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *api.VlogsLaunchpadRequest
+		want string
+	}{
+		{
+			name: "limit, pod query and time are ignored",
+			req: &api.VlogsLaunchpadRequest{
+				Namespace:  "ns-a",
+				App:        "web",
+				Time:       "1h",
+				Limit:      "10",
+				NumberMode: "false",
+				PodQuery:   "true",
+				Pod:        []string{"web-0"},
+			},
+			want: "{pod='web-0',namespace='ns-a'} app:='web' | Drop _stream_id,_stream,app,job,namespace,node | sort by (_time)",
+		},
+		{
+			name: "keyword, stderr and json filters are kept",
+			req: &api.VlogsLaunchpadRequest{
+				Namespace:  "ns-a",
+				App:        "web",
+				Keyword:    "error",
+				StderrMode: "true",
+				JSONMode:   "true",
+				JSONQuery:  []api.JSONQuery{{Key: "level", Mode: "=", Value: "error"}},
+			},
+			want: "'error' {namespace='ns-a'} app:='web' | stream:=\"stderr\"  | unpack_json| 'level':='error' | Drop _stream_id,_stream,app,job,namespace,node | sort by (_time)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &VLogsQuery{}
+			got, err := v.GetExportQuery(tt.req)
+			if err != nil {
+				t.Errorf("GetExportQuery() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetExportQuery()\ngot  = %q\nwant = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVLogsQuery_GetTailQuery(t *testing.T) {
+	v := &VLogsQuery{}
+	got, err := v.GetTailQuery(&api.VlogsLaunchpadRequest{
+		Namespace:  "ns-a",
+		App:        "web",
+		Time:       "1h",
+		Limit:      "10",
+		NumberMode: "false",
+		StderrMode: "true",
+		Container:  []string{"app"},
+		Keyword:    "error",
+	})
+	if err != nil {
+		t.Fatalf("GetTailQuery() error = %v", err)
+	}
+	want := "'error' {container='app',namespace='ns-a'} app:='web' | stream:=\"stderr\" | Drop _stream_id,_stream,app,job,namespace,node"
+	if got != want {
+		t.Errorf("GetTailQuery()\ngot  = %q\nwant = %q", got, want)
+	}
+}
+
+func TestVLogsQuery_GetCountQuery(t *testing.T) {
+	v := &VLogsQuery{}
+	got, err := v.GetCountQuery(&api.VlogsLaunchpadRequest{
+		Namespace:  "ns-a",
+		App:        "web",
+		Time:       "1h",
+		Limit:      "10",
+		NumberMode: "true",
+		Keyword:    "error",
+	})
+	if err != nil {
+		t.Fatalf("GetCountQuery() error = %v", err)
+	}
+	want := "'error' {namespace='ns-a'} app:='web' | Drop _stream_id,_stream,app,job,namespace,node | stats count() logs_total"
+	if got != want {
+		t.Errorf("GetCountQuery()\ngot  = %q\nwant = %q", got, want)
+	}
+}
@@ -24,6 +24,18 @@ func (v *DBLogsQuery) GetDBQuery(req *api.VlogsDatabaseRequest) (string, error)
 	return v.query, nil
 }
 
+// GetDBExportQuery returns the query of all the logs matched by the request, without
+// limit and sorted by time. The time range is given along with the query.
+func (v *DBLogsQuery) GetDBExportQuery(req *api.VlogsDatabaseRequest) (string, error) {
+	v.query = ""
+	v.generateVolumeUIDQuery(req)
+	v.generateKeywordQuery(req)
+	v.generateContainerQuery(req)
+	v.generateTypeQuery(req)
+	v.query += "| sort by (_time)"
+	return v.query, nil
+}
+
 func (v *DBLogsQuery) generateVolumeUIDQuery(req *api.VlogsDatabaseRequest) {
 	if len(req.Pvc) == 0 {
 		return
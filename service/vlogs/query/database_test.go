@@ -168,3 +168,45 @@ func TestDBLogsQuery_GetDBQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestDBLogsQuery_GetDBExportQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *api.VlogsDatabaseRequest
+		want string
+	}{
+		{
+			name: "limit, number mode and time are ignored",
+			req: &api.VlogsDatabaseRequest{
+				Pvc:         []string{"pvc-001"},
+				Keyword:     "error",
+				Container:   []string{"mysql"},
+				Type:        []string{"slow"},
+				Time:        "1h",
+				Limit:       "50",
+				NumberMode:  "true",
+				NumberLevel: "m",
+			},
+			want: "{volume_uid=~'pvc-001'} 'error' container:in('mysql') log_type:in('slow') | sort by (_time)",
+		},
+		{
+			name: "pvc only",
+			req:  &api.VlogsDatabaseRequest{Pvc: []string{"pvc-001"}},
+			want: "{volume_uid=~'pvc-001'} | sort by (_time)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &DBLogsQuery{}
+			got, err := v.GetDBExportQuery(tt.req)
+			if err != nil {
+				t.Errorf("GetDBExportQuery() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetDBExportQuery()\ngot  = %q\nwant = %q", got, tt.want)
+			}
+		})
+	}
+}
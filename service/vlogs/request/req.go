@@ -1,6 +1,7 @@
 package request
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -8,6 +9,11 @@ import (
 	"net/url"
 )
 
+const (
+	queryEndpoint = "select/logsql/query"
+	tailEndpoint  = "select/logsql/tail"
+)
+
 type QueryParams struct {
 	Path      string
 	Username  string
@@ -15,11 +21,42 @@ type QueryParams struct {
 	Query     string
 	StartTime string
 	EndTime   string
+	// StartOffset is how far back in time the logs are returned before tailing
+	StartOffset string
 }
 
 func QueryLogsByParams(query *QueryParams) (io.ReadCloser, error) {
+	return QueryLogs(context.Background(), query)
+}
+
+// QueryLogs queries VictoriaLogs, the request is cancelled when the context is done
+func QueryLogs(ctx context.Context, query *QueryParams) (io.ReadCloser, error) {
+	params := url.Values{}
+	params.Add("query", query.Query)
+	params.Add("start", query.StartTime)
+	params.Add("end", query.EndTime)
+	return doReq(ctx, queryEndpoint, params, query)
+}
+
+// TailLogs streams the logs matching the query as they are ingested by VictoriaLogs,
+// until the context is done
+func TailLogs(ctx context.Context, query *QueryParams) (io.ReadCloser, error) {
+	params := url.Values{}
+	params.Add("query", query.Query)
+	if query.StartOffset != "" {
+		params.Add("start_offset", query.StartOffset)
+	}
+	return doReq(ctx, tailEndpoint, params, query)
+}
+
+func doReq(
+	ctx context.Context,
+	endpoint string,
+	params url.Values,
+	query *QueryParams,
+) (io.ReadCloser, error) {
 	httpClient := http.DefaultClient
-	req, err := generateReq(query)
+	req, err := generateReq(ctx, endpoint, params, query)
 	if err != nil {
 		return nil, err
 	}
@@ -48,18 +85,19 @@ func QueryLogsByParams(query *QueryParams) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
-func generateReq(query *QueryParams) (*http.Request, error) {
+func generateReq(
+	ctx context.Context,
+	endpoint string,
+	params url.Values,
+	query *QueryParams,
+) (*http.Request, error) {
 	parsedURL, err := url.Parse(query.Path)
 	if err != nil {
 		return nil, fmt.Errorf("can not parser API URL: %w", err)
 	}
-	parsedURL = parsedURL.JoinPath("select/logsql/query")
-	params := url.Values{}
-	params.Add("query", query.Query)
-	params.Add("start", query.StartTime)
-	params.Add("end", query.EndTime)
+	parsedURL = parsedURL.JoinPath(endpoint)
 	parsedURL.RawQuery = params.Encode()
-	req, err := http.NewRequest(http.MethodGet, parsedURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsedURL.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("create HTTP req error: %w", err)
 	}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labring/sealos/service/pkg/auth"
+	"github.com/labring/sealos/service/vlogs/export"
+	"github.com/labring/sealos/service/vlogs/query"
+	"github.com/labring/sealos/service/vlogs/request"
+)
+
+const (
+	exportJobPath      = "/exportJob"
+	exportDownloadPath = "/exportDownload"
+)
+
+var errExportDisabled = errors.New("log export is disabled")
+
+func (vl *VLogsServer) fetchLogs(
+	ctx context.Context,
+	query string,
+	start, end time.Time,
+) (io.ReadCloser, error) {
+	return request.QueryLogs(ctx, &request.QueryParams{
+		Path:      vl.path,
+		Query:     query,
+		Username:  vl.username,
+		Password:  vl.password,
+		StartTime: start.Format(time.RFC3339Nano),
+		EndTime:   end.Format(time.RFC3339Nano),
+	})
+}
+
+func (vl *VLogsServer) exportLogsByParams(rw http.ResponseWriter, req *http.Request) error {
+	if vl.exports == nil {
+		return errExportDisabled
+	}
+	vlogsReq, kubeConfig, err := vl.verifyParams(req)
+	if err != nil {
+		return fmt.Errorf("bad request (%w)", err)
+	}
+	err = auth.Authenticate(vlogsReq.Namespace, kubeConfig)
+	if err != nil {
+		return fmt.Errorf("authentication failed (%w)", err)
+	}
+	var vlogs query.VLogsQuery
+	query, err := vlogs.GetExportQuery(vlogsReq)
+	if err != nil {
+		return fmt.Errorf("failed to parse request body: %w", err)
+	}
+	start, end, err := export.TimeRange(vlogsReq.StartTime, vlogsReq.EndTime, vlogsReq.Time, time.Now())
+	if err != nil {
+		return fmt.Errorf("bad request (%w)", err)
+	}
+	return vl.submitExport(rw, req, &export.Request{
+		Namespace: vlogsReq.Namespace,
+		Query:     query,
+		Format:    req.URL.Query().Get("format"),
+		Start:     start,
+		End:       end,
+	})
+}
+
+func (vl *VLogsServer) exportDBLogs(rw http.ResponseWriter, req *http.Request) error {
+	if vl.exports == nil {
+		return errExportDisabled
+	}
+	vlogsReq, kubeConfig, err := vl.verifyDBParams(req)
+	if err != nil {
+		return fmt.Errorf("bad request (%w)", err)
+	}
+	err = auth.Authenticate(vlogsReq.Namespace, kubeConfig)
+	if err != nil {
+		return fmt.Errorf("authentication failed (%w)", err)
+	}
+	err = auth.AuthenticatePVC(vlogsReq.Namespace, kubeConfig, vlogsReq.Pvc)
+	if err != nil {
+		return fmt.Errorf("authentication pvc failed (%w)", err)
+	}
+	var vlogs query.DBLogsQuery
+	query, err := vlogs.GetDBExportQuery(vlogsReq)
+	if err != nil {
+		return fmt.Errorf("failed to parse request body: %w", err)
+	}
+	start, end, err := export.TimeRange(vlogsReq.StartTime, vlogsReq.EndTime, vlogsReq.Time, time.Now())
+	if err != nil {
+		return fmt.Errorf("bad request (%w)", err)
+	}
+	return vl.submitExport(rw, req, &export.Request{
+		Namespace: vlogsReq.Namespace,
+		Query:     query,
+		Format:    req.URL.Query().Get("format"),
+		Start:     start,
+		End:       end,
+	})
+}
+
+func (vl *VLogsServer) submitExport(
+	rw http.ResponseWriter,
+	req *http.Request,
+	exportReq *export.Request,
+) error {
+	job, err := vl.exports.Submit(exportReq)
+	if err != nil {
+		return fmt.Errorf("bad request (%w)", err)
+	}
+	return vl.writeExportJob(rw, req, job)
+}
+
+// exportJob returns the status of the export job given by the id parameter
+func (vl *VLogsServer) exportJob(rw http.ResponseWriter, req *http.Request) error {
+	job, err := vl.authorizeExportJob(req)
+	if err != nil {
+		return err
+	}
+	return vl.writeExportJob(rw, req, job)
+}
+
+// exportDownload sends the export of the job given by the id parameter
+func (vl *VLogsServer) exportDownload(rw http.ResponseWriter, req *http.Request) error {
+	job, err := vl.authorizeExportJob(req)
+	if err != nil {
+		return err
+	}
+	file, err := vl.exports.Open(req.Context(), job)
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+	defer file.Close()
+	return sendExport(rw, file, job)
+}
+
+// sendExport writes the export as an attachment. Once part of it is written the
+// status is sent, so a failed copy is logged rather than returned as an error
+// response
+func sendExport(rw http.ResponseWriter, file io.Reader, job export.Status) error {
+	if job.Format == export.FormatNDJSON {
+		rw.Header().Set("Content-Type", "application/gzip")
+	} else {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.Filename()))
+	n, err := io.Copy(rw, file)
+	if err == nil {
+		return nil
+	}
+	if n == 0 {
+		rw.Header().Del("Content-Disposition")
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+	log.Printf("Failed to send export %s (%s)\n", job.ID, err)
+	return nil
+}
+
+// authorizeExportJob returns the job given by the id parameter if the kubeconfig
+// of the request has access to its namespace
+func (vl *VLogsServer) authorizeExportJob(req *http.Request) (export.Status, error) {
+	if vl.exports == nil {
+		return export.Status{}, errExportDisabled
+	}
+	kubeConfig, err := vl.extractKubeConfig(req)
+	if err != nil {
+		return export.Status{}, fmt.Errorf("bad request (%w)", err)
+	}
+	job, ok := vl.exports.Get(req.URL.Query().Get("id"))
+	if !ok {
+		return export.Status{}, export.ErrJobNotFound
+	}
+	err = auth.Authenticate(job.Namespace, kubeConfig)
+	if err != nil {
+		return export.Status{}, fmt.Errorf("authentication failed (%w)", err)
+	}
+	return job, nil
+}
+
+func (vl *VLogsServer) writeExportJob(
+	rw http.ResponseWriter,
+	req *http.Request,
+	job export.Status,
+) error {
+	if job.State == export.StateSucceeded {
+		link, err := vl.exports.Link(req.Context(), job)
+		if err != nil {
+			return fmt.Errorf("failed to sign download link: %w", err)
+		}
+		if link == "" {
+			link = exportDownloadPath + "?" + url.Values{"id": {job.ID}}.Encode()
+		}
+		job.DownloadURL = link
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(job); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
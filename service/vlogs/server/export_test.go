@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labring/sealos/service/vlogs/export"
+)
+
+// failingReader returns data and then fails
+type failingReader struct {
+	data io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestSendExport(t *testing.T) {
+	job := export.Status{ID: "job", Format: export.FormatText}
+	rec := httptest.NewRecorder()
+
+	if err := sendExport(rec, strings.NewReader("line\n"), job); err != nil {
+		t.Fatalf("sendExport() error = %v", err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "line\n" {
+		t.Errorf("response = %d %q, want 200 with the export", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="job.log"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestSendExport_CopyFailed(t *testing.T) {
+	job := export.Status{ID: "job", Format: export.FormatText}
+
+	// The status is already sent, so the error is not written into the export
+	rec := httptest.NewRecorder()
+	err := sendExport(rec, &failingReader{data: strings.NewReader("line\n")}, job)
+	if err != nil {
+		t.Errorf("sendExport() error = %v, want the failure to be logged", err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "line\n" {
+		t.Errorf("response = %d %q, want 200 with the partial export", rec.Code, rec.Body.String())
+	}
+
+	// Nothing is written yet, so the error can still be sent to the client
+	rec = httptest.NewRecorder()
+	err = sendExport(rec, &failingReader{data: strings.NewReader("")}, job)
+	if err == nil {
+		t.Error("sendExport() expected error")
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Disposition") != "" {
+		t.Errorf("response = %q %v, want no attachment", rec.Body.String(), rec.Header())
+	}
+}
@@ -13,7 +13,11 @@ import (
 )
 
 func (vl *VLogsServer) extractKubeConfig(req *http.Request) (string, error) {
-	kubeConfig := req.Header.Get("Authorization")
+	return unescapeKubeConfig(req.Header.Get("Authorization"))
+}
+
+// unescapeKubeConfig decodes the kubeconfig sent as the Authorization header
+func unescapeKubeConfig(kubeConfig string) (string, error) {
 	if config, err := url.PathUnescape(kubeConfig); err == nil {
 		kubeConfig = config
 	} else {
@@ -1,18 +1,25 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 
+	"github.com/labring/sealos/service/vlogs/alert"
 	"github.com/labring/sealos/service/vlogs/config"
+	"github.com/labring/sealos/service/vlogs/export"
 )
 
 type VLogsServer struct {
 	path     string
 	username string
 	password string
+	exports  *export.Manager
+	tail     tailOptions
+	// tails limits the live tail connections
+	tails chan struct{}
 }
 
 func NewVLogsServer(config *config.Config) (*VLogsServer, error) {
@@ -21,6 +28,27 @@ func NewVLogsServer(config *config.Config) (*VLogsServer, error) {
 		username: config.Server.Username,
 		password: config.Server.Password,
 	}
+	tail, maxTails, err := newTailOptions(config.Tail)
+	if err != nil {
+		return nil, err
+	}
+	vl.tail = tail
+	vl.tails = make(chan struct{}, maxTails)
+	exports, err := export.NewFromConfig(config.Export, vl.fetchLogs)
+	if err != nil {
+		return nil, err
+	}
+	if exports != nil {
+		vl.exports = exports
+		go exports.Run(context.Background())
+	}
+	alerts, interval, err := alert.NewFromConfig(config.Alert, vl.fetchLogs)
+	if err != nil {
+		return nil, err
+	}
+	if alerts != nil {
+		go alerts.Run(context.Background(), interval)
+	}
 	return vl, nil
 }
 
@@ -56,6 +84,16 @@ func (vl *VLogsServer) queryConvert(
 		return vl.queryPodList, nil
 	case "/queryLogsByPod":
 		return vl.queryDBLogs, nil
+	case "/exportLogsByParams":
+		return vl.exportLogsByParams, nil
+	case "/exportLogsByPod":
+		return vl.exportDBLogs, nil
+	case exportJobPath:
+		return vl.exportJob, nil
+	case exportDownloadPath:
+		return vl.exportDownload, nil
+	case tailPath:
+		return vl.tailLogs, nil
 	default:
 		return nil, errors.New("unknown url path")
 	}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/service/pkg/api"
+	"github.com/labring/sealos/service/pkg/auth"
+	"github.com/labring/sealos/service/vlogs/config"
+	"github.com/labring/sealos/service/vlogs/export"
+	"github.com/labring/sealos/service/vlogs/query"
+	"github.com/labring/sealos/service/vlogs/request"
+	"golang.org/x/net/websocket"
+	"golang.org/x/time/rate"
+)
+
+const tailPath = "/tailLogs"
+
+// Events sent to the tailing clients
+const (
+	// tailEventLog carries a log entry as returned by VictoriaLogs
+	tailEventLog = "log"
+	// tailEventDropped reports the number of entries dropped by the rate limit
+	tailEventDropped = "dropped"
+	// tailEventPing is sent when nothing else was sent for a heartbeat
+	tailEventPing = "ping"
+	// tailEventEnd is sent before the server closes the connection
+	tailEventEnd = "end"
+	// tailEventError reports the failure to start the tailing on WebSocket connections
+	tailEventError = "error"
+)
+
+var errTooManyTails = errors.New("too many live tail connections")
+
+// authenticate checks the access of a kubeconfig to a namespace
+var authenticate = auth.Authenticate
+
+type tailOptions struct {
+	linesPerSecond rate.Limit
+	burst          int
+	maxDuration    time.Duration
+	heartbeat      time.Duration
+}
+
+// newTailOptions parses the tail config, by default a connection receives up to
+// 100 lines per second with bursts of 500 lines for 30m, with 100 connections at most
+func newTailOptions(c config.TailConfig) (tailOptions, int, error) {
+	options := tailOptions{
+		linesPerSecond: rate.Limit(c.LinesPerSecond),
+		burst:          c.Burst,
+		maxDuration:    30 * time.Minute,
+		heartbeat:      15 * time.Second,
+	}
+	if options.linesPerSecond == 0 {
+		options.linesPerSecond = 100
+	}
+	if options.burst == 0 {
+		options.burst = 500
+	}
+	maxConnections := c.MaxConnections
+	if maxConnections == 0 {
+		maxConnections = 100
+	}
+
+	var err error
+	if c.MaxDuration != "" {
+		if options.maxDuration, err = export.ParseDuration(c.MaxDuration); err != nil {
+			return tailOptions{}, 0, fmt.Errorf("invalid tail maxDuration: %s", err)
+		}
+	}
+	if c.Heartbeat != "" {
+		if options.heartbeat, err = time.ParseDuration(c.Heartbeat); err != nil {
+			return tailOptions{}, 0, fmt.Errorf("invalid tail heartbeat: %s", err)
+		}
+	}
+	if options.linesPerSecond < 0 || options.burst < 0 || maxConnections < 0 ||
+		options.maxDuration <= 0 || options.heartbeat <= 0 {
+		return tailOptions{}, 0, fmt.Errorf("invalid tail config: %+v", c)
+	}
+	return options, maxConnections, nil
+}
+
+// tailSink sends the events to a tailing client
+type tailSink interface {
+	send(event string, data []byte) error
+}
+
+// sseSink sends the events as server-sent events
+type sseSink struct {
+	rw      http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *sseSink) send(event string, data []byte) error {
+	if _, err := fmt.Fprintf(s.rw, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// wsSink sends the events as WebSocket text messages {"event": ..., "data": ...}
+type wsSink struct {
+	ws *websocket.Conn
+}
+
+func (s *wsSink) send(event string, data []byte) error {
+	message, err := json.Marshal(struct {
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}{event, data})
+	if err != nil {
+		return err
+	}
+	return websocket.Message.Send(s.ws, string(message))
+}
+
+// wsTailRequest is the first message of a WebSocket client. Browsers can not set the
+// Authorization header of a WebSocket handshake, so the kubeconfig is sent with the request.
+type wsTailRequest struct {
+	api.VlogsLaunchpadRequest
+	Authorization string `json:"authorization,omitempty"`
+}
+
+// tailLogs streams the logs matched by the request as they are ingested. The logs are
+// sent as server-sent events to POST requests, whose body is the body of /queryLogsByParams.
+// WebSocket clients send that body as their first message instead, with the kubeconfig.
+func (vl *VLogsServer) tailLogs(rw http.ResponseWriter, req *http.Request) error {
+	select {
+	case vl.tails <- struct{}{}:
+		defer func() { <-vl.tails }()
+	default:
+		return errTooManyTails
+	}
+
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		// The origin is not checked, the clients are authorized by their kubeconfig
+		websocket.Server{Handler: vl.tailWebSocket}.ServeHTTP(rw, req)
+		return nil
+	}
+
+	kubeConfig, err := vl.extractKubeConfig(req)
+	if err != nil {
+		return fmt.Errorf("bad request (%w)", err)
+	}
+	vlogsReq, err := vl.verifyTailParams(req.Body)
+	if err != nil {
+		return fmt.Errorf("bad request (%w)", err)
+	}
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		return errors.New("streaming is not supported")
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), vl.tail.maxDuration)
+	defer cancel()
+	body, err := vl.openTail(ctx, kubeConfig, vlogsReq, req.URL.Query().Get("startOffset"))
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	vl.pumpTail(ctx, &sseSink{rw: rw, flusher: flusher}, body)
+	return nil
+}
+
+func (vl *VLogsServer) tailWebSocket(ws *websocket.Conn) {
+	defer ws.Close()
+	sink := &wsSink{ws: ws}
+	req := ws.Request()
+
+	// The context of a hijacked connection is not cancelled when the client leaves
+	ctx, cancel := context.WithTimeout(context.Background(), vl.tail.maxDuration)
+	defer cancel()
+
+	body, err := vl.openWebSocketTail(ctx, ws, req)
+	if err != nil {
+		data, _ := json.Marshal(map[string]string{"error": err.Error()})
+		_ = sink.send(tailEventError, data)
+		return
+	}
+	defer body.Close()
+
+	// Nothing is expected from the client anymore, reading detects when it leaves
+	go func() {
+		_, _ = io.Copy(io.Discard, ws)
+		cancel()
+	}()
+
+	vl.pumpTail(ctx, sink, body)
+}
+
+func (vl *VLogsServer) openWebSocketTail(
+	ctx context.Context,
+	ws *websocket.Conn,
+	req *http.Request,
+) (io.ReadCloser, error) {
+	var message string
+	if err := websocket.Message.Receive(ws, &message); err != nil {
+		return nil, fmt.Errorf("failed to receive request: %w", err)
+	}
+	wsReq := &wsTailRequest{}
+	if err := json.Unmarshal([]byte(message), wsReq); err != nil {
+		return nil, fmt.Errorf("bad request (failed to parse request body: %w)", err)
+	}
+	if wsReq.Namespace == "" {
+		return nil, errors.New("bad request (failed to get namespace)")
+	}
+	// Clients which can set headers may still send the kubeconfig as Authorization header
+	kubeConfig := wsReq.Authorization
+	if kubeConfig == "" {
+		kubeConfig = req.Header.Get("Authorization")
+	}
+	kubeConfig, err := unescapeKubeConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("bad request (%w)", err)
+	}
+	return vl.openTail(ctx, kubeConfig, &wsReq.VlogsLaunchpadRequest, req.URL.Query().Get("startOffset"))
+}
+
+func (vl *VLogsServer) verifyTailParams(r io.Reader) (*api.VlogsLaunchpadRequest, error) {
+	vlogsReq := &api.VlogsLaunchpadRequest{}
+	err := json.NewDecoder(r).Decode(&vlogsReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	if vlogsReq.Namespace == "" {
+		return nil, errors.New("failed to get namespace")
+	}
+	return vlogsReq, nil
+}
+
+// openTail authorizes the tailing of the namespace and starts it
+func (vl *VLogsServer) openTail(
+	ctx context.Context,
+	kubeConfig string,
+	vlogsReq *api.VlogsLaunchpadRequest,
+	startOffset string,
+) (io.ReadCloser, error) {
+	err := authenticate(vlogsReq.Namespace, kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed (%w)", err)
+	}
+	var vlogs query.VLogsQuery
+	query, err := vlogs.GetTailQuery(vlogsReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	resp, err := request.TailLogs(ctx, &request.QueryParams{
+		Path:        vl.path,
+		Query:       query,
+		Username:    vl.username,
+		Password:    vl.password,
+		StartOffset: startOffset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tail failed (%w)", err)
+	}
+	return resp, nil
+}
+
+// pumpTail sends the lines of the tail to the sink until the tail, the client or the
+// context ends. The lines over the rate limit are dropped, their number is sent with
+// the next heartbeat.
+func (vl *VLogsServer) pumpTail(ctx context.Context, sink tailSink, body io.Reader) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan []byte)
+	done := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		done <- scanner.Err()
+	}()
+
+	limiter := rate.NewLimiter(vl.tail.linesPerSecond, vl.tail.burst)
+	heartbeat := time.NewTicker(vl.tail.heartbeat)
+	defer heartbeat.Stop()
+
+	dropped := 0
+	end := func(reason string) {
+		data, _ := json.Marshal(map[string]any{"reason": reason, "dropped": dropped})
+		_ = sink.send(tailEventEnd, data)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				end("timeout")
+			}
+			return
+		case err := <-done:
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Live tail failed (%s)\n", err)
+			}
+			end("closed")
+			return
+		case line := <-lines:
+			if !limiter.Allow() {
+				dropped++
+				continue
+			}
+			if err := sink.send(tailEventLog, line); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			var err error
+			if dropped > 0 {
+				err = sink.send(tailEventDropped, []byte(fmt.Sprintf(`{"dropped":%d}`, dropped)))
+				dropped = 0
+			} else {
+				err = sink.send(tailEventPing, []byte("{}"))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/service/pkg/auth"
+	"github.com/labring/sealos/service/vlogs/config"
+	"golang.org/x/net/websocket"
+)
+
+type recordedEvent struct {
+	event string
+	data  string
+}
+
+type recordingSink struct {
+	events []recordedEvent
+}
+
+func (s *recordingSink) send(event string, data []byte) error {
+	s.events = append(s.events, recordedEvent{event, string(data)})
+	return nil
+}
+
+func TestPumpTail_RateLimit(t *testing.T) {
+	vl := &VLogsServer{tail: tailOptions{
+		linesPerSecond: 0.001,
+		burst:          2,
+		maxDuration:    time.Minute,
+		heartbeat:      time.Hour,
+	}}
+	body := strings.NewReader("{\"_msg\":\"1\"}\n\n{\"_msg\":\"2\"}\n{\"_msg\":\"3\"}\n{\"_msg\":\"4\"}\n")
+	sink := &recordingSink{}
+
+	vl.pumpTail(context.Background(), sink, body)
+
+	want := []recordedEvent{
+		{tailEventLog, `{"_msg":"1"}`},
+		{tailEventLog, `{"_msg":"2"}`},
+		{tailEventEnd, `{"dropped":2,"reason":"closed"}`},
+	}
+	if len(sink.events) != len(want) {
+		t.Fatalf("events = %v, want %v", sink.events, want)
+	}
+	for i := range want {
+		if sink.events[i] != want[i] {
+			t.Errorf("event %d = %v, want %v", i, sink.events[i], want[i])
+		}
+	}
+}
+
+func TestPumpTail_Timeout(t *testing.T) {
+	vl := &VLogsServer{tail: tailOptions{
+		linesPerSecond: 100,
+		burst:          100,
+		heartbeat:      10 * time.Millisecond,
+	}}
+	// The tail never ends by itself
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	sink := &recordingSink{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	vl.pumpTail(ctx, sink, reader)
+
+	last := sink.events[len(sink.events)-1]
+	if last.event != tailEventEnd || !strings.Contains(last.data, `"reason":"timeout"`) {
+		t.Errorf("last event = %v, want the end of the tail on timeout", last)
+	}
+	if sink.events[0].event != tailEventPing {
+		t.Errorf("first event = %v, want a heartbeat", sink.events[0])
+	}
+}
+
+func TestSSESink(t *testing.T) {
+	rw := httptest.NewRecorder()
+	sink := &sseSink{rw: rw, flusher: rw}
+
+	if err := sink.send(tailEventLog, []byte(`{"_msg":"hello"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rw.Body.String(), "event: log\ndata: {\"_msg\":\"hello\"}\n\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if !rw.Flushed {
+		t.Error("event not flushed")
+	}
+}
+
+func TestNewTailOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.TailConfig
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "days", config: config.TailConfig{MaxDuration: "1d", Heartbeat: "5s"}},
+		{name: "invalid duration", config: config.TailConfig{MaxDuration: "forever"}, wantErr: true},
+		{name: "negative rate", config: config.TailConfig{LinesPerSecond: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := newTailOptions(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newTailOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTailWebSocket_KubeConfigInMessage(t *testing.T) {
+	var gotNamespace, gotKubeConfig string
+	authenticate = func(namespace, kubeConfig string) error {
+		gotNamespace, gotKubeConfig = namespace, kubeConfig
+		return errors.New("denied")
+	}
+	t.Cleanup(func() { authenticate = auth.Authenticate })
+
+	vl := &VLogsServer{
+		tail:  tailOptions{maxDuration: time.Minute, heartbeat: time.Minute},
+		tails: make(chan struct{}, 1),
+	}
+	server := httptest.NewServer(vl)
+	defer server.Close()
+
+	// Browsers can not set headers, the kubeconfig comes with the request
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+tailPath, "", server.URL)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer ws.Close()
+	request := `{"namespace":"ns-a","app":"web","authorization":"apiVersion%3A%20v1"}`
+	if err := websocket.Message.Send(ws, request); err != nil {
+		t.Fatal(err)
+	}
+
+	var message string
+	if err := websocket.Message.Receive(ws, &message); err != nil {
+		t.Fatal(err)
+	}
+	var event struct {
+		Event string            `json:"event"`
+		Data  map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(message), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != tailEventError || !strings.Contains(event.Data["error"], "denied") {
+		t.Errorf("event = %+v, want the authentication error", event)
+	}
+	if gotNamespace != "ns-a" || gotKubeConfig != "apiVersion: v1" {
+		t.Errorf("authenticated %q with %q, want ns-a with the kubeconfig of the message", gotNamespace, gotKubeConfig)
+	}
+}
@@ -4,51 +4,186 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
-// sendAlert sends Feishu alert
+// AlertSink delivers zombie node alerts
+type AlertSink interface {
+	Name() string
+	Send(ctx context.Context, alert *ZombieNodeAlert) error
+}
+
+// ZombieNodeAlert describes a Ready node without metrics data
+type ZombieNodeAlert struct {
+	ClusterName string
+	NodeName    string
+	Duration    time.Duration
+	AlertTime   time.Time
+}
+
+// message formats the alert as the text sent to chat bots
+func (a *ZombieNodeAlert) message() string {
+	if a.ClusterName != "" {
+		return fmt.Sprintf(
+			alertFormatWithCluster,
+			a.ClusterName,
+			a.NodeName,
+			a.Duration.Round(time.Second).String(),
+			a.AlertTime.Format(time.DateTime),
+		)
+	}
+
+	return fmt.Sprintf(
+		alertFormat,
+		a.NodeName,
+		a.Duration.Round(time.Second).String(),
+		a.AlertTime.Format(time.DateTime),
+	)
+}
+
+// description describes the alert in one line
+func (a *ZombieNodeAlert) description() string {
+	return fmt.Sprintf("Node is Ready, but no metrics data for %v", a.Duration.Round(time.Second))
+}
+
+// sendAlert sends the alert to every sink
 func (m *Monitor) sendAlert(
 	ctx context.Context,
 	nodeName string,
 	duration time.Duration,
 ) error {
-	var message string
-	if m.clusterName != "" {
-		message = fmt.Sprintf(
-			alertFormatWithCluster,
-			m.clusterName,
-			nodeName,
-			duration.Round(time.Second).String(),
-			time.Now().Format(time.DateTime),
-		)
-	} else {
-		message = fmt.Sprintf(
-			alertFormat,
-			nodeName,
-			duration.Round(time.Second).String(),
-			time.Now().Format(time.DateTime),
-		)
+	alert := &ZombieNodeAlert{
+		ClusterName: m.clusterName,
+		NodeName:    nodeName,
+		Duration:    duration,
+		AlertTime:   time.Now(),
 	}
 
+	var errs []error
+	for _, sink := range m.alertSinks {
+		if err := sink.Send(ctx, alert); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// FeishuSink sends alerts to a Feishu bot
+type FeishuSink struct {
+	webhook    string
+	httpClient *http.Client
+}
+
+func NewFeishuSink(webhook string, httpClient *http.Client) *FeishuSink {
+	return &FeishuSink{
+		webhook:    webhook,
+		httpClient: httpClient,
+	}
+}
+
+func (s *FeishuSink) Name() string {
+	return "feishu"
+}
+
+func (s *FeishuSink) Send(ctx context.Context, alert *ZombieNodeAlert) error {
 	feishuMsg := FeishuMessage{
 		MsgType: "text",
 		Content: map[string]any{
-			"text": message,
+			"text": alert.message(),
 		},
 	}
 
-	jsonData, err := json.Marshal(feishuMsg)
+	return postJSON(ctx, s.httpClient, s.webhook, feishuMsg)
+}
+
+// WebhookSink posts alerts as JSON to a generic webhook
+type WebhookSink struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhookSink(url string, httpClient *http.Client) *WebhookSink {
+	return &WebhookSink{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Send(ctx context.Context, alert *ZombieNodeAlert) error {
+	return postJSON(ctx, s.httpClient, s.url, WebhookAlert{
+		ClusterName:     alert.ClusterName,
+		NodeName:        alert.NodeName,
+		Description:     alert.description(),
+		DurationSeconds: int64(alert.Duration.Seconds()),
+		AlertTime:       alert.AlertTime,
+	})
+}
+
+// AlertmanagerSink posts alerts to the Alertmanager v2 API. Alerts expire after
+// resolveTimeout unless they are sent again, so recovered nodes resolve on their own
+type AlertmanagerSink struct {
+	url            string
+	resolveTimeout time.Duration
+	httpClient     *http.Client
+}
+
+func NewAlertmanagerSink(
+	baseURL string,
+	resolveTimeout time.Duration,
+	httpClient *http.Client,
+) *AlertmanagerSink {
+	return &AlertmanagerSink{
+		url:            strings.TrimSuffix(baseURL, "/") + alertmanagerAlertsPath,
+		resolveTimeout: resolveTimeout,
+		httpClient:     httpClient,
+	}
+}
+
+func (s *AlertmanagerSink) Name() string {
+	return "alertmanager"
+}
+
+func (s *AlertmanagerSink) Send(ctx context.Context, alert *ZombieNodeAlert) error {
+	labels := map[string]string{
+		"alertname": alertmanagerAlertName,
+		"node":      alert.NodeName,
+		"severity":  alertmanagerSeverity,
+	}
+	if alert.ClusterName != "" {
+		labels["cluster"] = alert.ClusterName
+	}
+
+	return postJSON(ctx, s.httpClient, s.url, []AlertmanagerAlert{{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("Zombie node %s", alert.NodeName),
+			"description": alert.description(),
+		},
+		StartsAt: alert.AlertTime.Add(-alert.Duration),
+		EndsAt:   alert.AlertTime.Add(s.resolveTimeout),
+	}})
+}
+
+// postJSON posts body as JSON and expects a 2xx response
+func postJSON(ctx context.Context, httpClient *http.Client, url string, body any) error {
+	jsonData, err := json.Marshal(body)
 	if err != nil {
-		return fmt.Errorf("error marshaling feishu message: %w", err)
+		return fmt.Errorf("error marshaling alert: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodPost,
-		m.feishuWebhook,
+		url,
 		bytes.NewReader(jsonData),
 	)
 	if err != nil {
@@ -57,9 +192,9 @@ func (m *Monitor) sendAlert(
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := m.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("error sending feishu alert: %w", err)
+		return fmt.Errorf("error sending alert: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -68,13 +203,13 @@ func (m *Monitor) sendAlert(
 		return nil
 	}
 
-	var body bytes.Buffer
+	var respBody bytes.Buffer
 
-	_, err = body.ReadFrom(resp.Body)
+	_, err = respBody.ReadFrom(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading feishu response body: %w", err)
+		return fmt.Errorf("error reading response body: %w", err)
 	}
 
-	return fmt.Errorf("feishu webhook returned status code: %d, body: %s",
-		resp.StatusCode, body.String())
+	return fmt.Errorf("returned status code: %d, body: %s",
+		resp.StatusCode, respBody.String())
 }
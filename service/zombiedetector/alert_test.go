@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordRequest starts a server answering status and decoding the body of the
+// last request into body
+func recordRequest(t *testing.T, status int, body any) (*httptest.Server, *http.Request) {
+	t.Helper()
+
+	var recorded http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded = *r
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("response body"))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &recorded
+}
+
+func testAlert() *ZombieNodeAlert {
+	return &ZombieNodeAlert{
+		ClusterName: "cluster-a",
+		NodeName:    "node-a",
+		Duration:    5*time.Minute + 30*time.Second,
+		AlertTime:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestFeishuSink_Send(t *testing.T) {
+	var msg FeishuMessage
+	server, req := recordRequest(t, http.StatusOK, &msg)
+
+	sink := NewFeishuSink(server.URL+"/hook", server.Client())
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if req.Method != http.MethodPost || req.URL.Path != "/hook" {
+		t.Errorf("request = %s %s, want POST /hook", req.Method, req.URL.Path)
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if msg.MsgType != "text" {
+		t.Errorf("msg_type = %q, want text", msg.MsgType)
+	}
+	text, _ := msg.Content["text"].(string)
+	for _, want := range []string{"Cluster Name: cluster-a", "Node Name: node-a", "5m30s", "2025-01-02 03:04:05"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text %q does not contain %q", text, want)
+		}
+	}
+}
+
+func TestWebhookSink_Send(t *testing.T) {
+	var body WebhookAlert
+	server, _ := recordRequest(t, http.StatusNoContent, &body)
+
+	sink := NewWebhookSink(server.URL, server.Client())
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	want := WebhookAlert{
+		ClusterName:     "cluster-a",
+		NodeName:        "node-a",
+		Description:     "Node is Ready, but no metrics data for 5m30s",
+		DurationSeconds: 330,
+		AlertTime:       testAlert().AlertTime,
+	}
+	if !body.AlertTime.Equal(want.AlertTime) {
+		t.Errorf("alertTime = %v, want %v", body.AlertTime, want.AlertTime)
+	}
+	body.AlertTime = want.AlertTime
+	if body != want {
+		t.Errorf("body = %+v, want %+v", body, want)
+	}
+}
+
+func TestWebhookSink_SendError(t *testing.T) {
+	var body WebhookAlert
+	server, _ := recordRequest(t, http.StatusBadGateway, &body)
+
+	sink := NewWebhookSink(server.URL, server.Client())
+	err := sink.Send(context.Background(), testAlert())
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "response body") {
+		t.Errorf("Send() error = %v, want status code and body", err)
+	}
+}
+
+func TestAlertmanagerSink_Send(t *testing.T) {
+	var alerts []AlertmanagerAlert
+	server, req := recordRequest(t, http.StatusOK, &alerts)
+
+	resolveTimeout := 3 * time.Minute
+	sink := NewAlertmanagerSink(server.URL+"/", resolveTimeout, server.Client())
+	alert := testAlert()
+	if err := sink.Send(context.Background(), alert); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if req.URL.Path != alertmanagerAlertsPath {
+		t.Errorf("path = %q, want %q", req.URL.Path, alertmanagerAlertsPath)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(alerts))
+	}
+	got := alerts[0]
+	wantLabels := map[string]string{
+		"alertname": alertmanagerAlertName,
+		"node":      "node-a",
+		"severity":  alertmanagerSeverity,
+		"cluster":   "cluster-a",
+	}
+	if len(got.Labels) != len(wantLabels) {
+		t.Errorf("labels = %v, want %v", got.Labels, wantLabels)
+	}
+	for k, v := range wantLabels {
+		if got.Labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, got.Labels[k], v)
+		}
+	}
+	if got.Annotations["description"] != alert.description() {
+		t.Errorf("description = %q, want %q", got.Annotations["description"], alert.description())
+	}
+	if want := alert.AlertTime.Add(-alert.Duration); !got.StartsAt.Equal(want) {
+		t.Errorf("startsAt = %v, want %v", got.StartsAt, want)
+	}
+	if want := alert.AlertTime.Add(resolveTimeout); !got.EndsAt.Equal(want) {
+		t.Errorf("endsAt = %v, want %v", got.EndsAt, want)
+	}
+}
+
+func TestAlertmanagerSink_SendWithoutCluster(t *testing.T) {
+	var alerts []AlertmanagerAlert
+	server, _ := recordRequest(t, http.StatusOK, &alerts)
+
+	alert := testAlert()
+	alert.ClusterName = ""
+	sink := NewAlertmanagerSink(server.URL, time.Minute, server.Client())
+	if err := sink.Send(context.Background(), alert); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if _, ok := alerts[0].Labels["cluster"]; ok {
+		t.Errorf("labels = %v, want no cluster label", alerts[0].Labels)
+	}
+}
+
+// fakeSink records the alerts and fails with err
+type fakeSink struct {
+	name   string
+	err    error
+	alerts []*ZombieNodeAlert
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Send(_ context.Context, alert *ZombieNodeAlert) error {
+	s.alerts = append(s.alerts, alert)
+	return s.err
+}
+
+func TestMonitor_SendAlert(t *testing.T) {
+	errFailed := errors.New("failed")
+	ok, failed := &fakeSink{name: "ok"}, &fakeSink{name: "failed", err: errFailed}
+	m := &Monitor{clusterName: "cluster-a", alertSinks: []AlertSink{failed, ok}}
+
+	err := m.sendAlert(context.Background(), "node-a", time.Minute)
+	if !errors.Is(err, errFailed) || !strings.Contains(err.Error(), "failed: failed") {
+		t.Errorf("sendAlert() error = %v, want the error of the failed sink", err)
+	}
+	if len(ok.alerts) != 1 || len(failed.alerts) != 1 {
+		t.Fatalf("alerts sent = %d, %d, want every sink to be tried", len(ok.alerts), len(failed.alerts))
+	}
+	if got := ok.alerts[0]; got.ClusterName != "cluster-a" || got.NodeName != "node-a" || got.Duration != time.Minute {
+		t.Errorf("alert = %+v", got)
+	}
+}
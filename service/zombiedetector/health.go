@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func startHealthServer() {
@@ -19,13 +21,16 @@ func startHealthServer() {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// Prometheus metrics
+	mux.Handle("/metrics", promhttp.Handler())
+
 	server := &http.Server{
 		Addr:              ":8080",
 		Handler:           mux,
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	log.Println("Starting health check server on :8080")
+	log.Println("Starting health check and metrics server on :8080")
 
 	if err := server.ListenAndServe(); err != nil {
 		log.Printf("Health check server error: %v", err)
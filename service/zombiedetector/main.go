@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
+	"time"
 
 	"k8s.io/client-go/rest"
 )
@@ -12,10 +14,15 @@ func main() {
 	// Set log format
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
-	// Get Feishu webhook URL from environment variable
-	feishuWebhook := os.Getenv("FEISHU_WEBHOOK_URL")
-	if feishuWebhook == "" {
-		log.Fatal("FEISHU_WEBHOOK_URL environment variable is required")
+	// Register Prometheus metrics
+	registerMetrics()
+
+	// Create alert sinks from environment variables
+	alertSinks := newAlertSinks()
+	if len(alertSinks) == 0 {
+		log.Fatal(
+			"At least one of FEISHU_WEBHOOK_URL, ALERT_WEBHOOK_URL or ALERTMANAGER_URL environment variables is required",
+		)
 	}
 
 	// Use in-cluster config (for ServiceAccount)
@@ -27,11 +34,21 @@ func main() {
 	// Create monitor
 	monitor, err := NewMonitor(
 		config,
-		feishuWebhook,
+		WithAlertSinks(alertSinks...),
 		WithClusterName(os.Getenv("CLUSTER_NAME")),
 		WithCheckInterval(getEnvDuration("CHECK_INTERVAL")),
 		WithAlertThreshold(getEnvDuration("ALERT_THRESHOLD")),
 		WithAlertInterval(getEnvDuration("ALERT_INTERVAL")),
+		WithRemediation(RemediationConfig{
+			Enabled:       getEnvBool("REMEDIATION_ENABLED"),
+			DryRun:        getEnvBool("REMEDIATION_DRY_RUN"),
+			Threshold:     getEnvDuration("REMEDIATION_THRESHOLD"),
+			MaxConcurrent: getEnvInt("REMEDIATION_MAX_CONCURRENT"),
+			Drain:         getEnvBool("REMEDIATION_DRAIN"),
+			DrainTimeout:  getEnvDuration("REMEDIATION_DRAIN_TIMEOUT"),
+			HookURL:       os.Getenv("REMEDIATION_HOOK_URL"),
+			HookTimeout:   getEnvDuration("REMEDIATION_HOOK_TIMEOUT"),
+		}),
 	)
 	if err != nil {
 		log.Fatalf("Failed to create monitor: %v", err)
@@ -52,3 +69,30 @@ func main() {
 	runWithLeaderElection(ctx, monitor)
 	log.Println("Node zombie detector stopped")
 }
+
+// newAlertSinks creates the alert sinks configured by environment variables
+func newAlertSinks() []AlertSink {
+	httpClient := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	var sinks []AlertSink
+	if url := os.Getenv("FEISHU_WEBHOOK_URL"); url != "" {
+		sinks = append(sinks, NewFeishuSink(url, httpClient))
+	}
+
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		sinks = append(sinks, NewWebhookSink(url, httpClient))
+	}
+
+	if url := os.Getenv("ALERTMANAGER_URL"); url != "" {
+		alertInterval := getEnvDuration("ALERT_INTERVAL")
+		if alertInterval <= 0 {
+			alertInterval = defaultAlertInterval
+		}
+		// Alerts are resent every alert interval while the node has no metrics
+		sinks = append(sinks, NewAlertmanagerSink(url, 3*alertInterval, httpClient))
+	}
+
+	return sinks
+}
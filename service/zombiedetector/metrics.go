@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Define Prometheus metrics
+var (
+	nodesWithoutMetricsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "zombie_detector_nodes_without_metrics",
+			Help: "Number of Ready nodes without metrics data",
+		},
+	)
+	nodeSecondsSinceLastMetricsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_detector_node_seconds_since_last_metrics",
+			Help: "Seconds since metrics data was last seen for each Ready node, 0 when the node has metrics",
+		},
+		[]string{"node"},
+	)
+)
+
+func registerMetrics() {
+	prometheus.MustRegister(nodesWithoutMetricsGauge)
+	prometheus.MustRegister(nodeSecondsSinceLastMetricsGauge)
+}
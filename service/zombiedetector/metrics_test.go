@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func newReadyNode(name string, ready v1.ConditionStatus) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: ready}},
+		},
+	}
+}
+
+// addNodeMetrics adds the metrics of a node, under the resource the metrics client lists
+func addNodeMetrics(t *testing.T, clientset *metricsfake.Clientset, nodeName string) {
+	t.Helper()
+
+	if err := clientset.Tracker().Create(
+		metricsv1beta1.SchemeGroupVersion.WithResource("nodes"),
+		&metricsv1beta1.NodeMetrics{ObjectMeta: metav1.ObjectMeta{Name: nodeName}},
+		"",
+	); err != nil {
+		t.Fatalf("Failed to add node metrics: %v", err)
+	}
+}
+
+func TestMonitor_CheckNodesGauges(t *testing.T) {
+	nodesWithoutMetricsGauge.Set(0)
+	nodeSecondsSinceLastMetricsGauge.Reset()
+	t.Cleanup(nodeSecondsSinceLastMetricsGauge.Reset)
+
+	clientset := fake.NewClientset(
+		newReadyNode("with-metrics", v1.ConditionTrue),
+		newReadyNode("zombie", v1.ConditionTrue),
+		newReadyNode("not-ready", v1.ConditionFalse),
+	)
+	metricsClientset := metricsfake.NewSimpleClientset()
+	addNodeMetrics(t, metricsClientset, "with-metrics")
+	sink := &fakeSink{name: "fake"}
+	m := &Monitor{
+		clientset:        clientset,
+		metricsClientset: metricsClientset,
+		alertSinks:       []AlertSink{sink},
+		alertThreshold:   time.Minute,
+		alertInterval:    5 * time.Minute,
+	}
+
+	// The zombie node was last seen with metrics 2 minutes ago, the status of
+	// a deleted node is cleaned up
+	nodeStatus := map[string]*NodeStatus{
+		"zombie":  {LastSeenWithMetrics: time.Now().Add(-2 * time.Minute)},
+		"deleted": {LastSeenWithMetrics: time.Now()},
+	}
+	nodeSecondsSinceLastMetricsGauge.WithLabelValues("deleted").Set(30)
+	nodeSecondsSinceLastMetricsGauge.WithLabelValues("not-ready").Set(30)
+
+	m.checkNodes(context.Background(), nodeStatus)
+
+	if got := testutil.ToFloat64(nodesWithoutMetricsGauge); got != 1 {
+		t.Errorf("nodes without metrics = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(nodeSecondsSinceLastMetricsGauge.WithLabelValues("with-metrics")); got != 0 {
+		t.Errorf("seconds since last metrics of with-metrics = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(nodeSecondsSinceLastMetricsGauge.WithLabelValues("zombie")); got < 120 || got > 130 {
+		t.Errorf("seconds since last metrics of zombie = %v, want about 120", got)
+	}
+	nodeSecondsSinceLastMetricsGauge.DeleteLabelValues("with-metrics")
+	nodeSecondsSinceLastMetricsGauge.DeleteLabelValues("zombie")
+	if got := testutil.CollectAndCount(nodeSecondsSinceLastMetricsGauge); got != 0 {
+		t.Errorf("got %d series for deleted and not ready nodes, want 0", got)
+	}
+	if _, ok := nodeStatus["deleted"]; ok {
+		t.Error("status of deleted node should be cleaned up")
+	}
+	if len(sink.alerts) != 1 || sink.alerts[0].NodeName != "zombie" {
+		t.Errorf("alerts = %v, want one alert for zombie", sink.alerts)
+	}
+
+	// Once the zombie node reports metrics again it is counted as healthy
+	addNodeMetrics(t, metricsClientset, "zombie")
+
+	m.checkNodes(context.Background(), nodeStatus)
+
+	if got := testutil.ToFloat64(nodesWithoutMetricsGauge); got != 0 {
+		t.Errorf("nodes without metrics = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(nodeSecondsSinceLastMetricsGauge.WithLabelValues("zombie")); got != 0 {
+		t.Errorf("seconds since last metrics of zombie = %v, want 0", got)
+	}
+}
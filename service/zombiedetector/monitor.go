@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -18,13 +18,14 @@ import (
 // Monitor K8s node monitor
 type Monitor struct {
 	clusterName      string
-	clientset        *kubernetes.Clientset
-	metricsClientset *metricsclientset.Clientset
-	feishuWebhook    string
+	clientset        kubernetes.Interface
+	metricsClientset metricsclientset.Interface
+	alertSinks       []AlertSink
 	checkInterval    time.Duration
 	alertThreshold   time.Duration
 	alertInterval    time.Duration
-	httpClient       *http.Client
+	remediation      RemediationConfig
+	remediator       *Remediator
 }
 
 type MonitorConfigFunc func(m *Monitor)
@@ -65,10 +66,21 @@ func WithClusterName(name string) MonitorConfigFunc {
 	}
 }
 
+func WithAlertSinks(sinks ...AlertSink) MonitorConfigFunc {
+	return func(m *Monitor) {
+		m.alertSinks = append(m.alertSinks, sinks...)
+	}
+}
+
+func WithRemediation(config RemediationConfig) MonitorConfigFunc {
+	return func(m *Monitor) {
+		m.remediation = config
+	}
+}
+
 // NewMonitor creates a new monitor instance
 func NewMonitor(
 	config *rest.Config,
-	feishuWebhook string,
 	opts ...MonitorConfigFunc,
 ) (*Monitor, error) {
 	clientset, err := kubernetes.NewForConfig(config)
@@ -84,19 +96,23 @@ func NewMonitor(
 	m := Monitor{
 		clientset:        clientset,
 		metricsClientset: metricsClientset,
-		feishuWebhook:    feishuWebhook,
 		checkInterval:    defaultCheckInterval,
 		alertThreshold:   defaultAlertThreshold,
 		alertInterval:    defaultAlertInterval,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
 	}
 
 	for _, opt := range opts {
 		opt(&m)
 	}
 
+	if len(m.alertSinks) == 0 {
+		return nil, errors.New("at least one alert sink is required")
+	}
+
+	if m.remediation.Enabled {
+		m.remediator = NewRemediator(clientset, m.clusterName, m.remediation)
+	}
+
 	return &m, nil
 }
 
@@ -143,6 +159,10 @@ func (m *Monitor) checkNodes(ctx context.Context, nodeStatus map[string]*NodeSta
 	// Clean up deleted nodes' status
 	m.cleanupDeletedNodes(activeNodes, nodeStatus)
 
+	if m.remediator != nil {
+		m.remediator.syncActive(nodes.Items)
+	}
+
 	nodeMetrics, err := m.metricsClientset.MetricsV1beta1().
 		NodeMetricses().
 		List(ctx, metav1.ListOptions{})
@@ -160,13 +180,19 @@ func (m *Monitor) checkNodes(ctx context.Context, nodeStatus map[string]*NodeSta
 	}
 
 	// Check each node
+	nodesWithoutMetrics := 0
 	for _, node := range nodes.Items {
 		// Only check Ready nodes
 		if !isNodeReady(&node) {
 			log.Printf("Node %s is not ready, skipping", node.Name)
+			nodeSecondsSinceLastMetricsGauge.DeleteLabelValues(node.Name)
 			continue
 		}
 
+		if metricsMap[node.Name] == nil {
+			nodesWithoutMetrics++
+		}
+
 		err := m.checkNode(
 			ctx,
 			&node,
@@ -177,6 +203,8 @@ func (m *Monitor) checkNodes(ctx context.Context, nodeStatus map[string]*NodeSta
 			log.Printf("Error checking node %s: %v", node.Name, err)
 		}
 	}
+
+	nodesWithoutMetricsGauge.Set(float64(nodesWithoutMetrics))
 }
 
 // cleanupDeletedNodes cleans up status of deleted nodes
@@ -187,6 +215,7 @@ func (m *Monitor) cleanupDeletedNodes(
 	for nodeName := range nodeStatus {
 		if !activeNodes[nodeName] {
 			delete(nodeStatus, nodeName)
+			nodeSecondsSinceLastMetricsGauge.DeleteLabelValues(nodeName)
 			log.Printf("Cleaned up status for deleted node: %s", nodeName)
 		}
 	}
@@ -225,6 +254,11 @@ func (m *Monitor) checkNode(
 	// If node has metrics, update last seen time
 	if hasMetrics {
 		status.LastSeenWithMetrics = time.Now()
+		nodeSecondsSinceLastMetricsGauge.WithLabelValues(nodeName).Set(0)
+
+		if m.remediator != nil {
+			m.remediator.recover(ctx, node, status)
+		}
 
 		log.Printf("Node %s: CPU=%s, Memory=%s",
 			nodeName,
@@ -238,6 +272,11 @@ func (m *Monitor) checkNode(
 	log.Printf("Node %s: Metrics=<unknown> (API is working)", nodeName)
 
 	timeSinceLastMetrics := time.Since(status.LastSeenWithMetrics)
+	nodeSecondsSinceLastMetricsGauge.WithLabelValues(nodeName).Set(timeSinceLastMetrics.Seconds())
+
+	if m.remediator != nil && timeSinceLastMetrics >= m.remediator.config.Threshold {
+		m.remediator.remediate(ctx, node, status, timeSinceLastMetrics)
+	}
 
 	if timeSinceLastMetrics < m.alertThreshold {
 		return nil
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Remediator cordons zombie nodes, optionally drains them, asks the node agent
+// to restart kubelet and the metrics components, and uncordons them once their
+// metrics return
+type Remediator struct {
+	clusterName string
+	clientset   kubernetes.Interface
+	recorder    record.EventRecorder
+	config      RemediationConfig
+	httpClient  *http.Client
+	// active holds the nodes under remediation, with the cancel func of the
+	// drain and hook still running for the node if any
+	active map[string]context.CancelFunc
+}
+
+// NewRemediator creates a remediator recording events on nodes through clientset
+func NewRemediator(
+	clientset kubernetes.Interface,
+	clusterName string,
+	config RemediationConfig,
+) *Remediator {
+	if config.Threshold <= 0 {
+		config.Threshold = defaultRemediationThreshold
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = defaultRemediationMaxConcurrent
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = defaultDrainTimeout
+	}
+	if config.HookTimeout <= 0 {
+		config.HookTimeout = defaultHookTimeout
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: clientset.CoreV1().Events(""),
+	})
+
+	return &Remediator{
+		clusterName: clusterName,
+		clientset:   clientset,
+		recorder: broadcaster.NewRecorder(
+			scheme.Scheme,
+			v1.EventSource{Component: eventComponent},
+		),
+		config: config,
+		httpClient: &http.Client{
+			Timeout: config.HookTimeout,
+		},
+		active: make(map[string]context.CancelFunc),
+	}
+}
+
+// syncActive reconciles the nodes under remediation with the node annotations,
+// so that remediations survive restarts and leader changes
+func (r *Remediator) syncActive(nodes []v1.Node) {
+	remediated := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if r.config.DryRun {
+			// Dry runs never annotate nodes, only forget deleted ones
+			if _, ok := r.active[node.Name]; ok {
+				remediated[node.Name] = true
+			}
+			continue
+		}
+		if _, ok := node.Annotations[remediatedAnnotation]; ok {
+			remediated[node.Name] = true
+			if _, ok := r.active[node.Name]; !ok {
+				r.active[node.Name] = nil
+			}
+		}
+	}
+
+	for nodeName, cancel := range r.active {
+		if remediated[nodeName] {
+			continue
+		}
+		if cancel != nil {
+			cancel()
+		}
+		delete(r.active, nodeName)
+		log.Printf("Node %s is no longer under remediation", nodeName)
+	}
+}
+
+// remediate starts remediating a zombie node unless it is already under
+// remediation, cordoned by someone else, or the concurrency limit is reached
+func (r *Remediator) remediate(
+	ctx context.Context,
+	node *v1.Node,
+	status *NodeStatus,
+	duration time.Duration,
+) {
+	if _, ok := r.active[node.Name]; ok {
+		return
+	}
+	if node.Spec.Unschedulable {
+		// Cordoned by an administrator, leave the node alone
+		return
+	}
+	if len(r.active) >= r.config.MaxConcurrent {
+		if !status.RemediationDeferred {
+			status.RemediationDeferred = true
+			r.event(node, v1.EventTypeWarning, reasonRemediationDeferred,
+				"Remediation deferred, %d nodes are already under remediation", len(r.active))
+		}
+		return
+	}
+	status.RemediationDeferred = false
+
+	reason := fmt.Sprintf("node is Ready, but no metrics data for %v", duration.Round(time.Second))
+	if err := r.cordon(ctx, node, reason); err != nil {
+		r.event(node, v1.EventTypeWarning, reasonRemediationFailed, "Failed to cordon node: %v", err)
+		log.Printf("Error cordoning node %s: %v", node.Name, err)
+		return
+	}
+
+	remediationCtx, cancel := context.WithCancel(ctx)
+	r.active[node.Name] = cancel
+	go r.drainAndRestart(remediationCtx, node.DeepCopy(), reason)
+}
+
+// recover uncordons a node cordoned by the detector after its metrics returned
+func (r *Remediator) recover(ctx context.Context, node *v1.Node, status *NodeStatus) {
+	status.RemediationDeferred = false
+
+	cancel, ok := r.active[node.Name]
+	if !ok {
+		return
+	}
+	if cancel != nil {
+		cancel()
+	}
+
+	// An administrator may have cordoned the node again during the remediation
+	manager := unschedulableManager(node)
+	uncordon := manager == "" || manager == fieldManager
+
+	if !r.config.DryRun {
+		patch := map[string]any{
+			"metadata": map[string]any{
+				"annotations": map[string]any{remediatedAnnotation: nil},
+			},
+		}
+		if uncordon {
+			patch["spec"] = map[string]any{"unschedulable": nil}
+		}
+		if err := r.patchNode(ctx, node.Name, patch); err != nil {
+			r.event(node, v1.EventTypeWarning, reasonRemediationFailed, "Failed to uncordon node: %v", err)
+			log.Printf("Error uncordoning node %s: %v", node.Name, err)
+			return
+		}
+	}
+
+	delete(r.active, node.Name)
+	if !uncordon {
+		r.event(node, v1.EventTypeNormal, reasonCordonKept, "Metrics returned, node left cordoned by %s", manager)
+		log.Printf("Node %s: metrics returned, left cordoned by %s", node.Name, manager)
+		return
+	}
+	r.event(node, v1.EventTypeNormal, reasonUncordoned, "Metrics returned, node uncordoned")
+	log.Printf("Node %s: metrics returned, uncordoned", node.Name)
+}
+
+// unschedulableManager returns the field manager that last set the node unschedulable,
+// it is empty if the node is schedulable or the managed fields are unknown
+func unschedulableManager(node *v1.Node) string {
+	if !node.Spec.Unschedulable {
+		return ""
+	}
+	for _, entry := range node.ManagedFields {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var managed struct {
+			Spec map[string]json.RawMessage `json:"f:spec"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &managed); err != nil {
+			continue
+		}
+		if _, ok := managed.Spec["f:unschedulable"]; ok {
+			return entry.Manager
+		}
+	}
+	return ""
+}
+
+// cordon marks the node unschedulable and annotates it as remediated
+func (r *Remediator) cordon(ctx context.Context, node *v1.Node, reason string) error {
+	if !r.config.DryRun {
+		patch := map[string]any{
+			"metadata": map[string]any{
+				"annotations": map[string]any{
+					remediatedAnnotation: time.Now().Format(time.RFC3339),
+				},
+			},
+			"spec": map[string]any{"unschedulable": true},
+		}
+		if err := r.patchNode(ctx, node.Name, patch); err != nil {
+			return err
+		}
+	}
+
+	r.event(node, v1.EventTypeWarning, reasonCordoned, "Node cordoned: %s", reason)
+	log.Printf("Node %s: cordoned, %s", node.Name, reason)
+	return nil
+}
+
+// drainAndRestart drains the node if enabled and calls the node-agent hook
+func (r *Remediator) drainAndRestart(ctx context.Context, node *v1.Node, reason string) {
+	if r.config.Drain {
+		if err := r.drain(ctx, node); err != nil {
+			if ctx.Err() != nil {
+				// Metrics returned or leadership lost while draining
+				return
+			}
+			r.event(node, v1.EventTypeWarning, reasonDrainIncomplete, "Drain incomplete: %v", err)
+			log.Printf("Node %s: drain incomplete: %v", node.Name, err)
+		} else {
+			r.event(node, v1.EventTypeNormal, reasonDrained, "Node drained")
+			log.Printf("Node %s: drained", node.Name)
+		}
+	}
+
+	if r.config.HookURL == "" {
+		return
+	}
+	if err := r.callHook(ctx, node, reason); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		r.event(node, v1.EventTypeWarning, reasonHookFailed, "Node agent hook failed: %v", err)
+		log.Printf("Node %s: node agent hook failed: %v", node.Name, err)
+		return
+	}
+	r.event(node, v1.EventTypeNormal, reasonHookCalled,
+		"Node agent asked to %s", strings.Join(nodeAgentHookActions, ", "))
+	log.Printf("Node %s: node agent hook called", node.Name)
+}
+
+// drain evicts the pods of the node through the eviction API, so that
+// PodDisruptionBudgets are respected, retrying blocked evictions until the drain timeout
+func (r *Remediator) drain(ctx context.Context, node *v1.Node) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.DrainTimeout)
+	defer cancel()
+
+	for {
+		pods, err := r.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node.Name).String(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		pending := 0
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if !shouldEvict(pod) {
+				continue
+			}
+			pending++
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+			if r.config.DryRun {
+				log.Printf("Node %s: dry run, would evict pod %s/%s", node.Name, pod.Namespace, pod.Name)
+				continue
+			}
+
+			err := r.clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+			})
+			switch {
+			case err == nil, apierrors.IsNotFound(err):
+			case apierrors.IsTooManyRequests(err):
+				log.Printf("Node %s: eviction of pod %s/%s blocked by PodDisruptionBudget",
+					node.Name, pod.Namespace, pod.Name)
+			default:
+				log.Printf("Node %s: error evicting pod %s/%s: %v", node.Name, pod.Namespace, pod.Name, err)
+			}
+		}
+		if pending == 0 || r.config.DryRun {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d pods still running: %w", pending, ctx.Err())
+		case <-time.After(drainRetryInterval):
+		}
+	}
+}
+
+// shouldEvict reports whether the pod is evicted when draining, DaemonSet and
+// static pods would be recreated on the node and finished pods need no eviction
+func shouldEvict(pod *v1.Pod) bool {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return false
+	}
+	if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	return true
+}
+
+// callHook asks the node agent to restart kubelet and the metrics components
+func (r *Remediator) callHook(ctx context.Context, node *v1.Node, reason string) error {
+	nodeIP := nodeInternalIP(node)
+	url := strings.NewReplacer("{node}", node.Name, "{nodeIP}", nodeIP).Replace(r.config.HookURL)
+
+	if r.config.DryRun {
+		log.Printf("Node %s: dry run, would call node agent hook %s", node.Name, url)
+		return nil
+	}
+
+	jsonData, err := json.Marshal(NodeAgentHookRequest{
+		ClusterName: r.clusterName,
+		NodeName:    node.Name,
+		NodeIP:      nodeIP,
+		Actions:     nodeAgentHookActions,
+		Reason:      reason,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling node agent hook request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating http request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling node agent hook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusOK &&
+		resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	var body bytes.Buffer
+
+	_, err = body.ReadFrom(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading node agent hook response body: %w", err)
+	}
+
+	return fmt.Errorf("node agent hook returned status code: %d, body: %s",
+		resp.StatusCode, body.String())
+}
+
+func (r *Remediator) patchNode(ctx context.Context, nodeName string, patch map[string]any) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("error marshaling node patch: %w", err)
+	}
+
+	_, err = r.clientset.CoreV1().
+		Nodes().
+		Patch(ctx, nodeName, k8stypes.MergePatchType, data, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("error patching node: %w", err)
+	}
+
+	return nil
+}
+
+// event records an event on the node, prefixed in dry-run mode
+func (r *Remediator) event(node *v1.Node, eventType, reason, messageFmt string, args ...any) {
+	if r.config.DryRun {
+		messageFmt = "[dry-run] " + messageFmt
+	}
+	r.recorder.Eventf(node, eventType, reason, messageFmt, args...)
+}
+
+// nodeInternalIP returns the internal IP of the node
+func nodeInternalIP(node *v1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == v1.NodeInternalIP {
+			return address.Address
+		}
+	}
+
+	return ""
+}
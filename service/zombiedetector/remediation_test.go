@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestNode(name string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+		},
+	}
+}
+
+// nodePatches returns the patch actions on nodes recorded by the fake clientset
+func nodePatches(clientset *fake.Clientset) []k8stesting.PatchAction {
+	var patches []k8stesting.PatchAction
+	for _, action := range clientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && action.GetResource().Resource == "nodes" {
+			patches = append(patches, patch)
+		}
+	}
+	return patches
+}
+
+func getNode(t *testing.T, clientset *fake.Clientset, name string) *v1.Node {
+	t.Helper()
+
+	node, err := clientset.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get node %s: %v", name, err)
+	}
+	return node
+}
+
+func TestRemediator_MaxConcurrent(t *testing.T) {
+	nodeA, nodeB := newTestNode("node-a"), newTestNode("node-b")
+	clientset := fake.NewClientset(nodeA, nodeB)
+	r := NewRemediator(clientset, "", RemediationConfig{Enabled: true, MaxConcurrent: 1})
+
+	statusA, statusB := &NodeStatus{}, &NodeStatus{}
+	r.remediate(context.Background(), nodeA, statusA, 10*time.Minute)
+	r.remediate(context.Background(), nodeB, statusB, 10*time.Minute)
+
+	if !getNode(t, clientset, "node-a").Spec.Unschedulable {
+		t.Error("node-a should be cordoned")
+	}
+	if getNode(t, clientset, "node-b").Spec.Unschedulable {
+		t.Error("node-b should not be cordoned beyond the concurrency limit")
+	}
+	if statusA.RemediationDeferred || !statusB.RemediationDeferred {
+		t.Errorf("RemediationDeferred = %v, %v, want false, true", statusA.RemediationDeferred, statusB.RemediationDeferred)
+	}
+
+	// node-b is remediated once node-a recovered
+	r.recover(context.Background(), getNode(t, clientset, "node-a"), statusA)
+	r.remediate(context.Background(), nodeB, statusB, 10*time.Minute)
+	if !getNode(t, clientset, "node-b").Spec.Unschedulable {
+		t.Error("node-b should be cordoned after node-a recovered")
+	}
+	if statusB.RemediationDeferred {
+		t.Error("RemediationDeferred of node-b should be reset")
+	}
+}
+
+func TestRemediator_DryRun(t *testing.T) {
+	node := newTestNode("node-a")
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       v1.PodSpec{NodeName: node.Name},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	clientset := fake.NewClientset(node, pod)
+	r := NewRemediator(clientset, "", RemediationConfig{
+		Enabled: true,
+		DryRun:  true,
+		Drain:   true,
+		HookURL: "http://node-agent.invalid/{node}",
+	})
+
+	status := &NodeStatus{}
+	r.remediate(context.Background(), node, status, 10*time.Minute)
+	if _, ok := r.active[node.Name]; !ok {
+		t.Fatal("node should be under remediation in dry run")
+	}
+	if err := r.drain(context.Background(), node); err != nil {
+		t.Errorf("drain() error = %v", err)
+	}
+	if err := r.callHook(context.Background(), node, "test"); err != nil {
+		t.Errorf("callHook() error = %v", err)
+	}
+	r.recover(context.Background(), node, status)
+	if _, ok := r.active[node.Name]; ok {
+		t.Error("node should no longer be under remediation")
+	}
+
+	if patches := nodePatches(clientset); len(patches) != 0 {
+		t.Errorf("dry run patched nodes %d times", len(patches))
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetSubresource() == "eviction" {
+			t.Error("dry run evicted a pod")
+		}
+	}
+}
+
+func TestRemediator_DrainBlockedByPodDisruptionBudget(t *testing.T) {
+	node := newTestNode("node-a")
+	pods := []runtime.Object{
+		node,
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec:       v1.PodSpec{NodeName: node.Name},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "agent",
+				Namespace:       "kube-system",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent"}},
+			},
+			Spec:   v1.PodSpec{NodeName: node.Name},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		},
+	}
+	clientset := fake.NewClientset(pods...)
+
+	var evictions []string
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		evictions = append(evictions, action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName())
+		return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	})
+
+	r := NewRemediator(clientset, "", RemediationConfig{
+		Enabled:      true,
+		Drain:        true,
+		DrainTimeout: 100 * time.Millisecond,
+	})
+
+	err := r.drain(context.Background(), node)
+	if err == nil || !strings.Contains(err.Error(), "1 pods still running") {
+		t.Fatalf("drain() error = %v, want 1 pods still running", err)
+	}
+	if len(evictions) != 1 || evictions[0] != "app" {
+		t.Errorf("evictions = %v, want [app]", evictions)
+	}
+}
+
+func TestRemediator_AdoptAfterRestart(t *testing.T) {
+	node := newTestNode("node-a")
+	node.Annotations = map[string]string{remediatedAnnotation: time.Now().Format(time.RFC3339)}
+	node.Spec.Unschedulable = true
+	clientset := fake.NewClientset(node)
+
+	// A new leader finds the node cordoned by the previous one
+	r := NewRemediator(clientset, "", RemediationConfig{Enabled: true, MaxConcurrent: 1})
+	r.syncActive([]v1.Node{*node})
+	if _, ok := r.active[node.Name]; !ok {
+		t.Fatal("annotated node should be adopted")
+	}
+
+	// The adopted node counts against the concurrency limit
+	other := newTestNode("node-b")
+	if _, err := clientset.CoreV1().Nodes().Create(context.Background(), other, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	status := &NodeStatus{}
+	r.remediate(context.Background(), other, status, 10*time.Minute)
+	if !status.RemediationDeferred {
+		t.Error("node-b should be deferred while the adopted node is under remediation")
+	}
+
+	r.recover(context.Background(), node, &NodeStatus{})
+	recovered := getNode(t, clientset, node.Name)
+	if recovered.Spec.Unschedulable {
+		t.Error("adopted node should be uncordoned")
+	}
+	if _, ok := recovered.Annotations[remediatedAnnotation]; ok {
+		t.Error("remediated annotation should be removed")
+	}
+
+	// Nodes whose annotation was removed are forgotten
+	r.active[other.Name] = nil
+	r.syncActive([]v1.Node{*other})
+	if _, ok := r.active[other.Name]; ok {
+		t.Error("node without annotation should be forgotten")
+	}
+}
+
+func TestRemediator_RecoverKeepsAdministratorCordon(t *testing.T) {
+	node := newTestNode("node-a")
+	clientset := fake.NewClientset(node)
+	r := NewRemediator(clientset, "", RemediationConfig{Enabled: true})
+
+	status := &NodeStatus{}
+	r.remediate(context.Background(), node, status, 10*time.Minute)
+
+	// An administrator cordons the node again during the remediation
+	cordoned := getNode(t, clientset, node.Name)
+	cordoned.ManagedFields = []metav1.ManagedFieldsEntry{{
+		Manager:    "kubectl-cordon",
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:unschedulable":{}}}`)},
+	}}
+
+	r.recover(context.Background(), cordoned, status)
+	recovered := getNode(t, clientset, node.Name)
+	if !recovered.Spec.Unschedulable {
+		t.Error("node cordoned by an administrator should stay cordoned")
+	}
+	if _, ok := recovered.Annotations[remediatedAnnotation]; ok {
+		t.Error("remediated annotation should be removed")
+	}
+	if _, ok := r.active[node.Name]; ok {
+		t.Error("node should no longer be under remediation")
+	}
+}
+
+func TestUnschedulableManager(t *testing.T) {
+	entry := func(manager, fields string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:  manager,
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(fields)},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		unschedulable bool
+		managedFields []metav1.ManagedFieldsEntry
+		want          string
+	}{
+		{name: "schedulable", managedFields: []metav1.ManagedFieldsEntry{entry("kubectl-cordon", `{"f:spec":{"f:unschedulable":{}}}`)}},
+		{name: "unknown manager", unschedulable: true},
+		{
+			name:          "detector",
+			unschedulable: true,
+			managedFields: []metav1.ManagedFieldsEntry{
+				entry("kubelet", `{"f:status":{"f:conditions":{}}}`),
+				entry(fieldManager, `{"f:metadata":{"f:annotations":{}},"f:spec":{"f:unschedulable":{}}}`),
+			},
+			want: fieldManager,
+		},
+		{
+			name:          "administrator",
+			unschedulable: true,
+			managedFields: []metav1.ManagedFieldsEntry{entry("kubectl-cordon", `{"f:spec":{"f:unschedulable":{}}}`)},
+			want:          "kubectl-cordon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newTestNode("node-a")
+			node.Spec.Unschedulable = tt.unschedulable
+			node.ManagedFields = tt.managedFields
+			if got := unschedulableManager(node); got != tt.want {
+				t.Errorf("unschedulableManager() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 type NodeStatus struct {
 	LastSeenWithMetrics time.Time
 	LastAlertTime       time.Time
+	// RemediationDeferred is set when remediation was skipped due to the concurrency limit
+	RemediationDeferred bool
 }
 
 const (
@@ -21,12 +23,100 @@ const (
 
 const leaseName = "node-zombie-detector"
 
+const (
+	defaultRemediationThreshold     = 5 * time.Minute
+	defaultRemediationMaxConcurrent = 1
+	defaultDrainTimeout             = 5 * time.Minute
+	defaultHookTimeout              = 30 * time.Second
+	drainRetryInterval              = 5 * time.Second
+)
+
+// remediatedAnnotation marks nodes cordoned by the detector, the value is the cordon time.
+// Only nodes with this annotation are uncordoned when their metrics return.
+const remediatedAnnotation = "zombiedetector.sealos.io/remediated-at"
+
+// eventComponent is the source component of the events recorded on nodes
+const eventComponent = "node-zombie-detector"
+
+// fieldManager is the field manager of the node patches, the nodes set
+// unschedulable by another manager are not uncordoned
+const fieldManager = "node-zombie-detector"
+
+// Reasons of the events recorded on nodes during remediation
+const (
+	reasonCordoned            = "ZombieNodeCordoned"
+	reasonDrained             = "ZombieNodeDrained"
+	reasonDrainIncomplete     = "ZombieNodeDrainIncomplete"
+	reasonHookCalled          = "ZombieNodeHookCalled"
+	reasonHookFailed          = "ZombieNodeHookFailed"
+	reasonUncordoned          = "ZombieNodeUncordoned"
+	reasonCordonKept          = "ZombieNodeCordonKept"
+	reasonRemediationFailed   = "ZombieNodeRemediationFailed"
+	reasonRemediationDeferred = "ZombieNodeRemediationDeferred"
+)
+
+// RemediationConfig configures the remediation of zombie nodes
+type RemediationConfig struct {
+	// Enabled turns on remediation, nodes are only alerted on when disabled
+	Enabled bool
+	// DryRun records what would be done without changing any node
+	DryRun bool
+	// Threshold is the time without metrics before a node is remediated
+	Threshold time.Duration
+	// MaxConcurrent is the maximum number of nodes under remediation at once
+	MaxConcurrent int
+	// Drain evicts the pods of a cordoned node, respecting PodDisruptionBudgets
+	Drain bool
+	// DrainTimeout is how long to retry evictions blocked by PodDisruptionBudgets
+	DrainTimeout time.Duration
+	// HookURL is the node-agent endpoint asked to restart kubelet and the metrics
+	// components, {node} and {nodeIP} are replaced with the node name and internal IP
+	HookURL string
+	// HookTimeout is the timeout of the node-agent hook request
+	HookTimeout time.Duration
+}
+
+// NodeAgentHookRequest is the body posted to the node-agent hook
+type NodeAgentHookRequest struct {
+	ClusterName string   `json:"clusterName,omitempty"`
+	NodeName    string   `json:"nodeName"`
+	NodeIP      string   `json:"nodeIP,omitempty"`
+	Actions     []string `json:"actions"`
+	Reason      string   `json:"reason"`
+}
+
+// Actions requested from the node-agent hook
+var nodeAgentHookActions = []string{"restart-kubelet", "restart-metrics"}
+
 // FeishuMessage represents Feishu message structure
 type FeishuMessage struct {
 	MsgType string         `json:"msg_type"`
 	Content map[string]any `json:"content"`
 }
 
+// WebhookAlert is the body posted to the generic alert webhook
+type WebhookAlert struct {
+	ClusterName     string    `json:"clusterName,omitempty"`
+	NodeName        string    `json:"nodeName"`
+	Description     string    `json:"description"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	AlertTime       time.Time `json:"alertTime"`
+}
+
+// AlertmanagerAlert represents an alert of the Alertmanager v2 API
+type AlertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+const (
+	alertmanagerAlertsPath = "/api/v2/alerts"
+	alertmanagerAlertName  = "ZombieNode"
+	alertmanagerSeverity   = "warning"
+)
+
 const alertFormat = "⚠️ Node Monitor Alert\n\n" +
 	"Node Name: %s\n" +
 	"Description: Node is Ready, but no metrics data for %v\n" +
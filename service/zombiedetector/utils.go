@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 	_ "time/tzdata"
@@ -47,3 +48,27 @@ func getEnvDuration(key string) time.Duration {
 
 	return 0
 }
+
+func getEnvBool(key string) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+
+		log.Printf("Invalid bool for %s: %s", key, value)
+	}
+
+	return false
+}
+
+func getEnvInt(key string) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+
+		log.Printf("Invalid int for %s: %s", key, value)
+	}
+
+	return 0
+}